@@ -3,11 +3,12 @@ module github.com/wuhan005/Raika
 go 1.16
 
 require (
-	github.com/aws/aws-sdk-go v1.40.6
+	github.com/aws/aws-sdk-go v1.44.0
 	github.com/flamego/flamego v0.0.0-20210525090435-cdb552aa0e32
 	github.com/pkg/errors v0.9.1
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/thanhpk/randstr v1.0.4
 	github.com/urfave/cli/v2 v2.3.0
+	gopkg.in/yaml.v2 v2.4.0
 	unknwon.dev/clog/v2 v2.2.0
 )
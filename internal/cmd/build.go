@@ -0,0 +1,36 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/gobuild"
+)
+
+var Build = &cli.Command{
+	Name:  "build",
+	Usage: "Manage the binaries built from the Go source",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "clean",
+			Usage:  "Remove the cached binaries",
+			Action: cleanBuild,
+			Flags: []cli.Flag{
+				&cli.DurationFlag{Name: "older-than", Usage: "Only remove the binaries not used within the duration, e.g. 24h", Required: false},
+			},
+		},
+	},
+}
+
+func cleanBuild(c *cli.Context) error {
+	removed, err := gobuild.Clean(gobuild.DefaultCacheDir, c.Duration("older-than"))
+	if err != nil {
+		return err
+	}
+	log.Info("Removed %d cached binaries.", removed)
+	return nil
+}
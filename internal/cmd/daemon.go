@@ -55,8 +55,12 @@ func startDaemon(_ *cli.Context) error {
 	return cmd.Start()
 }
 
-func runDaemon(_ *cli.Context) error {
-	return daemon.Run()
+func runDaemon(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+	return daemon.Run(platforms)
 }
 
 func stopDaemon(_ *cli.Context) error {
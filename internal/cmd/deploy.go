@@ -0,0 +1,250 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/api"
+	"github.com/wuhan005/Raika/internal/config"
+	"github.com/wuhan005/Raika/internal/manifest"
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var Deploy = &cli.Command{
+	Name:   "deploy",
+	Usage:  "Deploy the functions in the manifest and delete the ones removed from it",
+	Action: deploy,
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "Manifest file path", Value: manifest.DefaultFileName},
+	},
+}
+
+func deploy(c *cli.Context) error {
+	m, err := manifest.Load(c.String("file"))
+	if err != nil {
+		return errors.Wrap(err, "load manifest")
+	}
+	accounts, err := loadAccounts(c)
+	if err != nil {
+		return err
+	}
+	if err := checkAccounts(m, accounts); err != nil {
+		return err
+	}
+
+	var failed int
+	desired := make(map[string]map[string]struct{}, len(m.Functions))
+	for _, f := range m.Functions {
+		opts := m.Options(f)
+		versionLabel := nextVersionLabel(f.Name)
+
+		desired[f.Name] = make(map[string]struct{})
+		for _, name := range m.AccountNames(f) {
+			p := accounts[name]
+			desired[f.Name][p.GetID()] = struct{}{}
+
+			// The functions deployed by the flags are taken over by the project.
+			if record := findRecord(store.Functions.Functions[f.Name], p.GetID()); record != nil && record.Project != "" && record.Project != m.Project {
+				log.Error("Function %q on %s belongs to project %q, skipped", f.Name, p, record.Project)
+				failed++
+				continue
+			}
+
+			log.Info("Deploy function %q on %s", f.Name, p)
+			opts, err := buildOptions(p, opts, m.SourceDir(f))
+			if err != nil {
+				log.Error("Failed to build function for %s: %v", p, err)
+				failed++
+				continue
+			}
+			if err := deployFunction(p, opts, versionLabel); err != nil {
+				log.Error("Failed to deploy function on %s: %v", p, err)
+				failed++
+				continue
+			}
+			if err := store.Functions.SetProject(f.Name, p.GetID(), m.Project); err != nil {
+				log.Error("Failed to save function project to file: %v", err)
+			}
+		}
+	}
+
+	// Delete the functions of the project which are removed from the manifest.
+	projectNames := projectFunctions(m.Project)
+	clients := make(map[string]platform.Cloud, len(accounts))
+	for _, p := range accounts {
+		clients[p.GetID()] = p
+	}
+	for _, record := range removedFunctions(m.Project, desired) {
+		p, ok := clients[record.PlatformID]
+		if !ok {
+			log.Warn("Account of %s is not found, function %q is kept", record.PlatformID, record.Name)
+			continue
+		}
+		log.Info("Delete function %q on %s", record.Name, p)
+		if err := p.DeleteFunction(record.RemoteName()); err != nil {
+			if err != platform.ErrFunctionNotExists {
+				log.Error("Failed to delete function on %s: %v", p, err)
+				failed++
+				continue
+			}
+			log.Warn("Function %q not found on %s", record.Name, p)
+		}
+		if err := store.Functions.Delete(record.Name, record.PlatformID); err != nil && err != store.ErrFunctionNotExists {
+			log.Error("Failed to remove function from file: %v", err)
+		}
+	}
+
+	if err := syncTasks(m, desired, projectNames); err != nil {
+		return err
+	}
+	if err := api.Reload(); err != nil {
+		return errors.Wrap(err, "reload")
+	}
+
+	if failed != 0 {
+		return errors.Errorf("%d changes failed", failed)
+	}
+	return nil
+}
+
+// syncTasks makes the periodic tasks of the project functions match the
+// manifest, the project names are the functions deployed from the project
+// before this deployment.
+func syncTasks(m *manifest.Manifest, desired map[string]map[string]struct{}, projectNames []string) error {
+	for _, f := range m.Functions {
+		if f.Task == nil {
+			continue
+		}
+		if err := store.Tasks.Upsert(store.CreateTaskOptions{
+			FunctionName: f.Name,
+			Duration:     f.Task.Interval,
+		}); err != nil {
+			return errors.Wrap(err, "save task")
+		}
+		if f.Task.Disabled {
+			if err := store.Tasks.Disable(f.Name); err != nil {
+				return errors.Wrap(err, "disable task")
+			}
+		}
+	}
+
+	for name := range desired {
+		if f, _ := m.Function(name); f.Task != nil {
+			continue
+		}
+		if _, err := store.Tasks.Get(name); err == nil {
+			if err := store.Tasks.Delete(name); err != nil {
+				return errors.Wrap(err, "delete task")
+			}
+		}
+	}
+
+	// Remove the tasks of the functions which are not deployed on any platform.
+	for _, name := range projectNames {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		if _, err := store.Functions.Get(name); err != store.ErrFunctionNotExists {
+			continue
+		}
+		if _, err := store.Tasks.Get(name); err == nil {
+			if err := store.Tasks.Delete(name); err != nil {
+				return errors.Wrap(err, "delete task")
+			}
+		}
+	}
+	return nil
+}
+
+// loadAccounts returns the clients of all the accounts in the config file,
+// the key is the account name.
+func loadAccounts(c *cli.Context) (map[string]platform.Cloud, error) {
+	configFile := config.New(c.String("config-file"))
+	if err := configFile.Load(); err != nil {
+		return nil, errors.Wrap(err, "load config file")
+	}
+
+	accounts := make(map[string]platform.Cloud, len(configFile.AuthConfigs))
+	for name, p := range configFile.AuthConfigs {
+		client, err := newClient(configFile, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load account %q", name)
+		}
+		accounts[name] = client
+	}
+	return accounts, nil
+}
+
+// checkAccounts checks all the accounts used by the manifest are logged in,
+// and support the triggers of the functions.
+func checkAccounts(m *manifest.Manifest, accounts map[string]platform.Cloud) error {
+	for _, name := range m.Platforms {
+		if _, ok := accounts[name]; !ok {
+			return errors.Errorf("account %q is not found in the config file", name)
+		}
+	}
+	for _, f := range m.Functions {
+		for _, name := range m.AccountNames(f) {
+			p, ok := accounts[name]
+			if !ok {
+				return errors.Errorf("function %q: account %q is not found in the config file", f.Name, name)
+			}
+			if err := platform.CheckTriggers(p, f.Triggers); err != nil {
+				return errors.Wrapf(err, "function %q: account %q", f.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// projectFunctions returns the sorted names of the functions in the function
+// file which are deployed from the project on any platform.
+func projectFunctions(project string) []string {
+	names := make([]string, 0)
+	for name, records := range store.Functions.Functions {
+		for _, record := range records {
+			if record.Project == project {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// removedFunction is a function record to delete.
+type removedFunction struct {
+	// Name is the function name in the function file, which differs from the
+	// name of the record imported from the platform, RemoteName still returns
+	// the name on the platform.
+	Name string
+	types.Function
+}
+
+// removedFunctions returns the function records deployed from the project
+// which are not in the desired function names and platform IDs.
+func removedFunctions(project string, desired map[string]map[string]struct{}) []removedFunction {
+	var removed []removedFunction
+	for _, name := range projectFunctions(project) {
+		for _, record := range store.Functions.Functions[name] {
+			if record.Project != project {
+				continue
+			}
+			if _, ok := desired[name][record.PlatformID]; ok {
+				continue
+			}
+			removed = append(removed, removedFunction{Name: name, Function: record})
+		}
+	}
+	return removed
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func TestRemovedFunctions(t *testing.T) {
+	desired := map[string]map[string]struct{}{
+		"hello": {"fake@1": {}},
+	}
+	tests := []struct {
+		name    string
+		records []types.Function
+		want    []string
+	}{
+		{
+			name:    "desired function is kept",
+			records: []types.Function{{Name: "hello", PlatformID: "fake@1", Project: "demo"}},
+		},
+		{
+			name:    "function removed from the manifest",
+			records: []types.Function{{Name: "bye", PlatformID: "fake@1", Project: "demo"}},
+			want:    []string{"bye"},
+		},
+		{
+			name:    "function removed from an account",
+			records: []types.Function{{Name: "hello", PlatformID: "fake@2", Project: "demo"}},
+			want:    []string{"hello"},
+		},
+		{
+			name: "function of another project is kept",
+			records: []types.Function{
+				{Name: "bye", PlatformID: "fake@1", Project: "other"},
+				{Name: "bye", PlatformID: "fake@2", Project: "demo"},
+			},
+			want: []string{"bye"},
+		},
+		{
+			name:    "function deployed by the flags is kept",
+			records: []types.Function{{Name: "bye", PlatformID: "fake@1"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initFunctionStore(t, test.records...)
+
+			var got []string
+			for _, record := range removedFunctions("demo", desired) {
+				if record.Project != "demo" {
+					t.Fatalf("want the function of project %q, got %q", "demo", record.Project)
+				}
+				got = append(got, record.Name)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRemovedFunctionsImported(t *testing.T) {
+	initFunctionStore(t)
+	imported := types.Function{Name: "greeting", Namespace: "my-service", PlatformID: "fake@1", Project: "demo"}
+	if err := store.Functions.Put("hello", imported); err != nil {
+		t.Fatal(err)
+	}
+
+	removed := removedFunctions("demo", nil)
+	if len(removed) != 1 {
+		t.Fatalf("want 1 function, got %d", len(removed))
+	}
+	if removed[0].Name != "hello" || removed[0].RemoteName() != "my-service/greeting" {
+		t.Fatalf("want hello on my-service/greeting, got %s on %s", removed[0].Name, removed[0].RemoteName())
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello"), []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	config := `{"auths": {"test": {"platform": "fake", "options": {"account": "1"}}}}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifestFile := filepath.Join(dir, "raika.yaml")
+	manifest := `
+project: demo
+platforms: [test]
+functions:
+  - name: hello
+    memory: 128
+    init_timeout: 10s
+    runtime_timeout: 10s
+    binary_file: hello
+    triggers: []
+    task:
+      interval: 1h
+`
+	if err := os.WriteFile(manifestFile, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tasks.Init(filepath.Join(dir, "tasks.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tasks.Upsert(store.CreateTaskOptions{FunctionName: "bye", Duration: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeCloud(t, "1")
+	initFunctionStore(t,
+		types.Function{Name: "bye", PlatformID: fake.GetID(), Project: "demo"},
+		types.Function{Name: "other", PlatformID: fake.GetID(), Project: "other"},
+		types.Function{Name: "flags", PlatformID: fake.GetID()},
+	)
+	// The function imported from my-service/greeting as "imported".
+	imported := types.Function{Name: "greeting", Namespace: "my-service", PlatformID: fake.GetID(), Project: "demo"}
+	if err := store.Functions.Put("imported", imported); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"bye", "my-service/greeting", "other", "flags"} {
+		fake.functions[name] = &platform.FunctionInfo{Name: name}
+	}
+
+	app := &cli.App{
+		Flags:          []cli.Flag{&cli.StringFlag{Name: "config-file"}},
+		Commands:       []*cli.Command{Deploy},
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	if err := app.Run([]string{"raika", "--config-file", configFile, "deploy", "-f", manifestFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fake.functions["hello"]; !ok {
+		t.Fatal("want function hello deployed")
+	}
+	if want := []string{"bye", "my-service/greeting"}; !reflect.DeepEqual(fake.deleted, want) {
+		t.Fatalf("want %q deleted, got %q", want, fake.deleted)
+	}
+	for name, want := range map[string]bool{"hello": true, "bye": false, "imported": false, "other": true, "flags": true} {
+		if _, err := store.Functions.Get(name); (err == nil) != want {
+			t.Fatalf("function %q: want recorded %v, got %v", name, want, err)
+		}
+	}
+	records, _ := store.Functions.Get("hello")
+	if len(records) != 1 || records[0].Project != "demo" {
+		t.Fatalf("want function hello of project demo, got %+v", records)
+	}
+
+	if _, err := store.Tasks.Get("hello"); err != nil {
+		t.Fatalf("want the task of hello: %v", err)
+	}
+	if _, err := store.Tasks.Get("bye"); err == nil {
+		t.Fatal("want the task of bye deleted")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/wuhan005/Raika/internal/emulator"
+)
+
+var Emulator = &cli.Command{
+	Name:   "emulator",
+	Usage:  "Run the local emulator of the Function Compute, SCF and Lambda APIs",
+	Action: runEmulator,
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "fc-addr", Usage: "Listen address of the Function Compute API, empty to disable", Value: "127.0.0.1:9101"},
+		&cli.StringFlag{Name: "scf-addr", Usage: "Listen address of the SCF API, empty to disable", Value: "127.0.0.1:9102"},
+		&cli.StringFlag{Name: "aws-addr", Usage: "Listen address of the AWS APIs, empty to disable", Value: "127.0.0.1:9103"},
+		&cli.StringFlag{Name: "access-key-id", Usage: "Access key ID accepted by the APIs", Value: "raika", EnvVars: []string{"RAIKA_EMULATOR_ACCESS_KEY_ID"}},
+		&cli.StringFlag{Name: "access-key-secret", Usage: "Access key secret accepted by the APIs", Value: "raika", EnvVars: []string{"RAIKA_EMULATOR_ACCESS_KEY_SECRET"}},
+	},
+}
+
+func runEmulator(c *cli.Context) error {
+	return emulator.Run(emulator.Options{
+		FCAddr:          c.String("fc-addr"),
+		SCFAddr:         c.String("scf-addr"),
+		AWSAddr:         c.String("aws-addr"),
+		AccessKeyID:     c.String("access-key-id"),
+		AccessKeySecret: c.String("access-key-secret"),
+	})
+}
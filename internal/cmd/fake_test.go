@@ -0,0 +1,184 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+// fakePlatform is the platform of the fake accounts, whose clients are the
+// fakeClouds registered by the platform ID.
+const fakePlatform = types.Platform("fake")
+
+var fakeClouds = make(map[string]platform.Cloud)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: fakePlatform,
+		Fields: []platform.Field{
+			{Name: "account", Usage: "Account of the fake platform", Required: true},
+		},
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts["account"]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			c, ok := fakeClouds[opts["id"]]
+			if !ok {
+				return nil, errors.Errorf("fake cloud %q is not found", opts["id"])
+			}
+			return c, nil
+		},
+	})
+}
+
+// fakeCloud keeps the functions in memory.
+type fakeCloud struct {
+	id        string
+	functions map[string]*platform.FunctionInfo
+	// deleted are the names of the deleted functions in order.
+	deleted []string
+}
+
+var _ platform.Cloud = (*fakeCloud)(nil)
+
+// newFakeCloud returns the fake cloud of the account, the account can be
+// loaded from the config file written by writeFakeConfig.
+func newFakeCloud(t *testing.T, account string) *fakeCloud {
+	c := &fakeCloud{
+		id:        string(fakePlatform) + "@" + account,
+		functions: make(map[string]*platform.FunctionInfo),
+	}
+	fakeClouds[c.id] = c
+	t.Cleanup(func() { delete(fakeClouds, c.id) })
+	return c
+}
+
+func (c *fakeCloud) String() string           { return c.id }
+func (c *fakeCloud) Platform() types.Platform { return fakePlatform }
+func (c *fakeCloud) GetID() string            { return c.id }
+func (c *fakeCloud) Authenticate() error      { return nil }
+
+func (c *fakeCloud) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	c.functions[opts.Name] = &platform.FunctionInfo{
+		Name:                  opts.Name,
+		Description:           opts.Description,
+		MemorySize:            opts.MemorySize,
+		EnvironmentVariables:  opts.EnvironmentVariables,
+		InitializationTimeout: opts.InitializationTimeout,
+		RuntimeTimeout:        opts.RuntimeTimeout,
+	}
+	return &platform.Deployment{}, nil
+}
+
+func (c *fakeCloud) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if _, ok := c.functions[opts.Name]; !ok {
+		return nil, platform.ErrFunctionNotExists
+	}
+	return c.CreateFunction(opts)
+}
+
+func (c *fakeCloud) DeleteFunction(name string) error {
+	if _, ok := c.functions[name]; !ok {
+		return platform.ErrFunctionNotExists
+	}
+	delete(c.functions, name)
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeCloud) Describe(name string) (*platform.FunctionInfo, error) {
+	info, ok := c.functions[name]
+	if !ok {
+		return nil, platform.ErrFunctionNotExists
+	}
+	return info, nil
+}
+
+func (c *fakeCloud) ListFunctions() ([]*platform.FunctionInfo, error) {
+	functions := make([]*platform.FunctionInfo, 0, len(c.functions))
+	for _, info := range c.functions {
+		functions = append(functions, info)
+	}
+	return functions, nil
+}
+
+func (c *fakeCloud) Invoke(string, []byte) (*platform.InvokeResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeCloud) Logs(string, platform.LogOptions) ([]*platform.LogEntry, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeCloud) PublishVersion(string, string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (c *fakeCloud) UpdateAlias(string, string, string) error {
+	return errors.New("not implemented")
+}
+
+func (c *fakeCloud) RemoveTrigger(string, string) error {
+	return errors.New("not implemented")
+}
+
+// checksumCloud reports the SHA-256 of the binary as the code checksum.
+type checksumCloud struct {
+	*fakeCloud
+}
+
+var _ platform.CodeChecksumPlatform = checksumCloud{}
+
+func (c checksumCloud) CodeChecksum(opts platform.CreateFunctionOptions) (string, error) {
+	data, err := os.ReadFile(opts.File)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// qualifierCloud describes the aliases of the functions.
+type qualifierCloud struct {
+	*fakeCloud
+	aliases map[string]struct{}
+}
+
+var _ platform.QualifierPlatform = qualifierCloud{}
+
+func (c qualifierCloud) DescribeQualifier(name, qualifier string) (*platform.FunctionInfo, error) {
+	if _, ok := c.aliases[qualifier]; !ok {
+		return nil, platform.ErrFunctionNotExists
+	}
+	return c.Describe(name)
+}
+
+func (c qualifierCloud) InvokeQualifier(string, string, []byte) (*platform.InvokeResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+// initFunctionStore replaces the function store with the records in a
+// temporary function file.
+func initFunctionStore(t *testing.T, records ...types.Function) {
+	store.Functions = store.FunctionStore{
+		FileName:  filepath.Join(t.TempDir(), "functions.json"),
+		Functions: make(map[string][]types.Function),
+	}
+	for _, record := range records {
+		if err := store.Functions.Put(record.Name, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
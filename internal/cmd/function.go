@@ -6,6 +6,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,11 +15,11 @@ import (
 	"github.com/urfave/cli/v2"
 	log "unknwon.dev/clog/v2"
 
+	"github.com/wuhan005/Raika/internal/api"
 	"github.com/wuhan005/Raika/internal/config"
+	"github.com/wuhan005/Raika/internal/gobuild"
 	"github.com/wuhan005/Raika/internal/platform"
-	"github.com/wuhan005/Raika/internal/platform/aliyun"
-	"github.com/wuhan005/Raika/internal/platform/aws"
-	"github.com/wuhan005/Raika/internal/platform/tencentcloud"
+	"github.com/wuhan005/Raika/internal/platform/plugin"
 	"github.com/wuhan005/Raika/internal/store"
 	"github.com/wuhan005/Raika/internal/types"
 )
@@ -36,86 +38,152 @@ var Function = &cli.Command{
 				&cli.Int64Flag{Name: "memory", Usage: "Function runtime memory size", Required: true},
 				&cli.IntFlag{Name: "init-timeout", Usage: "Function runtime initialization timeout", Required: true},
 				&cli.IntFlag{Name: "runtime-timeout", Usage: "Function runtime timeout", Required: true},
-				&cli.StringFlag{Name: "binary-file", Usage: "Function binary file", Required: true},
+				&cli.StringFlag{Name: "binary-file", Usage: "Function binary file", Required: false},
+				&cli.StringFlag{Name: "source", Usage: "Go main package directory to build the binary for each platform, e.g. ./cmd/fn", Required: false},
+				&cli.StringFlag{Name: "package-dir", Usage: "Directory of the files to pack with the binary", Required: false},
+				&cli.StringSliceFlag{Name: "include", Usage: "Glob of the files in the package directory to pack", Required: false},
+				&cli.StringSliceFlag{Name: "exclude", Usage: "Glob of the files in the package directory not to pack", Required: false},
+				&cli.StringFlag{Name: "image", Usage: "Container image to deploy on the container platforms instead of building from the binary", Required: false},
 				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to deploy", Required: false},
 				&cli.StringSliceFlag{Name: "env", Usage: "Environment variables", Required: false},
-				&cli.StringFlag{Name: "trigger", Usage: "Function trigger method", Required: false, DefaultText: "http"},
-				&cli.StringFlag{Name: "cron", Usage: "Cron expression for timer trigger", Required: false, DefaultText: "0 30 * * * *"},
+				&cli.StringFlag{Name: "trigger", Usage: "Function trigger method, http or cron", Required: false, Value: "http"},
+				&cli.StringFlag{Name: "cron", Usage: "Cron expression with seconds for timer trigger", Required: false, Value: "0 30 * * * *"},
+				&cli.StringFlag{Name: "trigger-file", Usage: "JSON file of the trigger list, overrides the trigger and the cron flags", Required: false},
+				&cli.StringFlag{Name: "alias", Usage: "Alias to bind the triggers to, a new version is published on each deployment, defaults to the alias the function is deployed with", Required: false},
 			},
 		},
+		{
+			Name:   "publish",
+			Usage:  "Publish a new version of the function and point the alias to it",
+			Action: publishFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.StringFlag{Name: "version", Usage: "Version label, defaults to the next version number", Required: false},
+				&cli.StringFlag{Name: "description", Usage: "Version description", Required: false},
+				&cli.StringFlag{Name: "alias", Usage: "Alias to point to the new version, which must be the alias the function is deployed with", Required: false},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to publish", Required: false},
+			},
+		},
+		{
+			Name:   "rollback",
+			Usage:  "Point the alias of the function back to the given version",
+			Action: rollbackFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.StringFlag{Name: "to", Usage: "Version label to roll back to", Required: true},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to roll back", Required: false},
+			},
+		},
+		{
+			Name:   "delete",
+			Usage:  "Delete the function from the cloud service",
+			Action: deleteFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to delete from", Required: false},
+			},
+		},
+		{
+			Name:   "invoke",
+			Usage:  "Invoke the function through the cloud service API",
+			Action: invokeFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.StringFlag{Name: "payload", Usage: "Invocation payload, use @file to read from file", Required: false},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to invoke", Required: false},
+			},
+		},
+		{
+			Name:   "logs",
+			Usage:  "Print the function logs from all the cloud services",
+			Action: logsFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.DurationFlag{Name: "since", Usage: "Show logs since the duration ago", Value: 10 * time.Minute},
+				&cli.BoolFlag{Name: "follow", Aliases: []string{"f"}, Usage: "Follow the log output"},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to get logs from", Required: false},
+			},
+		},
+		{
+			Name:   "import",
+			Usage:  "Import the existing functions on the cloud services",
+			Action: importFunction,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{Name: "function", Usage: "Function to import in `remote[=name]` format, list the functions if empty", Required: false},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to import from", Required: false},
+			},
+		},
+		Trigger,
 		{
 			Name:   "list",
 			Usage:  "List all the functions",
 			Action: listFunctions,
 		},
+		{
+			Name:   "status",
+			Usage:  "Check the live status of the functions on the cloud services",
+			Action: statusFunction,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name, check all the functions if empty", Required: false},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to check", Required: false},
+			},
+		},
 	},
 }
 
 func createFunction(c *cli.Context) error {
-	configFilePath := c.String("config-file")
-	configFile := config.New(configFilePath)
-	if err := configFile.Load(); err != nil {
-		return errors.Wrap(err, "load config file")
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
 	}
 
-	platforms := make([]platform.Cloud, 0, len(configFile.AuthConfigs))
-	platformNames := c.StringSlice("platform")
-	platformNameSet := make(map[types.Platform]struct{})
-	for _, platformName := range platformNames {
-		platformNameSet[types.Platform(platformName)] = struct{}{}
+	opts, err := functionOptions(c)
+	if err != nil {
+		return err
+	}
+	source := c.String("source")
+	if (opts.File == "") == (source == "") {
+		return errors.New("either binary-file or source is required")
+	}
+	for _, p := range platforms {
+		if err := platform.CheckTriggers(p, opts.Triggers); err != nil {
+			return errors.Wrapf(err, "check triggers on %s", p)
+		}
 	}
 
-	for _, p := range configFile.AuthConfigs {
-		_, ok := platformNameSet[p.Platform]
-		if len(platformNameSet) != 0 && !ok {
+	// The label is used if a version is published, which is shared by all the
+	// platforms.
+	versionLabel := nextVersionLabel(opts.Name)
+
+	for _, p := range platforms {
+		log.Info("Create function %q on %s", opts.Name, p)
+
+		opts, err := buildOptions(p, opts, source)
+		if err != nil {
+			log.Error("Failed to build function for %s: %v", p, err)
 			continue
 		}
-
-		switch p.Platform {
-		case types.Aliyun:
-			client := aliyun.New(platform.AuthenticateOptions{
-				"id":                        fmt.Sprintf("%s@%s@%s", types.Aliyun, p.AccountID, p.RegionID),
-				aliyun.RegionIDField:        p.RegionID,
-				aliyun.AccountIDField:       p.AccountID,
-				aliyun.AccessKeyIDField:     p.AccessKeyID,
-				aliyun.AccessKeySecretField: p.AccessKeySecret,
-			})
-			platforms = append(platforms, client)
-		case types.TencentCloud:
-			client := tencentcloud.New(platform.AuthenticateOptions{
-				"id":                        fmt.Sprintf("%s@%s@%s", types.TencentCloud, p.SecretID, p.RegionID),
-				tencentcloud.RegionIDField:  p.RegionID,
-				tencentcloud.SecretIDField:  p.SecretID,
-				tencentcloud.SecretKeyField: p.SecretKey,
-			})
-			platforms = append(platforms, client)
-		case types.AWS:
-			client := aws.New(platform.AuthenticateOptions{
-				"id":               fmt.Sprintf("%s@%s@%s", types.AWS, p.AccountID, p.RegionID),
-				aws.RegionIDField:  p.RegionID,
-				aws.AccountIDField: p.AccountID,
-				aws.AccessKeyField: p.AccessKeyID,
-				aws.SecretKeyField: p.SecretKey,
-			})
-			platforms = append(platforms, client)
-		default:
-			return errors.Errorf("unsupported platform: %q", p)
+		if err := deployFunction(p, opts, versionLabel); err != nil {
+			log.Error("Failed to create function on %s: %v", p, err)
+			continue
 		}
 	}
+	return nil
+}
 
-	name := c.String("name")
-	binaryFile := c.String("binary-file")
-	description := c.String("description")
-	memorySize := c.Int64("memory")
-	initTimeout := c.Int("init-timeout")
-	runtimeTimeout := c.Int("runtime-timeout")
-	environmentVariables := c.StringSlice("env")
-	trigger := c.String("trigger")
-	cron := c.String("cron")
+// functionOptions returns the options of the function given by the flags.
+func functionOptions(c *cli.Context) (platform.CreateFunctionOptions, error) {
+	triggers, err := loadTriggers(c)
+	if err != nil {
+		return platform.CreateFunctionOptions{}, errors.Wrap(err, "load triggers")
+	}
+	if err := platform.ValidateTriggers(triggers); err != nil {
+		return platform.CreateFunctionOptions{}, err
+	}
 
 	envs := make(map[string]string)
 	// Parse environment variables.
-	for _, env := range environmentVariables {
+	for _, env := range c.StringSlice("env") {
 		kv := strings.SplitN(env, "=", 2)
 		if len(kv) != 2 {
 			continue
@@ -123,34 +191,92 @@ func createFunction(c *cli.Context) error {
 		envs[kv[0]] = kv[1]
 	}
 
-	for _, p := range platforms {
-		log.Info("Create function %q on %s", name, p)
-
-		opts := platform.CreateFunctionOptions{
-			Name:                  name,
-			Description:           description,
-			MemorySize:            memorySize,
-			EnvironmentVariables:  envs,
-			InitializationTimeout: time.Duration(initTimeout) * time.Second,
-			RuntimeTimeout:        time.Duration(runtimeTimeout) * time.Second,
-			File:                  binaryFile,
-
-			TriggerType: trigger,
-			CronString:  cron,
-			HTTPPort:    9000, // For tencentcloud
-		}
-		triggerURL, err := p.CreateFunction(opts)
-		if err != nil {
-			log.Error("Failed to create function on %s: %v", p, err)
-			continue
-		}
-		// Save the function into file.
-		if err := store.Functions.Set(name, p.GetID(), triggerURL, opts); err != nil {
-			log.Error("Failed to save function to file: %v", err)
+	var pkg *platform.PackageSpec
+	if dir := c.String("package-dir"); dir != "" {
+		pkg = &platform.PackageSpec{
+			Dir:     dir,
+			Include: c.StringSlice("include"),
+			Exclude: c.StringSlice("exclude"),
 		}
+	} else if c.IsSet("include") || c.IsSet("exclude") {
+		return platform.CreateFunctionOptions{}, errors.New("package-dir is required by include and exclude")
+	}
+
+	return platform.CreateFunctionOptions{
+		Name:                  c.String("name"),
+		Description:           c.String("description"),
+		MemorySize:            c.Int64("memory"),
+		EnvironmentVariables:  envs,
+		InitializationTimeout: time.Duration(c.Int("init-timeout")) * time.Second,
+		RuntimeTimeout:        time.Duration(c.Int("runtime-timeout")) * time.Second,
+		File:                  c.String("binary-file"),
+		Package:               pkg,
+		Image:                 c.String("image"),
 
-		log.Info("[ %s ] - %s", p, triggerURL)
+		Triggers: triggers,
+		HTTPPort: 9000, // For tencentcloud
+		Alias:    c.String("alias"),
+	}, nil
+}
+
+// buildOptions returns the options with the binary built from the source for
+// the target of the platform. The options are returned as is if the source is
+// empty.
+func buildOptions(p platform.Cloud, opts platform.CreateFunctionOptions, source string) (platform.CreateFunctionOptions, error) {
+	if source == "" {
+		return opts, nil
+	}
+
+	file, err := gobuild.Build(gobuild.DefaultCacheDir, source, platform.TargetOf(p))
+	if err != nil {
+		return opts, err
+	}
+	opts.File = file
+	return opts, nil
+}
+
+// deployFunction creates or updates the function on the platform, and saves
+// it into the function file. The new version is labeled with the given label
+// if the function has an alias.
+func deployFunction(p platform.Cloud, opts platform.CreateFunctionOptions, versionLabel string) error {
+	// The function published with an alias keeps it, so that its triggers stay
+	// bound to the alias which is rolled back.
+	if record := findRecord(store.Functions.Functions[opts.Name], p.GetID()); opts.Alias == "" && record != nil {
+		opts.Alias = record.Alias
+	}
+
+	// The imported function is deployed under its name on the platform.
+	remote := opts
+	remote.Name = remoteName(opts.Name, p.GetID())
+	deployment, err := p.CreateFunction(remote)
+	if err != nil {
+		return err
+	}
+	if deployment == nil {
+		deployment = &platform.Deployment{}
+	}
+	removeStaleTriggers(p, opts.Name, opts.Triggers)
+	if len(deployment.Skipped) != 0 {
+		log.Info("[ %s ] Skipped %s: unchanged", p, strings.Join(deployment.Skipped, " and "))
 	}
+
+	// Save the function into file.
+	if err := store.Functions.Set(opts.Name, p.GetID(), deployment.URL, opts); err != nil {
+		log.Error("Failed to save function to file: %v", err)
+	}
+	if deployment.Version != "" {
+		err := store.Functions.AddVersion(opts.Name, p.GetID(), opts.Alias, types.FunctionVersion{
+			Label:     versionLabel,
+			ID:        deployment.Version,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			log.Error("Failed to save function version to file: %v", err)
+		}
+		log.Info("[ %s ] %s -> %s (%s)", p, opts.Alias, versionLabel, deployment.Version)
+	}
+
+	log.Info("[ %s ] - %s", p, deployment.URL)
 	return nil
 }
 
@@ -163,3 +289,225 @@ func listFunctions(c *cli.Context) error {
 	}
 	return nil
 }
+
+func deleteFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	for _, p := range platforms {
+		log.Info("Delete function %q on %s", name, p)
+
+		if err := p.DeleteFunction(remoteName(name, p.GetID())); err != nil {
+			if err != platform.ErrFunctionNotExists {
+				log.Error("Failed to delete function on %s: %v", p, err)
+				continue
+			}
+			log.Warn("Function %q not found on %s", name, p)
+		}
+
+		if err := store.Functions.Delete(name, p.GetID()); err != nil && err != store.ErrFunctionNotExists {
+			log.Error("Failed to remove function from file: %v", err)
+		}
+	}
+
+	// Remove the task once the function is not deployed on any platform.
+	if _, err := store.Functions.Get(name); err == store.ErrFunctionNotExists {
+		if _, err := store.Tasks.Get(name); err == nil {
+			if err := store.Tasks.Delete(name); err != nil {
+				return errors.Wrap(err, "delete task")
+			}
+		}
+	}
+
+	if err := api.Reload(); err != nil {
+		return errors.Wrap(err, "reload")
+	}
+	return nil
+}
+
+func invokeFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	payload := []byte(c.String("payload"))
+	if strings.HasPrefix(string(payload), "@") {
+		payload, err = os.ReadFile(strings.TrimPrefix(string(payload), "@"))
+		if err != nil {
+			return errors.Wrap(err, "read payload file")
+		}
+	}
+
+	records, _ := store.Functions.Get(name)
+	for _, p := range platforms {
+		// Invoke the alias the triggers are bound to.
+		var alias string
+		if record := findRecord(records, p.GetID()); record != nil {
+			alias = record.Alias
+		}
+
+		startAt := time.Now()
+		resp, err := platform.InvokeQualified(p, remoteName(name, p.GetID()), alias, payload)
+		latency := time.Since(startAt)
+		if err != nil {
+			log.Error("[ %s ] Failed to invoke function: %v", p.GetID(), err)
+			continue
+		}
+
+		if resp.Error != "" {
+			log.Warn("[ %s ] %d %s - %s", p.GetID(), resp.StatusCode, latency, resp.Error)
+		} else {
+			log.Info("[ %s ] %d %s", p.GetID(), resp.StatusCode, latency)
+		}
+		log.Trace("%s", resp.Body)
+	}
+	return nil
+}
+
+func statusFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	names := []string{c.String("name")}
+	if names[0] == "" {
+		names = names[:0]
+		for name := range store.Functions.Functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		log.Info("-  %s", name)
+		records, _ := store.Functions.Get(name)
+
+		for _, p := range platforms {
+			record := findRecord(records, p.GetID())
+			var alias string
+			if record != nil {
+				alias = record.Alias
+			}
+
+			info, err := platform.DescribeQualified(p, remoteName(name, p.GetID()), alias)
+			if err != nil {
+				if err != platform.ErrFunctionNotExists {
+					log.Error("   [%s] Failed to describe function: %v", p.GetID(), err)
+				} else if record != nil {
+					log.Warn("   [%s] NOT FOUND", p.GetID())
+				}
+				continue
+			}
+
+			status := info.Status
+			if !info.Active {
+				status += " (INACTIVE)"
+			}
+			if record == nil {
+				log.Warn("   [%s] %s, not recorded in the function file", p.GetID(), status)
+				continue
+			}
+
+			diffs := diffFunction(record, info)
+			if len(diffs) == 0 && info.Active {
+				log.Trace("   [%s] %s", p.GetID(), status)
+				continue
+			}
+			log.Warn("   [%s] %s", p.GetID(), status)
+			for _, diff := range diffs {
+				log.Warn("      ~ %s", diff)
+			}
+		}
+	}
+	return nil
+}
+
+// diffFunction returns the fields differ between the stored function and the live one.
+func diffFunction(f *types.Function, info *platform.FunctionInfo) []string {
+	var diffs []string
+	if f.Description != info.Description {
+		diffs = append(diffs, fmt.Sprintf("description: %q -> %q", f.Description, info.Description))
+	}
+	if f.MemorySize != info.MemorySize {
+		diffs = append(diffs, fmt.Sprintf("memory_size: %d -> %d", f.MemorySize, info.MemorySize))
+	}
+	// Not all the platforms have the initialization timeout.
+	if info.InitializationTimeout != 0 && f.InitializationTimeout != info.InitializationTimeout {
+		diffs = append(diffs, fmt.Sprintf("initialization_timeout: %s -> %s", f.InitializationTimeout, info.InitializationTimeout))
+	}
+	if f.RuntimeTimeout != info.RuntimeTimeout {
+		diffs = append(diffs, fmt.Sprintf("runtime_timeout: %s -> %s", f.RuntimeTimeout, info.RuntimeTimeout))
+	}
+
+	for k, v := range f.Environment {
+		live, ok := info.EnvironmentVariables[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("environment: %s removed", k))
+		} else if live != v {
+			diffs = append(diffs, fmt.Sprintf("environment: %s changed", k))
+		}
+	}
+	for k := range info.EnvironmentVariables {
+		if _, ok := f.Environment[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("environment: %s added", k))
+		}
+	}
+	return diffs
+}
+
+// loadPlatforms returns the clients of the logged in platforms,
+// filtered by the `--platform` flag if it is set.
+func loadPlatforms(c *cli.Context) ([]platform.Cloud, error) {
+	configFilePath := c.String("config-file")
+	configFile := config.New(configFilePath)
+	if err := configFile.Load(); err != nil {
+		return nil, errors.Wrap(err, "load config file")
+	}
+
+	platforms := make([]platform.Cloud, 0, len(configFile.AuthConfigs))
+	platformNames := c.StringSlice("platform")
+	platformNameSet := make(map[types.Platform]struct{})
+	for _, platformName := range platformNames {
+		platformNameSet[types.Platform(platformName)] = struct{}{}
+	}
+
+	for name, p := range configFile.AuthConfigs {
+		_, ok := platformNameSet[p.Platform]
+		if len(platformNameSet) != 0 && !ok {
+			continue
+		}
+
+		client, err := newClient(configFile, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load account %q", name)
+		}
+		platforms = append(platforms, client)
+	}
+	return platforms, nil
+}
+
+// newClient builds the client of the account from the platform registry, or
+// the registered plugin of the platform.
+func newClient(configFile *config.File, auth types.AuthConfig) (platform.Cloud, error) {
+	if provider, ok := platform.Lookup(auth.Platform); ok {
+		return provider.Build(auth.Options)
+	}
+
+	pluginConfig, ok := configFile.Plugins[string(auth.Platform)]
+	if !ok {
+		return nil, errors.Errorf("unsupported platform: %q", auth.Platform)
+	}
+	opts := platform.AuthenticateOptions{
+		"id": fmt.Sprintf("%s@%s", auth.Platform, auth.AccountID),
+	}
+	for k, v := range auth.Options {
+		opts[k] = v
+	}
+	return plugin.New(string(auth.Platform), pluginConfig.Path, opts), nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/api"
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func importFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	// Remote function name => Raika function name
+	selected := make(map[string]string)
+	for _, function := range c.StringSlice("function") {
+		kv := strings.SplitN(function, "=", 2)
+		if len(kv) == 1 {
+			kv = append(kv, kv[0][strings.LastIndex(kv[0], "/")+1:])
+		}
+		selected[kv[0]] = kv[1]
+	}
+
+	imported := 0
+	for _, p := range platforms {
+		functions, err := p.ListFunctions()
+		if err != nil {
+			log.Error("Failed to list functions on %s: %v", p.GetID(), err)
+			continue
+		}
+
+		if len(selected) == 0 {
+			log.Info("[ %s ]", p.GetID())
+			for _, function := range functions {
+				log.Trace("   %s %s", remoteFunctionName(function), function.URL)
+			}
+			continue
+		}
+
+		for _, function := range functions {
+			name, ok := selected[remoteFunctionName(function)]
+			if !ok {
+				name, ok = selected[function.Name]
+			}
+			if !ok {
+				continue
+			}
+
+			if function.URL == "" {
+				log.Warn("[ %s ] Function %q has no HTTP trigger, it can only be invoked through the platform API", p.GetID(), function.Name)
+			}
+			err := store.Functions.Put(name, types.Function{
+				PlatformID:            p.GetID(),
+				URL:                   function.URL,
+				CreatedAt:             time.Now(),
+				Name:                  function.Name,
+				Namespace:             function.Namespace,
+				Description:           function.Description,
+				MemorySize:            function.MemorySize,
+				Environment:           function.EnvironmentVariables,
+				InitializationTimeout: function.InitializationTimeout,
+				RuntimeTimeout:        function.RuntimeTimeout,
+			})
+			if err != nil {
+				return errors.Wrap(err, "save function")
+			}
+			imported++
+			log.Info("[ %s ] %s -> %s", p.GetID(), remoteFunctionName(function), name)
+		}
+	}
+
+	if imported > 0 {
+		if err := api.Reload(); err != nil {
+			return errors.Wrap(err, "reload")
+		}
+	}
+	return nil
+}
+
+// remoteName returns the name of the function on the given platform, which
+// differs from the Raika function name if the function is imported.
+func remoteName(name, platformID string) string {
+	records, _ := store.Functions.Get(name)
+	if record := findRecord(records, platformID); record != nil {
+		return record.RemoteName()
+	}
+	return name
+}
+
+// remoteFunctionName returns the function name with its namespace.
+func remoteFunctionName(function *platform.FunctionInfo) string {
+	if function.Namespace == "" {
+		return function.Name
+	}
+	return function.Namespace + "/" + function.Name
+}
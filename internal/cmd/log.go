@@ -0,0 +1,99 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// logFollowInterval is the interval of polling the logs when following.
+const logFollowInterval = 3 * time.Second
+
+type platformLogEntry struct {
+	PlatformID string
+	*platform.LogEntry
+}
+
+func logsFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	follow := c.Bool("follow")
+	since := time.Now().Add(-c.Duration("since"))
+
+	// The last log time of each platform and the logs printed at that time,
+	// which are used to skip the printed logs when following.
+	lastTimes := make(map[string]time.Time, len(platforms))
+	printed := make(map[string]map[string]int, len(platforms))
+	for _, p := range platforms {
+		lastTimes[p.GetID()] = since
+	}
+
+	for {
+		var entries []*platformLogEntry
+		for _, p := range platforms {
+			id := p.GetID()
+			logs, err := p.Logs(remoteName(name, id), platform.LogOptions{Since: lastTimes[id]})
+			if err != nil {
+				log.Error("[ %s ] Failed to get logs: %v", id, err)
+				continue
+			}
+
+			// The logs at the last time are fetched again by the next poll.
+			// They are told apart by the IDs from the platform, or counted
+			// by the message, as the same line may be printed several times
+			// at the same time.
+			seen := make(map[string]int)
+			for _, l := range logs {
+				if l.Time.Before(lastTimes[id]) {
+					continue
+				}
+				if l.Time.After(lastTimes[id]) {
+					lastTimes[id] = l.Time
+					printed[id] = make(map[string]int)
+					seen = make(map[string]int)
+				}
+				if printed[id] == nil {
+					printed[id] = make(map[string]int)
+				}
+
+				key := "message:" + l.Message
+				if l.ID != "" {
+					key = "id:" + l.ID
+				}
+				seen[key]++
+				if seen[key] <= printed[id][key] {
+					continue
+				}
+				printed[id][key] = seen[key]
+
+				entries = append(entries, &platformLogEntry{
+					PlatformID: id,
+					LogEntry:   l,
+				})
+			}
+		}
+
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+		for _, entry := range entries {
+			fmt.Printf("%s [ %s ] %s\n", entry.Time.Format(time.RFC3339), entry.PlatformID, entry.Message)
+		}
+
+		if !follow {
+			return nil
+		}
+		time.Sleep(logFollowInterval)
+	}
+}
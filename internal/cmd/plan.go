@@ -0,0 +1,443 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/gobuild"
+	"github.com/wuhan005/Raika/internal/manifest"
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var Plan = &cli.Command{
+	Name:   "plan",
+	Usage:  "Preview the changes of deploying the manifest, or the function given by the flags",
+	Action: plan,
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "Manifest file path", Value: manifest.DefaultFileName},
+		&cli.StringFlag{Name: "name", Usage: "Function name, preview the function given by the flags instead of the manifest", Required: false},
+		&cli.StringFlag{Name: "description", Usage: "Function description", Required: false},
+		&cli.Int64Flag{Name: "memory", Usage: "Function runtime memory size", Required: false},
+		&cli.IntFlag{Name: "init-timeout", Usage: "Function runtime initialization timeout", Required: false},
+		&cli.IntFlag{Name: "runtime-timeout", Usage: "Function runtime timeout", Required: false},
+		&cli.StringFlag{Name: "binary-file", Usage: "Function binary file", Required: false},
+		&cli.StringFlag{Name: "source", Usage: "Go main package directory to build the binary for each platform", Required: false},
+		&cli.BoolFlag{Name: "build", Usage: "Build the binaries from the source which are not in the build cache, so that their code is compared", Required: false},
+		&cli.StringFlag{Name: "package-dir", Usage: "Directory of the files to pack with the binary", Required: false},
+		&cli.StringSliceFlag{Name: "include", Usage: "Glob of the files in the package directory to pack", Required: false},
+		&cli.StringSliceFlag{Name: "exclude", Usage: "Glob of the files in the package directory not to pack", Required: false},
+		&cli.StringFlag{Name: "image", Usage: "Container image to deploy on the container platforms instead of building from the binary", Required: false},
+		&cli.StringSliceFlag{Name: "platform", Usage: "Platform to deploy", Required: false},
+		&cli.StringSliceFlag{Name: "env", Usage: "Environment variables", Required: false},
+		&cli.StringFlag{Name: "trigger", Usage: "Function trigger method, http or cron", Required: false, Value: "http"},
+		&cli.StringFlag{Name: "cron", Usage: "Cron expression with seconds for timer trigger", Required: false, Value: "0 30 * * * *"},
+		&cli.StringFlag{Name: "trigger-file", Usage: "JSON file of the trigger list, overrides the trigger and the cron flags", Required: false},
+		&cli.StringFlag{Name: "alias", Usage: "Alias to bind the triggers to", Required: false},
+	},
+}
+
+// The actions of the planned changes.
+const (
+	planAdd     = "+"
+	planUpdate  = "~"
+	planReplace = "-/+"
+	planDelete  = "-"
+)
+
+// planChange is a change of the function on a platform account.
+type planChange struct {
+	Action string
+	Name   string
+	Diffs  []string
+}
+
+// planTarget is the desired function on a platform account.
+type planTarget struct {
+	Platform platform.Cloud
+	Options  platform.CreateFunctionOptions
+	// Source is the Go main package directory the binary is built from.
+	Source string
+}
+
+func plan(c *cli.Context) error {
+	var targets []planTarget
+	var removed []removedFunction
+	var project string
+	clients := make(map[string]platform.Cloud)
+
+	if c.String("name") != "" {
+		opts, err := functionOptions(c)
+		if err != nil {
+			return err
+		}
+		if opts.MemorySize <= 0 || opts.InitializationTimeout <= 0 || opts.RuntimeTimeout <= 0 {
+			return errors.New("memory, init-timeout and runtime-timeout are required")
+		}
+		source := c.String("source")
+		if (opts.File == "") == (source == "") {
+			return errors.New("either binary-file or source is required")
+		}
+
+		platforms, err := loadPlatforms(c)
+		if err != nil {
+			return err
+		}
+		for _, p := range platforms {
+			if err := platform.CheckTriggers(p, opts.Triggers); err != nil {
+				return errors.Wrapf(err, "check triggers on %s", p)
+			}
+			targets = append(targets, planTarget{Platform: p, Options: opts, Source: source})
+			clients[p.GetID()] = p
+		}
+
+	} else {
+		m, err := manifest.Load(c.String("file"))
+		if err != nil {
+			return errors.Wrap(err, "load manifest")
+		}
+		accounts, err := loadAccounts(c)
+		if err != nil {
+			return err
+		}
+		if err := checkAccounts(m, accounts); err != nil {
+			return err
+		}
+
+		desired := make(map[string]map[string]struct{}, len(m.Functions))
+		for _, f := range m.Functions {
+			opts := m.Options(f)
+			desired[f.Name] = make(map[string]struct{})
+			for _, name := range m.AccountNames(f) {
+				p := accounts[name]
+				desired[f.Name][p.GetID()] = struct{}{}
+				targets = append(targets, planTarget{Platform: p, Options: opts, Source: m.SourceDir(f)})
+			}
+		}
+		for _, p := range accounts {
+			clients[p.GetID()] = p
+		}
+		removed = removedFunctions(m.Project, desired)
+		project = m.Project
+	}
+
+	// Platform ID => changes
+	changes := make(map[string][]planChange)
+	var failed int
+	for _, target := range targets {
+		p := target.Platform
+		opts := target.Options
+		if target.Source != "" {
+			// The binary is compared by its path in the build cache, which
+			// changes with the source. It is only built on demand, as the plan
+			// should not run the toolchain by default.
+			resolve := gobuild.Path
+			if c.Bool("build") {
+				resolve = gobuild.Build
+			}
+			file, err := resolve(gobuild.DefaultCacheDir, target.Source, platform.TargetOf(p))
+			if err != nil {
+				log.Error("[ %s ] Failed to plan function %q: %v", p.GetID(), opts.Name, err)
+				failed++
+				continue
+			}
+			opts.File = file
+		}
+
+		change, err := planFunction(p, opts, project)
+		if err != nil {
+			log.Error("[ %s ] Failed to plan function %q: %v", p.GetID(), target.Options.Name, err)
+			failed++
+			continue
+		}
+		if change != nil {
+			changes[p.GetID()] = append(changes[p.GetID()], *change)
+		}
+	}
+	for _, record := range removed {
+		change := planChange{Action: planDelete, Name: record.Name}
+		if p, ok := clients[record.PlatformID]; !ok {
+			change.Diffs = append(change.Diffs, "account is not found, the function is kept")
+		} else if _, err := p.Describe(record.RemoteName()); err == platform.ErrFunctionNotExists {
+			change.Diffs = append(change.Diffs, "not found on the platform, only the record is removed")
+		}
+		changes[record.PlatformID] = append(changes[record.PlatformID], change)
+	}
+
+	platformIDs := make([]string, 0, len(changes))
+	for id := range changes {
+		platformIDs = append(platformIDs, id)
+	}
+	sort.Strings(platformIDs)
+
+	counts := make(map[string]int)
+	for _, id := range platformIDs {
+		log.Info("[ %s ]", id)
+		for _, change := range changes[id] {
+			counts[change.Action]++
+			if change.Action == planAdd {
+				log.Info("   %s %s", change.Action, change.Name)
+			} else {
+				log.Warn("   %s %s", change.Action, change.Name)
+			}
+			for _, diff := range change.Diffs {
+				log.Warn("      %s", diff)
+			}
+		}
+	}
+
+	if failed != 0 {
+		return errors.Errorf("failed to plan %d functions", failed)
+	}
+	log.Info("Plan: %d to add, %d to update, %d to replace, %d to delete.",
+		counts[planAdd], counts[planUpdate], counts[planReplace], counts[planDelete])
+
+	var pending int
+	for _, count := range counts {
+		pending += count
+	}
+	if pending != 0 {
+		return cli.Exit(fmt.Sprintf("%d changes are pending", pending), 2)
+	}
+	log.Info("No changes.")
+	return nil
+}
+
+// planFunction compares the desired function with the function file and the
+// live function on the platform. It returns nil if nothing would change. The
+// project is empty if the function is given by the flags.
+func planFunction(p platform.Cloud, opts platform.CreateFunctionOptions, project string) (*planChange, error) {
+	record := findRecord(store.Functions.Functions[opts.Name], p.GetID())
+	if project != "" && record != nil && record.Project != "" && record.Project != project {
+		return nil, errors.Errorf("the function belongs to project %q", record.Project)
+	}
+	// The function keeps its alias if it is not given, the same as deploying.
+	if opts.Alias == "" && record != nil {
+		opts.Alias = record.Alias
+	}
+
+	info, err := p.Describe(remoteName(opts.Name, p.GetID()))
+	if err == platform.ErrFunctionNotExists {
+		change := &planChange{Action: planAdd, Name: opts.Name}
+		if record != nil {
+			change.Diffs = append(change.Diffs, "recorded in the function file but not found on the platform")
+		}
+		return change, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "describe")
+	}
+
+	change := &planChange{Action: planUpdate, Name: opts.Name}
+	change.Diffs = diffOptions(info, opts)
+	// The code is compared by its checksum if the platform reports it, so
+	// that a binary rebuilt at the same path is told. Otherwise only the
+	// path of the binary is compared with the record.
+	var codeCompared bool
+	if cp, ok := p.(platform.CodeChecksumPlatform); ok && opts.File != "" {
+		diff, compared, err := diffCode(cp, info, opts)
+		if err != nil {
+			return nil, err
+		}
+		if diff != "" {
+			change.Diffs = append(change.Diffs, diff)
+		}
+		codeCompared = compared
+	}
+	if diff, err := diffAlias(p, opts); err != nil {
+		return nil, err
+	} else if diff != "" {
+		change.Diffs = append(change.Diffs, diff)
+	}
+	if record == nil {
+		change.Diffs = append(change.Diffs, "not recorded in the function file, the existing function is taken over")
+		if opts.Alias != "" {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("alias: %q, the triggers are bound to it", opts.Alias))
+		}
+	} else {
+		if project != "" && record.Project != project {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("project: %q -> %q", record.Project, project))
+		}
+		if !codeCompared && record.File != opts.File {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("file: %q -> %q", record.File, opts.File))
+		}
+		// The port is compared with the live one by diffOptions if the
+		// platform reports it.
+		if info.HTTPPort == 0 && record.HTTPPort != opts.HTTPPort {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("http_port: %d -> %d", record.HTTPPort, opts.HTTPPort))
+		}
+		if diff := diffPackage(record.Package, opts.Package); diff != "" {
+			change.Diffs = append(change.Diffs, diff)
+		}
+		if record.Image != opts.Image {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("image: %q -> %q", record.Image, opts.Image))
+		}
+
+		replaces := diffTriggers(record, opts, change)
+		if record.Alias != opts.Alias {
+			// The triggers are bound to the new alias, which are re-created on
+			// the platforms can't change the qualifier of a trigger.
+			change.Diffs = append(change.Diffs, fmt.Sprintf("alias: %q -> %q, the triggers are re-bound", record.Alias, opts.Alias))
+			replaces = true
+		}
+		if replaces {
+			change.Action = planReplace
+		}
+	}
+
+	if len(change.Diffs) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// diffCode compares the checksum of the code package with the live one, and
+// reports whether the code is compared. The code is not compared if the binary
+// is not built yet.
+func diffCode(cp platform.CodeChecksumPlatform, info *platform.FunctionInfo, opts platform.CreateFunctionOptions) (string, bool, error) {
+	if _, err := os.Stat(opts.File); os.IsNotExist(err) {
+		log.Warn("The binary %q of function %q is not built, the code is not compared, use --build to build it", opts.File, opts.Name)
+		return "", false, nil
+	}
+
+	checksum, err := cp.CodeChecksum(opts)
+	if err != nil {
+		return "", false, errors.Wrap(err, "get code checksum")
+	}
+	if checksum == info.CodeChecksum {
+		return "", true, nil
+	}
+	return fmt.Sprintf("code: %s -> %s", info.CodeChecksum, checksum), true, nil
+}
+
+// diffAlias checks the alias the triggers are bound to exists on the platform,
+// it is only checked on the platforms which describe the aliases.
+func diffAlias(p platform.Cloud, opts platform.CreateFunctionOptions) (string, error) {
+	if _, ok := p.(platform.QualifierPlatform); !ok || opts.Alias == "" {
+		return "", nil
+	}
+	_, err := platform.DescribeQualified(p, remoteName(opts.Name, p.GetID()), opts.Alias)
+	if err == platform.ErrFunctionNotExists {
+		return fmt.Sprintf("alias %s: not found on the platform, it is created", opts.Alias), nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "describe alias")
+	}
+	return "", nil
+}
+
+// diffOptions returns the fields differ between the live function and the
+// desired one.
+func diffOptions(info *platform.FunctionInfo, opts platform.CreateFunctionOptions) []string {
+	var diffs []string
+	if info.Description != opts.Description {
+		diffs = append(diffs, fmt.Sprintf("description: %q -> %q", info.Description, opts.Description))
+	}
+	if info.MemorySize != opts.MemorySize {
+		diffs = append(diffs, fmt.Sprintf("memory_size: %d -> %d", info.MemorySize, opts.MemorySize))
+	}
+	// Not all the platforms have the initialization timeout.
+	if info.InitializationTimeout != 0 && info.InitializationTimeout != opts.InitializationTimeout {
+		diffs = append(diffs, fmt.Sprintf("initialization_timeout: %s -> %s", info.InitializationTimeout, opts.InitializationTimeout))
+	}
+	if info.RuntimeTimeout != opts.RuntimeTimeout {
+		diffs = append(diffs, fmt.Sprintf("runtime_timeout: %s -> %s", info.RuntimeTimeout, opts.RuntimeTimeout))
+	}
+	if info.HTTPPort != 0 && info.HTTPPort != opts.HTTPPort {
+		diffs = append(diffs, fmt.Sprintf("http_port: %d -> %d", info.HTTPPort, opts.HTTPPort))
+	}
+
+	keys := make([]string, 0, len(opts.EnvironmentVariables))
+	for k := range opts.EnvironmentVariables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		live, ok := info.EnvironmentVariables[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("environment: %s added", k))
+		} else if live != opts.EnvironmentVariables[k] {
+			diffs = append(diffs, fmt.Sprintf("environment: %s changed", k))
+		}
+	}
+	keys = keys[:0]
+	for k := range info.EnvironmentVariables {
+		if _, ok := opts.EnvironmentVariables[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		diffs = append(diffs, fmt.Sprintf("environment: %s removed", k))
+	}
+	return diffs
+}
+
+// diffPackage returns the difference between the recorded package and the
+// desired one, it is empty if they are the same.
+func diffPackage(record *types.FunctionPackage, spec *platform.PackageSpec) string {
+	var desired *types.FunctionPackage
+	if spec != nil {
+		desired = &types.FunctionPackage{Dir: spec.Dir, Include: spec.Include, Exclude: spec.Exclude}
+	}
+	from, to := formatPackage(record), formatPackage(desired)
+	if from == to {
+		return ""
+	}
+	return fmt.Sprintf("package: %s -> %s", from, to)
+}
+
+func formatPackage(pkg *types.FunctionPackage) string {
+	if pkg == nil {
+		return "none"
+	}
+	s := fmt.Sprintf("%q", pkg.Dir)
+	if len(pkg.Include) != 0 {
+		s += fmt.Sprintf(" include %q", pkg.Include)
+	}
+	if len(pkg.Exclude) != 0 {
+		s += fmt.Sprintf(" exclude %q", pkg.Exclude)
+	}
+	return s
+}
+
+// diffTriggers appends the trigger differences between the function record and
+// the desired triggers to the change. It reports whether any trigger would be
+// replaced.
+func diffTriggers(record *types.Function, opts platform.CreateFunctionOptions, change *planChange) bool {
+	var replaces bool
+	for _, spec := range opts.Triggers {
+		trigger, ok := record.GetTrigger(spec.Name)
+		switch {
+		case !ok:
+			change.Diffs = append(change.Diffs, fmt.Sprintf("trigger %s: added", spec.Name))
+		case trigger.Type != spec.Type:
+			change.Diffs = append(change.Diffs, fmt.Sprintf("trigger %s: %s -> %s, the trigger is re-created", spec.Name, trigger.Type, spec.Type))
+			replaces = true
+		case trigger.Cron != spec.Cron || trigger.Payload != spec.Payload:
+			change.Diffs = append(change.Diffs, fmt.Sprintf("trigger %s: changed", spec.Name))
+		}
+	}
+
+	for _, trigger := range record.Triggers {
+		var found bool
+		for _, spec := range opts.Triggers {
+			if spec.Name == trigger.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			change.Diffs = append(change.Diffs, fmt.Sprintf("trigger %s: removed", trigger.Name))
+		}
+	}
+	return replaces
+}
@@ -0,0 +1,328 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func TestPlanFunction(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "hello")
+	if err := os.WriteFile(binary, []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(dir, "hello-copy")
+	if err := os.WriteFile(copied, []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := filepath.Join(dir, "hello-rebuilt")
+	if err := os.WriteFile(rebuilt, []byte("hello, world"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The SHA-256 of "hello".
+	const checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	opts := platform.CreateFunctionOptions{
+		Name:                  "hello",
+		MemorySize:            128,
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        10 * time.Second,
+		File:                  binary,
+		Triggers:              []platform.TriggerSpec{{Name: "http", Type: platform.HTTPTrigger}},
+	}
+	live := platform.FunctionInfo{
+		Name:                  "hello",
+		MemorySize:            128,
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        10 * time.Second,
+		CodeChecksum:          checksum,
+	}
+	record := types.Function{
+		Name:                  "hello",
+		MemorySize:            128,
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        10 * time.Second,
+		File:                  binary,
+		Triggers:              []types.FunctionTrigger{{Name: "http", Type: platform.HTTPTrigger}},
+	}
+
+	tests := []struct {
+		name    string
+		cloud   func(*fakeCloud) platform.Cloud
+		live    *platform.FunctionInfo
+		record  *types.Function
+		opts    func(*platform.CreateFunctionOptions)
+		project string
+
+		wantAction string
+		wantDiffs  []string
+		wantErr    string
+	}{
+		{
+			name:       "add",
+			wantAction: planAdd,
+		},
+		{
+			name:       "add the recorded function not found",
+			record:     &record,
+			wantAction: planAdd,
+			wantDiffs:  []string{"recorded in the function file but not found on the platform"},
+		},
+		{
+			name:   "no change",
+			live:   &live,
+			record: &record,
+		},
+		{
+			name:       "configuration changed",
+			live:       &live,
+			record:     &record,
+			opts:       func(opts *platform.CreateFunctionOptions) { opts.MemorySize = 256 },
+			wantAction: planUpdate,
+			wantDiffs:  []string{"memory_size: 128 -> 256"},
+		},
+		{
+			name:       "taken over",
+			live:       &live,
+			wantAction: planUpdate,
+			wantDiffs:  []string{"not recorded in the function file, the existing function is taken over"},
+		},
+		{
+			name:       "binary rebuilt at the same path",
+			cloud:      func(c *fakeCloud) platform.Cloud { return checksumCloud{c} },
+			live:       &live,
+			record:     &types.Function{Name: "hello", MemorySize: 128, InitializationTimeout: 10 * time.Second, RuntimeTimeout: 10 * time.Second, File: rebuilt, Triggers: record.Triggers},
+			opts:       func(opts *platform.CreateFunctionOptions) { opts.File = rebuilt },
+			wantAction: planUpdate,
+			wantDiffs:  []string{"code: " + checksum + " -> "},
+		},
+		{
+			name:   "same code at another path",
+			cloud:  func(c *fakeCloud) platform.Cloud { return checksumCloud{c} },
+			live:   &live,
+			record: &record,
+			opts:   func(opts *platform.CreateFunctionOptions) { opts.File = copied },
+		},
+		{
+			name:       "another path without the code checksum",
+			live:       &live,
+			record:     &record,
+			opts:       func(opts *platform.CreateFunctionOptions) { opts.File = copied },
+			wantAction: planUpdate,
+			wantDiffs:  []string{"file: "},
+		},
+		{
+			name:   "trigger type changed",
+			live:   &live,
+			record: &record,
+			opts: func(opts *platform.CreateFunctionOptions) {
+				opts.Triggers = []platform.TriggerSpec{{Name: "http", Type: platform.CronTrigger, Cron: "0 0 * * * *"}}
+			},
+			wantAction: planReplace,
+			wantDiffs:  []string{"trigger http: http -> cron, the trigger is re-created"},
+		},
+		{
+			name:       "alias changed",
+			live:       &live,
+			record:     &types.Function{Name: "hello", MemorySize: 128, InitializationTimeout: 10 * time.Second, RuntimeTimeout: 10 * time.Second, File: binary, Triggers: record.Triggers, Alias: "live"},
+			opts:       func(opts *platform.CreateFunctionOptions) { opts.Alias = "canary" },
+			wantAction: planReplace,
+			wantDiffs:  []string{`alias: "live" -> "canary", the triggers are re-bound`},
+		},
+		{
+			name:   "alias kept",
+			live:   &live,
+			record: &types.Function{Name: "hello", MemorySize: 128, InitializationTimeout: 10 * time.Second, RuntimeTimeout: 10 * time.Second, File: binary, Triggers: record.Triggers, Alias: "live"},
+		},
+		{
+			name:       "alias not found",
+			cloud:      func(c *fakeCloud) platform.Cloud { return qualifierCloud{fakeCloud: c} },
+			live:       &live,
+			record:     &types.Function{Name: "hello", MemorySize: 128, InitializationTimeout: 10 * time.Second, RuntimeTimeout: 10 * time.Second, File: binary, Triggers: record.Triggers, Alias: "live"},
+			wantAction: planUpdate,
+			wantDiffs:  []string{"alias live: not found on the platform, it is created"},
+		},
+		{
+			name:       "alias of the function taken over",
+			live:       &live,
+			opts:       func(opts *platform.CreateFunctionOptions) { opts.Alias = "live" },
+			wantAction: planUpdate,
+			wantDiffs:  []string{"not recorded in the function file", `alias: "live", the triggers are bound to it`},
+		},
+		{
+			name:    "function of another project",
+			live:    &live,
+			record:  &types.Function{Name: "hello", Project: "other"},
+			project: "demo",
+			wantErr: `the function belongs to project "other"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeCloud(t, "1")
+			var p platform.Cloud = fake
+			if test.cloud != nil {
+				p = test.cloud(fake)
+			}
+			if test.live != nil {
+				info := *test.live
+				fake.functions[info.Name] = &info
+			}
+			if test.record != nil {
+				record := *test.record
+				record.PlatformID = fake.GetID()
+				initFunctionStore(t, record)
+			} else {
+				initFunctionStore(t)
+			}
+			opts := opts
+			if test.opts != nil {
+				test.opts(&opts)
+			}
+
+			change, err := planFunction(p, opts, test.project)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.wantAction == "" {
+				if change != nil {
+					t.Fatalf("want no change, got %+v", change)
+				}
+				return
+			}
+			if change == nil || change.Action != test.wantAction {
+				t.Fatalf("want action %q, got %+v", test.wantAction, change)
+			}
+			if len(change.Diffs) != len(test.wantDiffs) {
+				t.Fatalf("want diffs %q, got %q", test.wantDiffs, change.Diffs)
+			}
+			for i, diff := range test.wantDiffs {
+				if !strings.HasPrefix(change.Diffs[i], diff) {
+					t.Fatalf("want diffs %q, got %q", test.wantDiffs, change.Diffs)
+				}
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "hello")
+	if err := os.WriteFile(binary, []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	config := `{"auths": {"test": {"platform": "fake", "options": {"account": "1"}}}}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifestFile := filepath.Join(dir, "raika.yaml")
+	manifest := `
+project: demo
+platforms: [test]
+functions:
+  - name: hello
+    memory: 128
+    init_timeout: 10s
+    runtime_timeout: 10s
+    binary_file: hello
+    triggers: []
+`
+	if err := os.WriteFile(manifestFile, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hello := types.Function{
+		Name:                  "hello",
+		MemorySize:            128,
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        10 * time.Second,
+		HTTPPort:              9000,
+		File:                  binary,
+		Project:               "demo",
+	}
+	tests := []struct {
+		name     string
+		records  []types.Function
+		live     []string
+		wantCode int
+	}{
+		{
+			name:     "function added",
+			wantCode: 2,
+		},
+		{
+			name:     "function removed from the manifest",
+			records:  []types.Function{hello, {Name: "bye", Project: "demo"}},
+			live:     []string{"hello", "bye"},
+			wantCode: 2,
+		},
+		{
+			name:    "function of another project is kept",
+			records: []types.Function{hello, {Name: "bye", Project: "other"}},
+			live:    []string{"hello", "bye"},
+		},
+		{
+			name:    "no changes",
+			records: []types.Function{hello},
+			live:    []string{"hello"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeCloud(t, "1")
+			for _, name := range test.live {
+				fake.functions[name] = &platform.FunctionInfo{
+					Name:                  name,
+					MemorySize:            128,
+					InitializationTimeout: 10 * time.Second,
+					RuntimeTimeout:        10 * time.Second,
+				}
+			}
+			for i := range test.records {
+				test.records[i].PlatformID = fake.GetID()
+			}
+			initFunctionStore(t, test.records...)
+
+			app := &cli.App{
+				Flags:          []cli.Flag{&cli.StringFlag{Name: "config-file"}},
+				Commands:       []*cli.Command{Plan},
+				ExitErrHandler: func(*cli.Context, error) {},
+			}
+			err := app.Run([]string{"raika", "--config-file", configFile, "plan", "-f", manifestFile})
+			if test.wantCode == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			exitErr, ok := err.(cli.ExitCoder)
+			if !ok || exitErr.ExitCode() != test.wantCode {
+				t.Fatalf("want exit code %d, got %v", test.wantCode, err)
+			}
+			// The plan changes nothing.
+			if len(fake.deleted) != 0 {
+				t.Fatalf("want no function deleted, got %q", fake.deleted)
+			}
+		})
+	}
+}
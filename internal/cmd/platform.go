@@ -5,16 +5,29 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 	log "unknwon.dev/clog/v2"
 
 	"github.com/wuhan005/Raika/internal/config"
 	"github.com/wuhan005/Raika/internal/platform"
-	"github.com/wuhan005/Raika/internal/platform/aliyun"
-	"github.com/wuhan005/Raika/internal/platform/aws"
-	"github.com/wuhan005/Raika/internal/platform/tencentcloud"
+	"github.com/wuhan005/Raika/internal/platform/local"
+	"github.com/wuhan005/Raika/internal/platform/plugin"
 	"github.com/wuhan005/Raika/internal/types"
+
+	// Register the built-in platforms.
+	_ "github.com/wuhan005/Raika/internal/platform/aliyun"
+	_ "github.com/wuhan005/Raika/internal/platform/aws"
+	_ "github.com/wuhan005/Raika/internal/platform/azure"
+	_ "github.com/wuhan005/Raika/internal/platform/gcp"
+	_ "github.com/wuhan005/Raika/internal/platform/huaweicloud"
+	_ "github.com/wuhan005/Raika/internal/platform/knative"
+	_ "github.com/wuhan005/Raika/internal/platform/openfaas"
+	_ "github.com/wuhan005/Raika/internal/platform/tencentcloud"
 )
 
 var Platform = &cli.Command{
@@ -25,66 +38,168 @@ var Platform = &cli.Command{
 			Name:   "login",
 			Usage:  "Login in to a new cloud service",
 			Action: loginPlatform,
-			Flags: []cli.Flag{
-				&cli.StringFlag{Name: "platform", Usage: "Cloud platform name", Required: true},
-				&cli.StringFlag{Name: "region-id", Usage: "Cloud platform region ID"},
-				&cli.StringFlag{Name: "secret-id", Usage: "Cloud platform secret ID"},
-				&cli.StringFlag{Name: "secret-key", Usage: "Cloud platform secret key ID"},
-				&cli.StringFlag{Name: "account-id", Usage: "Cloud platform account ID"},
-				&cli.StringFlag{Name: "access-key-id", Usage: "Cloud platform access key ID"},
-				&cli.StringFlag{Name: "access-key-secret", Usage: "Cloud platform access key secret"},
-				&cli.StringFlag{Name: "name", Usage: "Name of this account"},
-			},
+			Flags:  loginFlags(),
 		},
 		{
 			Name:   "list",
 			Usage:  "List the current cloud service",
 			Action: listPlatform,
 		},
+		pluginCommand,
+		{
+			Name:   "local-supervise",
+			Usage:  "Run the supervisor of a local function",
+			Hidden: true,
+			Action: superviseLocal,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dir", Usage: "Function directory", Required: true},
+			},
+		},
 	},
 }
 
+// loginFlags returns the login flags generated from the credential fields of
+// the registered platforms, the platforms using a flag are listed in its usage.
+func loginFlags() []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "platform", Usage: "Cloud platform name", Required: true},
+		&cli.StringFlag{Name: "name", Usage: "Name of this account"},
+		&cli.StringSliceFlag{Name: "option", Usage: "Option of the platform plugin in key=value format"},
+	}
+
+	var fields []platform.Field
+	platforms := make(map[string][]string)
+	for _, provider := range platform.Providers() {
+		for _, field := range provider.Fields {
+			if _, ok := platforms[field.Name]; !ok {
+				fields = append(fields, field)
+			}
+			platforms[field.Name] = append(platforms[field.Name], string(provider.Platform))
+		}
+	}
+
+	for _, field := range fields {
+		usage := field.Usage
+		// The remarks after the comma are specific to a platform.
+		if len(platforms[field.Name]) > 1 {
+			usage = strings.SplitN(usage, ", ", 2)[0]
+		}
+		flag := &cli.StringFlag{
+			Name:  field.Flag(),
+			Usage: fmt.Sprintf("%s (%s)", usage, strings.Join(platforms[field.Name], ", ")),
+		}
+		if field.Secret {
+			flag.EnvVars = []string{field.EnvVar()}
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// credentialOptions returns the credential options of the platform from the
+// login flags, the flags of the other platforms are rejected.
+func credentialOptions(c *cli.Context, provider *platform.Provider) (platform.AuthenticateOptions, error) {
+	opts := make(platform.AuthenticateOptions)
+	known := make(map[string]struct{}, len(provider.Fields))
+	for _, field := range provider.Fields {
+		known[field.Flag()] = struct{}{}
+
+		value := c.String(field.Flag())
+		if value == "" {
+			continue
+		}
+		if field.File {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "read --%s", field.Flag())
+			}
+			value = string(data)
+		}
+		opts[field.Name] = value
+	}
+
+	for _, name := range c.LocalFlagNames() {
+		switch name {
+		case "platform", "name":
+			continue
+		}
+		// The secrets exported for the other platforms are not an error.
+		if _, ok := known[name]; !ok && !fromEnv(c, name) {
+			return nil, errors.Errorf("flag --%s is not used by %s", name, provider.Platform)
+		}
+	}
+	return opts, nil
+}
+
+// fromEnv returns true if the value of the flag is given by its environment
+// variable rather than on the command line.
+func fromEnv(c *cli.Context, name string) bool {
+	for _, flag := range c.Command.Flags {
+		f, ok := flag.(*cli.StringFlag)
+		if !ok || f.Name != name {
+			continue
+		}
+		for _, env := range f.EnvVars {
+			if value, ok := os.LookupEnv(env); ok && value == c.String(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func loginPlatform(c *cli.Context) error {
 	name := c.String("name")
 	configFilePath := c.String("config-file")
-	regionID := c.String("region-id")
 
-	secretID := c.String("secret-id")
-	secretKey := c.String("secret-key")
-
-	accountID := c.String("account-id")
-	accessKeyID := c.String("access-key-id")
-	accessKeySecret := c.String("access-key-secret")
+	configFile := config.New(configFilePath)
+	if err := configFile.Load(); err != nil {
+		return errors.Wrap(err, "load config file")
+	}
 
 	var client platform.Cloud
+	var pluginClient *plugin.Client
+	var options platform.AuthenticateOptions
 	p := types.Platform(c.String("platform"))
-	switch p {
-	case types.Aliyun:
-		client = aliyun.New(platform.AuthenticateOptions{
-			aliyun.RegionIDField:        regionID,
-			aliyun.AccountIDField:       accountID,
-			aliyun.AccessKeyIDField:     accessKeyID,
-			aliyun.AccessKeySecretField: accessKeySecret,
-		})
-	case types.TencentCloud:
-		client = tencentcloud.New(platform.AuthenticateOptions{
-			tencentcloud.RegionIDField:  regionID,
-			tencentcloud.SecretIDField:  secretID,
-			tencentcloud.SecretKeyField: secretKey,
-		})
-	case types.AWS:
-		client = aws.New(platform.AuthenticateOptions{
-			aws.RegionIDField:  regionID,
-			aws.AccountIDField: accountID,
-			aws.AccessKeyField: accessKeyID,
-			aws.SecretKeyField: secretKey,
-		})
-	default:
-		return errors.Errorf("unsupported platform: %q", p)
-	}
+	if provider, ok := platform.Lookup(p); ok {
+		var err error
+		options, err = credentialOptions(c, provider)
+		if err != nil {
+			return err
+		}
+		if err := provider.Validate(options); err != nil {
+			return err
+		}
+		if provider.Prepare != nil {
+			if err := provider.Prepare(options); err != nil {
+				return err
+			}
+		}
 
-	if client == nil {
-		return errors.New("unexpected error, client is nil")
+		client, err = provider.Build(options)
+		if err != nil {
+			return err
+		}
+	} else if pluginConfig, ok := configFile.Plugins[string(p)]; ok {
+		options = make(platform.AuthenticateOptions)
+		for _, name := range c.LocalFlagNames() {
+			switch name {
+			case "platform", "name", "option":
+				continue
+			}
+			return errors.Errorf("flag --%s is not used by the %q plugin, use --option instead", name, p)
+		}
+		for _, option := range c.StringSlice("option") {
+			kv := strings.SplitN(option, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return errors.Errorf("invalid option %q, it should be in key=value format", option)
+			}
+			options[kv[0]] = kv[1]
+		}
+		pluginClient = plugin.New(string(p), pluginConfig.Path, options)
+		client = pluginClient
+	} else {
+		return errors.Errorf("unsupported platform: %q", p)
 	}
 
 	if err := client.Authenticate(); err != nil {
@@ -93,24 +208,22 @@ func loginPlatform(c *cli.Context) error {
 
 	log.Info("Authenticate to %q succeed.", p)
 
-	// Save the authenticate config to file.
-	configFile := config.New(configFilePath)
-	if err := configFile.Load(); err != nil {
-		return errors.Wrap(err, "load config file")
-	}
-
 	if name == "" {
 		name = string(p)
 	}
+	var accountID string
+	if pluginClient != nil {
+		accountID = pluginClient.AccountID()
+		if accountID == "" {
+			accountID = name
+		}
+	}
 
+	// Save the authenticate config to file.
 	configFile.AuthConfigs[name] = types.AuthConfig{
-		Platform:        p,
-		RegionID:        regionID,
-		SecretID:        secretID,
-		SecretKey:       secretKey,
-		AccountID:       accountID,
-		AccessKeyID:     accessKeyID,
-		AccessKeySecret: accessKeySecret,
+		Platform:  p,
+		AccountID: accountID,
+		Options:   options,
 	}
 	return configFile.Save()
 }
@@ -128,9 +241,18 @@ func listPlatform(c *cli.Context) error {
 	}
 
 	i := 0
-	for _, p := range configFile.AuthConfigs {
+	for name, auth := range configFile.AuthConfigs {
 		i++
-		log.Trace("%02d - [ %s ] %s", i, p.Platform, p.GetID())
+		client, err := newClient(configFile, auth)
+		if err != nil {
+			log.Warn("%02d - [ %s ] %s: %v", i, auth.Platform, name, err)
+			continue
+		}
+		log.Trace("%02d - [ %s ] %s", i, auth.Platform, client.GetID())
 	}
 	return nil
 }
+
+func superviseLocal(c *cli.Context) error {
+	return local.Supervise(c.String("dir"))
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/openfaas"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func TestCredentialOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    platform.AuthenticateOptions
+		wantErr string
+	}{
+		{
+			name: "flags",
+			args: []string{"--gateway", "http://127.0.0.1:8080", "--password", "secret"},
+			want: platform.AuthenticateOptions{
+				openfaas.GatewayField:  "http://127.0.0.1:8080",
+				openfaas.PasswordField: "secret",
+			},
+		},
+		{
+			name: "secret from the environment",
+			args: []string{"--gateway", "http://127.0.0.1:8080"},
+			env:  map[string]string{"RAIKA_PASSWORD": "secret"},
+			want: platform.AuthenticateOptions{
+				openfaas.GatewayField:  "http://127.0.0.1:8080",
+				openfaas.PasswordField: "secret",
+			},
+		},
+		{
+			name: "secret of another platform from the environment",
+			args: []string{"--gateway", "http://127.0.0.1:8080"},
+			env:  map[string]string{"RAIKA_ACCESS_KEY_SECRET": "secret"},
+			want: platform.AuthenticateOptions{
+				openfaas.GatewayField: "http://127.0.0.1:8080",
+			},
+		},
+		{
+			name:    "flag of another platform",
+			args:    []string{"--gateway", "http://127.0.0.1:8080", "--access-key-secret", "secret"},
+			wantErr: "flag --access-key-secret is not used by openfaas",
+		},
+		{
+			name:    "flag of another platform overriding the environment",
+			args:    []string{"--gateway", "http://127.0.0.1:8080", "--access-key-secret", "secret"},
+			env:     map[string]string{"RAIKA_ACCESS_KEY_SECRET": "exported"},
+			wantErr: "flag --access-key-secret is not used by openfaas",
+		},
+	}
+	provider, ok := platform.Lookup(types.Platform("openfaas"))
+	if !ok {
+		t.Fatal("openfaas is not registered")
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for k, v := range test.env {
+				if err := os.Setenv(k, v); err != nil {
+					t.Fatal(err)
+				}
+				defer func(k string) { _ = os.Unsetenv(k) }(k)
+			}
+
+			var got platform.AuthenticateOptions
+			var err error
+			app := &cli.App{
+				Commands: []*cli.Command{
+					{
+						Name:  "login",
+						Flags: loginFlags(),
+						Action: func(c *cli.Context) error {
+							got, err = credentialOptions(c, provider)
+							return nil
+						},
+					},
+				},
+			}
+			args := append([]string{"raika", "login", "--platform", "openfaas"}, test.args...)
+			if err := app.Run(args); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+			for k, v := range test.want {
+				if got[k] != v {
+					t.Fatalf("want %v, got %v", test.want, got)
+				}
+			}
+		})
+	}
+}
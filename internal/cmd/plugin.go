@@ -0,0 +1,129 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/config"
+	"github.com/wuhan005/Raika/internal/platform/plugin"
+)
+
+var pluginCommand = &cli.Command{
+	Name:  "plugin",
+	Usage: "Manage the platform plugins",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "add",
+			Usage:  "Register a platform plugin",
+			Action: addPlugin,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Platform name of the plugin", Required: true},
+				&cli.StringFlag{Name: "path", Usage: "Path of the plugin executable, `raika-platform-<name>` in the PATH is used by default"},
+			},
+		},
+		{
+			Name:   "list",
+			Usage:  "List the registered platform plugins",
+			Action: listPlugins,
+		},
+		{
+			Name:   "remove",
+			Usage:  "Unregister a platform plugin",
+			Action: removePlugin,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Platform name of the plugin", Required: true},
+			},
+		},
+	},
+}
+
+func addPlugin(c *cli.Context) error {
+	name := c.String("name")
+	if err := plugin.CheckName(name); err != nil {
+		return err
+	}
+
+	path := c.String("path")
+	if path == "" {
+		var err error
+		path, err = plugin.Lookup(name)
+		if err != nil {
+			return err
+		}
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return errors.Wrap(err, "get absolute path")
+	}
+
+	info, err := plugin.Handshake(path)
+	if err != nil {
+		return errors.Wrapf(err, "handshake with plugin %q", path)
+	}
+
+	configFile := config.New(c.String("config-file"))
+	if err := configFile.Load(); err != nil {
+		return errors.Wrap(err, "load config file")
+	}
+	if configFile.Plugins == nil {
+		configFile.Plugins = make(map[string]config.Plugin)
+	}
+	configFile.Plugins[name] = config.Plugin{Path: path}
+	if err := configFile.Save(); err != nil {
+		return errors.Wrap(err, "save config file")
+	}
+
+	log.Info("Plugin %q (%s) is registered, run `Raika platform login --platform %s` to login.", name, info.Name, name)
+	return nil
+}
+
+func listPlugins(c *cli.Context) error {
+	configFile := config.New(c.String("config-file"))
+	if err := configFile.Load(); err != nil {
+		return errors.Wrap(err, "load config file")
+	}
+
+	if len(configFile.Plugins) == 0 {
+		log.Warn("No plugin is registered. Run `Raika platform plugin add` to register one.")
+		return nil
+	}
+
+	i := 0
+	for name, p := range configFile.Plugins {
+		i++
+		log.Trace("%02d - [ %s ] %s", i, name, p.Path)
+	}
+	return nil
+}
+
+func removePlugin(c *cli.Context) error {
+	name := c.String("name")
+
+	configFile := config.New(c.String("config-file"))
+	if err := configFile.Load(); err != nil {
+		return errors.Wrap(err, "load config file")
+	}
+	if _, ok := configFile.Plugins[name]; !ok {
+		return errors.Errorf("plugin %q is not registered", name)
+	}
+
+	for accountName, auth := range configFile.AuthConfigs {
+		if string(auth.Platform) == name {
+			return errors.Errorf("plugin %q is used by the account %q", name, accountName)
+		}
+	}
+
+	delete(configFile.Plugins, name)
+	if err := configFile.Save(); err != nil {
+		return errors.Wrap(err, "save config file")
+	}
+	log.Info("Plugin %q is removed.", name)
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/api"
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var Trigger = &cli.Command{
+	Name:  "trigger",
+	Usage: "Manage the triggers of the functions",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "List the triggers of the function",
+			Action: listTriggers,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+			},
+		},
+		{
+			Name:   "delete",
+			Usage:  "Delete the trigger of the function",
+			Action: deleteTrigger,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "name", Usage: "Function name", Required: true},
+				&cli.StringFlag{Name: "trigger", Usage: "Trigger name", Required: true},
+				&cli.StringSliceFlag{Name: "platform", Usage: "Platform to delete the trigger from", Required: false},
+			},
+		},
+	},
+}
+
+// loadTriggers returns the trigger specs from the trigger file, or the single
+// trigger given by the trigger and the cron flags.
+func loadTriggers(c *cli.Context) ([]platform.TriggerSpec, error) {
+	triggerFile := c.String("trigger-file")
+	if triggerFile == "" {
+		switch trigger := c.String("trigger"); trigger {
+		case platform.HTTPTrigger:
+			return []platform.TriggerSpec{{Name: platform.HTTPTriggerName, Type: platform.HTTPTrigger}}, nil
+		case platform.CronTrigger:
+			return []platform.TriggerSpec{{Name: platform.CronTriggerName, Type: platform.CronTrigger, Cron: c.String("cron")}}, nil
+		default:
+			return nil, errors.Errorf("unexpected trigger type %q", trigger)
+		}
+	}
+
+	data, err := os.ReadFile(triggerFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+	var triggers []platform.TriggerSpec
+	if err := json.Unmarshal(data, &triggers); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return triggers, nil
+}
+
+// removeStaleTriggers deletes the triggers deployed before but not in the
+// given trigger specs from the platform.
+func removeStaleTriggers(p platform.Cloud, name string, triggers []platform.TriggerSpec) {
+	records, err := store.Functions.Get(name)
+	if err != nil {
+		return
+	}
+	record := findRecord(records, p.GetID())
+	if record == nil {
+		return
+	}
+
+	names := make(map[string]struct{}, len(triggers))
+	for _, trigger := range triggers {
+		names[trigger.Name] = struct{}{}
+	}
+
+	// The records saved before the functions have multiple triggers have no
+	// triggers, their only trigger is the default one of the trigger type.
+	deployed, legacy := record.Triggers, false
+	if deployed == nil {
+		deployed, legacy = legacyTriggers(record), true
+	}
+	for _, trigger := range deployed {
+		if _, ok := names[trigger.Name]; ok {
+			continue
+		}
+		log.Info("[ %s ] Remove trigger %q", p.GetID(), trigger.Name)
+		if err := p.RemoveTrigger(record.RemoteName(), trigger.Name); err != nil {
+			if legacy {
+				// The platform may have removed it while deploying the function.
+				log.Trace("Legacy trigger %q on %s is not removed: %v", trigger.Name, p, err)
+				continue
+			}
+			log.Error("Failed to remove trigger %q on %s: %v", trigger.Name, p, err)
+		}
+	}
+}
+
+// legacyTriggers returns the trigger of the function record saved before the
+// functions have multiple triggers, which is the HTTP trigger if the function
+// has the trigger URL, or the cron trigger otherwise.
+func legacyTriggers(record *types.Function) []types.FunctionTrigger {
+	if record.URL != "" {
+		return []types.FunctionTrigger{{Name: platform.HTTPTriggerName, Type: platform.HTTPTrigger, URL: record.URL}}
+	}
+	return []types.FunctionTrigger{{Name: platform.CronTriggerName, Type: platform.CronTrigger}}
+}
+
+func listTriggers(c *cli.Context) error {
+	name := c.String("name")
+	records, err := store.Functions.Get(name)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		log.Info("[ %s ]", record.PlatformID)
+		for _, trigger := range record.Triggers {
+			switch trigger.Type {
+			case platform.HTTPTrigger:
+				log.Trace("   %s (http) %s", trigger.Name, trigger.URL)
+			case platform.CronTrigger:
+				log.Trace("   %s (cron) %q %s", trigger.Name, trigger.Cron, trigger.Payload)
+			default:
+				log.Trace("   %s (%s)", trigger.Name, trigger.Type)
+			}
+		}
+	}
+	return nil
+}
+
+func deleteTrigger(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	triggerName := c.String("trigger")
+	records, err := store.Functions.Get(name)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range platforms {
+		record := findRecord(records, p.GetID())
+		if record == nil {
+			continue
+		}
+		if _, ok := record.GetTrigger(triggerName); !ok {
+			log.Warn("Trigger %q not found on %s", triggerName, p)
+			continue
+		}
+
+		log.Info("Delete trigger %q of function %q on %s", triggerName, name, p)
+		if err := p.RemoveTrigger(record.RemoteName(), triggerName); err != nil {
+			log.Error("Failed to delete trigger on %s: %v", p, err)
+			continue
+		}
+		if err := store.Functions.DeleteTrigger(name, p.GetID(), triggerName); err != nil {
+			log.Error("Failed to remove trigger from file: %v", err)
+		}
+	}
+
+	if err := api.Reload(); err != nil {
+		return errors.Wrap(err, "reload")
+	}
+	return nil
+}
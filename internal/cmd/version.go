@@ -0,0 +1,148 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func publishFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	records, err := store.Functions.Get(name)
+	if err != nil {
+		return errors.Wrap(err, "get function")
+	}
+
+	label := c.String("version")
+	if label == "" {
+		label = nextVersionLabel(name)
+	}
+	for _, record := range records {
+		if _, ok := record.GetVersion(label); ok {
+			return errors.Errorf("version %q already exists on %s", label, record.PlatformID)
+		}
+	}
+
+	var failed int
+	for _, p := range platforms {
+		record := findRecord(records, p.GetID())
+		if record == nil {
+			log.Warn("Function %q is not deployed on %s, skip", name, p.GetID())
+			continue
+		}
+
+		// The new version is only served through the alias the triggers are
+		// bound to, which is given on deployment.
+		alias := record.Alias
+		if alias == "" {
+			log.Error("The triggers on %s are bound to the latest version, redeploy the function with `--alias %s` to bind them to an alias", p.GetID(), platform.DefaultAliasName)
+			failed++
+			continue
+		} else if c.String("alias") != "" && c.String("alias") != alias {
+			log.Error("The triggers on %s are bound to alias %q, redeploy the function with `--alias %s` to bind them to it", p.GetID(), alias, c.String("alias"))
+			failed++
+			continue
+		}
+
+		version, err := platform.PublishAlias(p, record.RemoteName(), alias, c.String("description"))
+		if err != nil {
+			log.Error("Failed to publish function on %s: %v", p.GetID(), err)
+			failed++
+			continue
+		}
+
+		err = store.Functions.AddVersion(name, p.GetID(), alias, types.FunctionVersion{
+			Label:     label,
+			ID:        version,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			log.Error("Failed to save function version to file: %v", err)
+		}
+		log.Info("[ %s ] %s -> %s (%s)", p.GetID(), alias, label, version)
+	}
+	if failed != 0 {
+		return errors.Errorf("failed to publish on %d platforms", failed)
+	}
+	return nil
+}
+
+func rollbackFunction(c *cli.Context) error {
+	platforms, err := loadPlatforms(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	records, err := store.Functions.Get(name)
+	if err != nil {
+		return errors.Wrap(err, "get function")
+	}
+
+	label := c.String("to")
+	for _, p := range platforms {
+		record := findRecord(records, p.GetID())
+		if record == nil {
+			continue
+		}
+
+		version, ok := record.GetVersion(label)
+		if !ok {
+			log.Error("Version %q not found on %s", label, p.GetID())
+			continue
+		}
+		if record.Alias == "" {
+			log.Error("Function %q has no alias on %s", name, p.GetID())
+			continue
+		}
+
+		if err := p.UpdateAlias(record.RemoteName(), record.Alias, version.ID); err != nil {
+			log.Error("Failed to roll back function on %s: %v", p.GetID(), err)
+			continue
+		}
+		if err := store.Functions.SetVersion(name, p.GetID(), version.ID); err != nil {
+			log.Error("Failed to save function version to file: %v", err)
+		}
+		log.Info("[ %s ] %s -> %s (%s)", p.GetID(), record.Alias, label, version.ID)
+	}
+	return nil
+}
+
+// nextVersionLabel returns the next version label of the function, which is
+// shared by all the platforms.
+func nextVersionLabel(name string) string {
+	records, _ := store.Functions.Get(name)
+	count := 0
+	for _, record := range records {
+		if len(record.Versions) > count {
+			count = len(record.Versions)
+		}
+	}
+	return fmt.Sprintf("v%d", count+1)
+}
+
+// findRecord returns the function record deployed on the given platform.
+func findRecord(records []types.Function, platformID string) *types.Function {
+	for i := range records {
+		if records[i].PlatformID == platformID {
+			return &records[i]
+		}
+	}
+	return nil
+}
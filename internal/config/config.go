@@ -25,6 +25,13 @@ type File struct {
 	FileName string `json:"-"` // Note: for internal use only
 
 	AuthConfigs map[string]types.AuthConfig `json:"auths"`
+	// Plugins are the registered platform plugins, the key is the platform name.
+	Plugins map[string]Plugin `json:"plugins,omitempty"`
+}
+
+// Plugin is a platform plugin executable.
+type Plugin struct {
+	Path string `json:"path"`
 }
 
 // New initializes an empty configuration file for the given filename 'fileName'.
@@ -32,6 +39,7 @@ func New(fileName string) *File {
 	return &File{
 		FileName:    fileName,
 		AuthConfigs: make(map[string]types.AuthConfig),
+		Plugins:     make(map[string]Plugin),
 	}
 }
 
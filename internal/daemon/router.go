@@ -7,7 +7,6 @@ package daemon
 import (
 	gocontext "context"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -17,10 +16,16 @@ import (
 	log "unknwon.dev/clog/v2"
 
 	"github.com/wuhan005/Raika/internal/context"
+	"github.com/wuhan005/Raika/internal/platform"
 	"github.com/wuhan005/Raika/internal/store"
 )
 
-func Run() error {
+func Run(platforms []platform.Cloud) error {
+	clouds = make(map[string]platform.Cloud, len(platforms))
+	for _, p := range platforms {
+		clouds[p.GetID()] = p
+	}
+
 	c := cron.New()
 	taskEntrySets := make(map[string]cron.EntryID)
 
@@ -56,13 +61,7 @@ func Run() error {
 	f.Group("/task", func() {
 		f.Post("/run", func(ctx context.Context) {
 			functionName := ctx.Request().URL.Query().Get("functionName")
-			resp, err := runFunction(functionName)
-			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, err.Error())
-				return
-			}
-
-			body, err := io.ReadAll(resp.Body)
+			body, err := runFunction(functionName)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, err.Error())
 				return
@@ -5,15 +5,21 @@
 package daemon
 
 import (
+	"io"
 	"math/rand"
 	"net/http"
 
 	"github.com/pkg/errors"
 
+	"github.com/wuhan005/Raika/internal/platform"
 	"github.com/wuhan005/Raika/internal/store"
+	"github.com/wuhan005/Raika/internal/types"
 )
 
-func runFunction(functionName string) (*http.Response, error) {
+// clouds contains the platform clients indexed by the platform ID.
+var clouds map[string]platform.Cloud
+
+func runFunction(functionName string) ([]byte, error) {
 	for fn := range store.Tasks.Tasks {
 		if fn == functionName {
 			platformFunctions, err := store.Functions.Get(functionName)
@@ -23,8 +29,53 @@ func runFunction(functionName string) (*http.Response, error) {
 
 			index := rand.Intn(len(platformFunctions))
 			platformFunction := platformFunctions[index]
-			return http.Get(platformFunction.URL)
+
+			triggerURL, payload := route(&platformFunction)
+			// Functions without trigger URL are invoked through the platform API.
+			if triggerURL == "" {
+				client, ok := clouds[platformFunction.PlatformID]
+				if !ok {
+					return nil, errors.Errorf("platform %q not found", platformFunction.PlatformID)
+				}
+				resp, err := platform.InvokeQualified(client, platformFunction.RemoteName(), platformFunction.Alias, payload)
+				if err != nil {
+					return nil, errors.Wrap(err, "invoke")
+				}
+				if resp.Error != "" {
+					return nil, errors.Errorf("invoke error: %s", resp.Error)
+				}
+				return resp.Body, nil
+			}
+
+			resp, err := http.Get(triggerURL)
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = resp.Body.Close() }()
+			return io.ReadAll(resp.Body)
 		}
 	}
 	return nil, errors.Wrapf(store.ErrFunctionNotExists, "platform function: %q", functionName)
 }
+
+// route returns the URL of the HTTP trigger the task requests. The function
+// without HTTP trigger is invoked with the payload of its cron trigger instead.
+func route(f *types.Function) (triggerURL string, payload []byte) {
+	// The records saved before the functions have multiple triggers only have
+	// the trigger URL.
+	if f.Triggers == nil {
+		return f.URL, nil
+	}
+
+	for _, trigger := range f.Triggers {
+		if trigger.Type == platform.HTTPTrigger && trigger.URL != "" {
+			return trigger.URL, nil
+		}
+	}
+	for _, trigger := range f.Triggers {
+		if trigger.Type == platform.CronTrigger && trigger.Payload != "" {
+			return "", []byte(trigger.Payload)
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,1047 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+)
+
+const (
+	// awsAccountID is the account ID in the ARNs issued by the emulator.
+	awsAccountID = "000000000000"
+	// lambdaAPIVersion is the path prefix of the Lambda API.
+	lambdaAPIVersion = "2015-03-31"
+	// lambdaLatest is the qualifier of the latest code of the function.
+	lambdaLatest = "$LATEST"
+	// lambdaTimeFormat is the time format of the `LastModified` field.
+	lambdaTimeFormat = "2006-01-02T15:04:05.000-0700"
+)
+
+// aws emulates the Lambda, the API Gateway v2 and the EventBridge APIs on one
+// endpoint, the APIs are told apart by the service in the credential scope.
+// The HTTP APIs are served on the `/execute-api/<api-id>/` URLs.
+type aws struct {
+	cred    credential
+	runtime *runtime
+
+	mu        sync.Mutex
+	functions map[string]*lambdaFunction
+	apis      map[string]*gatewayAPI
+	rules     map[string]*eventRule
+}
+
+type lambdaFunction struct {
+	Latest *function
+	// Versions are the published versions, the version is the index plus one.
+	Versions []*function
+	Aliases  map[string]string
+	// Statements are the statement IDs of the resource policy, which are
+	// prefixed by the qualifier.
+	Statements map[string]struct{}
+}
+
+type gatewayAPI struct {
+	ID        string
+	Name      string
+	Protocol  string
+	Target    string
+	CreatedAt time.Time
+}
+
+type eventRule struct {
+	Name               string
+	Arn                string
+	ScheduleExpression string
+	State              string
+	Targets            []eventTarget
+}
+
+type eventTarget struct {
+	ID    string `json:"Id"`
+	Arn   string `json:"Arn"`
+	Input string `json:"Input,omitempty"`
+}
+
+func newAWS(cred credential, rt *runtime) *aws {
+	return &aws{
+		cred:      cred,
+		runtime:   rt,
+		functions: make(map[string]*lambdaFunction),
+		apis:      make(map[string]*gatewayAPI),
+		rules:     make(map[string]*eventRule),
+	}
+}
+
+// awsError writes the error of both the REST JSON and the JSON 1.1 protocols.
+func awsError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("X-Amzn-ErrorType", code)
+	writeJSON(w, statusCode, map[string]string{
+		"__type":  code,
+		"message": message,
+	})
+}
+
+// awsRequest is the verified request to the AWS APIs.
+type awsRequest struct {
+	*http.Request
+	region   string
+	body     []byte
+	segments []string
+}
+
+func (r *awsRequest) decode(w http.ResponseWriter, v interface{}) bool {
+	if len(r.body) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(r.body, v); err != nil {
+		awsError(w, http.StatusBadRequest, "InvalidRequestContentException", err.Error())
+		return false
+	}
+	return true
+}
+
+func (s *aws) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+	if len(segments) >= 2 && segments[0] == "execute-api" {
+		s.serveProxy(w, r, segments[1])
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		awsError(w, http.StatusBadRequest, "InvalidRequestContentException", err.Error())
+		return
+	}
+	region, service, err := s.cred.verifySigV4(r, body)
+	if err != nil {
+		switch err {
+		case errInvalidAccessKeyID:
+			awsError(w, http.StatusForbidden, "UnrecognizedClientException", err.Error())
+		case errMissingSignature:
+			awsError(w, http.StatusForbidden, "MissingAuthenticationTokenException", err.Error())
+		default:
+			awsError(w, http.StatusForbidden, "InvalidSignatureException", err.Error())
+		}
+		return
+	}
+	log.Trace("AWS: %s %s %s %s", service, r.Header.Get("X-Amz-Target"), r.Method, r.URL.Path)
+
+	req := &awsRequest{Request: r, region: region, body: body, segments: segments}
+	switch service {
+	case "lambda":
+		s.serveLambda(w, req)
+	case "apigateway":
+		s.serveGateway(w, req)
+	case "events":
+		s.serveEvents(w, req)
+	case "logs":
+		// The logs of the functions are printed by the emulator.
+		awsError(w, http.StatusBadRequest, "ResourceNotFoundException", "The specified log group does not exist.")
+	default:
+		awsError(w, http.StatusBadRequest, "UnknownServiceException", "the service "+service+" is not supported")
+	}
+}
+
+// serveProxy proxies the request on the HTTP API URL to the target function.
+func (s *aws) serveProxy(w http.ResponseWriter, r *http.Request, apiID string) {
+	s.mu.Lock()
+	var f *function
+	api, ok := s.apis[apiID]
+	if ok {
+		f = s.resolveARN(api.Target)
+	}
+	s.mu.Unlock()
+	if f == nil {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		return
+	}
+	path := proxyPath(r.URL.Path, 2)
+	if !usesRuntimeAPI(f) {
+		s.runtime.serve(w, r, f, path)
+		return
+	}
+
+	event, err := httpAPIEvent(r, apiID, path)
+	if err != nil {
+		http.Error(w, `{"message":"Bad Request"}`, http.StatusBadRequest)
+		return
+	}
+	_, payload, err := s.runtime.invoke(f, event)
+	if err != nil {
+		log.Error("Failed to invoke function %q: %v", f.Name, err)
+		http.Error(w, `{"message":"Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+	writeHTTPAPIResponse(w, payload)
+}
+
+// httpAPIEvent returns the event of the HTTP API request in the payload format 2.0.
+func httpAPIEvent(r *http.Request, apiID, path string) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		if name != "Cookie" {
+			headers[strings.ToLower(name)] = strings.Join(values, ",")
+		}
+	}
+	headers["host"] = r.Host
+	var cookies []string
+	for _, cookie := range r.Cookies() {
+		cookies = append(cookies, cookie.String())
+	}
+	sourceIP, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	now := time.Now()
+	requestID := newID(16)
+	return json.Marshal(map[string]interface{}{
+		"version":        "2.0",
+		"routeKey":       "$default",
+		"rawPath":        path,
+		"rawQueryString": r.URL.RawQuery,
+		"cookies":        cookies,
+		"headers":        headers,
+		"requestContext": map[string]interface{}{
+			"accountId":  awsAccountID,
+			"apiId":      apiID,
+			"domainName": r.Host,
+			"http": map[string]string{
+				"method":    r.Method,
+				"path":      path,
+				"protocol":  r.Proto,
+				"sourceIp":  sourceIP,
+				"userAgent": r.UserAgent(),
+			},
+			"requestId": requestID,
+			"routeKey":  "$default",
+			"stage":     "$default",
+			"timeEpoch": now.UnixNano() / int64(time.Millisecond),
+		},
+		"body":            base64.StdEncoding.EncodeToString(body),
+		"isBase64Encoded": true,
+	})
+}
+
+// writeHTTPAPIResponse writes the response of the function in the payload
+// format 2.0. The payload without the status code is the JSON response body.
+func writeHTTPAPIResponse(w http.ResponseWriter, payload []byte) {
+	var resp struct {
+		StatusCode      int               `json:"statusCode"`
+		Headers         map[string]string `json:"headers"`
+		Cookies         []string          `json:"cookies"`
+		Body            string            `json:"body"`
+		IsBase64Encoded bool              `json:"isBase64Encoded"`
+	}
+	if err := json.Unmarshal(payload, &resp); err != nil || resp.StatusCode == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+		return
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(resp.Body); err != nil {
+			http.Error(w, `{"message":"Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+	for _, cookie := range resp.Cookies {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// resolveARN returns the code of the function with the ARN
+// `arn:aws:lambda:<region>:<account>:function:<name>[:<qualifier>]`.
+func (s *aws) resolveARN(arn string) *function {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 7 || parts[5] != "function" {
+		return nil
+	}
+	fn, ok := s.functions[parts[6]]
+	if !ok {
+		return nil
+	}
+	qualifier := ""
+	if len(parts) > 7 {
+		qualifier = parts[7]
+	}
+	return fn.qualified(qualifier)
+}
+
+func (fn *lambdaFunction) qualified(qualifier string) *function {
+	if qualifier == "" || qualifier == lambdaLatest {
+		return fn.Latest
+	}
+	if version, ok := fn.Aliases[qualifier]; ok {
+		qualifier = version
+	}
+	index, err := strconv.Atoi(qualifier)
+	if err != nil || index < 1 || index > len(fn.Versions) {
+		return nil
+	}
+	return fn.Versions[index-1]
+}
+
+// published returns true if the code is published as a version.
+func (fn *lambdaFunction) published(f *function) bool {
+	for _, v := range fn.Versions {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+func functionARN(region, name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", region, awsAccountID, name)
+}
+
+func (s *aws) serveLambda(w http.ResponseWriter, r *awsRequest) {
+	segments := r.segments
+	if len(segments) < 2 || segments[0] != lambdaAPIVersion || segments[1] != "functions" {
+		awsError(w, http.StatusNotFound, "UnknownOperationException", "the path is not supported")
+		return
+	}
+	segments = segments[1:]
+
+	if match(segments, "functions", "*", "invocations") && r.Method == http.MethodPost {
+		s.invoke(w, r, segments[1])
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case match(segments, "functions") && r.Method == http.MethodGet:
+		s.listFunctions(w, r)
+	case match(segments, "functions") && r.Method == http.MethodPost:
+		s.createFunction(w, r)
+	case (match(segments, "functions", "*") || match(segments, "functions", "*", "configuration")) && r.Method == http.MethodGet:
+		if fn := s.function(w, segments[1]); fn != nil {
+			f := fn.qualified(r.URL.Query().Get("Qualifier"))
+			if f == nil {
+				awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Function not found: "+segments[1])
+				return
+			}
+			configuration := toLambdaConfiguration(r.region, f, r.URL.Query().Get("Qualifier"))
+			if len(segments) == 2 {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"Configuration": configuration})
+				return
+			}
+			writeJSON(w, http.StatusOK, configuration)
+		}
+	case match(segments, "functions", "*") && r.Method == http.MethodDelete:
+		s.deleteFunction(w, segments[1])
+	case match(segments, "functions", "*", "code") && r.Method == http.MethodPut:
+		s.updateFunctionCode(w, r, segments[1])
+	case match(segments, "functions", "*", "configuration") && r.Method == http.MethodPut:
+		s.updateFunctionConfiguration(w, r, segments[1])
+	case match(segments, "functions", "*", "versions") && r.Method == http.MethodPost:
+		s.publishVersion(w, r, segments[1])
+	case match(segments, "functions", "*", "aliases") && r.Method == http.MethodPost:
+		s.createAlias(w, r, segments[1])
+	case match(segments, "functions", "*", "aliases") && r.Method == http.MethodGet:
+		s.listAliases(w, r, segments[1])
+	case match(segments, "functions", "*", "aliases", "*") && r.Method == http.MethodGet:
+		if fn := s.function(w, segments[1]); fn != nil {
+			version, ok := fn.Aliases[segments[3]]
+			if !ok {
+				awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Alias not found: "+segments[3])
+				return
+			}
+			writeJSON(w, http.StatusOK, toLambdaAlias(r.region, segments[1], segments[3], version))
+		}
+	case match(segments, "functions", "*", "aliases", "*") && r.Method == http.MethodPut:
+		s.updateAlias(w, r, segments[1], segments[3])
+	case match(segments, "functions", "*", "policy") && r.Method == http.MethodPost:
+		s.addPermission(w, r, segments[1])
+	case match(segments, "functions", "*", "policy", "*") && r.Method == http.MethodDelete:
+		s.removePermission(w, r, segments[1], segments[3])
+	default:
+		awsError(w, http.StatusNotFound, "UnknownOperationException", "the path is not supported")
+	}
+}
+
+func (s *aws) function(w http.ResponseWriter, name string) *lambdaFunction {
+	fn, ok := s.functions[name]
+	if !ok {
+		awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Function not found: "+functionARN("", name))
+		return nil
+	}
+	return fn
+}
+
+type lambdaEnvironment struct {
+	Variables map[string]string `json:"Variables"`
+}
+
+type lambdaConfiguration struct {
+	FunctionName     string             `json:"FunctionName"`
+	FunctionArn      string             `json:"FunctionArn"`
+	Description      string             `json:"Description"`
+	Runtime          string             `json:"Runtime"`
+	Architectures    []string           `json:"Architectures"`
+	Handler          string             `json:"Handler"`
+	Role             string             `json:"Role"`
+	MemorySize       int64              `json:"MemorySize"`
+	Timeout          int64              `json:"Timeout"`
+	Environment      *lambdaEnvironment `json:"Environment"`
+	CodeSha256       string             `json:"CodeSha256"`
+	CodeSize         int                `json:"CodeSize"`
+	LastModified     string             `json:"LastModified"`
+	Version          string             `json:"Version"`
+	State            string             `json:"State"`
+	LastUpdateStatus string             `json:"LastUpdateStatus"`
+}
+
+// lambdaChecksum returns the base64 encoded SHA-256 checksum of the package,
+// which is reported by Lambda as the code checksum.
+func lambdaChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func toLambdaConfiguration(region string, f *function, version string) *lambdaConfiguration {
+	if version == "" {
+		version = lambdaLatest
+	}
+	return &lambdaConfiguration{
+		FunctionName:     f.Name,
+		FunctionArn:      functionARN(region, f.Name),
+		Description:      f.Description,
+		Runtime:          f.Runtime,
+		Architectures:    []string{f.Architecture},
+		Handler:          f.Handler,
+		Role:             f.Role,
+		MemorySize:       f.MemorySize,
+		Timeout:          int64(f.Timeout / time.Second),
+		Environment:      &lambdaEnvironment{Variables: f.Environment},
+		CodeSha256:       lambdaChecksum(f.Package),
+		CodeSize:         len(f.Package),
+		LastModified:     f.UpdatedAt.UTC().Format(lambdaTimeFormat),
+		Version:          version,
+		State:            "Active",
+		LastUpdateStatus: "Successful",
+	}
+}
+
+func (s *aws) listFunctions(w http.ResponseWriter, r *awsRequest) {
+	names := make([]string, 0, len(s.functions))
+	for name := range s.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	functions := make([]*lambdaConfiguration, 0, len(names))
+	for _, name := range names {
+		functions = append(functions, toLambdaConfiguration(r.region, s.functions[name].Latest, ""))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"Functions": functions})
+}
+
+type lambdaConfigurationRequest struct {
+	Description *string            `json:"Description"`
+	Environment *lambdaEnvironment `json:"Environment"`
+	Handler     *string            `json:"Handler"`
+	MemorySize  *int64             `json:"MemorySize"`
+	Role        *string            `json:"Role"`
+	Runtime     *string            `json:"Runtime"`
+	Timeout     *int64             `json:"Timeout"`
+}
+
+func (c *lambdaConfigurationRequest) apply(f *function) {
+	if c.Description != nil {
+		f.Description = *c.Description
+	}
+	if c.Environment != nil {
+		f.Environment = c.Environment.Variables
+		if f.Environment == nil {
+			f.Environment = map[string]string{}
+		}
+	}
+	if c.Handler != nil {
+		f.Handler = *c.Handler
+	}
+	if c.MemorySize != nil {
+		f.MemorySize = *c.MemorySize
+	}
+	if c.Role != nil {
+		f.Role = *c.Role
+	}
+	if c.Runtime != nil {
+		f.Runtime = *c.Runtime
+	}
+	if c.Timeout != nil {
+		f.Timeout = time.Duration(*c.Timeout) * time.Second
+	}
+}
+
+func (s *aws) createFunction(w http.ResponseWriter, r *awsRequest) {
+	var request struct {
+		lambdaConfigurationRequest
+		FunctionName  string   `json:"FunctionName"`
+		Architectures []string `json:"Architectures"`
+		Code          struct {
+			ZipFile []byte `json:"ZipFile"`
+		} `json:"Code"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+	if request.FunctionName == "" {
+		awsError(w, http.StatusBadRequest, "InvalidParameterValueException", "FunctionName is required")
+		return
+	} else if _, ok := s.functions[request.FunctionName]; ok {
+		awsError(w, http.StatusConflict, "ResourceConflictException", "Function already exist: "+request.FunctionName)
+		return
+	} else if err := checkPackage(request.Code.ZipFile); err != nil {
+		awsError(w, http.StatusBadRequest, "InvalidParameterValueException", err.Error())
+		return
+	}
+	architecture, ok := lambdaArchitecture(w, request.Architectures)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	f := &function{
+		Name:         request.FunctionName,
+		Architecture: architecture,
+		Package:      request.Code.ZipFile,
+		MemorySize:   128,
+		Timeout:      3 * time.Second,
+		Environment:  map[string]string{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	request.apply(f)
+
+	s.functions[f.Name] = &lambdaFunction{
+		Latest:     f,
+		Aliases:    make(map[string]string),
+		Statements: make(map[string]struct{}),
+	}
+	writeJSON(w, http.StatusCreated, toLambdaConfiguration(r.region, f, ""))
+}
+
+// lambdaArchitecture returns the only architecture of the request, which
+// defaults to x86_64. The architecture is only recorded, the functions are
+// always run on the local machine.
+func lambdaArchitecture(w http.ResponseWriter, architectures []string) (string, bool) {
+	switch len(architectures) {
+	case 0:
+		return "x86_64", true
+	case 1:
+		if architectures[0] == "x86_64" || architectures[0] == "arm64" {
+			return architectures[0], true
+		}
+	}
+	awsError(w, http.StatusBadRequest, "InvalidParameterValueException", "Architectures should be one of x86_64 and arm64")
+	return "", false
+}
+
+// update replaces the latest code of the function.
+func (s *aws) update(fn *lambdaFunction, f *function) {
+	if !fn.published(fn.Latest) {
+		s.runtime.stop(fn.Latest)
+	}
+	fn.Latest = f
+}
+
+func (s *aws) updateFunctionCode(w http.ResponseWriter, r *awsRequest, name string) {
+	fn := s.function(w, name)
+	if fn == nil {
+		return
+	}
+
+	var request struct {
+		ZipFile       []byte   `json:"ZipFile"`
+		Architectures []string `json:"Architectures"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+	if err := checkPackage(request.ZipFile); err != nil {
+		awsError(w, http.StatusBadRequest, "InvalidParameterValueException", err.Error())
+		return
+	}
+
+	f := fn.Latest.clone()
+	f.Package = request.ZipFile
+	if request.Architectures != nil {
+		architecture, ok := lambdaArchitecture(w, request.Architectures)
+		if !ok {
+			return
+		}
+		f.Architecture = architecture
+	}
+	s.update(fn, f)
+	writeJSON(w, http.StatusOK, toLambdaConfiguration(r.region, f, ""))
+}
+
+func (s *aws) updateFunctionConfiguration(w http.ResponseWriter, r *awsRequest, name string) {
+	fn := s.function(w, name)
+	if fn == nil {
+		return
+	}
+
+	var request lambdaConfigurationRequest
+	if !r.decode(w, &request) {
+		return
+	}
+
+	f := fn.Latest.clone()
+	request.apply(f)
+	s.update(fn, f)
+	writeJSON(w, http.StatusOK, toLambdaConfiguration(r.region, f, ""))
+}
+
+func (s *aws) deleteFunction(w http.ResponseWriter, name string) {
+	fn := s.function(w, name)
+	if fn == nil {
+		return
+	}
+
+	s.runtime.stop(fn.Latest)
+	for _, f := range fn.Versions {
+		s.runtime.stop(f)
+	}
+	delete(s.functions, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *aws) invoke(w http.ResponseWriter, r *awsRequest, name string) {
+	// The function is not locked while it is running.
+	s.mu.Lock()
+	var f *function
+	fn := s.function(w, name)
+	if fn != nil {
+		if f = fn.qualified(r.URL.Query().Get("Qualifier")); f == nil {
+			awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Function not found: "+name)
+		}
+	}
+	s.mu.Unlock()
+	if f == nil {
+		return
+	}
+
+	statusCode, body, err := s.runtime.invoke(f, r.body)
+	if err != nil {
+		w.Header().Set("X-Amz-Function-Error", "Unhandled")
+		writeJSON(w, http.StatusOK, map[string]string{
+			"errorMessage": err.Error(),
+			"errorType":    "Runtime.ExitError",
+		})
+		return
+	}
+	if statusCode >= http.StatusInternalServerError {
+		w.Header().Set("X-Amz-Function-Error", "Unhandled")
+	}
+	_, _ = w.Write(body)
+}
+
+func (s *aws) publishVersion(w http.ResponseWriter, r *awsRequest, name string) {
+	fn := s.function(w, name)
+	if fn == nil {
+		return
+	}
+
+	var request struct {
+		Description string `json:"Description"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+
+	fn.Versions = append(fn.Versions, fn.Latest)
+	configuration := toLambdaConfiguration(r.region, fn.Latest, strconv.Itoa(len(fn.Versions)))
+	configuration.Description = request.Description
+	writeJSON(w, http.StatusCreated, configuration)
+}
+
+func toLambdaAlias(region, functionName, name, version string) map[string]string {
+	return map[string]string{
+		"AliasArn":        functionARN(region, functionName) + ":" + name,
+		"Name":            name,
+		"FunctionVersion": version,
+	}
+}
+
+func (s *aws) createAlias(w http.ResponseWriter, r *awsRequest, functionName string) {
+	fn := s.function(w, functionName)
+	if fn == nil {
+		return
+	}
+
+	var request struct {
+		Name            string `json:"Name"`
+		FunctionVersion string `json:"FunctionVersion"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+	if request.Name == "" {
+		awsError(w, http.StatusBadRequest, "InvalidParameterValueException", "Name is required")
+		return
+	} else if request.FunctionVersion == lambdaLatest || fn.qualified(request.FunctionVersion) == nil {
+		awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Function version not found: "+request.FunctionVersion)
+		return
+	} else if _, ok := fn.Aliases[request.Name]; ok {
+		awsError(w, http.StatusConflict, "ResourceConflictException", "Alias already exists: "+request.Name)
+		return
+	}
+
+	fn.Aliases[request.Name] = request.FunctionVersion
+	writeJSON(w, http.StatusCreated, toLambdaAlias(r.region, functionName, request.Name, request.FunctionVersion))
+}
+
+func (s *aws) listAliases(w http.ResponseWriter, r *awsRequest, functionName string) {
+	fn := s.function(w, functionName)
+	if fn == nil {
+		return
+	}
+
+	names := make([]string, 0, len(fn.Aliases))
+	for name := range fn.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliases := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		aliases = append(aliases, toLambdaAlias(r.region, functionName, name, fn.Aliases[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"Aliases": aliases})
+}
+
+func (s *aws) updateAlias(w http.ResponseWriter, r *awsRequest, functionName, name string) {
+	fn := s.function(w, functionName)
+	if fn == nil {
+		return
+	}
+	if _, ok := fn.Aliases[name]; !ok {
+		awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Alias not found: "+name)
+		return
+	}
+
+	var request struct {
+		FunctionVersion string `json:"FunctionVersion"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+	if request.FunctionVersion == lambdaLatest || fn.qualified(request.FunctionVersion) == nil {
+		awsError(w, http.StatusNotFound, "ResourceNotFoundException", "Function version not found: "+request.FunctionVersion)
+		return
+	}
+
+	fn.Aliases[name] = request.FunctionVersion
+	writeJSON(w, http.StatusOK, toLambdaAlias(r.region, functionName, name, request.FunctionVersion))
+}
+
+func (s *aws) addPermission(w http.ResponseWriter, r *awsRequest, functionName string) {
+	fn := s.function(w, functionName)
+	if fn == nil {
+		return
+	}
+
+	var request struct {
+		Action      string `json:"Action"`
+		Principal   string `json:"Principal"`
+		SourceArn   string `json:"SourceArn"`
+		StatementID string `json:"StatementId"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+	key := r.URL.Query().Get("Qualifier") + "/" + request.StatementID
+	if _, ok := fn.Statements[key]; ok {
+		awsError(w, http.StatusConflict, "ResourceConflictException", "The statement id ("+request.StatementID+") provided already exists.")
+		return
+	}
+
+	fn.Statements[key] = struct{}{}
+	statement, _ := json.Marshal(map[string]interface{}{
+		"Sid":       request.StatementID,
+		"Effect":    "Allow",
+		"Principal": map[string]string{"Service": request.Principal},
+		"Action":    request.Action,
+		"Resource":  functionARN(r.region, functionName),
+		"Condition": map[string]interface{}{"ArnLike": map[string]string{"AWS:SourceArn": request.SourceArn}},
+	})
+	writeJSON(w, http.StatusCreated, map[string]string{"Statement": string(statement)})
+}
+
+func (s *aws) removePermission(w http.ResponseWriter, r *awsRequest, functionName, statementID string) {
+	fn := s.function(w, functionName)
+	if fn == nil {
+		return
+	}
+
+	key := r.URL.Query().Get("Qualifier") + "/" + statementID
+	if _, ok := fn.Statements[key]; !ok {
+		awsError(w, http.StatusNotFound, "ResourceNotFoundException", "The resource you requested does not exist.")
+		return
+	}
+	delete(fn.Statements, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *aws) serveGateway(w http.ResponseWriter, r *awsRequest) {
+	segments := r.segments
+	if len(segments) < 2 || segments[0] != "v2" || segments[1] != "apis" {
+		awsError(w, http.StatusNotFound, "NotFoundException", "the path is not supported")
+		return
+	}
+	segments = segments[1:]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var api *gatewayAPI
+	if len(segments) == 2 {
+		var ok bool
+		if api, ok = s.apis[segments[1]]; !ok {
+			awsError(w, http.StatusNotFound, "NotFoundException", "Invalid API identifier specified "+awsAccountID+":"+segments[1])
+			return
+		}
+	}
+
+	var request struct {
+		Name         *string `json:"name"`
+		ProtocolType string  `json:"protocolType"`
+		Target       *string `json:"target"`
+	}
+	switch {
+	case match(segments, "apis") && r.Method == http.MethodGet:
+		ids := make([]string, 0, len(s.apis))
+		for id := range s.apis {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		items := make([]map[string]interface{}, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, toGatewayAPI(r.Host, s.apis[id]))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+
+	case match(segments, "apis") && r.Method == http.MethodPost:
+		if !r.decode(w, &request) {
+			return
+		}
+		if request.Name == nil || *request.Name == "" {
+			awsError(w, http.StatusBadRequest, "BadRequestException", "name is required")
+			return
+		} else if request.ProtocolType != "HTTP" {
+			awsError(w, http.StatusBadRequest, "BadRequestException", "only the HTTP protocol is supported")
+			return
+		} else if request.Target == nil || s.resolveARN(*request.Target) == nil {
+			awsError(w, http.StatusBadRequest, "BadRequestException", "the target function does not exist")
+			return
+		}
+
+		api = &gatewayAPI{
+			ID:        newID(10),
+			Name:      *request.Name,
+			Protocol:  request.ProtocolType,
+			Target:    *request.Target,
+			CreatedAt: time.Now(),
+		}
+		s.apis[api.ID] = api
+		writeJSON(w, http.StatusCreated, toGatewayAPI(r.Host, api))
+
+	case match(segments, "apis", "*") && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, toGatewayAPI(r.Host, api))
+
+	case match(segments, "apis", "*") && r.Method == http.MethodPatch:
+		if !r.decode(w, &request) {
+			return
+		}
+		if request.Name != nil {
+			api.Name = *request.Name
+		}
+		if request.Target != nil {
+			if s.resolveARN(*request.Target) == nil {
+				awsError(w, http.StatusBadRequest, "BadRequestException", "the target function does not exist")
+				return
+			}
+			api.Target = *request.Target
+		}
+		writeJSON(w, http.StatusOK, toGatewayAPI(r.Host, api))
+
+	case match(segments, "apis", "*") && r.Method == http.MethodDelete:
+		delete(s.apis, api.ID)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		awsError(w, http.StatusNotFound, "NotFoundException", "the path is not supported")
+	}
+}
+
+func toGatewayAPI(host string, api *gatewayAPI) map[string]interface{} {
+	return map[string]interface{}{
+		"apiId":        api.ID,
+		"name":         api.Name,
+		"protocolType": api.Protocol,
+		"apiEndpoint":  "http://" + host + "/execute-api/" + api.ID,
+		"createdDate":  api.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func (s *aws) serveEvents(w http.ResponseWriter, r *awsRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var request struct {
+		Name               string        `json:"Name"`
+		NamePrefix         string        `json:"NamePrefix"`
+		ScheduleExpression string        `json:"ScheduleExpression"`
+		State              string        `json:"State"`
+		Rule               string        `json:"Rule"`
+		Targets            []eventTarget `json:"Targets"`
+		Ids                []string      `json:"Ids"`
+	}
+	if !r.decode(w, &request) {
+		return
+	}
+
+	rule := func(name string) *eventRule {
+		rule, ok := s.rules[name]
+		if !ok {
+			awsError(w, http.StatusBadRequest, "ResourceNotFoundException", "Rule "+name+" does not exist.")
+			return nil
+		}
+		return rule
+	}
+
+	switch target := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AWSEvents."); target {
+	case "PutRule":
+		if request.Name == "" {
+			awsError(w, http.StatusBadRequest, "ValidationException", "Name is required")
+			return
+		}
+		if request.State == "" {
+			request.State = "ENABLED"
+		}
+		rule, ok := s.rules[request.Name]
+		if !ok {
+			rule = &eventRule{
+				Name: request.Name,
+				Arn:  fmt.Sprintf("arn:aws:events:%s:%s:rule/%s", r.region, awsAccountID, request.Name),
+			}
+			s.rules[rule.Name] = rule
+		}
+		rule.ScheduleExpression = request.ScheduleExpression
+		rule.State = request.State
+		writeJSON(w, http.StatusOK, map[string]string{"RuleArn": rule.Arn})
+
+	case "DescribeRule":
+		if rule := rule(request.Name); rule != nil {
+			writeJSON(w, http.StatusOK, toEventRule(rule))
+		}
+
+	case "ListRules":
+		names := make([]string, 0, len(s.rules))
+		for name := range s.rules {
+			if strings.HasPrefix(name, request.NamePrefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		rules := make([]map[string]string, 0, len(names))
+		for _, name := range names {
+			rules = append(rules, toEventRule(s.rules[name]))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"Rules": rules})
+
+	case "DeleteRule":
+		if rule := rule(request.Name); rule != nil {
+			if len(rule.Targets) > 0 {
+				awsError(w, http.StatusBadRequest, "ValidationException", "Rule can't be deleted since it has targets.")
+				return
+			}
+			delete(s.rules, rule.Name)
+			writeJSON(w, http.StatusOK, map[string]string{})
+		}
+
+	case "PutTargets":
+		if rule := rule(request.Rule); rule != nil {
+			for _, target := range request.Targets {
+				replaced := false
+				for i := range rule.Targets {
+					if rule.Targets[i].ID == target.ID {
+						rule.Targets[i], replaced = target, true
+					}
+				}
+				if !replaced {
+					rule.Targets = append(rule.Targets, target)
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"FailedEntryCount": 0, "FailedEntries": []string{}})
+		}
+
+	case "ListTargetsByRule":
+		if rule := rule(request.Rule); rule != nil {
+			targets := rule.Targets
+			if targets == nil {
+				targets = []eventTarget{}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"Targets": targets})
+		}
+
+	case "RemoveTargets":
+		if rule := rule(request.Rule); rule != nil {
+			ids := make(map[string]struct{}, len(request.Ids))
+			for _, id := range request.Ids {
+				ids[id] = struct{}{}
+			}
+			targets := rule.Targets[:0]
+			for _, target := range rule.Targets {
+				if _, ok := ids[target.ID]; !ok {
+					targets = append(targets, target)
+				}
+			}
+			rule.Targets = targets
+			writeJSON(w, http.StatusOK, map[string]interface{}{"FailedEntryCount": 0, "FailedEntries": []string{}})
+		}
+
+	default:
+		awsError(w, http.StatusBadRequest, "UnknownOperationException", "the operation "+target+" is not supported")
+	}
+}
+
+func toEventRule(rule *eventRule) map[string]string {
+	return map[string]string{
+		"Name":               rule.Name,
+		"Arn":                rule.Arn,
+		"ScheduleExpression": rule.ScheduleExpression,
+		"State":              rule.State,
+	}
+}
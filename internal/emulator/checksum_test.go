@@ -0,0 +1,31 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"testing"
+)
+
+// The emulated checksums are checked with the known vectors rather than the
+// clients, so that the same mistake on both sides is told.
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum func([]byte) string
+		data     string
+		want     string
+	}{
+		{name: "Function Compute", checksum: fcChecksum, data: "123456789", want: "11051210869376104954"},
+		{name: "Lambda", checksum: lambdaChecksum, data: "123456789", want: "FeKw08M4keuw8e9gnsQZQgwg4yDOlMZfvIwzEkSOsiU="},
+		{name: "SCF", checksum: scfChecksum, data: "123456789", want: "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.checksum([]byte(test.data)); got != test.want {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package emulator serves the subset of the aliyun Function Compute, Tencent
+// SCF and AWS Lambda APIs used by Raika on the local machine, so that the
+// functions can be deployed and invoked without cloud accounts.
+package emulator
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/thanhpk/randstr"
+	log "unknwon.dev/clog/v2"
+)
+
+type Options struct {
+	// FCAddr, SCFAddr and AWSAddr are the listen addresses of the APIs, the
+	// API is disabled if its address is empty.
+	FCAddr  string
+	SCFAddr string
+	AWSAddr string
+	// AccessKeyID and AccessKeySecret are the only credential accepted by
+	// the APIs, it is the secret ID and the secret key on Tencent SCF.
+	AccessKeyID     string
+	AccessKeySecret string
+}
+
+// Run serves the APIs until it is interrupted. The functions and the triggers
+// are kept in memory, and the packages are unpacked to a temporary directory
+// and run on the first request.
+func Run(opts Options) error {
+	dir, err := os.MkdirTemp("", "raika-emulator-")
+	if err != nil {
+		return errors.Wrap(err, "create work directory")
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	rt := newRuntime(dir, os.Stdout)
+	defer rt.stopAll()
+
+	cred := credential{id: opts.AccessKeyID, secret: opts.AccessKeySecret}
+	apis := []struct {
+		name    string
+		addr    string
+		handler http.Handler
+	}{
+		{"Function Compute", opts.FCAddr, newFC(cred, rt)},
+		{"SCF", opts.SCFAddr, newSCF(cred, rt)},
+		{"AWS", opts.AWSAddr, newAWS(cred, rt)},
+	}
+
+	errc := make(chan error, len(apis))
+	var servers []*http.Server
+	for _, api := range apis {
+		if api.addr == "" {
+			continue
+		}
+
+		listener, err := net.Listen("tcp", api.addr)
+		if err != nil {
+			return errors.Wrapf(err, "listen %s API", api.name)
+		}
+		server := &http.Server{Handler: api.handler}
+		servers = append(servers, server)
+		go func() { errc <- server.Serve(listener) }()
+		log.Info("%s API is listening on http://%s", api.name, listener.Addr())
+	}
+	if len(servers) == 0 {
+		return errors.New("no API is enabled")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-signals:
+	case err = <-errc:
+	}
+
+	for _, server := range servers {
+		_ = server.Shutdown(gocontext.Background())
+	}
+	return err
+}
+
+// writeJSON writes the value as the JSON response body.
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Failed to encode response body: %v", err)
+	}
+}
+
+// splitPath splits the path into the segments, the empty segments are removed.
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// match returns true if the segments match the pattern, `*` matches any segment.
+func match(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// proxyPath returns the path after the given number of segments, which is
+// passed to the function.
+func proxyPath(path string, skip int) string {
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", skip+1)
+	if len(segments) <= skip {
+		return "/"
+	}
+	return "/" + segments[skip]
+}
+
+func newID(n int) string {
+	return strings.ToLower(randstr.String(n))
+}
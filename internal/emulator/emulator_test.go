@@ -0,0 +1,201 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	goruntime "runtime"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/gobuild"
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/aliyun"
+	awsplatform "github.com/wuhan005/Raika/internal/platform/aws"
+	"github.com/wuhan005/Raika/internal/platform/tencentcloud"
+)
+
+const (
+	testAccessKeyID     = "test-id"
+	testAccessKeySecret = "test-secret"
+)
+
+// newTestServer serves the API of the emulator, the processes of the
+// functions are stopped after the test.
+func newTestServer(t *testing.T, newAPI func(cred credential, rt *runtime) http.Handler) string {
+	rt := newRuntime(t.TempDir(), io.Discard)
+	server := httptest.NewServer(newAPI(credential{id: testAccessKeyID, secret: testAccessKeySecret}, rt))
+	t.Cleanup(func() {
+		server.Close()
+		rt.stopAll()
+	})
+	return server.URL
+}
+
+// buildEcho builds the function which echoes the requests.
+func buildEcho(t *testing.T) string {
+	binary, err := gobuild.Build(t.TempDir(), "testdata/echo", platform.DefaultTarget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// testPlatform is an emulated platform with the client deploying to it.
+type testPlatform struct {
+	name      string
+	newAPI    func(cred credential, rt *runtime) http.Handler
+	newClient func(endpoint string) (platform.Cloud, error)
+	triggers  []platform.TriggerSpec
+	// requires is the generated file the test requires.
+	requires string
+}
+
+func newSCFClient(endpoint string) (platform.Cloud, error) {
+	return tencentcloud.New(platform.AuthenticateOptions{
+		tencentcloud.RegionIDField:  "ap-guangzhou",
+		tencentcloud.SecretIDField:  testAccessKeyID,
+		tencentcloud.SecretKeyField: testAccessKeySecret,
+		platform.EndpointField:      endpoint,
+	})
+}
+
+var testPlatforms = []testPlatform{
+	{
+		name:   "FC",
+		newAPI: func(cred credential, rt *runtime) http.Handler { return newFC(cred, rt) },
+		newClient: func(endpoint string) (platform.Cloud, error) {
+			return aliyun.New(platform.AuthenticateOptions{
+				aliyun.RegionIDField:        "cn-hangzhou",
+				aliyun.AccountIDField:       "1234567890",
+				aliyun.AccessKeyIDField:     testAccessKeyID,
+				aliyun.AccessKeySecretField: testAccessKeySecret,
+				platform.EndpointField:      endpoint,
+			})
+		},
+	},
+	{
+		// The function without HTTP trigger is an event function, which is
+		// invoked through the runtime API.
+		name:      "SCF event function",
+		newAPI:    func(cred credential, rt *runtime) http.Handler { return newSCF(cred, rt) },
+		newClient: newSCFClient,
+		triggers: []platform.TriggerSpec{
+			{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+		},
+	},
+	{
+		// The web function is invoked through its HTTP trigger.
+		name:      "SCF web function",
+		newAPI:    func(cred credential, rt *runtime) http.Handler { return newSCF(cred, rt) },
+		newClient: newSCFClient,
+		triggers: []platform.TriggerSpec{
+			{Name: "http", Type: platform.HTTPTrigger},
+		},
+	},
+	{
+		name:     "Lambda",
+		requires: "../platform/aws/adapter/bootstrap-" + awsplatform.ArchitectureX86,
+		newAPI:   func(cred credential, rt *runtime) http.Handler { return newAWS(cred, rt) },
+		newClient: func(endpoint string) (platform.Cloud, error) {
+			return awsplatform.New(platform.AuthenticateOptions{
+				awsplatform.RegionIDField:        "us-east-1",
+				awsplatform.AccountIDField:       "123456789012",
+				awsplatform.RoleNameField:        "raika",
+				awsplatform.AccessKeyIDField:     testAccessKeyID,
+				awsplatform.SecretAccessKeyField: testAccessKeySecret,
+				platform.EndpointField:           endpoint,
+			})
+		},
+	},
+}
+
+// client returns the authenticated client of the emulated platform.
+func (p testPlatform) client(t *testing.T) platform.Cloud {
+	if p.requires != "" {
+		if _, err := os.Stat(p.requires); err != nil {
+			t.Skipf("%s is not generated, run `go generate ./internal/platform/aws`", p.requires)
+		}
+	}
+
+	client, err := p.newClient(newTestServer(t, p.newAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Authenticate(); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	return client
+}
+
+// skipEndToEnd skips the test if the function binary can't run on the host.
+func skipEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the function binary is built in the end-to-end tests")
+	}
+	// The functions are built for the default target and run on the host.
+	if goruntime.GOOS != platform.DefaultTarget.GOOS || goruntime.GOARCH != platform.DefaultTarget.GOARCH {
+		t.Skipf("the functions can not run on %s/%s", goruntime.GOOS, goruntime.GOARCH)
+	}
+}
+
+// invokeEcho invokes the echo function and checks the response.
+func invokeEcho(t *testing.T, client platform.Cloud, name string) {
+	resp, err := client.Invoke(name, []byte("ping"))
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if want := "POST /: ping"; string(resp.Body) != want || resp.Error != "" {
+		t.Fatalf("want %q, got %q %q", want, resp.Body, resp.Error)
+	}
+}
+
+func TestEndToEnd(t *testing.T) {
+	skipEndToEnd(t)
+	binary := buildEcho(t)
+
+	for _, test := range testPlatforms {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.client(t)
+
+			opts := platform.CreateFunctionOptions{
+				Name:                  "raika_echo",
+				Description:           "Echo",
+				MemorySize:            128,
+				InitializationTimeout: 10 * time.Second,
+				RuntimeTimeout:        10 * time.Second,
+				File:                  binary,
+				Triggers:              test.triggers,
+			}
+			if _, err := client.CreateFunction(opts); err != nil {
+				t.Fatalf("create function: %v", err)
+			}
+
+			info, err := client.Describe(opts.Name)
+			if err != nil {
+				t.Fatalf("describe: %v", err)
+			}
+			if info.Name != opts.Name || info.MemorySize != opts.MemorySize {
+				t.Fatalf("unexpected function info: %+v", info)
+			}
+
+			functions, err := client.ListFunctions()
+			if err != nil {
+				t.Fatalf("list functions: %v", err)
+			}
+			if len(functions) != 1 {
+				t.Fatalf("want 1 function, got %d", len(functions))
+			}
+			if f := functions[0]; f.Name != opts.Name || f.MemorySize != opts.MemorySize || f.RuntimeTimeout != opts.RuntimeTimeout {
+				t.Fatalf("unexpected function info: %+v", f)
+			}
+
+			invokeEcho(t, client, opts.Name)
+		})
+	}
+}
@@ -0,0 +1,807 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"encoding/json"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+)
+
+// fcAPIVersion is the path prefix of the Function Compute API.
+const fcAPIVersion = "2016-08-15"
+
+// fcLatest is the qualifier of the latest code of the function.
+const fcLatest = "LATEST"
+
+// fc emulates the Function Compute API, the functions are served on the
+// `/2016-08-15/proxy/<service>[.<qualifier>]/<function>/` trigger URLs. The
+// `DescribeRegions` action of the ECS API is also served for the login.
+type fc struct {
+	cred    credential
+	runtime *runtime
+
+	mu       sync.Mutex
+	services map[string]*fcService
+}
+
+type fcService struct {
+	ID          string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Functions   map[string]*fcFunction
+	// Versions are the snapshots of the functions in the service, the
+	// version ID is the index plus one.
+	Versions []map[string]*function
+	Aliases  map[string]*fcAlias
+}
+
+type fcFunction struct {
+	ID       string
+	Latest   *function
+	Triggers map[string]*fcTrigger
+}
+
+type fcTrigger struct {
+	ID             string
+	Name           string
+	Type           string
+	Qualifier      string
+	Config         json.RawMessage
+	InvocationRole string
+	SourceArn      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type fcAlias struct {
+	Name      string
+	VersionID string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func newFC(cred credential, rt *runtime) *fc {
+	return &fc{
+		cred:     cred,
+		runtime:  rt,
+		services: make(map[string]*fcService),
+	}
+}
+
+func fcError(w http.ResponseWriter, statusCode int, code, message string) {
+	writeJSON(w, statusCode, map[string]string{
+		"ErrorCode":    code,
+		"ErrorMessage": message,
+	})
+}
+
+func (s *fc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Trace("FC: %s %s", r.Method, r.URL.Path)
+	w.Header().Set("X-Fc-Request-Id", newID(16))
+
+	segments := splitPath(r.URL.Path)
+	if len(segments) == 0 && r.URL.Query().Get("Action") != "" {
+		s.serveRPC(w, r)
+		return
+	} else if len(segments) < 2 || segments[0] != fcAPIVersion {
+		fcError(w, http.StatusNotFound, "PathNotSupported", "the path is not supported")
+		return
+	}
+	if segments[1] == "proxy" {
+		s.serveProxy(w, r, segments[2:])
+		return
+	}
+
+	if err := s.cred.verifyFC(r); err != nil {
+		code := "SignatureNotMatch"
+		if err == errInvalidAccessKeyID {
+			code = "InvalidAccessKeyID"
+		}
+		fcError(w, http.StatusForbidden, code, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments = segments[1:]
+	switch {
+	case match(segments, "services") && r.Method == http.MethodGet:
+		s.listServices(w)
+	case match(segments, "services") && r.Method == http.MethodPost:
+		s.createService(w, body)
+	case match(segments, "services", "*") && r.Method == http.MethodGet:
+		if service := s.service(w, segments[1]); service != nil {
+			writeJSON(w, http.StatusOK, toFCService(service))
+		}
+	case match(segments, "services", "*", "functions") && r.Method == http.MethodGet:
+		s.listFunctions(w, segments[1])
+	case match(segments, "services", "*", "functions") && r.Method == http.MethodPost:
+		s.createFunction(w, segments[1], body)
+	case match(segments, "services", "*", "functions", "*") && r.Method == http.MethodGet:
+		if function := s.function(w, segments[1], segments[3]); function != nil {
+			writeJSON(w, http.StatusOK, toFCFunction(function))
+		}
+	case match(segments, "services", "*", "functions", "*") && r.Method == http.MethodPut:
+		s.updateFunction(w, segments[1], segments[3], body)
+	case match(segments, "services", "*", "functions", "*") && r.Method == http.MethodDelete:
+		s.deleteFunction(w, segments[1], segments[3])
+	case match(segments, "services", "*", "functions", "*", "invocations") && r.Method == http.MethodPost:
+		s.invoke(w, segments[1], segments[3], body)
+	case match(segments, "services", "*", "functions", "*", "triggers") && r.Method == http.MethodGet:
+		s.listTriggers(w, segments[1], segments[3])
+	case match(segments, "services", "*", "functions", "*", "triggers") && r.Method == http.MethodPost:
+		s.createTrigger(w, segments[1], segments[3], body)
+	case match(segments, "services", "*", "functions", "*", "triggers", "*") && r.Method == http.MethodGet:
+		if trigger := s.trigger(w, segments[1], segments[3], segments[5]); trigger != nil {
+			writeJSON(w, http.StatusOK, toFCTrigger(trigger))
+		}
+	case match(segments, "services", "*", "functions", "*", "triggers", "*") && r.Method == http.MethodPut:
+		s.updateTrigger(w, segments[1], segments[3], segments[5], body)
+	case match(segments, "services", "*", "functions", "*", "triggers", "*") && r.Method == http.MethodDelete:
+		s.deleteTrigger(w, segments[1], segments[3], segments[5])
+	case match(segments, "services", "*", "versions") && r.Method == http.MethodPost:
+		s.publishVersion(w, segments[1], body)
+	case match(segments, "services", "*", "aliases") && r.Method == http.MethodPost:
+		s.createAlias(w, segments[1], body)
+	case match(segments, "services", "*", "aliases", "*") && r.Method == http.MethodGet:
+		if service := s.service(w, segments[1]); service != nil {
+			alias, ok := service.Aliases[segments[3]]
+			if !ok {
+				fcError(w, http.StatusNotFound, "AliasNotFound", "alias "+segments[3]+" does not exist")
+				return
+			}
+			writeJSON(w, http.StatusOK, toFCAlias(alias))
+		}
+	case match(segments, "services", "*", "aliases", "*") && r.Method == http.MethodPut:
+		s.updateAlias(w, segments[1], segments[3], body)
+	default:
+		fcError(w, http.StatusNotFound, "PathNotSupported", "the path is not supported")
+	}
+}
+
+// serveRPC serves the `DescribeRegions` action of the ECS API, which is used to check the credential.
+func (s *fc) serveRPC(w http.ResponseWriter, r *http.Request) {
+	rpcError := func(statusCode int, code, message string) {
+		writeJSON(w, statusCode, map[string]string{
+			"RequestId": newID(16),
+			"Code":      code,
+			"Message":   message,
+			"Recommend": "Check the credential given to the Raika emulator.",
+		})
+	}
+
+	if err := s.cred.verifyRPC(r); err != nil {
+		if err == errInvalidAccessKeyID {
+			rpcError(http.StatusNotFound, "InvalidAccessKeyId.NotFound", err.Error())
+			return
+		}
+		rpcError(http.StatusBadRequest, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	if action := r.URL.Query().Get("Action"); action != "DescribeRegions" {
+		rpcError(http.StatusNotFound, "InvalidAction.NotFound", "the action "+action+" is not supported")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"RequestId": newID(16),
+		"Regions": map[string]interface{}{
+			"Region": []map[string]string{{"RegionId": r.URL.Query().Get("RegionId")}},
+		},
+	})
+}
+
+// serveProxy proxies the request on the HTTP trigger URL to the function.
+func (s *fc) serveProxy(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) < 2 {
+		fcError(w, http.StatusNotFound, "PathNotSupported", "the path is not supported")
+		return
+	}
+	serviceName, qualifier := segments[0], fcLatest
+	if i := strings.Index(serviceName, "."); i != -1 {
+		serviceName, qualifier = serviceName[:i], serviceName[i+1:]
+	}
+
+	s.mu.Lock()
+	f := s.resolve(w, serviceName, segments[1], qualifier)
+	s.mu.Unlock()
+	if f == nil {
+		return
+	}
+	s.runtime.serve(w, r, f, proxyPath(r.URL.Path, 4))
+}
+
+// resolve returns the code of the function with an HTTP trigger bound to the qualifier.
+func (s *fc) resolve(w http.ResponseWriter, serviceName, functionName, qualifier string) *function {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return nil
+	}
+	fn := s.function(w, serviceName, functionName)
+	if fn == nil {
+		return nil
+	}
+
+	var bound bool
+	for _, trigger := range fn.Triggers {
+		if trigger.Type == "http" && fcQualifier(trigger.Qualifier) == qualifier {
+			bound = true
+			break
+		}
+	}
+	if !bound {
+		fcError(w, http.StatusNotFound, "TriggerNotFound", "no HTTP trigger of "+functionName+" is bound to "+qualifier)
+		return nil
+	}
+
+	if qualifier == fcLatest {
+		return fn.Latest
+	}
+	versionID := qualifier
+	if alias, ok := service.Aliases[qualifier]; ok {
+		versionID = alias.VersionID
+	}
+	if version := service.version(versionID); version != nil && version[functionName] != nil {
+		return version[functionName]
+	}
+	fcError(w, http.StatusNotFound, "VersionNotFound", "function "+functionName+" is not published in "+qualifier)
+	return nil
+}
+
+func fcQualifier(qualifier string) string {
+	if qualifier == "" {
+		return fcLatest
+	}
+	return qualifier
+}
+
+func (s *fcService) version(id string) map[string]*function {
+	index, err := strconv.Atoi(id)
+	if err != nil || index < 1 || index > len(s.Versions) {
+		return nil
+	}
+	return s.Versions[index-1]
+}
+
+// published returns true if the code is published as a version.
+func (s *fcService) published(f *function) bool {
+	for _, version := range s.Versions {
+		for _, v := range version {
+			if v == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *fc) service(w http.ResponseWriter, name string) *fcService {
+	service, ok := s.services[name]
+	if !ok {
+		fcError(w, http.StatusNotFound, "ServiceNotFound", "service "+name+" does not exist")
+		return nil
+	}
+	return service
+}
+
+func (s *fc) function(w http.ResponseWriter, serviceName, name string) *fcFunction {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return nil
+	}
+	fn, ok := service.Functions[name]
+	if !ok {
+		fcError(w, http.StatusNotFound, "FunctionNotFound", "function "+name+" does not exist")
+		return nil
+	}
+	return fn
+}
+
+func (s *fc) trigger(w http.ResponseWriter, serviceName, functionName, name string) *fcTrigger {
+	fn := s.function(w, serviceName, functionName)
+	if fn == nil {
+		return nil
+	}
+	trigger, ok := fn.Triggers[name]
+	if !ok {
+		fcError(w, http.StatusNotFound, "TriggerNotFound", "trigger "+name+" does not exist")
+		return nil
+	}
+	return trigger
+}
+
+func decodeFC(w http.ResponseWriter, body []byte, v interface{}) bool {
+	if err := json.Unmarshal(body, v); err != nil {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func toFCService(service *fcService) map[string]interface{} {
+	return map[string]interface{}{
+		"serviceId":        service.ID,
+		"serviceName":      service.Name,
+		"description":      service.Description,
+		"createdTime":      service.CreatedAt,
+		"lastModifiedTime": service.UpdatedAt,
+	}
+}
+
+func (s *fc) listServices(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.services))
+	for name := range s.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		services = append(services, toFCService(s.services[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"services": services})
+}
+
+func (s *fc) createService(w http.ResponseWriter, body []byte) {
+	var request struct {
+		Name        string `json:"serviceName"`
+		Description string `json:"description"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if request.Name == "" {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "serviceName is required")
+		return
+	} else if _, ok := s.services[request.Name]; ok {
+		fcError(w, http.StatusConflict, "ServiceAlreadyExists", "service "+request.Name+" already exists")
+		return
+	}
+
+	now := time.Now()
+	service := &fcService{
+		ID:          newID(16),
+		Name:        request.Name,
+		Description: request.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Functions:   make(map[string]*fcFunction),
+		Aliases:     make(map[string]*fcAlias),
+	}
+	s.services[service.Name] = service
+	writeJSON(w, http.StatusOK, toFCService(service))
+}
+
+// fcChecksum returns the CRC-64/ECMA checksum of the package, which is
+// reported by Function Compute as the code checksum.
+func fcChecksum(data []byte) string {
+	return strconv.FormatUint(crc64.Checksum(data, crc64.MakeTable(crc64.ECMA)), 10)
+}
+
+func toFCFunction(fn *fcFunction) map[string]interface{} {
+	f := fn.Latest
+	return map[string]interface{}{
+		"functionId":            fn.ID,
+		"functionName":          f.Name,
+		"description":           f.Description,
+		"runtime":               f.Runtime,
+		"handler":               f.Handler,
+		"memorySize":            f.MemorySize,
+		"timeout":               int(f.Timeout / time.Second),
+		"initializationTimeout": int(f.InitializationTimeout / time.Second),
+		"caPort":                f.CAPort,
+		"environmentVariables":  f.Environment,
+		"codeChecksum":          fcChecksum(f.Package),
+		"codeSize":              len(f.Package),
+		"createdTime":           f.CreatedAt,
+		"lastModifiedTime":      f.UpdatedAt,
+	}
+}
+
+func (s *fc) listFunctions(w http.ResponseWriter, serviceName string) {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return
+	}
+
+	names := make([]string, 0, len(service.Functions))
+	for name := range service.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	functions := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		functions = append(functions, toFCFunction(service.Functions[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"functions": functions})
+}
+
+type fcFunctionRequest struct {
+	Name        string  `json:"functionName"`
+	Description *string `json:"description"`
+	Code        *struct {
+		ZipFile []byte `json:"zipFile"`
+	} `json:"code"`
+	Handler               *string           `json:"handler"`
+	Runtime               *string           `json:"runtime"`
+	MemorySize            *int64            `json:"memorySize"`
+	InitializationTimeout *int              `json:"initializationTimeout"`
+	Timeout               *int              `json:"timeout"`
+	CAPort                *int              `json:"caPort"`
+	EnvironmentVariables  map[string]string `json:"environmentVariables"`
+}
+
+// apply applies the fields given in the request to the function.
+func (r *fcFunctionRequest) apply(w http.ResponseWriter, f *function) bool {
+	if r.Code != nil {
+		if err := checkPackage(r.Code.ZipFile); err != nil {
+			fcError(w, http.StatusBadRequest, "InvalidArgument", "invalid code: "+err.Error())
+			return false
+		}
+		f.Package = r.Code.ZipFile
+	}
+	if r.Description != nil {
+		f.Description = *r.Description
+	}
+	if r.Handler != nil {
+		f.Handler = *r.Handler
+	}
+	if r.Runtime != nil {
+		f.Runtime = *r.Runtime
+	}
+	if r.MemorySize != nil {
+		f.MemorySize = *r.MemorySize
+	}
+	if r.InitializationTimeout != nil {
+		f.InitializationTimeout = time.Duration(*r.InitializationTimeout) * time.Second
+	}
+	if r.Timeout != nil {
+		f.Timeout = time.Duration(*r.Timeout) * time.Second
+	}
+	if r.CAPort != nil {
+		f.CAPort = *r.CAPort
+	}
+	if r.EnvironmentVariables != nil {
+		f.Environment = r.EnvironmentVariables
+	}
+	return true
+}
+
+func (s *fc) createFunction(w http.ResponseWriter, serviceName string, body []byte) {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return
+	}
+
+	var request fcFunctionRequest
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if request.Name == "" {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "functionName is required")
+		return
+	} else if request.Code == nil {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "code is required")
+		return
+	} else if _, ok := service.Functions[request.Name]; ok {
+		fcError(w, http.StatusConflict, "FunctionAlreadyExists", "function "+request.Name+" already exists")
+		return
+	}
+
+	now := time.Now()
+	f := &function{
+		Name:        request.Name,
+		Runtime:     "custom",
+		CAPort:      9000,
+		MemorySize:  128,
+		Timeout:     3 * time.Second,
+		Environment: map[string]string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if !request.apply(w, f) {
+		return
+	}
+
+	fn := &fcFunction{
+		ID:       newID(16),
+		Latest:   f,
+		Triggers: make(map[string]*fcTrigger),
+	}
+	service.Functions[f.Name] = fn
+	writeJSON(w, http.StatusOK, toFCFunction(fn))
+}
+
+func (s *fc) updateFunction(w http.ResponseWriter, serviceName, name string, body []byte) {
+	fn := s.function(w, serviceName, name)
+	if fn == nil {
+		return
+	}
+	service := s.services[serviceName]
+
+	var request fcFunctionRequest
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	f := fn.Latest.clone()
+	if !request.apply(w, f) {
+		return
+	}
+
+	if !service.published(fn.Latest) {
+		s.runtime.stop(fn.Latest)
+	}
+	fn.Latest = f
+	writeJSON(w, http.StatusOK, toFCFunction(fn))
+}
+
+func (s *fc) deleteFunction(w http.ResponseWriter, serviceName, name string) {
+	fn := s.function(w, serviceName, name)
+	if fn == nil {
+		return
+	}
+	service := s.services[serviceName]
+	if len(fn.Triggers) > 0 {
+		fcError(w, http.StatusPreconditionFailed, "FunctionNotEmpty", "function "+name+" still has triggers")
+		return
+	}
+
+	if !service.published(fn.Latest) {
+		s.runtime.stop(fn.Latest)
+	}
+	delete(service.Functions, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *fc) invoke(w http.ResponseWriter, serviceName, name string, payload []byte) {
+	fn := s.function(w, serviceName, name)
+	if fn == nil {
+		return
+	}
+
+	// The function is not locked while it is running.
+	f := fn.Latest
+	s.mu.Unlock()
+	statusCode, body, err := s.runtime.invoke(f, payload)
+	s.mu.Lock()
+
+	if err != nil {
+		w.Header().Set("X-Fc-Error-Type", "UnhandledInvocationError")
+		writeJSON(w, http.StatusOK, map[string]string{"errorMessage": err.Error()})
+		return
+	}
+	if statusCode >= http.StatusInternalServerError {
+		w.Header().Set("X-Fc-Error-Type", "UnhandledInvocationError")
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
+func toFCTrigger(trigger *fcTrigger) map[string]interface{} {
+	return map[string]interface{}{
+		"triggerId":        trigger.ID,
+		"triggerName":      trigger.Name,
+		"triggerType":      trigger.Type,
+		"qualifier":        trigger.Qualifier,
+		"triggerConfig":    trigger.Config,
+		"invocationRole":   trigger.InvocationRole,
+		"sourceArn":        trigger.SourceArn,
+		"createdTime":      trigger.CreatedAt,
+		"lastModifiedTime": trigger.UpdatedAt,
+	}
+}
+
+func (s *fc) listTriggers(w http.ResponseWriter, serviceName, functionName string) {
+	fn := s.function(w, serviceName, functionName)
+	if fn == nil {
+		return
+	}
+
+	names := make([]string, 0, len(fn.Triggers))
+	for name := range fn.Triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	triggers := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		triggers = append(triggers, toFCTrigger(fn.Triggers[name]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"triggers": triggers})
+}
+
+func (s *fc) createTrigger(w http.ResponseWriter, serviceName, functionName string, body []byte) {
+	fn := s.function(w, serviceName, functionName)
+	if fn == nil {
+		return
+	}
+
+	var request struct {
+		Name           string          `json:"triggerName"`
+		Type           string          `json:"triggerType"`
+		Config         json.RawMessage `json:"triggerConfig"`
+		InvocationRole string          `json:"invocationRole"`
+		Qualifier      string          `json:"qualifier"`
+		SourceArn      string          `json:"sourceArn"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if request.Name == "" {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "triggerName is required")
+		return
+	} else if request.Type != "http" && request.Type != "timer" {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "triggerType "+request.Type+" is not supported")
+		return
+	} else if _, ok := fn.Triggers[request.Name]; ok {
+		fcError(w, http.StatusConflict, "TriggerAlreadyExists", "trigger "+request.Name+" already exists")
+		return
+	}
+
+	now := time.Now()
+	trigger := &fcTrigger{
+		ID:             newID(16),
+		Name:           request.Name,
+		Type:           request.Type,
+		Qualifier:      fcQualifier(request.Qualifier),
+		Config:         request.Config,
+		InvocationRole: request.InvocationRole,
+		SourceArn:      request.SourceArn,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	fn.Triggers[trigger.Name] = trigger
+	writeJSON(w, http.StatusOK, toFCTrigger(trigger))
+}
+
+func (s *fc) updateTrigger(w http.ResponseWriter, serviceName, functionName, name string, body []byte) {
+	trigger := s.trigger(w, serviceName, functionName, name)
+	if trigger == nil {
+		return
+	}
+
+	var request struct {
+		Config    json.RawMessage `json:"triggerConfig"`
+		Qualifier *string         `json:"qualifier"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if request.Config != nil {
+		trigger.Config = request.Config
+	}
+	if request.Qualifier != nil {
+		trigger.Qualifier = fcQualifier(*request.Qualifier)
+	}
+	trigger.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusOK, toFCTrigger(trigger))
+}
+
+func (s *fc) deleteTrigger(w http.ResponseWriter, serviceName, functionName, name string) {
+	if s.trigger(w, serviceName, functionName, name) == nil {
+		return
+	}
+	delete(s.services[serviceName].Functions[functionName].Triggers, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishVersion publishes the latest code of all the functions in the service.
+func (s *fc) publishVersion(w http.ResponseWriter, serviceName string, body []byte) {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return
+	}
+
+	var request struct {
+		Description string `json:"description"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+
+	version := make(map[string]*function, len(service.Functions))
+	for name, fn := range service.Functions {
+		version[name] = fn.Latest
+	}
+	service.Versions = append(service.Versions, version)
+
+	now := time.Now()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"versionId":        strconv.Itoa(len(service.Versions)),
+		"description":      request.Description,
+		"createdTime":      now,
+		"lastModifiedTime": now,
+	})
+}
+
+func toFCAlias(alias *fcAlias) map[string]interface{} {
+	return map[string]interface{}{
+		"aliasName":        alias.Name,
+		"versionId":        alias.VersionID,
+		"createdTime":      alias.CreatedAt,
+		"lastModifiedTime": alias.UpdatedAt,
+	}
+}
+
+func (s *fc) createAlias(w http.ResponseWriter, serviceName string, body []byte) {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return
+	}
+
+	var request struct {
+		Name      string `json:"aliasName"`
+		VersionID string `json:"versionId"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if request.Name == "" {
+		fcError(w, http.StatusBadRequest, "InvalidArgument", "aliasName is required")
+		return
+	} else if service.version(request.VersionID) == nil {
+		fcError(w, http.StatusNotFound, "VersionNotFound", "version "+request.VersionID+" does not exist")
+		return
+	} else if _, ok := service.Aliases[request.Name]; ok {
+		fcError(w, http.StatusConflict, "AliasAlreadyExists", "alias "+request.Name+" already exists")
+		return
+	}
+
+	now := time.Now()
+	alias := &fcAlias{
+		Name:      request.Name,
+		VersionID: request.VersionID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	service.Aliases[alias.Name] = alias
+	writeJSON(w, http.StatusOK, toFCAlias(alias))
+}
+
+func (s *fc) updateAlias(w http.ResponseWriter, serviceName, name string, body []byte) {
+	service := s.service(w, serviceName)
+	if service == nil {
+		return
+	}
+	alias, ok := service.Aliases[name]
+	if !ok {
+		fcError(w, http.StatusNotFound, "AliasNotFound", "alias "+name+" does not exist")
+		return
+	}
+
+	var request struct {
+		VersionID string `json:"versionId"`
+	}
+	if !decodeFC(w, body, &request) {
+		return
+	}
+	if service.version(request.VersionID) == nil {
+		fcError(w, http.StatusNotFound, "VersionNotFound", "version "+request.VersionID+" does not exist")
+		return
+	}
+
+	alias.VersionID = request.VersionID
+	alias.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusOK, toFCAlias(alias))
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package emulator
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op, only the bootstrap itself is killed on the
+// platforms other than Linux.
+func setProcessGroup(_ *exec.Cmd) {}
+
+func kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,481 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"archive/zip"
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	bootstrapFileName    = "bootstrap"
+	scfBootstrapFileName = "scf_bootstrap"
+	defaultInitTimeout   = 10 * time.Second
+)
+
+// function is the code and the configuration of a function. It is never
+// modified once created, the updates create a new one, so the published
+// versions keep their own code.
+type function struct {
+	Name                  string
+	Description           string
+	Runtime               string
+	Architecture          string
+	Handler               string
+	Role                  string
+	CAPort                int
+	Package               []byte
+	MemorySize            int64
+	Environment           map[string]string
+	InitializationTimeout time.Duration
+	Timeout               time.Duration
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// clone returns a copy of the function to be updated.
+func (f *function) clone() *function {
+	c := *f
+	c.Environment = make(map[string]string, len(f.Environment))
+	for k, v := range f.Environment {
+		c.Environment[k] = v
+	}
+	c.UpdatedAt = time.Now()
+	return &c
+}
+
+// checkPackage checks the package is a zip file with the bootstrap.
+func checkPackage(data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return errors.Wrap(err, "invalid zip file")
+	}
+	for _, file := range reader.File {
+		if file.Name == bootstrapFileName || file.Name == scfBootstrapFileName {
+			return nil
+		}
+	}
+	return errors.Errorf("%q is not found in the zip file", bootstrapFileName)
+}
+
+// runtime runs the functions as the local processes. A process is started on
+// the first request to the function, and kept running until the function is
+// updated or deleted.
+type runtime struct {
+	dir    string
+	output io.Writer
+
+	mu        sync.Mutex
+	instances map[*function]*instance
+}
+
+func newRuntime(dir string, output io.Writer) *runtime {
+	return &runtime{
+		dir:       dir,
+		output:    output,
+		instances: make(map[*function]*instance),
+	}
+}
+
+type instance struct {
+	dir  string
+	port int
+	cmd  *exec.Cmd
+	// api is the Runtime API of the Lambda custom runtime or the SCF event
+	// functions, the function does not serve HTTP if it is set.
+	api *runtimeAPI
+
+	ready  chan struct{}
+	exited chan struct{}
+	err    error
+}
+
+func (i *instance) host() string {
+	return fmt.Sprintf("127.0.0.1:%d", i.port)
+}
+
+// acquire returns the running instance of the function, the process is started
+// if it is not running.
+func (r *runtime) acquire(f *function) (*instance, error) {
+	r.mu.Lock()
+	inst, ok := r.instances[f]
+	if ok {
+		select {
+		case <-inst.exited:
+			// Restart the process if it exits.
+			ok = false
+		default:
+		}
+	}
+	if !ok {
+		inst = &instance{
+			ready:  make(chan struct{}),
+			exited: make(chan struct{}),
+		}
+		r.instances[f] = inst
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		inst.err = r.start(f, inst)
+		close(inst.ready)
+	}
+	<-inst.ready
+	return inst, inst.err
+}
+
+func (r *runtime) start(f *function, inst *instance) (err error) {
+	defer func() {
+		// The exited channel is closed by the process once it is started.
+		if err != nil && inst.cmd == nil {
+			if inst.dir != "" {
+				_ = os.RemoveAll(inst.dir)
+			}
+			if inst.api != nil {
+				inst.api.close()
+			}
+			close(inst.exited)
+		}
+	}()
+
+	inst.dir, err = os.MkdirTemp(r.dir, f.Name+"-")
+	if err != nil {
+		return errors.Wrap(err, "create directory")
+	}
+	if err := unpack(f.Package, inst.dir); err != nil {
+		return errors.Wrap(err, "unpack")
+	}
+
+	// The SCF package is started by its `scf_bootstrap` script.
+	entry := filepath.Join(inst.dir, bootstrapFileName)
+	if _, err := os.Stat(filepath.Join(inst.dir, scfBootstrapFileName)); err == nil {
+		entry = filepath.Join(inst.dir, scfBootstrapFileName)
+	}
+
+	output := &prefixWriter{prefix: "[" + f.Name + "] ", w: r.output}
+	cmd := exec.Command(entry)
+	cmd.Dir = inst.dir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+	cmd.Env = os.Environ()
+	for k, v := range f.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	if usesRuntimeAPI(f) {
+		inst.api, err = newRuntimeAPI(functionARN("", f.Name))
+		if err != nil {
+			return errors.Wrap(err, "start runtime API")
+		}
+		cmd.Env = append(cmd.Env,
+			"AWS_LAMBDA_RUNTIME_API="+inst.api.addr(),
+			"AWS_LAMBDA_FUNCTION_NAME="+f.Name,
+			"AWS_LAMBDA_FUNCTION_MEMORY_SIZE="+strconv.FormatInt(f.MemorySize, 10),
+			"LAMBDA_TASK_ROOT="+inst.dir,
+			"_HANDLER="+f.Handler,
+		)
+	} else if usesSCFRuntimeAPI(f) {
+		inst.api, err = newRuntimeAPI("")
+		if err != nil {
+			return errors.Wrap(err, "start runtime API")
+		}
+		host, apiPort, _ := net.SplitHostPort(inst.api.addr())
+		cmd.Env = append(cmd.Env,
+			"SCF_RUNTIME_API="+host,
+			"SCF_RUNTIME_API_PORT="+apiPort,
+			"SCF_FUNCTIONNAME="+f.Name,
+			"SCF_FUNCTIONMEMORYSIZE="+strconv.FormatInt(f.MemorySize, 10),
+		)
+		// The port of the binary started by the bootstrap, which is 9000 on
+		// the platform, is changed to run the instances side by side.
+		port, err := freePort()
+		if err != nil {
+			return errors.Wrap(err, "get free port")
+		}
+		cmd.Env = append(cmd.Env, "PORT="+strconv.Itoa(port))
+	} else {
+		inst.port, err = freePort()
+		if err != nil {
+			return errors.Wrap(err, "get free port")
+		}
+		port := strconv.Itoa(inst.port)
+		cmd.Env = append(cmd.Env,
+			"PORT="+port,
+			"FC_SERVER_PORT="+port,
+			"FUNCTIONS_CUSTOMHANDLER_PORT="+port,
+		)
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "start bootstrap")
+	}
+	inst.cmd = cmd
+	go func() {
+		_ = cmd.Wait()
+		if inst.api != nil {
+			inst.api.close()
+		}
+		_ = os.RemoveAll(inst.dir)
+		close(inst.exited)
+	}()
+
+	timeout := f.InitializationTimeout
+	if timeout <= 0 {
+		timeout = defaultInitTimeout
+	}
+	if inst.api != nil {
+		return inst.waitRuntimeAPI(f, timeout)
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-inst.exited:
+			return errors.Errorf("function %q exited on start", f.Name)
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", inst.host(), time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = kill(cmd)
+	return errors.Errorf("function %q is not ready after %s", f.Name, timeout)
+}
+
+// waitRuntimeAPI waits until the instance asks for the next invocation.
+func (inst *instance) waitRuntimeAPI(f *function, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-inst.api.ready:
+		return nil
+	case <-inst.api.initFailed:
+		_ = kill(inst.cmd)
+		return errors.Wrapf(inst.api.initErr, "function %q failed to initialize", f.Name)
+	case <-inst.exited:
+		return errors.Errorf("function %q exited on start", f.Name)
+	case <-timer.C:
+		_ = kill(inst.cmd)
+		return errors.Errorf("function %q is not ready after %s", f.Name, timeout)
+	}
+}
+
+// stop stops the process of the function if it is running.
+func (r *runtime) stop(f *function) {
+	r.mu.Lock()
+	inst, ok := r.instances[f]
+	delete(r.instances, f)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	<-inst.ready
+	if inst.cmd != nil {
+		_ = kill(inst.cmd)
+	}
+	<-inst.exited
+}
+
+func (r *runtime) stopAll() {
+	r.mu.Lock()
+	functions := make([]*function, 0, len(r.instances))
+	for f := range r.instances {
+		functions = append(functions, f)
+	}
+	r.mu.Unlock()
+
+	for _, f := range functions {
+		r.stop(f)
+	}
+}
+
+// serve proxies the request to the function with the given path.
+func (r *runtime) serve(w http.ResponseWriter, req *http.Request, f *function, path string) {
+	inst, err := r.acquire(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if f.Timeout > 0 {
+		ctx, cancel := gocontext.WithTimeout(req.Context(), f.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = inst.host()
+			req.URL.Path = path
+			req.URL.RawPath = ""
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(r.Context().Err(), gocontext.DeadlineExceeded) {
+				http.Error(w, "function timed out", http.StatusGatewayTimeout)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// invoke posts the payload to the function, and returns the status code and
+// the body of the response. The payload is passed as the event to the function
+// which uses the Runtime API, and the status code is always 200.
+func (r *runtime) invoke(f *function, payload []byte) (int, []byte, error) {
+	inst, err := r.acquire(f)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ctx := gocontext.Background()
+	if f.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	if inst.api != nil {
+		body, err := inst.api.invoke(ctx, payload, inst.exited)
+		if err != nil {
+			if errors.Is(ctx.Err(), gocontext.DeadlineExceeded) {
+				// The instance is stopped on timeout like Lambda does.
+				_ = kill(inst.cmd)
+				return 0, nil, errors.Errorf("function timed out after %s", f.Timeout)
+			}
+			return 0, nil, err
+		}
+		return http.StatusOK, body, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+inst.host()+"/", bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "new request")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), gocontext.DeadlineExceeded) {
+			return 0, nil, errors.Errorf("function timed out after %s", f.Timeout)
+		}
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "read body")
+	}
+	return resp.StatusCode, body, nil
+}
+
+// unpack extracts the zip file to the directory with the file modes.
+func unpack(data []byte, dir string) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		path := filepath.Join(dir, file.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return errors.Errorf("illegal file path %q", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := extract(file, path); err != nil {
+			return errors.Wrapf(err, "extract %q", file.Name)
+		}
+	}
+	return nil
+}
+
+func extract(file *zip.File, path string) error {
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Close() }()
+
+	target, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = target.Close() }()
+
+	if _, err := io.Copy(target, source); err != nil {
+		return err
+	}
+	return target.Close()
+}
+
+// freePort returns a free loopback TCP port.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// prefixWriter prefixes each line written to it with the function name.
+type prefixWriter struct {
+	prefix string
+
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		index := bytes.IndexByte(w.buf, '\n')
+		if index == -1 {
+			break
+		}
+		if _, err := io.WriteString(w.w, w.prefix+string(w.buf[:index+1])); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[index+1:]
+	}
+	return len(p), nil
+}
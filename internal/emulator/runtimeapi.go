@@ -0,0 +1,263 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// runtimeAPIPrefix is the path prefix of the Lambda Runtime API.
+	runtimeAPIPrefix = "/2018-06-01/runtime/"
+	// scfRuntimeAPIPrefix is the path prefix of the runtime API of the SCF
+	// event functions on the custom runtime.
+	scfRuntimeAPIPrefix = "/runtime/"
+	// scfCustomRuntime is the runtime of the SCF custom runtime functions.
+	scfCustomRuntime = "CustomRuntime"
+)
+
+// usesRuntimeAPI returns true if the function is run by the Lambda custom
+// runtime, which receives the events from the Runtime API instead of serving
+// HTTP.
+func usesRuntimeAPI(f *function) bool {
+	return strings.HasPrefix(f.Runtime, "provided")
+}
+
+// usesSCFRuntimeAPI returns true if the function is an SCF event function on
+// the custom runtime, which receives the events from the runtime API.
+func usesSCFRuntimeAPI(f *function) bool {
+	return f.Runtime == scfCustomRuntime && f.Handler == scfEventFunction
+}
+
+type invocationResult struct {
+	body []byte
+	err  error
+}
+
+type invocation struct {
+	id       string
+	deadline time.Time
+	payload  []byte
+	result   chan invocationResult
+}
+
+// runtimeAPI serves the Lambda Runtime API to an instance of the function. The
+// instance is ready once it asks for the next invocation. The runtime API of
+// the SCF event functions is served as well, which has no request ID in the
+// paths as an instance runs one invocation at a time.
+type runtimeAPI struct {
+	functionARN string
+	listener    net.Listener
+	server      *http.Server
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	// initFailed is closed once the instance reports the initialization error.
+	initFailed chan struct{}
+	initOnce   sync.Once
+	initErr    error
+
+	invocations chan *invocation
+	mu          sync.Mutex
+	pending     map[string]*invocation
+	// current is the ID of the last invocation sent to the instance.
+	current string
+}
+
+func newRuntimeAPI(functionARN string) (*runtimeAPI, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	api := &runtimeAPI{
+		functionARN: functionARN,
+		listener:    listener,
+		ready:       make(chan struct{}),
+		initFailed:  make(chan struct{}),
+		invocations: make(chan *invocation),
+		pending:     make(map[string]*invocation),
+	}
+	api.server = &http.Server{Handler: api}
+	go func() { _ = api.server.Serve(listener) }()
+	return api, nil
+}
+
+// addr returns the address of the API, which is given to the instance by the
+// `AWS_LAMBDA_RUNTIME_API` environment variable.
+func (api *runtimeAPI) addr() string {
+	return api.listener.Addr().String()
+}
+
+func (api *runtimeAPI) close() {
+	_ = api.server.Close()
+}
+
+func (api *runtimeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, scfRuntimeAPIPrefix) {
+		api.serveSCF(w, r)
+		return
+	} else if !strings.HasPrefix(r.URL.Path, runtimeAPIPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	segments := splitPath(strings.TrimPrefix(r.URL.Path, runtimeAPIPrefix))
+	switch {
+	case r.Method == http.MethodGet && match(segments, "invocation", "next"):
+		api.next(w, r)
+	case r.Method == http.MethodPost && match(segments, "invocation", "*", "response"):
+		api.complete(w, r, segments[1], false)
+	case r.Method == http.MethodPost && match(segments, "invocation", "*", "error"):
+		api.complete(w, r, segments[1], true)
+	case r.Method == http.MethodPost && match(segments, "init", "error"):
+		api.initError(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveSCF serves the runtime API of the SCF event functions.
+func (api *runtimeAPI) serveSCF(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(strings.TrimPrefix(r.URL.Path, scfRuntimeAPIPrefix))
+	switch {
+	case r.Method == http.MethodPost && match(segments, "init", "ready"):
+		api.readyOnce.Do(func() { close(api.ready) })
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && match(segments, "init", "error"):
+		api.initError(w, r)
+	case r.Method == http.MethodGet && match(segments, "invocation", "next"):
+		api.next(w, r)
+	case r.Method == http.MethodPost && match(segments, "invocation", "response"):
+		api.complete(w, r, api.currentID(), false)
+	case r.Method == http.MethodPost && match(segments, "invocation", "error"):
+		api.complete(w, r, api.currentID(), true)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (api *runtimeAPI) currentID() string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.current
+}
+
+func (api *runtimeAPI) next(w http.ResponseWriter, r *http.Request) {
+	api.readyOnce.Do(func() { close(api.ready) })
+
+	var inv *invocation
+	select {
+	case inv = <-api.invocations:
+	case <-r.Context().Done():
+		return
+	}
+
+	api.mu.Lock()
+	api.pending[inv.id] = inv
+	api.current = inv.id
+	api.mu.Unlock()
+
+	w.Header().Set("Lambda-Runtime-Aws-Request-Id", inv.id)
+	w.Header().Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(inv.deadline.UnixNano()/int64(time.Millisecond), 10))
+	w.Header().Set("Lambda-Runtime-Invoked-Function-Arn", api.functionARN)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(inv.payload)
+}
+
+func (api *runtimeAPI) complete(w http.ResponseWriter, r *http.Request, id string, failed bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	api.mu.Lock()
+	inv, ok := api.pending[id]
+	delete(api.pending, id)
+	api.mu.Unlock()
+	if !ok {
+		http.Error(w, "invocation "+id+" is not found", http.StatusBadRequest)
+		return
+	}
+
+	if failed {
+		inv.result <- invocationResult{err: functionError(body)}
+	} else {
+		inv.result <- invocationResult{body: body}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (api *runtimeAPI) initError(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	api.initOnce.Do(func() {
+		api.initErr = functionError(body)
+		close(api.initFailed)
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// functionError returns the error reported by the function in the format of
+// `{"errorMessage": "...", "errorType": "..."}`.
+func functionError(body []byte) error {
+	var payload struct {
+		ErrorMessage string `json:"errorMessage"`
+		ErrorType    string `json:"errorType"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ErrorMessage == "" {
+		return errors.Errorf("function error: %s", body)
+	}
+	if payload.ErrorType == "" {
+		return errors.New(payload.ErrorMessage)
+	}
+	return errors.Errorf("%s: %s", payload.ErrorType, payload.ErrorMessage)
+}
+
+// invoke passes the payload to the instance and waits for the result, until
+// the context is done or the instance exits.
+func (api *runtimeAPI) invoke(ctx gocontext.Context, payload []byte, exited <-chan struct{}) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		// The deadline is required by the Runtime API.
+		deadline = time.Now().Add(24 * time.Hour)
+	}
+	inv := &invocation{
+		id:       newID(32),
+		deadline: deadline,
+		payload:  payload,
+		result:   make(chan invocationResult, 1),
+	}
+
+	select {
+	case api.invocations <- inv:
+	case <-exited:
+		return nil, errors.New("function exited")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case result := <-inv.result:
+		return result.body, result.err
+	case <-exited:
+		return nil, errors.New("function exited")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,793 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+)
+
+// scfLatest is the qualifier of the latest code of the function.
+const scfLatest = "$LATEST"
+
+// The types of the functions, the web functions serve HTTP on the API gateway
+// triggers, and the event functions receive the events from the runtime API.
+const (
+	scfHTTPFunction  = "HTTP"
+	scfEventFunction = "Event"
+)
+
+// scfTimeFormat is the time format of SCF, which is in China Standard Time.
+const scfTimeFormat = "2006-01-02 15:04:05"
+
+var cst = time.FixedZone("CST", 8*60*60)
+
+// scf emulates the Tencent SCF API, the functions are served on the
+// `/apigw/<function>/<trigger>/` URLs of the API gateway triggers.
+type scf struct {
+	cred    credential
+	runtime *runtime
+
+	mu        sync.Mutex
+	functions map[string]*scfFunction
+}
+
+type scfFunction struct {
+	ID     string
+	Latest *function
+	// Versions are the published versions, the version is the index plus one.
+	Versions []*function
+	Aliases  map[string]string
+	Triggers map[string]*scfTrigger
+}
+
+type scfTrigger struct {
+	Name           string
+	Type           string
+	Qualifier      string
+	Desc           string
+	CustomArgument string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func newSCF(cred credential, rt *runtime) *scf {
+	return &scf{
+		cred:      cred,
+		runtime:   rt,
+		functions: make(map[string]*scfFunction),
+	}
+}
+
+// scfError is the error in the response of the action.
+type scfError struct {
+	Code    string
+	Message string
+}
+
+func (e *scfError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+type scfRequest struct {
+	host   string
+	params []byte
+}
+
+// decode decodes the parameters of the action.
+func (r *scfRequest) decode(v interface{}) error {
+	if err := json.Unmarshal(r.params, v); err != nil {
+		return &scfError{Code: "InvalidParameter", Message: err.Error()}
+	}
+	return nil
+}
+
+type scfResponse map[string]interface{}
+
+func (s *scf) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+	if len(segments) >= 3 && segments[0] == "apigw" {
+		s.serveProxy(w, r, segments[1], segments[2])
+		return
+	}
+
+	action := r.Header.Get("X-TC-Action")
+	log.Trace("SCF: %s", action)
+
+	requestID := newID(32)
+	respond := func(response scfResponse, err error) {
+		if err != nil {
+			e, ok := err.(*scfError)
+			if !ok {
+				e = &scfError{Code: "InternalError", Message: err.Error()}
+			}
+			response = scfResponse{"Error": e}
+		}
+		response["RequestId"] = requestID
+		writeJSON(w, http.StatusOK, map[string]interface{}{"Response": response})
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respond(nil, err)
+		return
+	}
+	if _, err := s.cred.verifyTC3(r, body); err != nil {
+		code := "AuthFailure.SignatureFailure"
+		if err == errInvalidAccessKeyID {
+			code = "AuthFailure.SecretIdNotFound"
+		} else if err == errRequestExpired {
+			code = "AuthFailure.SignatureExpire"
+		}
+		respond(nil, &scfError{Code: code, Message: err.Error()})
+		return
+	}
+
+	// The parameters of the GET requests are in the query.
+	params := body
+	if r.Method == http.MethodGet {
+		query := make(map[string]string)
+		for k := range r.URL.Query() {
+			query[k] = r.URL.Query().Get(k)
+		}
+		params, _ = json.Marshal(query)
+	}
+	req := &scfRequest{host: r.Host, params: params}
+
+	actions := map[string]func(*scfRequest) (scfResponse, error){
+		"GetAccount":                  s.getAccount,
+		"CreateFunction":              s.createFunction,
+		"GetFunction":                 s.getFunction,
+		"GetFunctionAddress":          s.getFunctionAddress,
+		"UpdateFunctionCode":          s.updateFunctionCode,
+		"UpdateFunctionConfiguration": s.updateFunctionConfiguration,
+		"ListFunctions":               s.listFunctions,
+		"DeleteFunction":              s.deleteFunction,
+		"Invoke":                      s.invoke,
+		"CreateTrigger":               s.createTrigger,
+		"ListTriggers":                s.listTriggers,
+		"DeleteTrigger":               s.deleteTrigger,
+		"PublishVersion":              s.publishVersion,
+		"CreateAlias":                 s.createAlias,
+		"UpdateAlias":                 s.updateAlias,
+	}
+	handler, ok := actions[action]
+	if !ok {
+		respond(nil, &scfError{Code: "InvalidAction", Message: "the action " + action + " is not supported"})
+		return
+	}
+
+	if action != "Invoke" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	respond(handler(req))
+}
+
+// serveProxy proxies the request on the API gateway trigger URL to the function.
+func (s *scf) serveProxy(w http.ResponseWriter, r *http.Request, functionName, triggerName string) {
+	s.mu.Lock()
+	f, err := s.resolve(functionName, triggerName)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.runtime.serve(w, r, f, proxyPath(r.URL.Path, 3))
+}
+
+// resolve returns the code of the function bound to the API gateway trigger.
+func (s *scf) resolve(functionName, triggerName string) (*function, error) {
+	fn, err := s.function(functionName)
+	if err != nil {
+		return nil, err
+	}
+	trigger, ok := fn.Triggers[triggerName]
+	if !ok || trigger.Type != "apigw" {
+		return nil, &scfError{Code: "ResourceNotFound.Trigger", Message: "API gateway trigger " + triggerName + " does not exist"}
+	}
+	return fn.qualified(trigger.Qualifier)
+}
+
+// qualified returns the code of the function with the qualifier.
+func (fn *scfFunction) qualified(qualifier string) (*function, error) {
+	if qualifier == "" || qualifier == scfLatest {
+		return fn.Latest, nil
+	}
+	version := qualifier
+	if v, ok := fn.Aliases[qualifier]; ok {
+		version = v
+	}
+	if f := fn.version(version); f != nil {
+		return f, nil
+	}
+	return nil, &scfError{Code: "ResourceNotFound.Version", Message: "version " + qualifier + " does not exist"}
+}
+
+func (fn *scfFunction) version(version string) *function {
+	index, err := strconv.Atoi(version)
+	if err != nil || index < 1 || index > len(fn.Versions) {
+		return nil
+	}
+	return fn.Versions[index-1]
+}
+
+// published returns true if the code is published as a version.
+func (fn *scfFunction) published(f *function) bool {
+	for _, v := range fn.Versions {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scf) function(name string) (*scfFunction, error) {
+	fn, ok := s.functions[name]
+	if !ok {
+		return nil, &scfError{Code: "ResourceNotFound.Function", Message: "function " + name + " does not exist"}
+	}
+	return fn, nil
+}
+
+func (s *scf) getAccount(*scfRequest) (scfResponse, error) {
+	return scfResponse{
+		"AccountUsage": map[string]int{"Namespace": 1},
+	}, nil
+}
+
+type scfKV struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type scfEnvironment struct {
+	Variables []scfKV `json:"Variables"`
+}
+
+func toSCFEnvironment(environment map[string]string) scfEnvironment {
+	keys := make([]string, 0, len(environment))
+	for k := range environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	variables := make([]scfKV, 0, len(keys))
+	for _, k := range keys {
+		variables = append(variables, scfKV{Key: k, Value: environment[k]})
+	}
+	return scfEnvironment{Variables: variables}
+}
+
+type scfConfiguration struct {
+	Description *string         `json:"Description"`
+	MemorySize  *int64          `json:"MemorySize"`
+	Environment *scfEnvironment `json:"Environment"`
+	InitTimeout *int            `json:"InitTimeout"`
+	Timeout     *int            `json:"Timeout"`
+}
+
+func (c *scfConfiguration) apply(f *function) {
+	if c.Description != nil {
+		f.Description = *c.Description
+	}
+	if c.MemorySize != nil {
+		f.MemorySize = *c.MemorySize
+	}
+	if c.Environment != nil {
+		f.Environment = make(map[string]string, len(c.Environment.Variables))
+		for _, v := range c.Environment.Variables {
+			f.Environment[v.Key] = v.Value
+		}
+	}
+	if c.InitTimeout != nil {
+		f.InitializationTimeout = time.Duration(*c.InitTimeout) * time.Second
+	}
+	if c.Timeout != nil {
+		f.Timeout = time.Duration(*c.Timeout) * time.Second
+	}
+}
+
+func (s *scf) createFunction(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		scfConfiguration
+		FunctionName string `json:"FunctionName"`
+		Code         struct {
+			ZipFile []byte `json:"ZipFile"`
+		} `json:"Code"`
+		Runtime string `json:"Runtime"`
+		Type    string `json:"Type"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	if request.FunctionName == "" {
+		return nil, &scfError{Code: "MissingParameter", Message: "FunctionName is required"}
+	} else if _, ok := s.functions[request.FunctionName]; ok {
+		return nil, &scfError{Code: "ResourceInUse.Function", Message: "function " + request.FunctionName + " already exists"}
+	} else if err := checkPackage(request.Code.ZipFile); err != nil {
+		return nil, &scfError{Code: "InvalidParameterValue.Code", Message: err.Error()}
+	}
+	if request.Type == "" {
+		request.Type = scfEventFunction
+	}
+	switch request.Type {
+	case scfHTTPFunction:
+	case scfEventFunction:
+		// The event functions of the other runtimes are run by the handlers of
+		// the language libraries, which are not emulated.
+		if request.Runtime != scfCustomRuntime {
+			return nil, &scfError{Code: "InvalidParameterValue.Runtime", Message: "event functions of runtime " + request.Runtime + " are not supported"}
+		}
+	default:
+		return nil, &scfError{Code: "InvalidParameterValue.Type", Message: "function type " + request.Type + " is not supported"}
+	}
+
+	now := time.Now()
+	f := &function{
+		Name:        request.FunctionName,
+		Runtime:     request.Runtime,
+		Handler:     request.Type,
+		Package:     request.Code.ZipFile,
+		MemorySize:  128,
+		Timeout:     3 * time.Second,
+		Environment: map[string]string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	request.apply(f)
+
+	s.functions[f.Name] = &scfFunction{
+		ID:       "lam-" + newID(8),
+		Latest:   f,
+		Aliases:  make(map[string]string),
+		Triggers: make(map[string]*scfTrigger),
+	}
+	return scfResponse{}, nil
+}
+
+func (s *scf) getFunction(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+		Qualifier    string `json:"Qualifier"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fn.qualified(request.Qualifier)
+	if err != nil {
+		return nil, err
+	}
+
+	qualifier := request.Qualifier
+	if qualifier == "" {
+		qualifier = scfLatest
+	}
+	return scfResponse{
+		"FunctionName":    f.Name,
+		"FunctionId":      fn.ID,
+		"Namespace":       "default",
+		"Qualifier":       qualifier,
+		"FunctionVersion": qualifier,
+		"Description":     f.Description,
+		"Runtime":         f.Runtime,
+		"Type":            f.Handler,
+		"MemorySize":      f.MemorySize,
+		"Timeout":         int(f.Timeout / time.Second),
+		"InitTimeout":     int(f.InitializationTimeout / time.Second),
+		"Environment":     toSCFEnvironment(f.Environment),
+		"CodeSize":        len(f.Package),
+		"Status":          "Active",
+		"StatusDesc":      "",
+		"AddTime":         f.CreatedAt.In(cst).Format(scfTimeFormat),
+		"ModTime":         f.UpdatedAt.In(cst).Format(scfTimeFormat),
+	}, nil
+}
+
+// update replaces the latest code of the function.
+func (s *scf) update(fn *scfFunction, f *function) {
+	if !fn.published(fn.Latest) {
+		s.runtime.stop(fn.Latest)
+	}
+	fn.Latest = f
+}
+
+// getFunctionAddress returns the checksum of the code, the download URL is
+// not given as the code is not served by the emulator.
+func (s *scf) getFunctionAddress(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+		Qualifier    string `json:"Qualifier"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fn.qualified(request.Qualifier)
+	if err != nil {
+		return nil, err
+	}
+	return scfResponse{"CodeSha256": scfChecksum(f.Package)}, nil
+}
+
+func scfChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *scf) updateFunctionCode(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+		ZipFile      []byte `json:"ZipFile"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPackage(request.ZipFile); err != nil {
+		return nil, &scfError{Code: "InvalidParameterValue.Code", Message: err.Error()}
+	}
+
+	f := fn.Latest.clone()
+	f.Package = request.ZipFile
+	s.update(fn, f)
+	return scfResponse{}, nil
+}
+
+func (s *scf) updateFunctionConfiguration(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		scfConfiguration
+		FunctionName string `json:"FunctionName"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	f := fn.Latest.clone()
+	request.apply(f)
+	s.update(fn, f)
+	return scfResponse{}, nil
+}
+
+func (s *scf) listFunctions(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		Offset int `json:"Offset"`
+		Limit  int `json:"Limit"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	if request.Limit <= 0 {
+		request.Limit = 20
+	}
+
+	names := make([]string, 0, len(s.functions))
+	for name := range s.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	functions := make([]map[string]interface{}, 0, request.Limit)
+	for i := request.Offset; i < len(names) && len(functions) < request.Limit; i++ {
+		fn := s.functions[names[i]]
+		functions = append(functions, map[string]interface{}{
+			"FunctionName": fn.Latest.Name,
+			"FunctionId":   fn.ID,
+			"Namespace":    "default",
+			"Description":  fn.Latest.Description,
+			"Runtime":      fn.Latest.Runtime,
+			"Type":         fn.Latest.Handler,
+			"Status":       "Active",
+			"StatusDesc":   "",
+			"AddTime":      fn.Latest.CreatedAt.In(cst).Format(scfTimeFormat),
+			"ModTime":      fn.Latest.UpdatedAt.In(cst).Format(scfTimeFormat),
+		})
+	}
+	return scfResponse{
+		"Functions":  functions,
+		"TotalCount": len(names),
+	}, nil
+}
+
+func (s *scf) deleteFunction(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.runtime.stop(fn.Latest)
+	for _, f := range fn.Versions {
+		s.runtime.stop(f)
+	}
+	delete(s.functions, request.FunctionName)
+	return scfResponse{}, nil
+}
+
+func (s *scf) invoke(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName  string `json:"FunctionName"`
+		Qualifier     string `json:"Qualifier"`
+		ClientContext string `json:"ClientContext"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+
+	// The function is not locked while it is running.
+	s.mu.Lock()
+	fn, err := s.function(request.FunctionName)
+	var f *function
+	if err == nil {
+		f, err = fn.qualified(request.Qualifier)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if f.Handler == scfHTTPFunction {
+		return nil, &scfError{Code: "UnsupportedOperation", Message: "web functions can not be invoked by the Invoke API, request the API gateway trigger instead"}
+	}
+
+	startAt := time.Now()
+	statusCode, body, err := s.runtime.invoke(f, []byte(request.ClientContext))
+	result := map[string]interface{}{
+		"FunctionRequestId": newID(32),
+		"Duration":          float64(time.Since(startAt)) / float64(time.Millisecond),
+		"BillDuration":      int(time.Since(startAt) / time.Millisecond),
+		"MemUsage":          0,
+		"Log":               "",
+		"RetMsg":            string(body),
+		"ErrMsg":            "",
+		"InvokeResult":      0,
+	}
+	if err != nil {
+		result["ErrMsg"], result["InvokeResult"] = err.Error(), -1
+	} else if statusCode >= http.StatusInternalServerError {
+		result["ErrMsg"], result["InvokeResult"] = string(body), -1
+	}
+	return scfResponse{"Result": result}, nil
+}
+
+func (s *scf) subDomain(host, functionName, triggerName string) string {
+	return "http://" + host + "/apigw/" + functionName + "/" + triggerName + "/"
+}
+
+func (s *scf) toTriggerInfo(host, functionName string, trigger *scfTrigger) map[string]interface{} {
+	desc := trigger.Desc
+	switch trigger.Type {
+	case "apigw":
+		// The URL of the trigger is filled in the description.
+		var d map[string]interface{}
+		if err := json.Unmarshal([]byte(desc), &d); err != nil || d == nil {
+			d = make(map[string]interface{})
+		}
+		service, _ := d["service"].(map[string]interface{})
+		if service == nil {
+			service = make(map[string]interface{})
+		}
+		service["serviceId"] = "service-" + functionName
+		service["subDomain"] = s.subDomain(host, functionName, trigger.Name)
+		d["service"] = service
+		data, _ := json.Marshal(d)
+		desc = string(data)
+	case "timer":
+		data, _ := json.Marshal(map[string]string{"cron": trigger.Desc})
+		desc = string(data)
+	}
+
+	return map[string]interface{}{
+		"TriggerName":     trigger.Name,
+		"Type":            trigger.Type,
+		"Qualifier":       trigger.Qualifier,
+		"TriggerDesc":     desc,
+		"CustomArgument":  trigger.CustomArgument,
+		"Enable":          1,
+		"AvailableStatus": "Available",
+		"BindStatus":      "on",
+		"AddTime":         trigger.CreatedAt.In(cst).Format(scfTimeFormat),
+		"ModTime":         trigger.UpdatedAt.In(cst).Format(scfTimeFormat),
+	}
+}
+
+func (s *scf) createTrigger(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName   string `json:"FunctionName"`
+		TriggerName    string `json:"TriggerName"`
+		Type           string `json:"Type"`
+		TriggerDesc    string `json:"TriggerDesc"`
+		Qualifier      string `json:"Qualifier"`
+		CustomArgument string `json:"CustomArgument"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+	if request.TriggerName == "" {
+		return nil, &scfError{Code: "MissingParameter", Message: "TriggerName is required"}
+	} else if request.Type != "apigw" && request.Type != "timer" {
+		return nil, &scfError{Code: "InvalidParameterValue.Type", Message: "trigger type " + request.Type + " is not supported"}
+	} else if _, ok := fn.Triggers[request.TriggerName]; ok {
+		return nil, &scfError{Code: "ResourceInUse.Trigger", Message: "trigger " + request.TriggerName + " already exists"}
+	}
+	// The web functions only accept the API gateway triggers, and the API
+	// gateway triggers of the event functions are not emulated.
+	if isHTTP := fn.Latest.Handler == scfHTTPFunction; isHTTP != (request.Type == "apigw") {
+		return nil, &scfError{Code: "InvalidParameterValue.Type", Message: "trigger type " + request.Type + " is not supported by " + fn.Latest.Handler + " functions"}
+	}
+	if request.Qualifier == "" {
+		request.Qualifier = scfLatest
+	}
+	if _, err := fn.qualified(request.Qualifier); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	trigger := &scfTrigger{
+		Name:           request.TriggerName,
+		Type:           request.Type,
+		Qualifier:      request.Qualifier,
+		Desc:           request.TriggerDesc,
+		CustomArgument: request.CustomArgument,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	fn.Triggers[trigger.Name] = trigger
+	return scfResponse{"TriggerInfo": s.toTriggerInfo(req.host, fn.Latest.Name, trigger)}, nil
+}
+
+func (s *scf) listTriggers(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fn.Triggers))
+	for name := range fn.Triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	triggers := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		triggers = append(triggers, s.toTriggerInfo(req.host, fn.Latest.Name, fn.Triggers[name]))
+	}
+	return scfResponse{
+		"Triggers":   triggers,
+		"TotalCount": len(triggers),
+	}, nil
+}
+
+func (s *scf) deleteTrigger(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+		TriggerName  string `json:"TriggerName"`
+		Type         string `json:"Type"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+	trigger, ok := fn.Triggers[request.TriggerName]
+	if !ok || trigger.Type != request.Type {
+		return nil, &scfError{Code: "ResourceNotFound.Trigger", Message: "trigger " + request.TriggerName + " does not exist"}
+	}
+
+	delete(fn.Triggers, request.TriggerName)
+	return scfResponse{}, nil
+}
+
+func (s *scf) publishVersion(req *scfRequest) (scfResponse, error) {
+	var request struct {
+		FunctionName string `json:"FunctionName"`
+		Description  string `json:"Description"`
+	}
+	if err := req.decode(&request); err != nil {
+		return nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	fn.Versions = append(fn.Versions, fn.Latest)
+	return scfResponse{
+		"FunctionVersion": strconv.Itoa(len(fn.Versions)),
+		"Description":     request.Description,
+		"FunctionName":    fn.Latest.Name,
+		"Namespace":       "default",
+		"MemorySize":      fn.Latest.MemorySize,
+		"Timeout":         int(fn.Latest.Timeout / time.Second),
+		"Runtime":         fn.Latest.Runtime,
+	}, nil
+}
+
+type scfAliasRequest struct {
+	FunctionName    string `json:"FunctionName"`
+	Name            string `json:"Name"`
+	FunctionVersion string `json:"FunctionVersion"`
+}
+
+// alias decodes the alias request, and checks the function and the version exist.
+func (s *scf) alias(req *scfRequest) (*scfFunction, *scfAliasRequest, error) {
+	var request scfAliasRequest
+	if err := req.decode(&request); err != nil {
+		return nil, nil, err
+	}
+	fn, err := s.function(request.FunctionName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.Name == "" {
+		return nil, nil, &scfError{Code: "MissingParameter", Message: "Name is required"}
+	} else if fn.version(request.FunctionVersion) == nil {
+		return nil, nil, &scfError{Code: "ResourceNotFound.Version", Message: "version " + request.FunctionVersion + " does not exist"}
+	}
+	return fn, &request, nil
+}
+
+func (s *scf) createAlias(req *scfRequest) (scfResponse, error) {
+	fn, request, err := s.alias(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fn.Aliases[request.Name]; ok {
+		return nil, &scfError{Code: "ResourceInUse.Alias", Message: "alias " + request.Name + " already exists"}
+	}
+	fn.Aliases[request.Name] = request.FunctionVersion
+	return scfResponse{}, nil
+}
+
+func (s *scf) updateAlias(req *scfRequest) (scfResponse, error) {
+	fn, request, err := s.alias(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fn.Aliases[request.Name]; !ok {
+		return nil, &scfError{Code: "ResourceNotFound.Alias", Message: "alias " + request.Name + " does not exist"}
+	}
+	fn.Aliases[request.Name] = request.FunctionVersion
+	return scfResponse{}, nil
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+// maxClockSkew is the max difference between the request time and the local time.
+const maxClockSkew = 15 * time.Minute
+
+var (
+	errMissingSignature   = errors.New("the request is not signed")
+	errInvalidAccessKeyID = errors.New("the access key ID does not exist")
+	errSignatureMismatch  = errors.New("the request signature does not match")
+	errRequestExpired     = errors.New("the request time is too skewed")
+)
+
+type credential struct {
+	id, secret string
+}
+
+func checkTime(t time.Time) error {
+	if d := time.Since(t); d > maxClockSkew || d < -maxClockSkew {
+		return errRequestExpired
+	}
+	return nil
+}
+
+// verifyFC checks the `FC <id>:<signature>` authorization of the Function Compute API.
+func (c credential) verifyFC(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "FC ") {
+		return errMissingSignature
+	}
+	id, signature := auth[3:], ""
+	if i := strings.LastIndex(id, ":"); i != -1 {
+		id, signature = id[:i], id[i+1:]
+	}
+	if id != c.id {
+		return errInvalidAccessKeyID
+	}
+
+	date, err := time.Parse(http.TimeFormat, r.Header.Get("Date"))
+	if err != nil {
+		return errors.Wrap(err, "parse date")
+	}
+	if err := checkTime(date); err != nil {
+		return err
+	}
+
+	var keys []string
+	for k := range r.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-fc-") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+	var fcHeaders string
+	for _, k := range keys {
+		fcHeaders += strings.ToLower(k) + ":" + r.Header.Get(k) + "\n"
+	}
+
+	// The canonicalized resource is the path followed by the sorted query
+	// parameters, each in a line as `key=value`.
+	resource := r.URL.Path
+	var params []string
+	for k, values := range r.URL.Query() {
+		for _, v := range values {
+			params = append(params, k+"="+v)
+		}
+	}
+	if len(params) > 0 {
+		sort.Strings(params)
+		resource += "\n" + strings.Join(params, "\n")
+	}
+
+	signStr := r.Method + "\n" + r.Header.Get("Content-MD5") + "\n" + r.Header.Get("Content-Type") + "\n" + r.Header.Get("Date") + "\n" + fcHeaders + resource
+	h := hmac.New(sha256.New, []byte(c.secret))
+	_, _ = h.Write([]byte(signStr))
+	if !hmac.Equal([]byte(signature), []byte(base64.StdEncoding.EncodeToString(h.Sum(nil)))) {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+// verifyRPC checks the HMAC-SHA1 signature in the query of the aliyun RPC API.
+func (c credential) verifyRPC(r *http.Request) error {
+	query := r.URL.Query()
+	signature := query.Get("Signature")
+	if signature == "" {
+		return errMissingSignature
+	}
+	if query.Get("AccessKeyId") != c.id {
+		return errInvalidAccessKeyID
+	}
+
+	timestamp, err := time.Parse("2006-01-02T15:04:05Z", query.Get("Timestamp"))
+	if err != nil {
+		return errors.Wrap(err, "parse timestamp")
+	}
+	if err := checkTime(timestamp); err != nil {
+		return err
+	}
+
+	query.Del("Signature")
+	h := hmac.New(sha1.New, []byte(c.secret+"&"))
+	_, _ = h.Write([]byte(r.Method + "&%2F&" + url.QueryEscape(query.Encode())))
+	if !hmac.Equal([]byte(signature), []byte(base64.StdEncoding.EncodeToString(h.Sum(nil)))) {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+// authorization is the parsed `<algorithm> Credential=..., SignedHeaders=..., Signature=...`
+// authorization header of TC3 and SigV4.
+type authorization struct {
+	// Credential is `<id>/<date>/[<region>/]<service>/<terminator>`.
+	Credential    []string
+	SignedHeaders []string
+	Signature     string
+}
+
+func parseAuthorization(value, algorithm string) (*authorization, error) {
+	if !strings.HasPrefix(value, algorithm+" ") {
+		return nil, errMissingSignature
+	}
+
+	var auth authorization
+	for _, part := range strings.Split(strings.TrimPrefix(value, algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Credential":
+			auth.Credential = strings.Split(kv[1], "/")
+		case "SignedHeaders":
+			auth.SignedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			auth.Signature = kv[1]
+		}
+	}
+	if len(auth.Credential) < 4 || len(auth.SignedHeaders) == 0 || auth.Signature == "" {
+		return nil, errors.New("malformed authorization header")
+	}
+	return &auth, nil
+}
+
+// verifyTC3 checks the TC3-HMAC-SHA256 signature of the Tencent Cloud API,
+// and returns the service in the credential scope.
+func (c credential) verifyTC3(r *http.Request, body []byte) (string, error) {
+	auth, err := parseAuthorization(r.Header.Get("Authorization"), "TC3-HMAC-SHA256")
+	if err != nil {
+		return "", err
+	}
+	if len(auth.Credential) != 4 || auth.Credential[3] != "tc3_request" {
+		return "", errors.New("malformed credential scope")
+	}
+	if auth.Credential[0] != c.id {
+		return "", errInvalidAccessKeyID
+	}
+	date, service := auth.Credential[1], auth.Credential[2]
+
+	unix, err := strconv.ParseInt(r.Header.Get("X-TC-Timestamp"), 10, 64)
+	if err != nil {
+		return "", errors.Wrap(err, "parse timestamp")
+	}
+	timestamp := time.Unix(unix, 0).UTC()
+	if err := checkTime(timestamp); err != nil {
+		return "", err
+	}
+	if timestamp.Format("2006-01-02") != date {
+		return "", errors.New("the credential date does not match the timestamp")
+	}
+
+	var canonicalHeaders string
+	for _, k := range auth.SignedHeaders {
+		v := r.Header.Get(k)
+		if k == "host" {
+			v = r.Host
+		}
+		canonicalHeaders += k + ":" + v + "\n"
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.Path,
+		r.URL.RawQuery,
+		canonicalHeaders,
+		strings.Join(auth.SignedHeaders, ";"),
+		sha256Hex(body),
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"TC3-HMAC-SHA256",
+		strconv.FormatInt(unix, 10),
+		strings.Join(auth.Credential[1:], "/"),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("TC3"+c.secret), date)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "tc3_request")
+	if !hmac.Equal([]byte(auth.Signature), []byte(hex.EncodeToString(hmacSHA256(key, stringToSign)))) {
+		return "", errSignatureMismatch
+	}
+	return service, nil
+}
+
+// verifySigV4 checks the AWS Signature Version 4 of the request by signing
+// the signed headers of it again, and returns the region and the service in
+// the credential scope.
+func (c credential) verifySigV4(r *http.Request, body []byte) (region, service string, err error) {
+	auth, err := parseAuthorization(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+	if err != nil {
+		return "", "", err
+	}
+	if len(auth.Credential) != 5 || auth.Credential[4] != "aws4_request" {
+		return "", "", errors.New("malformed credential scope")
+	}
+	if auth.Credential[0] != c.id {
+		return "", "", errInvalidAccessKeyID
+	}
+	region, service = auth.Credential[2], auth.Credential[3]
+
+	signTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "", "", errors.Wrap(err, "parse date")
+	}
+	if err := checkTime(signTime); err != nil {
+		return "", "", err
+	}
+
+	u := *r.URL
+	u.Scheme, u.Host = "http", r.Host
+	req, err := http.NewRequest(r.Method, u.String(), nil)
+	if err != nil {
+		return "", "", errors.Wrap(err, "new request")
+	}
+	for _, k := range auth.SignedHeaders {
+		if k != "host" {
+			req.Header[http.CanonicalHeaderKey(k)] = r.Header.Values(k)
+		}
+	}
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials(c.id, c.secret, ""))
+	if _, err := signer.Sign(req, bytes.NewReader(body), service, region, signTime); err != nil {
+		return "", "", errors.Wrap(err, "sign")
+	}
+	expected, err := parseAuthorization(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+	if err != nil {
+		return "", "", err
+	}
+	if !hmac.Equal([]byte(auth.Signature), []byte(expected.Signature)) {
+		return "", "", errSignatureMismatch
+	}
+	return region, service, nil
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
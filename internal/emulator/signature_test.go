@@ -0,0 +1,231 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/aliyun"
+	awsplatform "github.com/wuhan005/Raika/internal/platform/aws"
+	"github.com/wuhan005/Raika/internal/platform/tencentcloud"
+)
+
+// sendFC sends a Function Compute API request signed by the aliyun client.
+func sendFC(t *testing.T, endpoint, id, secret string) {
+	client, err := aliyun.New(platform.AuthenticateOptions{
+		aliyun.RegionIDField:        "cn-hangzhou",
+		aliyun.AccountIDField:       "1234567890",
+		aliyun.AccessKeyIDField:     id,
+		aliyun.AccessKeySecretField: secret,
+		platform.EndpointField:      endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = client.Authenticate()
+}
+
+// sendRPC sends an ECS API request signed by the aliyun client.
+func sendRPC(t *testing.T, endpoint, id, secret string) {
+	client, err := aliyun.New(platform.AuthenticateOptions{
+		aliyun.AccessKeyIDField:     id,
+		aliyun.AccessKeySecretField: secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := url.Values{}
+	query.Set("AccessKeyId", id)
+	query.Set("Action", "DescribeRegions")
+	query.Set("Format", "JSON")
+	query.Set("RegionId", "cn-hangzhou")
+	query.Set("SignatureMethod", "HMAC-SHA1")
+	query.Set("SignatureNonce", "NONCE")
+	query.Set("SignatureVersion", "1.0")
+	query.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("Version", "2014-05-26")
+	rawQuery := query.Encode()
+	rawQuery += "&Signature=" + url.QueryEscape(client.GetRPCSignature(http.MethodGet, rawQuery))
+
+	resp, err := http.Get(endpoint + "/?" + rawQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+}
+
+// sendTC3 sends an SCF API request signed by the Tencent Cloud client.
+func sendTC3(t *testing.T, endpoint, id, secret string) {
+	client, err := tencentcloud.New(platform.AuthenticateOptions{
+		tencentcloud.RegionIDField:  "ap-guangzhou",
+		tencentcloud.SecretIDField:  id,
+		tencentcloud.SecretKeyField: secret,
+		platform.EndpointField:      endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = client.Authenticate()
+}
+
+// sendSigV4 sends a Lambda API request signed by the AWS SDK.
+func sendSigV4(t *testing.T, endpoint, id, secret string) {
+	client, err := awsplatform.New(platform.AuthenticateOptions{
+		awsplatform.RegionIDField:        "us-east-1",
+		awsplatform.AccessKeyIDField:     id,
+		awsplatform.SecretAccessKeyField: secret,
+		platform.EndpointField:           endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = client.Describe("hello")
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	cred := credential{id: "test-id", secret: "test-secret"}
+	tests := []struct {
+		name   string
+		send   func(t *testing.T, endpoint, id, secret string)
+		verify func(r *http.Request, body []byte) error
+	}{
+		{
+			name:   "FC",
+			send:   sendFC,
+			verify: func(r *http.Request, _ []byte) error { return cred.verifyFC(r) },
+		},
+		{
+			name:   "RPC",
+			send:   sendRPC,
+			verify: func(r *http.Request, _ []byte) error { return cred.verifyRPC(r) },
+		},
+		{
+			name: "TC3",
+			send: sendTC3,
+			verify: func(r *http.Request, body []byte) error {
+				service, err := cred.verifyTC3(r, body)
+				if err == nil && service != "scf" {
+					return errors.Errorf("unexpected service %q", service)
+				}
+				return err
+			},
+		},
+		{
+			name: "SigV4",
+			send: sendSigV4,
+			verify: func(r *http.Request, body []byte) error {
+				region, service, err := cred.verifySigV4(r, body)
+				if err == nil && (region != "us-east-1" || service != "lambda") {
+					return errors.Errorf("unexpected region %q and service %q", region, service)
+				}
+				return err
+			},
+		},
+	}
+
+	credentials := []struct {
+		name       string
+		id, secret string
+		want       error
+	}{
+		{name: "valid", id: cred.id, secret: cred.secret},
+		{name: "wrong secret", id: cred.id, secret: "wrong-secret", want: errSignatureMismatch},
+		{name: "wrong access key ID", id: "wrong-id", secret: cred.secret, want: errInvalidAccessKeyID},
+	}
+
+	for _, test := range tests {
+		for _, c := range credentials {
+			t.Run(test.name+"/"+c.name, func(t *testing.T) {
+				var mu sync.Mutex
+				var errs []error
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					mu.Lock()
+					errs = append(errs, test.verify(r, body))
+					mu.Unlock()
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte("{}"))
+				}))
+				defer server.Close()
+
+				test.send(t, server.URL, c.id, c.secret)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if len(errs) == 0 {
+					t.Fatal("no request is received")
+				}
+				for _, err := range errs {
+					if err != c.want {
+						t.Fatalf("want %v, got %v", c.want, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	cred := credential{id: "test-id", secret: "test-secret"}
+	req := httptest.NewRequest(http.MethodGet, "/2016-08-15/services", nil)
+	req.Header.Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	req.Header.Set("Authorization", "FC test-id:signature")
+	if err := cred.verifyFC(req); err != errRequestExpired {
+		t.Fatalf("want %v, got %v", errRequestExpired, err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/2016-08-15/services", nil)
+	if err := cred.verifyFC(req); err != errMissingSignature {
+		t.Fatalf("want %v, got %v", errMissingSignature, err)
+	}
+}
+
+func TestVerifyFCTamperedQuery(t *testing.T) {
+	cred := credential{id: "test-id", secret: "test-secret"}
+	client, err := aliyun.New(platform.AuthenticateOptions{
+		aliyun.RegionIDField:        "cn-hangzhou",
+		aliyun.AccountIDField:       "1234567890",
+		aliyun.AccessKeyIDField:     cred.id,
+		aliyun.AccessKeySecretField: cred.secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/2016-08-15/services?limit=1&prefix=raika", nil)
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Authorization", client.GetAuthorizationHeader(req))
+	if err := cred.verifyFC(req); err != nil {
+		t.Fatalf("want the signed query verified, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		rawQuery string
+	}{
+		{name: "value changed", rawQuery: "limit=100&prefix=raika"},
+		{name: "parameter added", rawQuery: "limit=1&prefix=raika&nextToken=abc"},
+		{name: "parameter removed", rawQuery: "limit=1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tampered := req.Clone(req.Context())
+			tampered.URL.RawQuery = test.rawQuery
+			if err := cred.verifyFC(tampered); err != errSignatureMismatch {
+				t.Fatalf("want %v, got %v", errSignatureMismatch, err)
+			}
+		})
+	}
+}
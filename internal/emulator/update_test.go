@@ -0,0 +1,154 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/aliyun"
+)
+
+// rebuild returns a copy of the binary with different content, which still runs.
+func rebuild(t *testing.T, binary string) string {
+	data, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := filepath.Join(t.TempDir(), filepath.Base(binary))
+	if err := os.WriteFile(rebuilt, append(data, 0), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return rebuilt
+}
+
+func TestUpdateFunction(t *testing.T) {
+	skipEndToEnd(t)
+	binary := buildEcho(t)
+	rebuilt := rebuild(t, binary)
+
+	for _, test := range testPlatforms {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.client(t)
+			checksumPlatform, ok := client.(platform.CodeChecksumPlatform)
+			if !ok {
+				t.Fatalf("%s does not report the code checksum", client)
+			}
+
+			opts := platform.CreateFunctionOptions{
+				Name:                  "raika_echo",
+				Description:           "Echo",
+				MemorySize:            128,
+				InitializationTimeout: 10 * time.Second,
+				RuntimeTimeout:        10 * time.Second,
+				File:                  binary,
+				Triggers:              test.triggers,
+			}
+			if _, err := client.CreateFunction(opts); err != nil {
+				t.Fatalf("create function: %v", err)
+			}
+
+			steps := []struct {
+				name        string
+				update      func(opts *platform.CreateFunctionOptions)
+				wantSkipped []string
+				// wantUploaded is set if the code is uploaded, the configuration
+				// is updated with the code on some platforms.
+				wantUploaded bool
+			}{
+				{
+					name:        "unchanged",
+					update:      func(*platform.CreateFunctionOptions) {},
+					wantSkipped: []string{platform.SkippedCodeUpload, platform.SkippedConfigurationUpdate},
+				},
+				{
+					name: "configuration changed",
+					update: func(opts *platform.CreateFunctionOptions) {
+						opts.MemorySize = 256
+						opts.EnvironmentVariables = map[string]string{"GREETING": "hi"}
+					},
+					wantSkipped: []string{platform.SkippedCodeUpload},
+				},
+				{
+					name:         "code changed",
+					update:       func(opts *platform.CreateFunctionOptions) { opts.File = rebuilt },
+					wantUploaded: true,
+				},
+			}
+			for _, step := range steps {
+				step.update(&opts)
+				deployment, err := client.UpdateFunction(opts)
+				if err != nil {
+					t.Fatalf("%s: update function: %v", step.name, err)
+				}
+				if step.wantUploaded {
+					for _, skipped := range deployment.Skipped {
+						if skipped == platform.SkippedCodeUpload {
+							t.Fatalf("%s: want the code uploaded, got skipped %q", step.name, deployment.Skipped)
+						}
+					}
+				} else if !reflect.DeepEqual(deployment.Skipped, step.wantSkipped) {
+					t.Fatalf("%s: want skipped %q, got %q", step.name, step.wantSkipped, deployment.Skipped)
+				}
+
+				info, err := client.Describe(opts.Name)
+				if err != nil {
+					t.Fatalf("%s: describe: %v", step.name, err)
+				}
+				checksum, err := checksumPlatform.CodeChecksum(opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.CodeChecksum != checksum || info.MemorySize != opts.MemorySize ||
+					info.EnvironmentVariables["GREETING"] != opts.EnvironmentVariables["GREETING"] {
+					t.Fatalf("%s: unexpected function info: %+v", step.name, info)
+				}
+				invokeEcho(t, client, opts.Name)
+			}
+		})
+	}
+}
+
+// The trigger of the same name is re-created on Function Compute if its type
+// is changed, as the type of a trigger can't be updated.
+func TestFCTriggerTypeChange(t *testing.T) {
+	skipEndToEnd(t)
+	binary := buildEcho(t)
+	client := testPlatforms[0].client(t).(*aliyun.Client)
+
+	opts := platform.CreateFunctionOptions{
+		Name:                  "raika_echo",
+		MemorySize:            128,
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        10 * time.Second,
+		File:                  binary,
+	}
+	steps := []struct {
+		trigger  platform.TriggerSpec
+		wantType string
+	}{
+		{trigger: platform.TriggerSpec{Name: "trigger", Type: platform.HTTPTrigger}, wantType: "http"},
+		{trigger: platform.TriggerSpec{Name: "trigger", Type: platform.CronTrigger, Cron: "0 0 * * * *"}, wantType: "timer"},
+		{trigger: platform.TriggerSpec{Name: "trigger", Type: platform.HTTPTrigger}, wantType: "http"},
+	}
+	for _, step := range steps {
+		opts.Triggers = []platform.TriggerSpec{step.trigger}
+		if _, err := client.CreateFunction(opts); err != nil {
+			t.Fatalf("deploy with %s trigger: %v", step.trigger.Type, err)
+		}
+
+		triggers, err := client.ListTriggers(aliyun.ServiceName, opts.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(triggers.Triggers) != 1 || triggers.Triggers[0].TriggerName != "trigger" || triggers.Triggers[0].TriggerType != step.wantType {
+			t.Fatalf("want a %s trigger, got %+v", step.wantType, triggers.Triggers)
+		}
+	}
+}
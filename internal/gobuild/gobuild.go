@@ -0,0 +1,405 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package gobuild builds the function binaries from the Go source for the
+// targets of the platforms. The binaries are cached by the hash of the source.
+package gobuild
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+var homePath, _ = os.UserHomeDir()
+
+// DefaultCacheDir is the directory the built binaries are cached in.
+var DefaultCacheDir = filepath.Join(homePath, ".raika", "build")
+
+// MaxCacheAge is how long a cached binary is kept since it was last used, the
+// expired ones are removed after each build.
+const MaxCacheAge = 7 * 24 * time.Hour
+
+// Build builds the Go main package in the source directory for the target with
+// CGO disabled, and returns the path of the binary. The cached binary is used
+// if the module of the source is not changed since it was built.
+func Build(cacheDir, source string, target platform.Target) (string, error) {
+	s, err := load(source)
+	if err != nil {
+		return "", err
+	}
+	path, err := s.binaryPath(cacheDir, target)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		log.Trace("Use the cached build of %q for %s", source, target)
+		// The modification time of the entry is the last used time.
+		now := time.Now()
+		_ = os.Chtimes(filepath.Dir(path), now, now)
+		return path, nil
+	}
+
+	log.Trace("Build %q for %s...", source, target)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", errors.Wrap(err, "mkdir")
+	}
+	// Build to a temporary file first, so that a failed build is never cached.
+	tmp := path + ".tmp"
+	cmd := exec.Command("go", "build", "-trimpath", "-o", tmp, s.pkg)
+	cmd.Dir = s.moduleDir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+target.GOOS, "GOARCH="+target.GOARCH)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(tmp)
+		return "", errors.Errorf("go build %s: %v\n%s", s.pkg, err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", errors.Wrap(err, "rename")
+	}
+
+	if _, err := Clean(cacheDir, MaxCacheAge); err != nil {
+		log.Warn("Failed to clean the build cache: %v", err)
+	}
+	return path, nil
+}
+
+// Clean removes the cached binaries which are not used within the age, all of
+// them are removed if the age is zero. It returns the number of the removed
+// binaries.
+func Clean(cacheDir string, age time.Duration) (int, error) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "read cache directory")
+	}
+
+	var removed int
+	for _, entry := range entries {
+		// Only the entries named by the hash are touched.
+		if !entry.IsDir() || !isHash(entry.Name()) {
+			continue
+		}
+		if age > 0 {
+			fi, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if time.Since(fi.ModTime()) < age {
+				continue
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(cacheDir, entry.Name())); err != nil {
+			return removed, errors.Wrapf(err, "remove %q", entry.Name())
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func isHash(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// Path returns the path of the binary built by Build, without building it.
+func Path(cacheDir, source string, target platform.Target) (string, error) {
+	s, err := load(source)
+	if err != nil {
+		return "", err
+	}
+	return s.binaryPath(cacheDir, target)
+}
+
+type sourceInfo struct {
+	moduleDir string
+	// pkg is the package path relative to the module directory, e.g. `./cmd/fn`.
+	pkg string
+	// hash is the hash of the files in the module.
+	hash string
+}
+
+var (
+	sourcesMu sync.Mutex
+	// sources caches the source information by the absolute source directory,
+	// so that the module is only hashed once for all the targets.
+	sources = make(map[string]*sourceInfo)
+)
+
+func load(source string) (*sourceInfo, error) {
+	dir, err := filepath.Abs(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "get absolute path")
+	}
+
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	if s, ok := sources[dir]; ok {
+		return s, nil
+	}
+
+	if fi, err := os.Stat(dir); err != nil {
+		return nil, errors.Wrap(err, "stat source")
+	} else if !fi.IsDir() {
+		return nil, errors.Errorf("source %q is not a directory", source)
+	}
+
+	moduleDir := dir
+	for {
+		if _, err := os.Stat(filepath.Join(moduleDir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(moduleDir)
+		if parent == moduleDir {
+			return nil, errors.Errorf("go.mod of the source %q is not found", source)
+		}
+		moduleDir = parent
+	}
+	rel, err := filepath.Rel(moduleDir, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "get relative path")
+	}
+
+	hash, err := hashSource(moduleDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "hash source")
+	}
+
+	s := &sourceInfo{
+		moduleDir: moduleDir,
+		pkg:       "./" + filepath.ToSlash(rel),
+		hash:      hash,
+	}
+	sources[dir] = s
+	return s, nil
+}
+
+// binaryPath returns the path of the binary in the cache directory, which is
+// named by the hash of the source, the package, the target and the Go version.
+func (s *sourceInfo) binaryPath(cacheDir string, target platform.Target) (string, error) {
+	version, err := goVersion()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", version, target, s.pkg, s.hash)
+	name := "bootstrap"
+	if target.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(cacheDir, hex.EncodeToString(h.Sum(nil)), name), nil
+}
+
+var (
+	goVersionOnce   sync.Once
+	goVersionString string
+	goVersionErr    error
+)
+
+// goVersion returns the version of the Go toolchain in PATH.
+func goVersion() (string, error) {
+	goVersionOnce.Do(func() {
+		output, err := exec.Command("go", "env", "GOVERSION").Output()
+		if err != nil {
+			goVersionErr = errors.Wrap(err, "get Go version")
+			return
+		}
+		goVersionString = strings.TrimSpace(string(output))
+	})
+	return goVersionString, goVersionErr
+}
+
+// hashSource returns the hash of the files the build of the module depends
+// on: the module directory, the workspace file, and the local directories of
+// the replace and use directives outside the module.
+func hashSource(moduleDir string) (string, error) {
+	dirs, err := localDirs(filepath.Join(moduleDir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	workFile, err := findWorkFile(moduleDir)
+	if err != nil {
+		return "", err
+	}
+	if workFile != "" {
+		for _, name := range []string{workFile, workFile + ".sum"} {
+			if err := hashFile(h, name, name); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		workDirs, err := localDirs(workFile)
+		if err != nil {
+			return "", err
+		}
+		dirs = append(dirs, workDirs...)
+	}
+
+	if err := hashDir(h, moduleDir); err != nil {
+		return "", err
+	}
+	sort.Strings(dirs)
+	for i, dir := range dirs {
+		if (i > 0 && dir == dirs[i-1]) || within(moduleDir, dir) {
+			continue
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00", dir)
+		if err := hashDir(h, dir); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findWorkFile returns the go.work file of the module the Go toolchain uses,
+// it is empty if the module is not in a workspace.
+func findWorkFile(moduleDir string) (string, error) {
+	switch work := os.Getenv("GOWORK"); work {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return filepath.Abs(work)
+	}
+
+	dir := moduleDir
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// localDirs returns the absolute directories of the local paths in the replace
+// and use directives of the go.mod or go.work file.
+func localDirs(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %q", filepath.Base(file))
+	}
+
+	var dirs []string
+	var block string
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var directive string
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block != "":
+			directive = block
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		default:
+			directive, fields = fields[0], fields[1:]
+		}
+
+		var path string
+		switch directive {
+		case "use":
+			if len(fields) > 0 {
+				path = fields[0]
+			}
+		case "replace":
+			// The target of the replacement is a local path if it has no version.
+			for i, field := range fields {
+				if field == "=>" && len(fields) == i+2 {
+					path = fields[i+1]
+				}
+			}
+		}
+		path = strings.Trim(path, `"`+"`")
+		if path == "" || !(filepath.IsAbs(path) || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || path == "." || path == "..") {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		dirs = append(dirs, filepath.Clean(path))
+	}
+	return dirs, nil
+}
+
+// within reports whether the path is the directory or under it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// hashDir writes the files in the directory to the hash. The hidden files and
+// the test files are skipped, as they are not part of the build.
+func hashDir(h io.Writer, root string) error {
+	return filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(fi.Name(), ".") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() || strings.HasSuffix(fi.Name(), "_test.go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return hashFile(h, path, filepath.ToSlash(rel))
+	})
+}
+
+// hashFile writes the name, the size and the content of the file to the hash.
+func hashFile(h io.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(h, "%s\x00%d\x00", name, fi.Size())
+	_, err = io.Copy(h, f)
+	return err
+}
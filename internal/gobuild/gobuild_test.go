@@ -0,0 +1,146 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gobuild
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalDirs(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "go.mod",
+			content: `module example.com/fn
+
+go 1.16
+
+require example.com/lib v1.0.0
+
+replace example.com/lib => ../lib // local copy
+
+replace (
+	example.com/a v1.2.0 => ./third_party/a
+	example.com/b => example.com/b-fork v1.0.1
+	example.com/c => /opt/c
+)
+`,
+			want: []string{filepath.Join(filepath.Dir(dir), "lib"), filepath.Join(dir, "third_party", "a"), "/opt/c"},
+		},
+		{
+			name: "go.work",
+			content: `go 1.18
+
+use .
+use (
+	../lib
+	"./tools"
+)
+
+replace example.com/d => ../d
+`,
+			want: []string{dir, filepath.Join(filepath.Dir(dir), "lib"), filepath.Join(dir, "tools"), filepath.Join(filepath.Dir(dir), "d")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name)
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := localDirs(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestHashSourceReplace(t *testing.T) {
+	root := t.TempDir()
+	gowork, ok := os.LookupEnv("GOWORK")
+	_ = os.Setenv("GOWORK", "off")
+	defer func() {
+		if ok {
+			_ = os.Setenv("GOWORK", gowork)
+		} else {
+			_ = os.Unsetenv("GOWORK")
+		}
+	}()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fn/go.mod", "module example.com/fn\n\nreplace example.com/lib => ../lib\n")
+	write("fn/main.go", "package main\n")
+	write("lib/lib.go", "package lib\n")
+
+	before, err := hashSource(filepath.Join(root, "fn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("lib/lib.go", "package lib\n\nconst A = 1\n")
+	after, err := hashSource(filepath.Join(root, "fn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before == after {
+		t.Fatal("the hash should change with the replaced module")
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	old := strings.Repeat("a", 64)
+	recent := strings.Repeat("b", 64)
+	for _, name := range []string{old, recent, "other"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expired := time.Now().Add(-2 * MaxCacheAge)
+	if err := os.Chtimes(filepath.Join(dir, old), expired, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Clean(dir, MaxCacheAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("want 1 removed, got %d", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+		t.Fatal("the expired build should be removed")
+	}
+
+	removed, err = Clean(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("want 1 removed, got %d", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other")); err != nil {
+		t.Fatal("the entries not named by the hash should be kept")
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package manifest parses the `raika.yaml` project manifest, which declares
+// the functions of the project and the accounts they are deployed to.
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const DefaultFileName = "raika.yaml"
+
+// Manifest is the desired state of the functions of a project.
+type Manifest struct {
+	// Project identifies the functions deployed from the manifest, the
+	// functions of the project not in the manifest are deleted on deploy.
+	Project string `yaml:"project"`
+	// Platforms are the account names in the config file the functions are
+	// deployed to, unless the function has its own.
+	Platforms []string    `yaml:"platforms"`
+	Functions []*Function `yaml:"functions"`
+
+	// Dir is the directory of the manifest, the paths in the manifest are
+	// relative to it.
+	Dir string `yaml:"-"`
+}
+
+// Function is a function declared in the manifest.
+type Function struct {
+	Name           string        `yaml:"name"`
+	Description    string        `yaml:"description"`
+	Platforms      []string      `yaml:"platforms"`
+	Memory         int64         `yaml:"memory"`
+	InitTimeout    time.Duration `yaml:"init_timeout"`
+	RuntimeTimeout time.Duration `yaml:"runtime_timeout"`
+	BinaryFile     string        `yaml:"binary_file"`
+	// Source is the Go main package directory the binary is built from for
+	// each platform, it can't be used with the binary file.
+	Source string `yaml:"source"`
+	// Package is the directory of the files packed with the binary.
+	Package *platform.PackageSpec `yaml:"package"`
+	Image   string                `yaml:"image"`
+	// Env values can reference the environment variables in `${NAME}` format.
+	Env map[string]string `yaml:"env"`
+	// Triggers default to a single HTTP trigger if they are not given, use
+	// an empty list for the function without triggers.
+	Triggers []platform.TriggerSpec `yaml:"triggers"`
+	Alias    string                 `yaml:"alias"`
+	// Task is the periodic task run by the daemon.
+	Task *Task `yaml:"task"`
+}
+
+// Task is the periodic task of the function.
+type Task struct {
+	Interval time.Duration `yaml:"interval"`
+	Disabled bool          `yaml:"disabled"`
+}
+
+var nameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]{0,63}$`)
+
+// Load reads and validates the manifest file.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, errors.Wrap(err, "yaml decode")
+	}
+	m.Dir, err = filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "get directory")
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	if !nameRegexp.MatchString(m.Project) {
+		return errors.Errorf("invalid project name %q: it should only contain letters, digits, `_` and `-`", m.Project)
+	}
+
+	names := make(map[string]struct{}, len(m.Functions))
+	for _, f := range m.Functions {
+		if !nameRegexp.MatchString(f.Name) {
+			return errors.Errorf("invalid function name %q: it should only contain letters, digits, `_` and `-`", f.Name)
+		}
+		if _, ok := names[f.Name]; ok {
+			return errors.Errorf("duplicate function %q", f.Name)
+		}
+		names[f.Name] = struct{}{}
+
+		if err := f.validate(); err != nil {
+			return errors.Wrapf(err, "function %q", f.Name)
+		}
+		if len(m.AccountNames(f)) == 0 {
+			return errors.Errorf("function %q: no platform to deploy to", f.Name)
+		}
+	}
+	return nil
+}
+
+func (f *Function) validate() error {
+	if f.BinaryFile == "" && f.Source == "" && f.Image == "" {
+		return errors.New("binary_file, source or image is required")
+	} else if f.BinaryFile != "" && f.Source != "" {
+		return errors.New("binary_file and source can't be both set")
+	} else if f.Package != nil && f.Package.Dir == "" {
+		return errors.New("dir of the package is required")
+	} else if f.Memory <= 0 {
+		return errors.New("memory is required")
+	} else if f.InitTimeout <= 0 {
+		return errors.New("init_timeout is required")
+	} else if f.RuntimeTimeout <= 0 {
+		return errors.New("runtime_timeout is required")
+	} else if f.Task != nil && f.Task.Interval <= 0 {
+		return errors.New("interval of the task is required")
+	}
+
+	if f.Triggers == nil {
+		f.Triggers = []platform.TriggerSpec{{Name: platform.HTTPTriggerName, Type: platform.HTTPTrigger}}
+	}
+	return platform.ValidateTriggers(f.Triggers)
+}
+
+// AccountNames returns the names of the accounts the function is deployed to.
+func (m *Manifest) AccountNames(f *Function) []string {
+	if len(f.Platforms) != 0 {
+		return f.Platforms
+	}
+	return m.Platforms
+}
+
+// Function returns the function with the given name.
+func (m *Manifest) Function(name string) (*Function, bool) {
+	for _, f := range m.Functions {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// SourceDir returns the source directory of the function, it is empty if the
+// function is not built from the source.
+func (m *Manifest) SourceDir(f *Function) string {
+	if f.Source == "" {
+		return ""
+	}
+	return m.path(f.Source)
+}
+
+// path returns the path relative to the directory of the manifest.
+func (m *Manifest) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.Dir, path)
+}
+
+// Options returns the options to create the function on the platforms.
+func (m *Manifest) Options(f *Function) platform.CreateFunctionOptions {
+	env := make(map[string]string, len(f.Env))
+	for k, v := range f.Env {
+		env[k] = os.ExpandEnv(v)
+	}
+
+	var file string
+	if f.BinaryFile != "" {
+		file = m.path(f.BinaryFile)
+	}
+	var pkg *platform.PackageSpec
+	if f.Package != nil {
+		pkg = &platform.PackageSpec{
+			Dir:     m.path(f.Package.Dir),
+			Include: f.Package.Include,
+			Exclude: f.Package.Exclude,
+		}
+	}
+
+	return platform.CreateFunctionOptions{
+		Name:                  f.Name,
+		Description:           f.Description,
+		MemorySize:            f.Memory,
+		EnvironmentVariables:  env,
+		InitializationTimeout: f.InitTimeout,
+		RuntimeTimeout:        f.RuntimeTimeout,
+		File:                  file,
+		Package:               pkg,
+		Image:                 f.Image,
+		Triggers:              f.Triggers,
+		HTTPPort:              9000, // For tencentcloud
+		Alias:                 f.Alias,
+	}
+}
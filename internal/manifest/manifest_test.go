@@ -0,0 +1,218 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// writeManifest writes the manifest to a temporary directory and returns its path.
+func writeManifest(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeManifest(t, `
+project: demo
+platforms: [aliyun]
+functions:
+  - name: hello
+    description: Hello
+    memory: 128
+    init_timeout: 10s
+    runtime_timeout: 1m
+    binary_file: ./bin/hello
+    package:
+      dir: static
+      include: ["*.html"]
+    env:
+      GREETING: ${RAIKA_TEST_GREETING}
+    alias: live
+  - name: cron_job
+    platforms: [aws, tencentcloud]
+    memory: 256
+    init_timeout: 10s
+    runtime_timeout: 10s
+    source: ./cmd/cron
+    triggers:
+      - name: hourly
+        type: cron
+        cron: 0 0 * * * *
+    task:
+      interval: 1h
+`)
+	if err := os.Setenv("RAIKA_TEST_GREETING", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Unsetenv("RAIKA_TEST_GREETING") }()
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(path)
+	if m.Project != "demo" || m.Dir != dir || len(m.Functions) != 2 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	hello, ok := m.Function("hello")
+	if !ok {
+		t.Fatal("want function hello")
+	}
+	if got := m.AccountNames(hello); len(got) != 1 || got[0] != "aliyun" {
+		t.Fatalf("want the platforms of the manifest, got %q", got)
+	}
+	opts := m.Options(hello)
+	if opts.File != filepath.Join(dir, "bin", "hello") {
+		t.Fatalf("want the binary relative to the manifest, got %q", opts.File)
+	}
+	if opts.Package == nil || opts.Package.Dir != filepath.Join(dir, "static") || len(opts.Package.Include) != 1 {
+		t.Fatalf("unexpected package: %+v", opts.Package)
+	}
+	if opts.EnvironmentVariables["GREETING"] != "hi" {
+		t.Fatalf("want the environment variable expanded, got %q", opts.EnvironmentVariables["GREETING"])
+	}
+	if opts.MemorySize != 128 || opts.InitializationTimeout != 10*time.Second || opts.RuntimeTimeout != time.Minute || opts.Alias != "live" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	// The HTTP trigger is the default.
+	if len(opts.Triggers) != 1 || opts.Triggers[0].Type != platform.HTTPTrigger {
+		t.Fatalf("want the default HTTP trigger, got %+v", opts.Triggers)
+	}
+	if m.SourceDir(hello) != "" {
+		t.Fatalf("want no source directory, got %q", m.SourceDir(hello))
+	}
+
+	cronJob, _ := m.Function("cron_job")
+	if got := m.AccountNames(cronJob); len(got) != 2 || got[0] != "aws" {
+		t.Fatalf("want the platforms of the function, got %q", got)
+	}
+	if m.SourceDir(cronJob) != filepath.Join(dir, "cmd", "cron") {
+		t.Fatalf("want the source relative to the manifest, got %q", m.SourceDir(cronJob))
+	}
+	if got := m.Options(cronJob).Triggers; len(got) != 1 || got[0].Type != platform.CronTrigger {
+		t.Fatalf("unexpected triggers: %+v", got)
+	}
+	if cronJob.Task == nil || cronJob.Task.Interval != time.Hour {
+		t.Fatalf("unexpected task: %+v", cronJob.Task)
+	}
+}
+
+func TestLoadNoTriggers(t *testing.T) {
+	m, err := Load(writeManifest(t, `
+project: demo
+platforms: [aliyun]
+functions:
+  - name: hello
+    memory: 128
+    init_timeout: 10s
+    runtime_timeout: 10s
+    image: hello:latest
+    triggers: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if triggers := m.Options(m.Functions[0]).Triggers; len(triggers) != 0 {
+		t.Fatalf("want no triggers, got %+v", triggers)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	const function = `
+    memory: 128
+    init_timeout: 10s
+    runtime_timeout: 10s
+    binary_file: hello`
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name:     "unknown field",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello\n    memory_size: 128",
+			wantErr:  "yaml decode",
+		},
+		{
+			name:     "invalid project",
+			manifest: "project: my demo",
+			wantErr:  `invalid project name "my demo"`,
+		},
+		{
+			name:     "no project",
+			manifest: "platforms: [aliyun]",
+			wantErr:  `invalid project name ""`,
+		},
+		{
+			name:     "invalid function name",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello.world" + function,
+			wantErr:  `invalid function name "hello.world"`,
+		},
+		{
+			name:     "duplicate function",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello" + function + "\n  - name: hello" + function,
+			wantErr:  `duplicate function "hello"`,
+		},
+		{
+			name:     "no code",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello\n    memory: 128",
+			wantErr:  "binary_file, source or image is required",
+		},
+		{
+			name:     "binary and source",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello" + function + "\n    source: ./cmd/hello",
+			wantErr:  "binary_file and source can't be both set",
+		},
+		{
+			name:     "package without dir",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello" + function + "\n    package:\n      include: [\"*\"]",
+			wantErr:  "dir of the package is required",
+		},
+		{
+			name:     "no memory",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello\n    binary_file: hello",
+			wantErr:  "memory is required",
+		},
+		{
+			name:     "no runtime timeout",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello\n    binary_file: hello\n    memory: 128\n    init_timeout: 10s",
+			wantErr:  "runtime_timeout is required",
+		},
+		{
+			name:     "task without interval",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello" + function + "\n    task:\n      disabled: true",
+			wantErr:  "interval of the task is required",
+		},
+		{
+			name:     "invalid trigger",
+			manifest: "project: demo\nplatforms: [aliyun]\nfunctions:\n  - name: hello" + function + "\n    triggers:\n      - name: hourly\n        type: timer",
+			wantErr:  `function "hello": trigger "hourly": unexpected trigger type "timer"`,
+		},
+		{
+			name:     "no platform",
+			manifest: "project: demo\nfunctions:\n  - name: hello" + function,
+			wantErr:  `function "hello": no platform to deploy to`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Load(writeManifest(t, test.manifest))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
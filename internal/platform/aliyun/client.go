@@ -6,9 +6,6 @@ package aliyun
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,16 +28,36 @@ type Client struct {
 	id                                      string
 	regionID                                string
 	accountID, accessKeyID, accessKeySecret string
+
+	// endpoint is the custom Function Compute endpoint, it is empty if the
+	// public endpoint of the region is used.
+	endpoint   string
+	httpClient *http.Client
 }
 
-func New(opts platform.AuthenticateOptions) *Client {
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	var endpoint string
+	if opts[platform.EndpointField] != "" {
+		u, err := platform.ParseEndpoint(opts[platform.EndpointField])
+		if err != nil {
+			return nil, err
+		}
+		endpoint = u.String()
+	}
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Client{
 		id:              opts["id"],
 		regionID:        opts[RegionIDField],
 		accountID:       opts[AccountIDField],
 		accessKeyID:     opts[AccessKeyIDField],
 		accessKeySecret: opts[AccessKeySecretField],
-	}
+		endpoint:        endpoint,
+		httpClient:      httpClient,
+	}, nil
 }
 
 func (c *Client) String() string {
@@ -56,6 +73,21 @@ func (c *Client) GetID() string {
 }
 
 func (c *Client) Authenticate() error {
+	// The ECS API is not served on the custom endpoint, the credential is
+	// checked by the Function Compute API instead.
+	if c.endpoint != "" {
+		resp, err := c.request(http.MethodGet, "/services?limit=1")
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			log.Error("Failed to authenticate to aliyun.")
+			return errors.New(resp.ToString())
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+
 	u, err := url.Parse(fmt.Sprintf("https://ecs-%s.aliyuncs.com/", c.regionID))
 	if err != nil {
 		return errors.Wrap(err, "parse url")
@@ -74,17 +106,13 @@ func (c *Client) Authenticate() error {
 	query.Set("Version", "2014-05-26")
 	u.RawQuery = query.Encode()
 
-	// Generate signature
-	hashSign := hmac.New(sha1.New, []byte(c.accessKeySecret+"&"))
-	_, _ = hashSign.Write([]byte(http.MethodGet + "&%2F&" + url.QueryEscape(u.RawQuery)))
-	signature := base64.StdEncoding.EncodeToString(hashSign.Sum(nil))
-	u.RawQuery += "&Signature=" + url.QueryEscape(signature)
+	u.RawQuery += "&Signature=" + url.QueryEscape(c.GetRPCSignature(http.MethodGet, u.RawQuery))
 
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
 		return errors.Wrap(err, "new request")
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return errors.Wrap(err, "do request")
 	}
@@ -108,15 +136,33 @@ func (c *Client) Authenticate() error {
 	return nil
 }
 
+// baseURL returns the Function Compute endpoint of the account.
+func (c *Client) baseURL() string {
+	if c.endpoint != "" {
+		return c.endpoint
+	}
+	return fmt.Sprintf("https://%s.%s.fc.aliyuncs.com", c.accountID, c.regionID)
+}
+
+// triggerURL returns the URL of the HTTP trigger, the qualified service is
+// in `<service>[.<qualifier>]` format.
+func (c *Client) triggerURL(qualifiedService, functionName string) string {
+	return fmt.Sprintf("%s/%s/proxy/%s/%s/", c.baseURL(), ApiVersion, qualifiedService, functionName)
+}
+
 func (c *Client) request(method string, baseURL string, requestBody ...interface{}) (*response, error) {
-	u := fmt.Sprintf("https://%s.%s.fc.aliyuncs.com/%s/%s", c.accountID, c.regionID, ApiVersion, strings.TrimLeft(baseURL, "/"))
+	u := fmt.Sprintf("%s/%s/%s", c.baseURL(), ApiVersion, strings.TrimLeft(baseURL, "/"))
 	var body io.Reader
 	if len(requestBody) == 1 {
-		repBody, err := json.Marshal(requestBody[0])
-		if err != nil {
-			return nil, errors.Wrap(err, "JSON encode")
+		if raw, ok := requestBody[0].([]byte); ok {
+			body = bytes.NewReader(raw)
+		} else {
+			repBody, err := json.Marshal(requestBody[0])
+			if err != nil {
+				return nil, errors.Wrap(err, "JSON encode")
+			}
+			body = bytes.NewReader(repBody)
 		}
-		body = bytes.NewReader(repBody)
 	}
 
 	req, err := http.NewRequest(method, u, body)
@@ -126,7 +172,7 @@ func (c *Client) request(method string, baseURL string, requestBody ...interface
 	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
 	req.Header.Set("Authorization", c.GetAuthorizationHeader(req))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "do request")
 	}
@@ -5,18 +5,19 @@
 package aliyun
 
 import (
-	"archive/zip"
-	"bytes"
 	"fmt"
+	"hash/crc64"
 	"io"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 	log "unknwon.dev/clog/v2"
 
 	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
 )
 
 type CreateFunctionRequest struct {
@@ -34,52 +35,45 @@ type CreateFunctionRequest struct {
 	EnvironmentVariables  map[string]string `json:"environmentVariables,omitempty"`
 }
 
-func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, error) {
+// CreateFunction creates the function in the Raika service, or updates it in
+// place if it exists. The name of the imported function is prefixed by its
+// service, e.g. "my-service/hello", which is updated in its own service.
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	serviceName, name := platform.SplitName(opts.Name, ServiceName)
+	opts.Name = name
+
 	// Create service if not exists.
-	_, err := c.GetRaikaService()
+	var err error
+	if serviceName == ServiceName {
+		_, err = c.GetRaikaService()
+	}
 	if err == ErrRaikaServiceNotFound {
 		log.Trace("Raika service not found on aliyun, create...")
 		_, err = c.CreateService(ServiceName, "Service for Raika.")
 		if err != nil {
-			return "", errors.Wrap(err, "create service")
+			return nil, errors.Wrap(err, "create service")
 		}
 	} else if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if opts.MemorySize < 128 || opts.MemorySize > 3072 || opts.MemorySize%64 != 0 {
-		return "", errors.Errorf("wrong memory size: %d", opts.MemorySize)
-	}
-
-	zipFile, err := packFile(opts.File)
-	if err != nil {
-		return "", errors.Wrap(err, "pack file")
+		return nil, errors.Errorf("wrong memory size: %d", opts.MemorySize)
 	}
 
 	// Check current function name exists.
-	_, err = c.GetFunction(ServiceName, opts.Name)
-	if err != nil && err != ErrFunctionNotExists {
-		return "", errors.Wrap(err, "get function")
+	_, err = c.GetFunction(serviceName, opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
 	} else if err == nil {
-		// Function exists, delete it.
-		log.Trace("Function %q exists on aliyun, replace...", opts.Name)
-
-		// Delete the triggers under the function.
-		triggers, err := c.ListTriggers(ServiceName, opts.Name)
-		if err != nil {
-			return "", errors.Wrap(err, "list triggers")
-		}
-		for _, trigger := range triggers.Triggers {
-			log.Trace("Delete trigger: %q...", trigger.TriggerName)
-			if err := c.DeleteTrigger(ServiceName, opts.Name, trigger.TriggerName); err != nil {
-				return "", errors.Wrapf(err, "delete trigger: %q", opts.Name)
-			}
-		}
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on aliyun, update...", opts.Name)
+		return c.updateFunction(serviceName, opts)
+	}
 
-		log.Trace("Delete function: %q...", opts.Name)
-		if err := c.DeleteFunction(ServiceName, opts.Name); err != nil && err != ErrFunctionNotExists {
-			return "", errors.Wrap(err, "delete function")
-		}
+	zipFile, err := pack.Zip(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
 	}
 
 	requestBody := CreateFunctionRequest{
@@ -100,79 +94,246 @@ func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, er
 	}
 
 	log.Trace("Deploy function: %q...", opts.Name)
-	_, err = c.request(http.MethodPost, fmt.Sprintf("/services/%s/functions", ServiceName), requestBody)
+	_, err = c.request(http.MethodPost, fmt.Sprintf("/services/%s/functions", serviceName), requestBody)
 	if err != nil {
-		return "", errors.Wrap(err, "create function")
+		return nil, errors.Wrap(err, "create function")
 	}
 
-	if opts.TriggerType == "http" {
-		// Create HTTP trigger for function.
-		err = c.CreateHTTPTrigger(CreateHTTPTriggerOptions{
-			TriggerName:  platform.HTTPTriggerName,
-			ServiceName:  ServiceName,
-			FunctionName: opts.Name,
-		})
-		if err != nil {
-			return "", errors.Wrap(err, "create HTTP trigger")
+	return c.release(serviceName, opts)
+}
+
+type UpdateFunctionRequest struct {
+	Description string `json:"description"`
+	// Code is nil if the code is not changed.
+	Code *struct {
+		ZipBase64 []byte `json:"zipFile"`
+	} `json:"code,omitempty"`
+	MemorySize            int64             `json:"memorySize"`
+	InitializationTimeout int               `json:"initializationTimeout"`
+	Timeout               int               `json:"timeout"`
+	CAPort                int               `json:"caPort"`
+	EnvironmentVariables  map[string]string `json:"environmentVariables"`
+}
+
+// UpdateFunction updates the code and the configuration of the existing function.
+// The triggers under the function are kept, so the trigger URL won't change.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	serviceName, name := platform.SplitName(opts.Name, ServiceName)
+	opts.Name = name
+	return c.updateFunction(serviceName, opts)
+}
+
+// updateFunction updates the existing function in the service.
+func (c *Client) updateFunction(serviceName string, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if opts.MemorySize < 128 || opts.MemorySize > 3072 || opts.MemorySize%64 != 0 {
+		return nil, errors.Errorf("wrong memory size: %d", opts.MemorySize)
+	}
+
+	zipFile, err := pack.Zip(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
+	}
+
+	function, err := c.GetFunction(serviceName, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	environmentVariables := opts.EnvironmentVariables
+	if environmentVariables == nil {
+		environmentVariables = map[string]string{}
+	}
+
+	// The code and the configuration are updated in one request on aliyun.
+	requestBody := UpdateFunctionRequest{
+		Description:           opts.Description,
+		MemorySize:            opts.MemorySize,
+		InitializationTimeout: int(opts.InitializationTimeout / time.Second),
+		Timeout:               int(opts.RuntimeTimeout / time.Second),
+		CAPort:                opts.HTTPPort,
+		EnvironmentVariables:  environmentVariables,
+	}
+
+	// The code is uploaded only if its checksum is changed, and the request is
+	// skipped if the configuration is not changed either.
+	var skipped []string
+	if function.CodeChecksum == codeChecksum(zipFile) {
+		log.Trace("Code of function %q is unchanged, skip uploading", opts.Name)
+		skipped = append(skipped, platform.SkippedCodeUpload)
+	} else {
+		requestBody.Code = &struct {
+			ZipBase64 []byte `json:"zipFile"`
+		}{
+			ZipBase64: zipFile,
 		}
+	}
+	configurationChanged := platform.ConfigurationChanged(toFunctionInfo(serviceName, function), opts) ||
+		function.CaPort != opts.HTTPPort
 
-		return fmt.Sprintf("https://%s.%s.fc.aliyuncs.com/2016-08-15/proxy/%s/%s/", c.accountID, c.regionID, ServiceName, opts.Name), nil
-	} else if opts.TriggerType == "cron" {
-		err = c.CreateCronTrigger(CreateCronTriggerOptions{
-			TriggerName:  platform.CronTriggerName,
-			ServiceName:  ServiceName,
-			FunctionName: opts.Name,
-			CronString:   opts.CronString,
-		})
+	if requestBody.Code == nil && !configurationChanged {
+		skipped = append(skipped, platform.SkippedConfigurationUpdate)
+	} else {
+		log.Trace("Update function: %q...", opts.Name)
+		resp, err := c.request(http.MethodPut, fmt.Sprintf("/services/%s/functions/%s", serviceName, opts.Name), requestBody)
 		if err != nil {
-			return "", errors.Wrap(err, "create timer trigger")
+			return nil, errors.Wrap(err, "update function")
 		}
-	} else {
-		return "", errors.Errorf("unexpected trigger type %q", opts.TriggerType)
+		if resp.StatusCode != http.StatusOK {
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, platform.ErrFunctionNotExists
+			}
+			return nil, errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
+		}
+		_ = resp.Body.Close()
 	}
-	return "", nil
+
+	deployment, err := c.release(serviceName, opts)
+	if err != nil {
+		return nil, err
+	}
+	deployment.Skipped = skipped
+	return deployment, nil
+}
+
+// codeChecksum returns the checksum of the code package in the format of the
+// `codeChecksum` of the function, which is the CRC-64 with the ECMA polynomial.
+func codeChecksum(zipFile []byte) string {
+	return strconv.FormatUint(crc64.Checksum(zipFile, crc64.MakeTable(crc64.ECMA)), 10)
 }
 
-func packFile(path string) ([]byte, error) {
-	output := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(output)
+// CodeChecksum returns the checksum of the code package of the function.
+func (c *Client) CodeChecksum(opts platform.CreateFunctionOptions) (string, error) {
+	zipFile, err := pack.Zip(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "pack file")
+	}
+	return codeChecksum(zipFile), nil
+}
 
-	header := &zip.FileHeader{
-		Name:     "bootstrap",
-		Modified: time.Now(),
+// release points the alias to a new version if the alias is set, and ensures
+// the trigger of the function.
+func (c *Client) release(serviceName string, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, serviceName+"/"+opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
 	}
-	header.SetMode(0777)
-	zipEntry, err := zipWriter.CreateHeader(header)
+
+	triggerURL, err := c.ensureTrigger(serviceName, opts)
 	if err != nil {
-		return nil, errors.Wrap(err, "create header")
+		return nil, err
 	}
+	deployment.URL = triggerURL
+	return deployment, nil
+}
 
-	file, err := os.Open(path)
+// ensureTrigger creates the triggers of the function if they do not exist, and
+// returns the trigger URL for HTTP trigger.
+func (c *Client) ensureTrigger(serviceName string, opts platform.CreateFunctionOptions) (string, error) {
+	triggers, err := c.ListTriggers(serviceName, opts.Name)
 	if err != nil {
-		return nil, errors.Wrap(err, "open file")
+		return "", errors.Wrap(err, "list triggers")
 	}
-	if _, err := io.Copy(zipEntry, file); err != nil {
-		return nil, errors.Wrap(err, "copy")
+	// Trigger name => qualifier
+	existingTriggers := make(map[string]string, len(triggers.Triggers))
+	for _, trigger := range triggers.Triggers {
+		// The type of a trigger can't be updated, the trigger is re-created
+		// if its type is changed.
+		if want, ok := findTrigger(opts.Triggers, trigger.TriggerName); ok && triggerType(want.Type) != trigger.TriggerType {
+			log.Trace("Trigger %q is changed from %s to %s, re-create...", trigger.TriggerName, trigger.TriggerType, triggerType(want.Type))
+			if err := c.DeleteTrigger(serviceName, opts.Name, trigger.TriggerName); err != nil {
+				return "", errors.Wrapf(err, "delete trigger %q", trigger.TriggerName)
+			}
+			continue
+		}
+		existingTriggers[trigger.TriggerName] = trigger.Qualifier
+	}
+
+	qualifier := "LATEST"
+	if opts.Alias != "" {
+		qualifier = aliasName(opts.Name, opts.Alias)
 	}
-	_ = zipWriter.Close()
 
-	return output.Bytes(), nil
+	var triggerURL string
+	for _, trigger := range opts.Triggers {
+		switch trigger.Type {
+		case platform.HTTPTrigger:
+			if current, ok := existingTriggers[trigger.Name]; !ok {
+				// Create HTTP trigger for function.
+				err = c.CreateHTTPTrigger(CreateHTTPTriggerOptions{
+					TriggerName:  trigger.Name,
+					ServiceName:  serviceName,
+					FunctionName: opts.Name,
+					Qualifier:    qualifier,
+				})
+				if err != nil {
+					return "", errors.Wrapf(err, "create HTTP trigger %q", trigger.Name)
+				}
+			} else if current != qualifier {
+				log.Trace("Bind trigger %q to %q...", trigger.Name, qualifier)
+				if err := c.UpdateTriggerQualifier(serviceName, opts.Name, trigger.Name, qualifier); err != nil {
+					return "", errors.Wrapf(err, "update HTTP trigger %q", trigger.Name)
+				}
+			}
+
+			if opts.Alias != "" {
+				triggerURL = c.triggerURL(serviceName+"."+qualifier, opts.Name)
+			} else {
+				triggerURL = c.triggerURL(serviceName, opts.Name)
+			}
+
+		case platform.CronTrigger:
+			cronOpts := CreateCronTriggerOptions{
+				TriggerName:  trigger.Name,
+				ServiceName:  serviceName,
+				FunctionName: opts.Name,
+				CronString:   trigger.Cron,
+				Payload:      trigger.Payload,
+				Qualifier:    qualifier,
+			}
+			if _, ok := existingTriggers[trigger.Name]; ok {
+				if err := c.UpdateCronTrigger(cronOpts); err != nil {
+					return "", errors.Wrapf(err, "update timer trigger %q", trigger.Name)
+				}
+				continue
+			}
+			if err := c.CreateCronTrigger(cronOpts); err != nil {
+				return "", errors.Wrapf(err, "create timer trigger %q", trigger.Name)
+			}
+
+		default:
+			return "", errors.Errorf("unexpected trigger type %q", trigger.Type)
+		}
+	}
+	return triggerURL, nil
+}
+
+// RemoveTrigger deletes the trigger under the function.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	serviceName, functionName := platform.SplitName(functionName, ServiceName)
+	log.Trace("Delete trigger %q...", triggerName)
+	return c.DeleteTrigger(serviceName, functionName, triggerName)
 }
 
 type GetFunctionResponse struct {
-	CodeChecksum          string    `json:"codeChecksum"`
-	CodeSize              int       `json:"codeSize"`
-	CreatedTime           time.Time `json:"createdTime"`
-	Description           string    `json:"description"`
-	FunctionId            string    `json:"functionId"`
-	FunctionName          string    `json:"functionName"`
-	Handler               string    `json:"handler"`
-	MemorySize            int       `json:"memorySize"`
-	Runtime               string    `json:"runtime"`
-	Timeout               int       `json:"timeout"`
-	InitializationTimeout int       `json:"initializationTimeout"`
-	Initializer           string    `json:"initializer"`
-	CaPort                int       `json:"caPort"`
+	CodeChecksum          string            `json:"codeChecksum"`
+	CodeSize              int               `json:"codeSize"`
+	CreatedTime           time.Time         `json:"createdTime"`
+	LastModifiedTime      time.Time         `json:"lastModifiedTime"`
+	Description           string            `json:"description"`
+	FunctionId            string            `json:"functionId"`
+	FunctionName          string            `json:"functionName"`
+	Handler               string            `json:"handler"`
+	MemorySize            int               `json:"memorySize"`
+	Runtime               string            `json:"runtime"`
+	Timeout               int               `json:"timeout"`
+	InitializationTimeout int               `json:"initializationTimeout"`
+	Initializer           string            `json:"initializer"`
+	CaPort                int               `json:"caPort"`
+	EnvironmentVariables  map[string]string `json:"environmentVariables"`
 	CustomContainerConfig struct {
 		Args             string `json:"args"`
 		Command          string `json:"command"`
@@ -185,8 +346,6 @@ type GetFunctionResponse struct {
 	Layers []string `json:"layers"`
 }
 
-var ErrFunctionNotExists = errors.New("function not found")
-
 func (c *Client) GetFunction(serviceName, functionName string) (*GetFunctionResponse, error) {
 	resp, err := c.request(http.MethodGet, fmt.Sprintf("/services/%s/functions/%s", serviceName, functionName))
 	if err != nil {
@@ -195,7 +354,7 @@ func (c *Client) GetFunction(serviceName, functionName string) (*GetFunctionResp
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
-			return nil, ErrFunctionNotExists
+			return nil, platform.ErrFunctionNotExists
 		}
 		return nil, errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
 	}
@@ -204,7 +363,147 @@ func (c *Client) GetFunction(serviceName, functionName string) (*GetFunctionResp
 	return &respJSON, resp.ToJSON(&respJSON)
 }
 
-func (c *Client) DeleteFunction(serviceName, functionName string) error {
+// Describe returns the live information of the function. The name is prefixed
+// by the service if the function is not under the Raika service.
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	serviceName, name := platform.SplitName(name, ServiceName)
+	function, err := c.GetFunction(serviceName, name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(serviceName, function), nil
+}
+
+type ListFunctionsResponse struct {
+	Functions []*GetFunctionResponse `json:"functions"`
+	NextToken string                 `json:"nextToken"`
+}
+
+// ListFunctions returns the functions under all the services.
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	services, err := c.ListServices()
+	if err != nil {
+		return nil, errors.Wrap(err, "list services")
+	}
+
+	var functions []*platform.FunctionInfo
+	for _, service := range services {
+		nextToken := ""
+		for {
+			query := url.Values{}
+			query.Set("limit", "100")
+			if nextToken != "" {
+				query.Set("nextToken", nextToken)
+			}
+			resp, err := c.request(http.MethodGet, fmt.Sprintf("/services/%s/functions?%s", service.Name, query.Encode()))
+			if err != nil {
+				return nil, errors.Wrap(err, "list functions")
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
+			}
+
+			var respJSON ListFunctionsResponse
+			if err := resp.ToJSON(&respJSON); err != nil {
+				return nil, errors.Wrap(err, "JSON decode")
+			}
+
+			for _, function := range respJSON.Functions {
+				info := toFunctionInfo(service.Name, function)
+
+				triggers, err := c.ListTriggers(service.Name, function.FunctionName)
+				if err != nil {
+					return nil, errors.Wrapf(err, "list triggers of %q", function.FunctionName)
+				}
+				for _, trigger := range triggers.Triggers {
+					if trigger.TriggerType != "http" {
+						continue
+					}
+					qualifier := service.Name
+					if trigger.Qualifier != "" && trigger.Qualifier != "LATEST" {
+						qualifier += "." + trigger.Qualifier
+					}
+					info.URL = c.triggerURL(qualifier, function.FunctionName)
+					break
+				}
+				functions = append(functions, info)
+			}
+
+			nextToken = respJSON.NextToken
+			if nextToken == "" {
+				break
+			}
+		}
+	}
+	return functions, nil
+}
+
+func toFunctionInfo(serviceName string, function *GetFunctionResponse) *platform.FunctionInfo {
+	// The function is ready once it is created on aliyun.
+	return &platform.FunctionInfo{
+		Name:                  function.FunctionName,
+		Namespace:             serviceName,
+		Description:           function.Description,
+		Status:                "Active",
+		Active:                true,
+		MemorySize:            int64(function.MemorySize),
+		EnvironmentVariables:  function.EnvironmentVariables,
+		InitializationTimeout: time.Duration(function.InitializationTimeout) * time.Second,
+		RuntimeTimeout:        time.Duration(function.Timeout) * time.Second,
+		HTTPPort:              function.CaPort,
+		CodeChecksum:          function.CodeChecksum,
+		UpdatedAt:             function.LastModifiedTime,
+	}
+}
+
+// Invoke calls the function synchronously.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	serviceName, name := platform.SplitName(name, ServiceName)
+	resp, err := c.request(http.MethodPost, fmt.Sprintf("/services/%s/functions/%s/invocations", serviceName, name), payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "invoke function")
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, platform.ErrFunctionNotExists
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	return &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		Error:      resp.Header.Get("X-Fc-Error-Type"),
+	}, nil
+}
+
+// DeleteFunction deletes the triggers under the function, then the function itself.
+func (c *Client) DeleteFunction(name string) error {
+	serviceName, name := platform.SplitName(name, ServiceName)
+	_, err := c.GetFunction(serviceName, name)
+	if err != nil {
+		return err
+	}
+
+	triggers, err := c.ListTriggers(serviceName, name)
+	if err != nil {
+		return errors.Wrap(err, "list triggers")
+	}
+	for _, trigger := range triggers.Triggers {
+		log.Trace("Delete trigger: %q...", trigger.TriggerName)
+		if err := c.DeleteTrigger(serviceName, name, trigger.TriggerName); err != nil {
+			return errors.Wrapf(err, "delete trigger: %q", trigger.TriggerName)
+		}
+	}
+
+	log.Trace("Delete function: %q...", name)
+	return c.deleteFunction(serviceName, name)
+}
+
+func (c *Client) deleteFunction(serviceName, functionName string) error {
 	resp, err := c.request(http.MethodDelete, fmt.Sprintf("/services/%s/functions/%s", serviceName, functionName))
 	if err != nil {
 		return errors.Wrap(err, "delete function")
@@ -212,7 +511,7 @@ func (c *Client) DeleteFunction(serviceName, functionName string) error {
 
 	if resp.StatusCode != http.StatusNoContent {
 		if resp.StatusCode == http.StatusNotFound {
-			return ErrFunctionNotExists
+			return platform.ErrFunctionNotExists
 		}
 		return errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
 	}
@@ -0,0 +1,100 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aliyun
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// logPageSize is the maximum number of the log lines returned in one request.
+const logPageSize = 100
+
+// Logs returns the function logs from the SLS logstore of the service.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	serviceName, name := platform.SplitName(name, ServiceName)
+	service, err := c.GetService(serviceName)
+	if err != nil {
+		return nil, errors.Wrap(err, "get service")
+	}
+	if service.LogConfig.Project == "" || service.LogConfig.Logstore == "" {
+		return nil, errors.Errorf("log config of service %q is not set", serviceName)
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var logs []*platform.LogEntry
+	for offset := 0; ; offset += logPageSize {
+		query := url.Values{}
+		query.Set("type", "log")
+		query.Set("from", strconv.FormatInt(opts.Since.Unix(), 10))
+		query.Set("to", strconv.FormatInt(until.Unix()+1, 10))
+		query.Set("query", fmt.Sprintf("serviceName: %s and functionName: %s", serviceName, name))
+		query.Set("line", strconv.Itoa(logPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("reverse", "false")
+
+		lines, err := c.getLogs(service.LogConfig.Project, service.LogConfig.Logstore, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "get logs")
+		}
+
+		for _, line := range lines {
+			timestamp, _ := strconv.ParseInt(line["__time__"], 10, 64)
+			logs = append(logs, &platform.LogEntry{
+				Time:    time.Unix(timestamp, 0),
+				Message: line["message"],
+			})
+		}
+		if len(lines) < logPageSize {
+			break
+		}
+	}
+	return logs, nil
+}
+
+func (c *Client) getLogs(project, logstore string, query url.Values) ([]map[string]string, error) {
+	u := fmt.Sprintf("https://%s.%s.log.aliyuncs.com/logstores/%s?%s", project, c.regionID, logstore, query.Encode())
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-log-apiversion", "0.6.0")
+	req.Header.Set("x-log-bodyrawsize", "0")
+	req.Header.Set("x-log-signaturemethod", "hmac-sha1")
+	req.Header.Set("Authorization", "LOG "+c.accessKeyID+":"+c.GetLogSignature(req))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var respJSON struct {
+			ErrorCode    string `json:"errorCode"`
+			ErrorMessage string `json:"errorMessage"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&respJSON); err != nil {
+			return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil, errors.Errorf("[%s] %s", respJSON.ErrorCode, respJSON.ErrorMessage)
+	}
+
+	var lines []map[string]string
+	return lines, json.NewDecoder(resp.Body).Decode(&lines)
+}
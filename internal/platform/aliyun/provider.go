@@ -0,0 +1,29 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aliyun
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.Aliyun,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. cn-hangzhou", Required: true},
+			{Name: AccountIDField, Usage: "Account ID", Required: true},
+			{Name: AccessKeyIDField, Usage: "Access key ID", Required: true},
+			{Name: AccessKeySecretField, Usage: "Access key secret", Required: true, Secret: true},
+			{Name: platform.EndpointField, Usage: "API endpoint, e.g. http://127.0.0.1:9101 for the Function Compute API of `Raika emulator`"},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[AccountIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
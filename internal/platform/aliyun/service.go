@@ -21,6 +21,10 @@ type Service struct {
 	Name      string `json:"serviceName"`
 	Desc      string `json:"description"`
 	CreatedAt string `json:"createdTime"`
+	LogConfig struct {
+		Project  string `json:"project"`
+		Logstore string `json:"logstore"`
+	} `json:"logConfig"`
 }
 
 type ListServicesResponse struct {
@@ -57,6 +61,19 @@ func (c *Client) CreateService(name, desc string) (*Service, error) {
 	return &response, resp.ToJSON(&response)
 }
 
+func (c *Client) GetService(name string) (*Service, error) {
+	var response Service
+	resp, err := c.request(http.MethodGet, "/services/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.ToString())
+	}
+	return &response, resp.ToJSON(&response)
+}
+
 func (c *Client) GetRaikaService() (*Service, error) {
 	services, err := c.ListServices()
 	if err != nil {
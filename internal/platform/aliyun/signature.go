@@ -7,11 +7,13 @@ package aliyun
 import (
 	"bytes"
 	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/base64"
 	"hash"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 )
@@ -41,7 +43,7 @@ func (c *Client) GetSignature(req *http.Request) string {
 	contentMd5 := req.Header.Get("Content-MD5")
 	contentType := req.Header.Get("Content-Type")
 	date := req.Header.Get("Date")
-	fcResource := req.URL.Path
+	fcResource := canonicalizedResource(req.URL)
 
 	signStr := httpMethod + "\n" + contentMd5 + "\n" + contentType + "\n" + date + "\n" + fcHeaders + fcResource
 
@@ -50,6 +52,71 @@ func (c *Client) GetSignature(req *http.Request) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
+// canonicalizedResource returns the path of the URL followed by the sorted
+// query parameters, each in a line as `key=value`.
+func canonicalizedResource(u *url.URL) string {
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for k, values := range query {
+		for _, v := range values {
+			params = append(params, k+"="+v)
+		}
+	}
+	if len(params) == 0 {
+		return u.Path
+	}
+	sort.Strings(params)
+	return u.Path + "\n" + strings.Join(params, "\n")
+}
+
+// GetRPCSignature returns the signature of the RPC API request with the
+// encoded query.
+func (c *Client) GetRPCSignature(method, rawQuery string) string {
+	h := hmac.New(sha1.New, []byte(c.accessKeySecret+"&"))
+	_, _ = io.WriteString(h, method+"&%2F&"+url.QueryEscape(rawQuery))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// GetLogSignature returns the signature string of the SLS request.
+func (c *Client) GetLogSignature(req *http.Request) string {
+	// Sort the x-log- and x-acs- headers.
+	headers := &fcHeaders{}
+	for k := range req.Header {
+		lowerKey := strings.ToLower(k)
+		if strings.HasPrefix(lowerKey, "x-log-") || strings.HasPrefix(lowerKey, "x-acs-") {
+			headers.Keys = append(headers.Keys, lowerKey)
+			headers.Values = append(headers.Values, req.Header.Get(k))
+		}
+	}
+	sort.Sort(headers)
+	logHeaders := make([]string, 0, len(headers.Keys))
+	for i := range headers.Keys {
+		logHeaders = append(logHeaders, headers.Keys[i]+":"+headers.Values[i])
+	}
+
+	// The query parameters are sorted and not escaped.
+	query := req.URL.Query()
+	queryKeys := make([]string, 0, len(query))
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+	sort.Strings(queryKeys)
+	params := make([]string, 0, len(queryKeys))
+	for _, k := range queryKeys {
+		params = append(params, k+"="+query.Get(k))
+	}
+	logResource := req.URL.Path
+	if len(params) > 0 {
+		logResource += "?" + strings.Join(params, "&")
+	}
+
+	signStr := req.Method + "\n" + req.Header.Get("Content-MD5") + "\n" + req.Header.Get("Content-Type") + "\n" + req.Header.Get("Date") + "\n" + strings.Join(logHeaders, "\n") + "\n" + logResource
+
+	h := hmac.New(sha1.New, []byte(c.accessKeySecret))
+	_, _ = io.WriteString(h, signStr)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
 type fcHeaders struct {
 	Keys   []string
 	Values []string
@@ -9,12 +9,33 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
 )
 
+// triggerType returns the type of the trigger on aliyun.
+func triggerType(typ string) string {
+	if typ == platform.CronTrigger {
+		return "timer"
+	}
+	return "http"
+}
+
+// findTrigger returns the trigger with the name in the specs.
+func findTrigger(specs []platform.TriggerSpec, name string) (platform.TriggerSpec, bool) {
+	for _, spec := range specs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return platform.TriggerSpec{}, false
+}
+
 type CreateHTTPTriggerOptions struct {
 	TriggerName  string
 	ServiceName  string
 	FunctionName string
+	Qualifier    string
 }
 
 type CreateHTTPTriggerRequest struct {
@@ -31,14 +52,14 @@ type CreateHTTPTriggerRequest struct {
 func (c *Client) CreateHTTPTrigger(opts CreateHTTPTriggerOptions) error {
 	requestBody := CreateHTTPTriggerRequest{
 		Name: opts.TriggerName,
-		Type: "http",
+		Type: triggerType(platform.HTTPTrigger),
 		Config: struct {
 			AuthType string `json:"authType"`
 		}{
 			AuthType: "anonymous",
 		},
 		InvocationRole: fmt.Sprintf("acs:ram::%s:role/aliyunfcdefaultrole", c.accountID),
-		Qualifier:      "LATEST",
+		Qualifier:      opts.Qualifier,
 		SourceArn:      "anonymous",
 	}
 	resp, err := c.request(http.MethodPost, fmt.Sprintf("/services/%s/functions/%s/triggers", opts.ServiceName, opts.FunctionName), requestBody)
@@ -70,6 +91,8 @@ type CreateCronTriggerOptions struct {
 	ServiceName  string
 	FunctionName string
 	CronString   string
+	Payload      string
+	Qualifier    string
 }
 
 type CreateCronTriggerRequest struct {
@@ -78,6 +101,7 @@ type CreateCronTriggerRequest struct {
 	Config struct {
 		AuthType       string `json:"authType"`
 		CronExpression string `json:"cronExpression"`
+		Payload        string `json:"payload,omitempty"`
 		Enable         bool   `json:"enable"`
 	} `json:"triggerConfig"`
 	InvocationRole string `json:"invocationRole"`
@@ -88,18 +112,20 @@ type CreateCronTriggerRequest struct {
 func (c *Client) CreateCronTrigger(opts CreateCronTriggerOptions) error {
 	requestBody := CreateCronTriggerRequest{
 		Name: opts.TriggerName,
-		Type: "timer",
+		Type: triggerType(platform.CronTrigger),
 		Config: struct {
 			AuthType       string `json:"authType"`
 			CronExpression string `json:"cronExpression"`
+			Payload        string `json:"payload,omitempty"`
 			Enable         bool   `json:"enable"`
 		}{
 			AuthType:       "anonymous",
 			CronExpression: opts.CronString,
+			Payload:        opts.Payload,
 			Enable:         true,
 		},
 		InvocationRole: fmt.Sprintf("acs:ram::%s:role/aliyunfcdefaultrole", c.accountID),
-		Qualifier:      "LATEST",
+		Qualifier:      opts.Qualifier,
 		SourceArn:      "anonymous",
 	}
 	resp, err := c.request(http.MethodPost, fmt.Sprintf("/services/%s/functions/%s/triggers", opts.ServiceName, opts.FunctionName), requestBody)
@@ -112,6 +138,46 @@ func (c *Client) CreateCronTrigger(opts CreateCronTriggerOptions) error {
 	return nil
 }
 
+type UpdateCronTriggerRequest struct {
+	Config struct {
+		CronExpression string `json:"cronExpression"`
+		Payload        string `json:"payload"`
+		Enable         bool   `json:"enable"`
+	} `json:"triggerConfig"`
+	Qualifier string `json:"qualifier"`
+}
+
+func (c *Client) UpdateCronTrigger(opts CreateCronTriggerOptions) error {
+	var requestBody UpdateCronTriggerRequest
+	requestBody.Config.CronExpression = opts.CronString
+	requestBody.Config.Payload = opts.Payload
+	requestBody.Config.Enable = true
+	requestBody.Qualifier = opts.Qualifier
+
+	resp, err := c.request(http.MethodPut, fmt.Sprintf("/services/%s/functions/%s/triggers/%s", opts.ServiceName, opts.FunctionName, opts.TriggerName), requestBody)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.ToString())
+	}
+	return nil
+}
+
+// UpdateTriggerQualifier binds the trigger to the given version or alias.
+func (c *Client) UpdateTriggerQualifier(serviceName, functionName, triggerName, qualifier string) error {
+	resp, err := c.request(http.MethodPut, fmt.Sprintf("/services/%s/functions/%s/triggers/%s", serviceName, functionName, triggerName), map[string]interface{}{
+		"qualifier": qualifier,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.ToString())
+	}
+	return nil
+}
+
 type ListTriggersResponse struct {
 	Triggers []struct {
 		TriggerName    string      `json:"triggerName"`
@@ -0,0 +1,84 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aliyun
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// aliasName returns the alias name of the function on aliyun.
+//
+// The versions and aliases are managed at the service level on aliyun, all the
+// functions share the Raika service. In order to roll back a function without
+// affecting the others, each function has its own alias.
+func aliasName(functionName, alias string) string {
+	return alias + "-" + functionName
+}
+
+type PublishVersionResponse struct {
+	VersionID   string `json:"versionId"`
+	Description string `json:"description"`
+	CreatedTime string `json:"createdTime"`
+}
+
+// PublishVersion publishes a version of the service of the function, which
+// contains the current code and configuration of the function.
+func (c *Client) PublishVersion(name, description string) (string, error) {
+	serviceName, _ := platform.SplitName(name, ServiceName)
+	resp, err := c.request(http.MethodPost, fmt.Sprintf("/services/%s/versions", serviceName), map[string]interface{}{
+		"description": description,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "publish version")
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
+	}
+
+	var respJSON PublishVersionResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "JSON decode")
+	}
+	return respJSON.VersionID, nil
+}
+
+// UpdateAlias points the alias of the function to the given service version.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	serviceName, name := platform.SplitName(name, ServiceName)
+	alias = aliasName(name, alias)
+
+	resp, err := c.request(http.MethodPut, fmt.Sprintf("/services/%s/aliases/%s", serviceName, alias), map[string]interface{}{
+		"versionId": version,
+	})
+	if err != nil {
+		return errors.Wrap(err, "update alias")
+	}
+	if resp.StatusCode == http.StatusOK {
+		_ = resp.Body.Close()
+		return nil
+	} else if resp.StatusCode != http.StatusNotFound {
+		return errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
+	}
+	_ = resp.Body.Close()
+
+	// Alias not found, create it.
+	resp, err = c.request(http.MethodPost, fmt.Sprintf("/services/%s/aliases", serviceName), map[string]interface{}{
+		"aliasName": alias,
+		"versionId": version,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create alias")
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return errors.Errorf("unexpected status code %d: %v", resp.StatusCode, resp.ToString())
+	}
+	_ = resp.Body.Close()
+	return nil
+}
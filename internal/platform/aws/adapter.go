@@ -0,0 +1,78 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+// functionFileName is the name of the function binary in the package, the
+// bootstrap of the package is the adapter.
+const functionFileName = "raika-function"
+
+// The adapter is built for each architecture by `go generate` before building
+// Raika, and embedded, so that deploying to Lambda needs no Go toolchain. The
+// binaries are not checked in.
+//go:generate env CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -trimpath -buildvcs=false "-ldflags=-s -w" -o adapter/bootstrap-x86_64 ./adapter
+//go:generate env CGO_ENABLED=0 GOOS=linux GOARCH=arm64 go build -trimpath -buildvcs=false "-ldflags=-s -w" -o adapter/bootstrap-arm64 ./adapter
+
+// The directory is embedded instead of the binaries, so that Raika still
+// compiles before they are generated.
+//go:embed adapter
+var adapterFS embed.FS
+
+// adapterBinary returns the adapter binary for the architecture.
+func adapterBinary(architecture string) ([]byte, error) {
+	if architecture != ArchitectureX86 && architecture != ArchitectureARM {
+		return nil, errors.Errorf("no adapter for the architecture %q", architecture)
+	}
+	data, err := adapterFS.ReadFile("adapter/bootstrap-" + architecture)
+	if err != nil {
+		return nil, errors.Errorf("the adapter for the architecture %q is not built, run `go generate ./internal/platform/aws` before building Raika", architecture)
+	}
+	return data, nil
+}
+
+// handler returns the handler of the function, which is the function binary
+// and the HTTP port it listens on.
+func handler(opts platform.CreateFunctionOptions) string {
+	port := opts.HTTPPort
+	if port == 0 {
+		port = defaultHTTPPort
+	}
+	return fmt.Sprintf("%s:%d", functionFileName, port)
+}
+
+// handlerPort returns the HTTP port in the handler, it is zero if the handler
+// is not set by the adapter.
+func handlerPort(handler string) int {
+	prefix := functionFileName + ":"
+	if !strings.HasPrefix(handler, prefix) {
+		return 0
+	}
+	port, _ := strconv.Atoi(strings.TrimPrefix(handler, prefix))
+	return port
+}
+
+// packFunction returns the code package of the function, which runs the
+// function binary by the adapter of the architecture of the account.
+func (c *Client) packFunction(opts platform.CreateFunctionOptions) ([]byte, error) {
+	adapter, err := adapterBinary(c.architecture)
+	if err != nil {
+		return nil, err
+	}
+	return pack.Zip(opts,
+		pack.Entry{Name: pack.BootstrapName, Mode: 0755, Data: adapter},
+		pack.Entry{Name: functionFileName, Mode: 0755, Path: opts.File},
+	)
+}
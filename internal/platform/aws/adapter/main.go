@@ -0,0 +1,358 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Command adapter is the bootstrap of the functions on AWS Lambda. The Raika
+// functions are HTTP servers, while the custom runtime of Lambda receives the
+// events from the Lambda Runtime API. The adapter runs the function binary
+// given by the handler `<binary>:<port>`, and forwards the events to it:
+//
+//   - The API Gateway HTTP API events (payload format 2.0) are converted to
+//     the HTTP requests, and the responses are converted back.
+//   - The other events, e.g. the invocations and the EventBridge rules, are
+//     posted to `/` as is, and the response body is the result.
+//
+// It only depends on the standard library. The binaries are prebuilt for the
+// architectures of Lambda by `go generate` in the aws package, and embedded in
+// Raika.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const runtimeAPIVersion = "2018-06-01"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("raika-adapter: ")
+
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		log.Fatal("AWS_LAMBDA_RUNTIME_API is not set")
+	}
+	runtime := &runtimeClient{
+		baseURL: "http://" + api + "/" + runtimeAPIVersion + "/runtime",
+		// The long polling of the next invocation never times out.
+		client: &http.Client{},
+	}
+
+	binary, port, err := parseHandler(os.Getenv("_HANDLER"))
+	if err != nil {
+		runtime.initError(err)
+		log.Fatal(err)
+	}
+	root := os.Getenv("LAMBDA_TASK_ROOT")
+	if root == "" {
+		root = "."
+	}
+
+	cmd := exec.Command(filepath.Join(root, binary))
+	cmd.Dir = root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "PORT="+strconv.Itoa(port))
+	if err := cmd.Start(); err != nil {
+		err = fmt.Errorf("start function: %v", err)
+		runtime.initError(err)
+		log.Fatal(err)
+	}
+	var waitErr error
+	exited := make(chan struct{})
+	go func() {
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+
+	host := fmt.Sprintf("127.0.0.1:%d", port)
+	if err := waitReady(host, exited); err != nil {
+		runtime.initError(err)
+		log.Fatal(err)
+	}
+	go func() {
+		<-exited
+		// Lambda starts a new sandbox for the next invocation.
+		log.Fatalf("function exited: %v", waitErr)
+	}()
+
+	f := &function{
+		baseURL: "http://" + host,
+		client: &http.Client{
+			// The redirects are returned to the client as is.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	for {
+		invocation, err := runtime.next()
+		if err != nil {
+			log.Fatalf("get next invocation: %v", err)
+		}
+
+		result, err := f.handle(invocation)
+		if err != nil {
+			err = runtime.invocationError(invocation.id, err)
+		} else {
+			err = runtime.response(invocation.id, result)
+		}
+		if err != nil {
+			log.Fatalf("report invocation %s: %v", invocation.id, err)
+		}
+	}
+}
+
+// parseHandler returns the function binary and its HTTP port in the handler.
+func parseHandler(handler string) (string, int, error) {
+	index := strings.LastIndex(handler, ":")
+	if index <= 0 {
+		return "", 0, fmt.Errorf("invalid handler %q, it should be <binary>:<port>", handler)
+	}
+	port, err := strconv.Atoi(handler[index+1:])
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port of the handler %q", handler)
+	}
+	return handler[:index], port, nil
+}
+
+// waitReady waits until the function accepts the connections.
+func waitReady(host string, exited <-chan struct{}) error {
+	for {
+		select {
+		case <-exited:
+			return fmt.Errorf("function exited on start")
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", host, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type invocation struct {
+	id       string
+	deadline time.Time
+	payload  []byte
+}
+
+// runtimeClient is the client of the Lambda Runtime API.
+type runtimeClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (c *runtimeClient) next() (*invocation, error) {
+	resp, err := c.client.Get(c.baseURL + "/invocation/next")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, payload)
+	}
+
+	inv := &invocation{
+		id:      resp.Header.Get("Lambda-Runtime-Aws-Request-Id"),
+		payload: payload,
+	}
+	if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		inv.deadline = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return inv, nil
+}
+
+func (c *runtimeClient) response(id string, result []byte) error {
+	return c.post("/invocation/"+id+"/response", result)
+}
+
+func (c *runtimeClient) invocationError(id string, err error) error {
+	return c.post("/invocation/"+id+"/error", errorPayload(err))
+}
+
+func (c *runtimeClient) initError(err error) {
+	_ = c.post("/init/error", errorPayload(err))
+}
+
+func (c *runtimeClient) post(path string, body []byte) error {
+	resp, err := c.client.Post(c.baseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusAccepted {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, data)
+	}
+	return nil
+}
+
+func errorPayload(err error) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"errorMessage": err.Error(),
+		"errorType":    "Raika.AdapterError",
+	})
+	return data
+}
+
+// function is the HTTP server of the function binary.
+type function struct {
+	baseURL string
+	client  *http.Client
+}
+
+// handle forwards the invocation to the function and returns the result.
+func (f *function) handle(inv *invocation) ([]byte, error) {
+	ctx := context.Background()
+	if !inv.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, inv.deadline)
+		defer cancel()
+	}
+
+	event, ok := parseHTTPEvent(inv.payload)
+	if !ok {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+"/", bytes.NewReader(inv.payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := f.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return io.ReadAll(resp.Body)
+	}
+
+	req, err := event.request(ctx, f.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return encodeHTTPResponse(resp)
+}
+
+// httpEvent is the event of the API Gateway HTTP API in the payload format 2.0.
+type httpEvent struct {
+	Version         string            `json:"version"`
+	RawPath         string            `json:"rawPath"`
+	RawQueryString  string            `json:"rawQueryString"`
+	Cookies         []string          `json:"cookies"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  struct {
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// parseHTTPEvent returns the HTTP API event in the payload, or false if the
+// payload is not an HTTP API event.
+func parseHTTPEvent(payload []byte) (*httpEvent, bool) {
+	var event httpEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, false
+	}
+	if event.Version != "2.0" || event.RequestContext.HTTP.Method == "" {
+		return nil, false
+	}
+	return &event, true
+}
+
+// request returns the HTTP request of the event to the function.
+func (e *httpEvent) request(ctx context.Context, baseURL string) (*http.Request, error) {
+	var body io.Reader = strings.NewReader(e.Body)
+	if e.IsBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(e.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decode body: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = e.RawPath
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = e.RawQueryString
+
+	req, err := http.NewRequestWithContext(ctx, e.RequestContext.HTTP.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range e.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(e.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(e.Cookies, "; "))
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	if e.RequestContext.HTTP.SourceIP != "" && req.Header.Get("X-Forwarded-For") == "" {
+		req.Header.Set("X-Forwarded-For", e.RequestContext.HTTP.SourceIP)
+	}
+	return req, nil
+}
+
+// encodeHTTPResponse returns the HTTP API response of the function response,
+// the body is always base64 encoded.
+func encodeHTTPResponse(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	var cookies []string
+	for name, values := range resp.Header {
+		if name == "Set-Cookie" {
+			cookies = append(cookies, values...)
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return json.Marshal(map[string]interface{}{
+		"statusCode":      resp.StatusCode,
+		"headers":         headers,
+		"cookies":         cookies,
+		"body":            base64.StdEncoding.EncodeToString(body),
+		"isBase64Encoded": true,
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseHandler(t *testing.T) {
+	tests := []struct {
+		handler string
+		binary  string
+		port    int
+		wantErr bool
+	}{
+		{handler: "raika-function:9000", binary: "raika-function", port: 9000},
+		{handler: "bin/a:b:8080", binary: "bin/a:b", port: 8080},
+		{handler: "bootstrap", wantErr: true},
+		{handler: ":9000", wantErr: true},
+		{handler: "raika-function:http", wantErr: true},
+		{handler: "raika-function:65536", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.handler, func(t *testing.T) {
+			binary, port, err := parseHandler(test.handler)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %q %d", binary, port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if binary != test.binary || port != test.port {
+				t.Fatalf("want %q %d, got %q %d", test.binary, test.port, binary, port)
+			}
+		})
+	}
+}
+
+func newTestFunction(t *testing.T) *function {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		w.Header().Set("X-Host", r.Host)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, "%s %s?%s cookie=%s body=%s", r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Cookie"), body)
+	}))
+	t.Cleanup(server.Close)
+	return &function{baseURL: server.URL, client: server.Client()}
+}
+
+func TestFunctionHandleHTTPEvent(t *testing.T) {
+	payload := []byte(`{
+  "version": "2.0",
+  "routeKey": "$default",
+  "rawPath": "/users/1",
+  "rawQueryString": "a=1&b=2",
+  "cookies": ["c1=v1", "c2=v2"],
+  "headers": {"host": "api.example.com", "content-type": "text/plain"},
+  "requestContext": {"http": {"method": "PUT", "path": "/users/1", "sourceIp": "1.2.3.4"}},
+  "body": "aGVsbG8=",
+  "isBase64Encoded": true
+}`)
+
+	result, err := newTestFunction(t).handle(&invocation{id: "1", payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		StatusCode      int               `json:"statusCode"`
+		Headers         map[string]string `json:"headers"`
+		Cookies         []string          `json:"cookies"`
+		Body            string            `json:"body"`
+		IsBase64Encoded bool              `json:"isBase64Encoded"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		t.Fatal(err)
+	}
+	body, err := base64.StdEncoding.DecodeString(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("want status code %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	if want := "PUT /users/1?a=1&b=2 cookie=c1=v1; c2=v2 body=hello"; string(body) != want {
+		t.Errorf("want body %q, got %q", want, body)
+	}
+	if resp.Headers["X-Host"] != "api.example.com" {
+		t.Errorf("want host %q, got %q", "api.example.com", resp.Headers["X-Host"])
+	}
+	if len(resp.Cookies) != 1 || resp.Cookies[0] != "session=1" {
+		t.Errorf("want cookies [session=1], got %v", resp.Cookies)
+	}
+	if _, ok := resp.Headers["Set-Cookie"]; ok {
+		t.Error("Set-Cookie should be in the cookies")
+	}
+}
+
+func TestFunctionHandleEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "invocation", payload: `{"job":"sync"}`},
+		{name: "scheduled event", payload: `{"version":"0","detail-type":"Scheduled Event","source":"aws.events"}`},
+		{name: "not JSON", payload: `hello`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := newTestFunction(t).handle(&invocation{id: "1", payload: []byte(test.payload)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "POST /? cookie= body=" + test.payload; string(result) != want {
+				t.Fatalf("want %q, got %q", want, result)
+			}
+		})
+	}
+}
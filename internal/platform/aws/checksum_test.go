@@ -0,0 +1,29 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"testing"
+)
+
+// The checksums are compared with the ones reported by the platform to skip
+// the unchanged code, which are the base64 encoded SHA-256 on AWS.
+func TestCodeChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		zipFile string
+		want    string
+	}{
+		{name: "empty", zipFile: "", want: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+		{name: "check", zipFile: "123456789", want: "FeKw08M4keuw8e9gnsQZQgwg4yDOlMZfvIwzEkSOsiU="},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := codeChecksum([]byte(test.zipFile)); got != test.want {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
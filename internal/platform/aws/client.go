@@ -5,9 +5,12 @@
 package aws
 
 import (
+	"net/http"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/pkg/errors"
 
 	"github.com/wuhan005/Raika/internal/platform"
 	"github.com/wuhan005/Raika/internal/types"
@@ -20,17 +23,48 @@ type Client struct {
 	regionID             string
 	accountID, roleName  string
 	accessKey, secretKey string
+	architecture         string
+
+	// endpoint is the custom endpoint of all the services, the endpoints of
+	// the region are used if it is empty.
+	endpoint   string
+	httpClient *http.Client
 }
 
-func New(opts platform.AuthenticateOptions) *Client {
-	return &Client{
-		id:        opts["id"],
-		regionID:  opts[RegionIDField],
-		roleName:  opts[RoleName],
-		accountID: opts[AccountIDField],
-		accessKey: opts[AccessKeyField],
-		secretKey: opts[SecretKeyField],
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	architecture := opts[ArchitectureField]
+	switch architecture {
+	case "":
+		architecture = ArchitectureX86
+	case ArchitectureX86, ArchitectureARM:
+	default:
+		return nil, errors.Errorf("unsupported architecture %q, it should be %s or %s", architecture, ArchitectureX86, ArchitectureARM)
+	}
+
+	var endpoint string
+	if opts[platform.EndpointField] != "" {
+		u, err := platform.ParseEndpoint(opts[platform.EndpointField])
+		if err != nil {
+			return nil, err
+		}
+		endpoint = u.String()
 	}
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		id:           opts["id"],
+		regionID:     opts[RegionIDField],
+		roleName:     opts[RoleNameField],
+		accountID:    opts[AccountIDField],
+		accessKey:    opts[AccessKeyIDField],
+		secretKey:    opts[SecretAccessKeyField],
+		architecture: architecture,
+		endpoint:     endpoint,
+		httpClient:   httpClient,
+	}, nil
 }
 
 func (c *Client) String() string {
@@ -45,14 +79,31 @@ func (c *Client) GetID() string {
 	return c.id
 }
 
+// Target returns the target of the function binary on the architecture of the account.
+func (c *Client) Target() platform.Target {
+	if c.architecture == ArchitectureARM {
+		return platform.Target{GOOS: "linux", GOARCH: "arm64"}
+	}
+	return platform.DefaultTarget
+}
+
 func (c *Client) Authenticate() error {
-	_, err := session.NewSession(&aws.Config{
-		Credentials: credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
-		Region:      &c.regionID,
-	})
+	_, err := c.newSession()
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (c *Client) newSession() (*session.Session, error) {
+	config := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
+		Region:      &c.regionID,
+		HTTPClient:  c.httpClient,
+	}
+	if c.endpoint != "" {
+		config.Endpoint = &c.endpoint
+	}
+	return session.NewSession(config)
+}
@@ -6,8 +6,24 @@ package aws
 
 const (
 	RegionIDField  = "region_id"
-	RoleName       = "role_name"
 	AccountIDField = "account_id"
-	AccessKeyField = "access_key"
-	SecretKeyField = "secret_key"
+	// RoleNameField is the IAM role the functions are executed with.
+	RoleNameField        = "role_name"
+	AccessKeyIDField     = "access_key_id"
+	SecretAccessKeyField = "secret_access_key"
+	// ArchitectureField is the instruction set of the functions, x86_64 or arm64.
+	ArchitectureField = "architecture"
 )
+
+// The instruction sets of the Lambda functions.
+const (
+	ArchitectureX86 = "x86_64"
+	ArchitectureARM = "arm64"
+)
+
+// functionRuntime is the custom runtime of the functions, the bootstrap of
+// the package is the adapter of the Lambda Runtime API.
+const functionRuntime = "provided.al2"
+
+// defaultHTTPPort is the port the function binary listens on if it is not set.
+const defaultHTTPPort = 9000
@@ -5,16 +5,16 @@
 package aws
 
 import (
-	"archive/zip"
-	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
-	"io"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewayv2"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/pkg/errors"
 	log "unknwon.dev/clog/v2"
@@ -22,19 +22,25 @@ import (
 	"github.com/wuhan005/Raika/internal/platform"
 )
 
-func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, error) {
-	fmt.Println(c.accessKey, c.secretKey, c.regionID)
-	sess, err := session.NewSession(&aws.Config{
-		Credentials: credentials.NewStaticCredentials(c.accessKey, c.secretKey, ""),
-		Region:      &c.regionID,
-	})
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	sess, err := c.newSession()
 	if err != nil {
-		return "", errors.Wrap(err, "new session")
+		return nil, errors.Wrap(err, "new session")
+	}
+
+	// Check current function name exists.
+	_, err = c.Describe(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on aws, update...", opts.Name)
+		return c.UpdateFunction(opts)
 	}
 
-	zipFileBase64, err := packFile(opts.File)
+	zipFileBase64, err := c.packFunction(opts)
 	if err != nil {
-		return "", errors.Wrap(err, "pack file")
+		return nil, errors.Wrap(err, "pack file")
 	}
 
 	environmentVariables := make(map[string]*string)
@@ -44,48 +50,275 @@ func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, er
 	}
 
 	lamb := lambda.New(sess)
-	resp, err := lamb.CreateFunction(&lambda.CreateFunctionInput{
+	log.Trace("Create function %q...", opts.Name)
+	_, err = lamb.CreateFunction(&lambda.CreateFunctionInput{
+		Architectures: aws.StringSlice([]string{c.architecture}),
 		Code: &lambda.FunctionCode{
 			ZipFile: zipFileBase64,
 		},
 		Description:  &opts.Description,
 		Environment:  &lambda.Environment{Variables: environmentVariables},
 		FunctionName: &opts.Name,
-		Handler:      aws.String("bootstrap"),
+		Handler:      aws.String(handler(opts)),
 		MemorySize:   &opts.MemorySize,
 		Role:         aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", c.accountID, c.roleName)),
-		Runtime:      aws.String("provided"),
+		Runtime:      aws.String(functionRuntime),
 		Timeout:      aws.Int64(int64(opts.RuntimeTimeout / time.Second)),
 	})
 	if err != nil {
-		return "", err
+		return nil, errors.Wrap(err, "create function")
+	}
+	if err := lamb.WaitUntilFunctionActive(&lambda.GetFunctionConfigurationInput{FunctionName: &opts.Name}); err != nil {
+		return nil, errors.Wrap(err, "wait for function active")
 	}
-	log.Trace("%+v", resp)
-	return "", nil
+
+	return c.release(sess, opts)
 }
 
-func packFile(path string) ([]byte, error) {
-	output := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(output)
+// UpdateFunction updates the code and the configuration of the existing function.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session")
+	}
 
-	header := &zip.FileHeader{
-		Name:     "bootstrap",
-		Modified: time.Now(),
+	zipFileBase64, err := c.packFunction(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
 	}
-	header.SetMode(0777)
-	zipEntry, err := zipWriter.CreateHeader(header)
+
+	environmentVariables := make(map[string]*string)
+	for k, v := range opts.EnvironmentVariables {
+		value := v
+		environmentVariables[k] = &value
+	}
+
+	lamb := lambda.New(sess)
+	configuration, err := getFunctionConfiguration(lamb, opts.Name)
 	if err != nil {
-		return nil, errors.Wrap(err, "create header")
+		return nil, err
 	}
+	info := toFunctionInfo(configuration)
+
+	// The architecture can only be changed along with the code, and the
+	// functions created by the previous versions are on the legacy runtime
+	// without the adapter.
+	architectureChanged := len(configuration.Architectures) != 1 ||
+		aws.StringValue(configuration.Architectures[0]) != c.architecture
+	runtimeChanged := aws.StringValue(configuration.Runtime) != functionRuntime ||
+		aws.StringValue(configuration.Handler) != handler(opts)
 
-	file, err := os.Open(path)
+	var skipped []string
+	if info.CodeChecksum == codeChecksum(zipFileBase64) && !architectureChanged {
+		log.Trace("Code of function %q is unchanged, skip uploading", opts.Name)
+		skipped = append(skipped, platform.SkippedCodeUpload)
+	} else {
+		log.Trace("Update function code %q...", opts.Name)
+		_, err = lamb.UpdateFunctionCode(&lambda.UpdateFunctionCodeInput{
+			Architectures: aws.StringSlice([]string{c.architecture}),
+			FunctionName:  &opts.Name,
+			ZipFile:       zipFileBase64,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update function code")
+		}
+		if err := lamb.WaitUntilFunctionUpdated(&lambda.GetFunctionConfigurationInput{FunctionName: &opts.Name}); err != nil {
+			return nil, errors.Wrap(err, "wait for function updated")
+		}
+	}
+
+	if !platform.ConfigurationChanged(info, opts) && !runtimeChanged {
+		skipped = append(skipped, platform.SkippedConfigurationUpdate)
+	} else {
+		log.Trace("Update function configuration %q...", opts.Name)
+		_, err = lamb.UpdateFunctionConfiguration(&lambda.UpdateFunctionConfigurationInput{
+			Description:  &opts.Description,
+			Environment:  &lambda.Environment{Variables: environmentVariables},
+			FunctionName: &opts.Name,
+			Handler:      aws.String(handler(opts)),
+			MemorySize:   &opts.MemorySize,
+			Runtime:      aws.String(functionRuntime),
+			Timeout:      aws.Int64(int64(opts.RuntimeTimeout / time.Second)),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update function configuration")
+		}
+		if err := lamb.WaitUntilFunctionUpdated(&lambda.GetFunctionConfigurationInput{FunctionName: &opts.Name}); err != nil {
+			return nil, errors.Wrap(err, "wait for function updated")
+		}
+	}
+
+	deployment, err := c.release(sess, opts)
+	if err != nil {
+		return nil, err
+	}
+	deployment.Skipped = skipped
+	return deployment, nil
+}
+
+// codeChecksum returns the checksum of the code package in the format of the
+// `CodeSha256` of the function, which is the base64 encoded SHA-256.
+func codeChecksum(zipFile []byte) string {
+	sum := sha256.Sum256(zipFile)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// CodeChecksum returns the checksum of the code package of the function.
+func (c *Client) CodeChecksum(opts platform.CreateFunctionOptions) (string, error) {
+	zipFile, err := c.packFunction(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "pack file")
+	}
+	return codeChecksum(zipFile), nil
+}
+
+// release points the alias to a new version if the alias is set, and ensures
+// the trigger of the function.
+func (c *Client) release(sess *session.Session, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
+	}
+
+	triggerURL, err := c.ensureTrigger(sess, opts)
+	if err != nil {
+		return nil, err
+	}
+	deployment.URL = triggerURL
+	return deployment, nil
+}
+
+func (c *Client) DeleteFunction(name string) error {
+	sess, err := c.newSession()
 	if err != nil {
-		return nil, errors.Wrap(err, "open file")
+		return errors.Wrap(err, "new session")
 	}
-	if _, err := io.Copy(zipEntry, file); err != nil {
-		return nil, errors.Wrap(err, "copy")
+
+	if err := c.deleteTriggers(sess, name); err != nil {
+		return errors.Wrap(err, "delete triggers")
 	}
-	_ = zipWriter.Close()
 
-	return output.Bytes(), nil
+	log.Trace("Delete function %q...", name)
+	_, err = lambda.New(sess).DeleteFunction(&lambda.DeleteFunctionInput{
+		FunctionName: &name,
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+			return platform.ErrFunctionNotExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session")
+	}
+
+	configuration, err := getFunctionConfiguration(lambda.New(sess), name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(configuration), nil
+}
+
+func getFunctionConfiguration(lamb *lambda.Lambda, name string) (*lambda.FunctionConfiguration, error) {
+	resp, err := lamb.GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+		FunctionName: &name,
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session")
+	}
+
+	var functions []*platform.FunctionInfo
+	err = lambda.New(sess).ListFunctionsPages(&lambda.ListFunctionsInput{}, func(output *lambda.ListFunctionsOutput, _ bool) bool {
+		for _, function := range output.Functions {
+			functions = append(functions, toFunctionInfo(function))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	apis, err := c.listAPIs(apigatewayv2.New(sess))
+	if err != nil {
+		return nil, errors.Wrap(err, "list APIs")
+	}
+	// Function name => HTTP API endpoint
+	endpoints := make(map[string]string, len(apis))
+	for _, api := range apis {
+		if parts := strings.SplitN(aws.StringValue(api.Name), ".", 2); len(parts) == 2 {
+			endpoints[parts[0]] = aws.StringValue(api.ApiEndpoint)
+		}
+	}
+	for _, function := range functions {
+		if endpoint, ok := endpoints[function.Name]; ok {
+			function.URL = endpoint + "/"
+		}
+	}
+	return functions, nil
+}
+
+func toFunctionInfo(function *lambda.FunctionConfiguration) *platform.FunctionInfo {
+	environment := make(map[string]string)
+	if function.Environment != nil {
+		for k, v := range function.Environment.Variables {
+			environment[k] = aws.StringValue(v)
+		}
+	}
+	updatedAt, _ := time.Parse("2006-01-02T15:04:05.000-0700", aws.StringValue(function.LastModified))
+
+	return &platform.FunctionInfo{
+		Name:                 aws.StringValue(function.FunctionName),
+		Description:          aws.StringValue(function.Description),
+		Status:               aws.StringValue(function.State),
+		Active:               aws.StringValue(function.State) == lambda.StateActive,
+		MemorySize:           aws.Int64Value(function.MemorySize),
+		EnvironmentVariables: environment,
+		RuntimeTimeout:       time.Duration(aws.Int64Value(function.Timeout)) * time.Second,
+		HTTPPort:             handlerPort(aws.StringValue(function.Handler)),
+		CodeChecksum:         aws.StringValue(function.CodeSha256),
+		UpdatedAt:            updatedAt,
+	}
+}
+
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session")
+	}
+
+	resp, err := lambda.New(sess).Invoke(&lambda.InvokeInput{
+		FunctionName: &name,
+		Payload:      payload,
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, err
+	}
+	return &platform.InvokeResponse{
+		StatusCode: int(aws.Int64Value(resp.StatusCode)),
+		Body:       resp.Payload,
+		Error:      aws.StringValue(resp.FunctionError),
+	}, nil
 }
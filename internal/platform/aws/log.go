@@ -0,0 +1,58 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// Logs returns the function logs from the CloudWatch Logs.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session")
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var logs []*platform.LogEntry
+	err = cloudwatchlogs.New(sess).FilterLogEventsPages(&cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String("/aws/lambda/" + name),
+		StartTime:    aws.Int64(opts.Since.UnixNano() / int64(time.Millisecond)),
+		EndTime:      aws.Int64(until.UnixNano() / int64(time.Millisecond)),
+	}, func(output *cloudwatchlogs.FilterLogEventsOutput, _ bool) bool {
+		for _, event := range output.Events {
+			logs = append(logs, &platform.LogEntry{
+				ID:      aws.StringValue(event.EventId),
+				Time:    time.Unix(0, aws.Int64Value(event.Timestamp)*int64(time.Millisecond)),
+				Message: strings.TrimRight(aws.StringValue(event.Message), "\n"),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		// The log group is created after the function is invoked for the first time.
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// The events from different log streams may be interleaved.
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].Time.Before(logs[j].Time) })
+	return logs, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.AWS,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. us-east-1", Required: true},
+			{Name: AccountIDField, Usage: "Account ID", Required: true},
+			{Name: RoleNameField, Usage: "IAM role name the Lambda functions are executed with", Required: true},
+			{Name: AccessKeyIDField, Usage: "Access key ID", Required: true},
+			{Name: SecretAccessKeyField, Usage: "Secret access key", Required: true, Secret: true},
+			{Name: ArchitectureField, Usage: "Instruction set architecture of the functions, x86_64 or arm64", Default: ArchitectureX86},
+			{Name: platform.EndpointField, Usage: "API endpoint of all the services, e.g. http://127.0.0.1:9103 for the AWS APIs of `Raika emulator`"},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[AccountIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
@@ -0,0 +1,458 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewayv2"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// triggerName returns the name of the API or the rule which triggers the function.
+// The function name can't contain `.`, so the names of different functions won't conflict.
+func triggerName(functionName, triggerName string) string {
+	return functionName + "." + triggerName
+}
+
+// legacyTriggerName returns the name of the API or the rule created for the
+// default trigger before the functions have multiple triggers.
+func legacyTriggerName(functionName, triggerName string) string {
+	return triggerName + "-" + functionName
+}
+
+// legacyTriggerNames are the names of the default triggers, which are the only
+// triggers created before the functions have multiple triggers.
+var legacyTriggerNames = []string{platform.HTTPTriggerName, platform.CronTriggerName}
+
+var errTriggerNotFound = errors.New("trigger not found")
+
+// ensureTrigger creates the triggers of the function if they do not exist, and
+// returns the trigger URL for HTTP trigger.
+func (c *Client) ensureTrigger(sess *session.Session, opts platform.CreateFunctionOptions) (string, error) {
+	function, err := lambda.New(sess).GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+		FunctionName: &opts.Name,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "get function")
+	}
+	targetARN := aws.StringValue(function.FunctionArn)
+	if opts.Alias != "" {
+		targetARN += ":" + opts.Alias
+	}
+
+	// The permissions of the triggers with the default names are added again
+	// below if the triggers are still wanted.
+	if err := c.deleteLegacyTriggers(sess, opts.Name); err != nil {
+		return "", errors.Wrap(err, "delete legacy triggers")
+	}
+
+	var triggerURL string
+	for _, trigger := range opts.Triggers {
+		switch trigger.Type {
+		case platform.HTTPTrigger:
+			triggerURL, err = c.ensureHTTPTrigger(sess, opts, trigger, targetARN)
+		case platform.CronTrigger:
+			err = c.ensureCronTrigger(sess, opts, trigger, targetARN)
+		default:
+			err = errors.Errorf("unexpected trigger type %q", trigger.Type)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "trigger %q", trigger.Name)
+		}
+	}
+	return triggerURL, nil
+}
+
+// ensureHTTPTrigger creates an API Gateway HTTP API which proxies all the requests to the function.
+func (c *Client) ensureHTTPTrigger(sess *session.Session, opts platform.CreateFunctionOptions, trigger platform.TriggerSpec, targetARN string) (string, error) {
+	gateway := apigatewayv2.New(sess)
+	apiName := triggerName(opts.Name, trigger.Name)
+
+	api, err := c.getAPI(gateway, apiName)
+	if err != nil {
+		return "", errors.Wrap(err, "get API")
+	}
+
+	var apiID, endpoint string
+	if api == nil {
+		log.Trace("Create HTTP API %q...", apiName)
+		resp, err := gateway.CreateApi(&apigatewayv2.CreateApiInput{
+			Name:         &apiName,
+			ProtocolType: aws.String(apigatewayv2.ProtocolTypeHttp),
+			Target:       &targetARN,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "create API")
+		}
+		apiID, endpoint = aws.StringValue(resp.ApiId), aws.StringValue(resp.ApiEndpoint)
+	} else {
+		// Update the target in case the alias changed.
+		resp, err := gateway.UpdateApi(&apigatewayv2.UpdateApiInput{
+			ApiId:  api.ApiId,
+			Target: &targetARN,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "update API")
+		}
+		apiID, endpoint = aws.StringValue(resp.ApiId), aws.StringValue(resp.ApiEndpoint)
+	}
+
+	sourceARN := fmt.Sprintf("arn:%s:execute-api:%s:%s:%s/*", arnPartition(targetARN), c.regionID, c.accountID, apiID)
+	if err := c.addPermission(sess, opts, trigger.Name, "apigateway.amazonaws.com", sourceARN); err != nil {
+		return "", err
+	}
+	return endpoint + "/", nil
+}
+
+// ensureCronTrigger creates an EventBridge rule which invokes the function on schedule.
+func (c *Client) ensureCronTrigger(sess *session.Session, opts platform.CreateFunctionOptions, trigger platform.TriggerSpec, targetARN string) error {
+	scheduleExpression, err := toScheduleExpression(trigger.Cron)
+	if err != nil {
+		return err
+	}
+
+	events := eventbridge.New(sess)
+	ruleName := triggerName(opts.Name, trigger.Name)
+
+	log.Trace("Put rule %q...", ruleName)
+	rule, err := events.PutRule(&eventbridge.PutRuleInput{
+		Name:               &ruleName,
+		ScheduleExpression: &scheduleExpression,
+		State:              aws.String(eventbridge.RuleStateEnabled),
+	})
+	if err != nil {
+		return errors.Wrap(err, "put rule")
+	}
+
+	if err := c.addPermission(sess, opts, trigger.Name, "events.amazonaws.com", aws.StringValue(rule.RuleArn)); err != nil {
+		return err
+	}
+
+	target := &eventbridge.Target{Id: aws.String(trigger.Name), Arn: &targetARN}
+	if trigger.Payload != "" {
+		// The input of the target must be a valid JSON text.
+		input := trigger.Payload
+		if !json.Valid([]byte(input)) {
+			data, err := json.Marshal(input)
+			if err != nil {
+				return errors.Wrap(err, "encode payload")
+			}
+			input = string(data)
+		}
+		target.Input = &input
+	}
+
+	_, err = events.PutTargets(&eventbridge.PutTargetsInput{
+		Rule:    &ruleName,
+		Targets: []*eventbridge.Target{target},
+	})
+	if err != nil {
+		return errors.Wrap(err, "put targets")
+	}
+	return nil
+}
+
+// addPermission allows the service principal to invoke the function, the
+// existing permission with the same statement ID is replaced.
+func (c *Client) addPermission(sess *session.Session, opts platform.CreateFunctionOptions, statementID, principal, sourceARN string) error {
+	var qualifier *string
+	if opts.Alias != "" {
+		qualifier = &opts.Alias
+	}
+	input := &lambda.AddPermissionInput{
+		Action:       aws.String("lambda:InvokeFunction"),
+		FunctionName: &opts.Name,
+		Principal:    &principal,
+		Qualifier:    qualifier,
+		SourceArn:    &sourceARN,
+		StatementId:  &statementID,
+	}
+
+	lamb := lambda.New(sess)
+	_, err := lamb.AddPermission(input)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceConflictException {
+		_, err = lamb.RemovePermission(&lambda.RemovePermissionInput{
+			FunctionName: &opts.Name,
+			Qualifier:    qualifier,
+			StatementId:  &statementID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "remove permission")
+		}
+		_, err = lamb.AddPermission(input)
+	}
+	return errors.Wrap(err, "add permission")
+}
+
+// RemoveTrigger deletes the API or the rule which triggers the function, as
+// well as its permission. The one named in the legacy format is also looked up.
+func (c *Client) RemoveTrigger(functionName, name string) error {
+	sess, err := c.newSession()
+	if err != nil {
+		return errors.Wrap(err, "new session")
+	}
+
+	err = c.removeTrigger(sess, functionName, name, triggerName(functionName, name))
+	if err == errTriggerNotFound {
+		err = c.removeTrigger(sess, functionName, name, legacyTriggerName(functionName, name))
+	}
+	if err == errTriggerNotFound {
+		return errors.Errorf("trigger %q not found", name)
+	}
+	return err
+}
+
+// removeTrigger deletes the API or the rule with the given resource name, and
+// the permission which allows it to invoke the function. It returns
+// errTriggerNotFound if neither exists.
+func (c *Client) removeTrigger(sess *session.Session, functionName, name, resourceName string) error {
+	gateway := apigatewayv2.New(sess)
+	api, err := c.getAPI(gateway, resourceName)
+	if err != nil {
+		return errors.Wrap(err, "get API")
+	}
+	if api != nil {
+		if err := c.deleteAPI(gateway, api); err != nil {
+			return err
+		}
+		// The API does not report the alias it targets, remove the permission
+		// of the function and of its aliases.
+		return c.removePermission(sess, functionName, name, "")
+	}
+
+	events := eventbridge.New(sess)
+	_, err = events.DescribeRule(&eventbridge.DescribeRuleInput{Name: &resourceName})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == eventbridge.ErrCodeResourceNotFoundException {
+		return errTriggerNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "describe rule")
+	}
+	targets, err := events.ListTargetsByRule(&eventbridge.ListTargetsByRuleInput{Rule: &resourceName})
+	if err != nil {
+		return errors.Wrap(err, "list targets")
+	}
+	if err := c.deleteRule(events, resourceName); err != nil {
+		return err
+	}
+
+	var targetARN string
+	if len(targets.Targets) > 0 {
+		targetARN = aws.StringValue(targets.Targets[0].Arn)
+	}
+	return c.removePermission(sess, functionName, name, targetARN)
+}
+
+// removePermission removes the permission with the statement ID from the
+// function, or from the alias of the target ARN if it is qualified. The
+// permissions of the function and of all its aliases are removed if the target
+// ARN is empty. The permissions which do not exist are skipped.
+func (c *Client) removePermission(sess *session.Session, functionName, statementID, targetARN string) error {
+	lamb := lambda.New(sess)
+
+	qualifiers := []*string{nil}
+	if targetARN != "" {
+		// arn:aws:lambda:<region>:<account>:function:<name>[:<qualifier>]
+		if parts := strings.Split(targetARN, ":"); len(parts) == 8 {
+			qualifiers[0] = aws.String(parts[7])
+		}
+	} else {
+		input := &lambda.ListAliasesInput{FunctionName: &functionName}
+		for {
+			resp, err := lamb.ListAliases(input)
+			if err != nil {
+				return errors.Wrap(err, "list aliases")
+			}
+			for _, alias := range resp.Aliases {
+				qualifiers = append(qualifiers, alias.Name)
+			}
+			if aws.StringValue(resp.NextMarker) == "" {
+				break
+			}
+			input.Marker = resp.NextMarker
+		}
+	}
+
+	for _, qualifier := range qualifiers {
+		_, err := lamb.RemovePermission(&lambda.RemovePermissionInput{
+			FunctionName: &functionName,
+			Qualifier:    qualifier,
+			StatementId:  &statementID,
+		})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "remove permission")
+		}
+	}
+	return nil
+}
+
+// deleteLegacyTriggers deletes the APIs and the rules named in the legacy
+// format, which are left behind once the function is deployed with the
+// triggers named in the current format.
+func (c *Client) deleteLegacyTriggers(sess *session.Session, functionName string) error {
+	for _, name := range legacyTriggerNames {
+		err := c.removeTrigger(sess, functionName, name, legacyTriggerName(functionName, name))
+		if err != nil && err != errTriggerNotFound {
+			return errors.Wrapf(err, "trigger %q", name)
+		}
+	}
+	return nil
+}
+
+// deleteTriggers deletes all the APIs and the rules which trigger the function,
+// including the ones named in the legacy format.
+func (c *Client) deleteTriggers(sess *session.Session, functionName string) error {
+	legacyNames := make(map[string]struct{}, len(legacyTriggerNames))
+	for _, name := range legacyTriggerNames {
+		legacyNames[legacyTriggerName(functionName, name)] = struct{}{}
+	}
+
+	gateway := apigatewayv2.New(sess)
+	apis, err := c.listAPIs(gateway)
+	if err != nil {
+		return errors.Wrap(err, "list APIs")
+	}
+	for _, api := range apis {
+		name := aws.StringValue(api.Name)
+		if _, ok := legacyNames[name]; ok || strings.HasPrefix(name, triggerName(functionName, "")) {
+			if err := c.deleteAPI(gateway, api); err != nil {
+				return err
+			}
+		}
+	}
+
+	events := eventbridge.New(sess)
+	input := &eventbridge.ListRulesInput{NamePrefix: aws.String(triggerName(functionName, ""))}
+	for {
+		resp, err := events.ListRules(input)
+		if err != nil {
+			return errors.Wrap(err, "list rules")
+		}
+		for _, rule := range resp.Rules {
+			if err := c.deleteRule(events, aws.StringValue(rule.Name)); err != nil {
+				return err
+			}
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+
+	for name := range legacyNames {
+		_, err := events.DescribeRule(&eventbridge.DescribeRuleInput{Name: aws.String(name)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == eventbridge.ErrCodeResourceNotFoundException {
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "describe rule")
+		}
+		if err := c.deleteRule(events, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) deleteAPI(gateway *apigatewayv2.ApiGatewayV2, api *apigatewayv2.Api) error {
+	log.Trace("Delete HTTP API %q...", aws.StringValue(api.Name))
+	if _, err := gateway.DeleteApi(&apigatewayv2.DeleteApiInput{ApiId: api.ApiId}); err != nil {
+		return errors.Wrap(err, "delete API")
+	}
+	return nil
+}
+
+func (c *Client) deleteRule(events *eventbridge.EventBridge, ruleName string) error {
+	targets, err := events.ListTargetsByRule(&eventbridge.ListTargetsByRuleInput{Rule: &ruleName})
+	if err != nil {
+		return errors.Wrap(err, "list targets")
+	}
+
+	log.Trace("Delete rule %q...", ruleName)
+	if len(targets.Targets) > 0 {
+		ids := make([]*string, 0, len(targets.Targets))
+		for _, target := range targets.Targets {
+			ids = append(ids, target.Id)
+		}
+		_, err = events.RemoveTargets(&eventbridge.RemoveTargetsInput{
+			Rule: &ruleName,
+			Ids:  ids,
+		})
+		if err != nil {
+			return errors.Wrap(err, "remove targets")
+		}
+	}
+	if _, err := events.DeleteRule(&eventbridge.DeleteRuleInput{Name: &ruleName}); err != nil {
+		return errors.Wrap(err, "delete rule")
+	}
+	return nil
+}
+
+// getAPI returns the HTTP API with the given name, it returns nil if the API does not exist.
+func (c *Client) getAPI(gateway *apigatewayv2.ApiGatewayV2, name string) (*apigatewayv2.Api, error) {
+	apis, err := c.listAPIs(gateway)
+	if err != nil {
+		return nil, err
+	}
+	for _, api := range apis {
+		if aws.StringValue(api.Name) == name {
+			return api, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Client) listAPIs(gateway *apigatewayv2.ApiGatewayV2) ([]*apigatewayv2.Api, error) {
+	var apis []*apigatewayv2.Api
+	input := &apigatewayv2.GetApisInput{}
+	for {
+		resp, err := gateway.GetApis(input)
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, resp.Items...)
+
+		if aws.StringValue(resp.NextToken) == "" {
+			return apis, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// arnPartition returns the partition of the ARN, e.g. `aws`, `aws-cn`.
+func arnPartition(arn string) string {
+	parts := strings.SplitN(arn, ":", 3)
+	if len(parts) < 2 {
+		return "aws"
+	}
+	return parts[1]
+}
+
+// toScheduleExpression converts the cron expression with seconds to the
+// EventBridge schedule expression, e.g. `0 30 * * * *` => `cron(30 * * * ? *)`.
+func toScheduleExpression(expr string) (string, error) {
+	fields, err := platform.QuartzCron(expr)
+	if err != nil {
+		return "", err
+	}
+	second, minute, hour, dayOfMonth, month, dayOfWeek := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	if second != "0" {
+		return "", errors.Errorf("invalid cron expression %q: the second must be 0 on aws", expr)
+	}
+	return fmt.Sprintf("cron(%s %s %s %s %s *)", minute, hour, dayOfMonth, month, dayOfWeek), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/pkg/errors"
+)
+
+func (c *Client) PublishVersion(name, description string) (string, error) {
+	sess, err := c.newSession()
+	if err != nil {
+		return "", errors.Wrap(err, "new session")
+	}
+
+	resp, err := lambda.New(sess).PublishVersion(&lambda.PublishVersionInput{
+		FunctionName: &name,
+		Description:  &description,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Version), nil
+}
+
+// UpdateAlias points the alias to the given version, the alias is created if it does not exist.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	sess, err := c.newSession()
+	if err != nil {
+		return errors.Wrap(err, "new session")
+	}
+
+	lamb := lambda.New(sess)
+	_, err = lamb.UpdateAlias(&lambda.UpdateAliasInput{
+		FunctionName:    &name,
+		Name:            &alias,
+		FunctionVersion: &version,
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+		_, err = lamb.CreateAlias(&lambda.CreateAliasInput{
+			FunctionName:    &name,
+			Name:            &alias,
+			FunctionVersion: &version,
+		})
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+type Client struct {
+	id                            string
+	regionID                      string
+	tenantID, clientID, secret    string
+	subscriptionID, resourceGroup string
+	httpClient                    *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceGroup := opts[ResourceGroupField]
+	if resourceGroup == "" {
+		resourceGroup = DefaultResourceGroup
+	}
+
+	return &Client{
+		id:             opts["id"],
+		regionID:       opts[RegionIDField],
+		tenantID:       opts[TenantIDField],
+		clientID:       opts[ClientIDField],
+		secret:         opts[ClientSecretField],
+		subscriptionID: opts[SubscriptionIDField],
+		resourceGroup:  resourceGroup,
+		httpClient:     httpClient,
+	}, nil
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.Azure
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+// Authenticate gets the access token of the service principal, and checks it
+// has access to the subscription.
+func (c *Client) Authenticate() error {
+	resp, err := c.request(http.MethodGet, "?api-version=2020-01-01")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "get subscription")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// accessToken returns the Azure Resource Manager access token of the service
+// principal, it is cached until it expires.
+func (c *Client) accessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	resp, err := c.httpClient.PostForm("https://login.microsoftonline.com/"+url.PathEscape(c.tenantID)+"/oauth2/v2.0/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.secret},
+		"scope":         {armScope},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "request token")
+	}
+	r := &response{Response: resp}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("request token: %s", r.ToString())
+	}
+
+	var respJSON struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := r.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "json decode")
+	}
+
+	c.token = respJSON.AccessToken
+	// Refresh the token a minute before it expires.
+	c.tokenExpiry = time.Now().Add(time.Duration(respJSON.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// request sends the Azure Resource Manager request, the path is relative to the subscription.
+func (c *Client) request(method, path string, requestBody ...interface{}) (*response, error) {
+	token, err := c.accessToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "get access token")
+	}
+
+	var body io.Reader
+	if len(requestBody) == 1 {
+		reqBody, err := json.Marshal(requestBody[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "JSON encode")
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	u := path
+	if !strings.HasPrefix(u, "https://") {
+		u = armEndpoint + "/subscriptions/" + c.subscriptionID + path
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+
+	return &response{
+		Response: resp,
+	}, nil
+}
+
+// resourceGroupPath returns the path of the resource group, it is relative to the subscription.
+func (c *Client) resourceGroupPath() string {
+	return "/resourceGroups/" + c.resourceGroup
+}
+
+// apiError is the error returned by Azure Resource Manager.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+type response struct {
+	*http.Response
+}
+
+func (r *response) ToJSON(v interface{}) error {
+	defer func() { _ = r.Body.Close() }()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (r *response) ToString() string {
+	defer func() { _ = r.Body.Close() }()
+	resp, _ := io.ReadAll(r.Body)
+	return string(resp)
+}
+
+// ToError returns the error in the response body.
+func (r *response) ToError() error {
+	body := r.ToString()
+	var respJSON struct {
+		Error *apiError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &respJSON); err != nil || respJSON.Error == nil {
+		return errors.Errorf("%d: %s", r.StatusCode, strings.TrimSpace(body))
+	}
+	return respJSON.Error
+}
+
+// functionError returns platform.ErrFunctionNotExists if the Function App is not found.
+func (r *response) functionError() error {
+	if r.StatusCode == http.StatusNotFound {
+		_ = r.Body.Close()
+		return platform.ErrFunctionNotExists
+	}
+	return r.ToError()
+}
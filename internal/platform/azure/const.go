@@ -0,0 +1,36 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+const (
+	RegionIDField       = "region_id"
+	TenantIDField       = "tenant_id"
+	ClientIDField       = "client_id"
+	ClientSecretField   = "client_secret"
+	SubscriptionIDField = "subscription_id"
+	ResourceGroupField  = "resource_group"
+)
+
+// DefaultResourceGroup is the resource group the Function Apps are created
+// in if it is not given.
+const DefaultResourceGroup = "raika"
+
+// PortEnv is the environment variable of the port the custom handler listens on,
+// it is set by the Functions host.
+const PortEnv = "FUNCTIONS_CUSTOMHANDLER_PORT"
+
+// The tags of the Function App.
+const (
+	functionNameTag   = "raika-function"
+	descriptionTag    = "raika-description"
+	runtimeTimeoutTag = "raika-runtime-timeout"
+	checksumTag       = "raika-code-checksum"
+	httpTriggerTag    = "raika-http-trigger"
+)
+
+const (
+	armEndpoint = "https://management.azure.com"
+	armScope    = "https://management.azure.com/.default"
+)
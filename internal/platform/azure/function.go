@@ -0,0 +1,456 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// appName returns the Function App name of the function. The name is global
+// on Azure, so it is suffixed with the hash of the subscription and the
+// resource group.
+func (c *Client) appName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-")) + "-" + c.resourceSuffix()
+}
+
+// sitePath returns the path of the Function App, it is relative to the subscription.
+func (c *Client) sitePath(name string) string {
+	return c.resourceGroupPath() + "/providers/Microsoft.Web/sites/" + c.appName(name)
+}
+
+type AppSetting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type SiteConfig struct {
+	AppSettings    []AppSetting `json:"appSettings,omitempty"`
+	LinuxFxVersion string       `json:"linuxFxVersion"`
+}
+
+type SiteProperties struct {
+	ServerFarmID string      `json:"serverFarmId,omitempty"`
+	Reserved     bool        `json:"reserved"`
+	HTTPSOnly    bool        `json:"httpsOnly"`
+	SiteConfig   *SiteConfig `json:"siteConfig,omitempty"`
+
+	// The following fields are output only.
+	State               string `json:"state,omitempty"`
+	DefaultHostName     string `json:"defaultHostName,omitempty"`
+	LastModifiedTimeUTC string `json:"lastModifiedTimeUtc,omitempty"`
+}
+
+type Site struct {
+	Name       string            `json:"name,omitempty"`
+	Location   string            `json:"location"`
+	Kind       string            `json:"kind"`
+	Tags       map[string]string `json:"tags"`
+	Properties SiteProperties    `json:"properties"`
+}
+
+// url returns the URL of the HTTP trigger.
+func (s *Site) url() string {
+	return "https://" + s.Properties.DefaultHostName + "/"
+}
+
+// functionName returns the function name kept in the tags.
+func (s *Site) functionName() string {
+	if name := s.Tags[functionNameTag]; name != "" {
+		return name
+	}
+	return s.Name
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	// Check current function name exists.
+	_, err := c.getSite(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on azure, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+	return c.deploy(opts)
+}
+
+// UpdateFunction replaces the package and the app settings of the existing
+// Function App. The triggers not in the options are removed with the package.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if _, err := c.getSite(opts.Name); err != nil {
+		return nil, err
+	}
+	return c.deploy(opts)
+}
+
+func (c *Client) deploy(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if opts.Alias != "" {
+		return nil, errors.New("alias is not supported on azure")
+	}
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	zipFile, err := packFile(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
+	}
+	// The package is the same for the same files, its checksum tells whether
+	// the code is changed.
+	sum := sha256.Sum256(zipFile)
+	checksum := hex.EncodeToString(sum[:])
+
+	connectionString, planID, err := c.ensureResources()
+	if err != nil {
+		return nil, err
+	}
+
+	// The HTTP port of the options is not used, the custom handler listens on
+	// the port given by the Functions host.
+	settings := map[string]string{
+		"FUNCTIONS_WORKER_RUNTIME":    "custom",
+		"FUNCTIONS_EXTENSION_VERSION": "~4",
+		"AzureWebJobsStorage":         connectionString,
+	}
+	for k, v := range opts.EnvironmentVariables {
+		settings[k] = v
+	}
+	appSettings := make([]AppSetting, 0, len(settings))
+	for k, v := range settings {
+		appSettings = append(appSettings, AppSetting{Name: k, Value: v})
+	}
+
+	tags := map[string]string{
+		functionNameTag:   opts.Name,
+		descriptionTag:    opts.Description,
+		runtimeTimeoutTag: opts.RuntimeTimeout.String(),
+		checksumTag:       checksum,
+	}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.HTTPTrigger {
+			tags[httpTriggerTag] = trigger.Name
+		}
+	}
+
+	log.Trace("Deploy Function App %q...", c.appName(opts.Name))
+	resp, err := c.request(http.MethodPut, c.sitePath(opts.Name)+"?api-version="+webAPIVersion, Site{
+		Location: c.regionID,
+		Kind:     "functionapp,linux",
+		Tags:     tags,
+		Properties: SiteProperties{
+			ServerFarmID: planID,
+			Reserved:     true,
+			HTTPSOnly:    true,
+			SiteConfig:   &SiteConfig{AppSettings: appSettings},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, errors.Wrap(resp.ToError(), "put Function App")
+	}
+	var site Site
+	if err := resp.ToJSON(&site); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	// The app settings of the existing Function App are replaced, including
+	// the ones disabling the removed triggers.
+	if err := c.updateAppSettings(opts.Name, settings); err != nil {
+		return nil, errors.Wrap(err, "update app settings")
+	}
+
+	log.Trace("Upload package of %q...", opts.Name)
+	if err := c.zipDeploy(opts.Name, zipFile); err != nil {
+		return nil, errors.Wrap(err, "zip deploy")
+	}
+
+	resp, err = c.request(http.MethodPost, c.sitePath(opts.Name)+"/syncfunctiontriggers?api-version="+webAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, errors.Wrap(resp.ToError(), "sync triggers")
+	}
+	_ = resp.Body.Close()
+
+	deployment := &platform.Deployment{}
+	if _, ok := tags[httpTriggerTag]; ok {
+		deployment.URL = site.url()
+	}
+	return deployment, nil
+}
+
+// zipDeploy uploads the package to the Kudu service of the Function App with
+// the publishing credentials, and waits until the deployment is done.
+func (c *Client) zipDeploy(name string, zipFile []byte) error {
+	resp, err := c.request(http.MethodPost, c.sitePath(name)+"/config/publishingcredentials/list?api-version="+webAPIVersion)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "get publishing credentials")
+	}
+	var credentials struct {
+		Properties struct {
+			PublishingUserName string `json:"publishingUserName"`
+			PublishingPassword string `json:"publishingPassword"`
+		} `json:"properties"`
+	}
+	if err := resp.ToJSON(&credentials); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	kudu := func(method, u string, body []byte) (*response, error) {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "new request")
+		}
+		req.SetBasicAuth(credentials.Properties.PublishingUserName, credentials.Properties.PublishingPassword)
+		req.Header.Set("Content-Type", "application/zip")
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "do request")
+		}
+		return &response{Response: resp}, nil
+	}
+
+	resp, err = kudu(http.MethodPost, "https://"+c.appName(name)+".scm.azurewebsites.net/api/zipdeploy?isAsync=true", zipFile)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return errors.Errorf("%d: %s", resp.StatusCode, resp.ToString())
+	}
+	_ = resp.Body.Close()
+	location := resp.Header.Get("Location")
+
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		time.Sleep(3 * time.Second)
+
+		resp, err := kudu(http.MethodGet, location, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+			return errors.Errorf("get deployment: %d: %s", resp.StatusCode, resp.ToString())
+		}
+		var respJSON struct {
+			Status     int    `json:"status"`
+			StatusText string `json:"status_text"`
+			Complete   bool   `json:"complete"`
+		}
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return errors.Wrap(err, "json decode")
+		}
+		if !respJSON.Complete {
+			continue
+		}
+		// The deployment status 4 is success, 3 is failed.
+		if respJSON.Status != 4 {
+			return errors.Errorf("deployment failed: %s", respJSON.StatusText)
+		}
+		return nil
+	}
+	return errors.New("deployment timed out")
+}
+
+func (c *Client) getSite(name string) (*Site, error) {
+	resp, err := c.request(http.MethodGet, c.sitePath(name)+"?api-version="+webAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var site Site
+	if err := resp.ToJSON(&site); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &site, nil
+}
+
+func (c *Client) listAppSettings(name string) (map[string]string, error) {
+	resp, err := c.request(http.MethodPost, c.sitePath(name)+"/config/appsettings/list?api-version="+webAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var respJSON struct {
+		Properties map[string]string `json:"properties"`
+	}
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return respJSON.Properties, nil
+}
+
+// updateAppSettings replaces all the app settings of the Function App.
+func (c *Client) updateAppSettings(name string, settings map[string]string) error {
+	resp, err := c.request(http.MethodPut, c.sitePath(name)+"/config/appsettings?api-version="+webAPIVersion, map[string]interface{}{
+		"properties": settings,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.functionError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// isSystemSetting returns true if the app setting is set by Raika or Azure,
+// rather than the environment variables of the function.
+func isSystemSetting(name string) bool {
+	switch name {
+	case "FUNCTIONS_WORKER_RUNTIME", "FUNCTIONS_EXTENSION_VERSION":
+		return true
+	}
+	for _, prefix := range []string{"AzureWebJobs", "WEBSITE_", "SCM_", "APPINSIGHTS_", "APPLICATIONINSIGHTS_"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	site, err := c.getSite(name)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := c.listAppSettings(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "list app settings")
+	}
+	return toFunctionInfo(site, settings), nil
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var functions []*platform.FunctionInfo
+	next := c.resourceGroupPath() + "/providers/Microsoft.Web/sites?api-version=" + webAPIVersion
+	for next != "" {
+		resp, err := c.request(http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			// The resource group is not created yet.
+			_ = resp.Body.Close()
+			return nil, nil
+		} else if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "list Function Apps")
+		}
+
+		var respJSON struct {
+			Value    []*Site `json:"value"`
+			NextLink string  `json:"nextLink"`
+		}
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, site := range respJSON.Value {
+			if !strings.Contains(site.Kind, "functionapp") {
+				continue
+			}
+			info := toFunctionInfo(site, nil)
+			if site.Tags[httpTriggerTag] != "" {
+				info.URL = site.url()
+			}
+			functions = append(functions, info)
+		}
+		next = respJSON.NextLink
+	}
+	return functions, nil
+}
+
+func toFunctionInfo(site *Site, settings map[string]string) *platform.FunctionInfo {
+	environment := make(map[string]string)
+	for k, v := range settings {
+		if !isSystemSetting(k) {
+			environment[k] = v
+		}
+	}
+	runtimeTimeout, _ := time.ParseDuration(site.Tags[runtimeTimeoutTag])
+	updatedAt, _ := time.Parse("2006-01-02T15:04:05.999999999", site.Properties.LastModifiedTimeUTC)
+
+	return &platform.FunctionInfo{
+		Name:                 site.functionName(),
+		Description:          site.Tags[descriptionTag],
+		Status:               site.Properties.State,
+		Active:               site.Properties.State == "Running",
+		EnvironmentVariables: environment,
+		RuntimeTimeout:       runtimeTimeout,
+		CodeChecksum:         site.Tags[checksumTag],
+		UpdatedAt:            updatedAt,
+	}
+}
+
+// Invoke posts the payload to the HTTP trigger of the function.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	site, err := c.getSite(name)
+	if err != nil {
+		return nil, err
+	}
+	if site.Tags[httpTriggerTag] == "" {
+		return nil, errors.Errorf("function %q has no HTTP trigger to invoke on azure", name)
+	}
+
+	resp, err := c.httpClient.Post(site.url(), "application/octet-stream", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+// DeleteFunction deletes the Function App with its triggers. The shared
+// storage account and plan are kept.
+func (c *Client) DeleteFunction(name string) error {
+	log.Trace("Delete Function App %q...", c.appName(name))
+	resp, err := c.request(http.MethodDelete, c.sitePath(name)+"?api-version="+webAPIVersion)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		_ = resp.Body.Close()
+		return platform.ErrFunctionNotExists
+	} else if resp.StatusCode != http.StatusOK {
+		return resp.functionError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
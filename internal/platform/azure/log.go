@@ -0,0 +1,17 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// Logs is not supported, the logs of the Function Apps are only kept in
+// Application Insights.
+func (c *Client) Logs(string, platform.LogOptions) ([]*platform.LogEntry, error) {
+	return nil, errors.New("logs are not supported on azure, use Application Insights instead")
+}
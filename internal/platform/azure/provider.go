@@ -0,0 +1,30 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.Azure,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. eastus", Required: true},
+			{Name: TenantIDField, Usage: "Tenant ID", Required: true},
+			{Name: ClientIDField, Usage: "Client ID of the service principal", Required: true},
+			{Name: ClientSecretField, Usage: "Client secret of the service principal", Required: true, Secret: true},
+			{Name: SubscriptionIDField, Usage: "Subscription ID", Required: true},
+			{Name: ResourceGroupField, Usage: "Resource group of the Function Apps", Default: DefaultResourceGroup},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[SubscriptionIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+)
+
+const (
+	resourcesAPIVersion = "2021-04-01"
+	storageAPIVersion   = "2021-04-01"
+	webAPIVersion       = "2021-02-01"
+)
+
+// planName is the Linux consumption plan shared by the Function Apps.
+const planName = "raika-linux-consumption"
+
+// resourceSuffix returns a short hash of the subscription and the resource
+// group, it makes the global resource names unique.
+func (c *Client) resourceSuffix() string {
+	sum := sha256.Sum256([]byte(c.subscriptionID + "/" + c.resourceGroup))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// storageAccountName returns the storage account used by the Function Apps,
+// which only contains lowercase letters and digits.
+func (c *Client) storageAccountName() string {
+	return "raika" + c.resourceSuffix()
+}
+
+// ensureResources creates the resource group, the storage account and the
+// consumption plan shared by the Function Apps. It returns the connection
+// string of the storage account and the ID of the plan.
+func (c *Client) ensureResources() (string, string, error) {
+	resp, err := c.request(http.MethodPut, c.resourceGroupPath()+"?api-version="+resourcesAPIVersion, map[string]string{
+		"location": c.regionID,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", "", errors.Wrap(resp.ToError(), "create resource group")
+	}
+	_ = resp.Body.Close()
+
+	connectionString, err := c.ensureStorageAccount()
+	if err != nil {
+		return "", "", errors.Wrap(err, "ensure storage account")
+	}
+
+	planID, err := c.ensurePlan()
+	if err != nil {
+		return "", "", errors.Wrap(err, "ensure plan")
+	}
+	return connectionString, planID, nil
+}
+
+func (c *Client) ensureStorageAccount() (string, error) {
+	accountPath := c.resourceGroupPath() + "/providers/Microsoft.Storage/storageAccounts/" + c.storageAccountName()
+
+	resp, err := c.request(http.MethodGet, accountPath+"?api-version="+storageAPIVersion)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+
+		log.Trace("Create storage account %q...", c.storageAccountName())
+		resp, err = c.request(http.MethodPut, accountPath+"?api-version="+storageAPIVersion, map[string]interface{}{
+			"location": c.regionID,
+			"kind":     "StorageV2",
+			"sku":      map[string]string{"name": "Standard_LRS"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+			return "", errors.Wrap(resp.ToError(), "create storage account")
+		}
+		_ = resp.Body.Close()
+
+		if err := c.waitProvisioned(accountPath + "?api-version=" + storageAPIVersion); err != nil {
+			return "", errors.Wrap(err, "wait storage account")
+		}
+	} else if resp.StatusCode != http.StatusOK {
+		return "", errors.Wrap(resp.ToError(), "get storage account")
+	} else {
+		_ = resp.Body.Close()
+	}
+
+	resp, err = c.request(http.MethodPost, accountPath+"/listKeys?api-version="+storageAPIVersion)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Wrap(resp.ToError(), "list keys")
+	}
+	var respJSON struct {
+		Keys []struct {
+			Value string `json:"value"`
+		} `json:"keys"`
+	}
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "json decode")
+	}
+	if len(respJSON.Keys) == 0 {
+		return "", errors.New("storage account has no keys")
+	}
+
+	return fmt.Sprintf("DefaultEndpointsProtocol=https;AccountName=%s;AccountKey=%s;EndpointSuffix=core.windows.net",
+		c.storageAccountName(), respJSON.Keys[0].Value), nil
+}
+
+func (c *Client) ensurePlan() (string, error) {
+	planPath := c.resourceGroupPath() + "/providers/Microsoft.Web/serverfarms/" + planName
+	resp, err := c.request(http.MethodPut, planPath+"?api-version="+webAPIVersion, map[string]interface{}{
+		"location": c.regionID,
+		"kind":     "functionapp",
+		"sku": map[string]string{
+			"name": "Y1",
+			"tier": "Dynamic",
+		},
+		"properties": map[string]interface{}{
+			// Linux plan.
+			"reserved": true,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", errors.Wrap(resp.ToError(), "create plan")
+	}
+
+	var respJSON struct {
+		ID string `json:"id"`
+	}
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "json decode")
+	}
+	return respJSON.ID, nil
+}
+
+// waitProvisioned waits until the provisioning state of the resource is succeeded.
+func (c *Client) waitProvisioned(path string) error {
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		resp, err := c.request(http.MethodGet, path)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return resp.ToError()
+		}
+		var respJSON struct {
+			Properties struct {
+				ProvisioningState string `json:"provisioningState"`
+			} `json:"properties"`
+		}
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return errors.Wrap(err, "json decode")
+		}
+
+		switch respJSON.Properties.ProvisioningState {
+		case "Succeeded":
+			return nil
+		case "Failed", "Canceled":
+			return errors.Errorf("provisioning %s", respJSON.Properties.ProvisioningState)
+		}
+		time.Sleep(3 * time.Second)
+	}
+	return errors.New("provisioning timed out")
+}
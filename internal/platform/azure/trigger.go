@@ -0,0 +1,185 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+// The function name of Azure Functions must start with a letter, and it is
+// also used in the app setting names.
+var triggerNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// checkTrigger checks the triggers before deploying the function.
+func checkTrigger(opts platform.CreateFunctionOptions) error {
+	if err := platform.ValidateTriggers(opts.Triggers); err != nil {
+		return err
+	}
+	for _, trigger := range opts.Triggers {
+		if !triggerNameRegexp.MatchString(trigger.Name) {
+			return errors.Errorf("trigger %q: the name must start with a letter and contain only letters, digits and `_` on azure", trigger.Name)
+		}
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+		if trigger.Payload != "" {
+			return errors.Errorf("trigger %q: payload is not supported on azure", trigger.Name)
+		}
+		if _, err := toSchedule(trigger.Cron); err != nil {
+			return errors.Wrapf(err, "trigger %q", trigger.Name)
+		}
+	}
+	return nil
+}
+
+// toSchedule converts the cron expression to the NCRONTAB schedule, which
+// has the second field as well.
+func toSchedule(expr string) (string, error) {
+	fields, err := platform.ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(strings.Join(fields, " "), "?", "*"), nil
+}
+
+type binding map[string]interface{}
+
+// functionJSON returns the `function.json` of the trigger.
+func functionJSON(trigger platform.TriggerSpec) ([]byte, error) {
+	var bindings []binding
+	switch trigger.Type {
+	case platform.HTTPTrigger:
+		bindings = []binding{
+			{
+				"type":      "httpTrigger",
+				"direction": "in",
+				"name":      "req",
+				"authLevel": "anonymous",
+				"methods":   []string{"get", "post", "put", "patch", "delete", "head", "options"},
+				"route":     "{*path}",
+			},
+			{
+				"type":      "http",
+				"direction": "out",
+				"name":      "res",
+			},
+		}
+	case platform.CronTrigger:
+		schedule, err := toSchedule(trigger.Cron)
+		if err != nil {
+			return nil, err
+		}
+		bindings = []binding{
+			{
+				"type":      "timerTrigger",
+				"direction": "in",
+				"name":      "timer",
+				"schedule":  schedule,
+			},
+		}
+	default:
+		return nil, errors.Errorf("unexpected trigger type %q", trigger.Type)
+	}
+	return json.MarshalIndent(map[string]interface{}{"bindings": bindings}, "", "  ")
+}
+
+// hostJSON returns the `host.json` of the custom handler. The HTTP requests
+// are forwarded to the binary as is, it listens on the port given by the
+// `FUNCTIONS_CUSTOMHANDLER_PORT` environment variable.
+func hostJSON(opts platform.CreateFunctionOptions) ([]byte, error) {
+	host := map[string]interface{}{
+		"version": "2.0",
+		"customHandler": map[string]interface{}{
+			"description": map[string]interface{}{
+				"defaultExecutablePath": "bootstrap",
+			},
+			"enableForwardingHttpRequest": true,
+		},
+		"extensionBundle": map[string]string{
+			"id":      "Microsoft.Azure.Functions.ExtensionBundle",
+			"version": "[3.*, 4.0.0)",
+		},
+		"extensions": map[string]interface{}{
+			"http": map[string]string{
+				// Serve the HTTP trigger on the root path.
+				"routePrefix": "",
+			},
+		},
+	}
+	if opts.RuntimeTimeout > 0 {
+		timeout := opts.RuntimeTimeout.Round(time.Second)
+		host["functionTimeout"] = fmt.Sprintf("%02d:%02d:%02d",
+			int(timeout.Hours()), int(timeout.Minutes())%60, int(timeout.Seconds())%60)
+	}
+	return json.MarshalIndent(host, "", "  ")
+}
+
+// packFile packs the binary with the `host.json`, and a `function.json` in
+// the directory of each trigger.
+func packFile(opts platform.CreateFunctionOptions) ([]byte, error) {
+	host, err := hostJSON(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode host.json")
+	}
+	entries := []pack.Entry{{Name: "host.json", Mode: 0644, Data: host}}
+
+	for _, trigger := range opts.Triggers {
+		function, err := functionJSON(trigger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encode function.json of %q", trigger.Name)
+		}
+		entries = append(entries, pack.Entry{Name: trigger.Name + "/function.json", Mode: 0644, Data: function})
+	}
+	return pack.Zip(opts, entries...)
+}
+
+// disabledSetting returns the app setting which disables the function of the trigger.
+func disabledSetting(triggerName string) string {
+	return "AzureWebJobs." + triggerName + ".Disabled"
+}
+
+// RemoveTrigger disables the function of the trigger in the Function App, the
+// function is removed from the package on the next deployment.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	site, err := c.getSite(functionName)
+	if err != nil {
+		return err
+	}
+
+	settings, err := c.listAppSettings(functionName)
+	if err != nil {
+		return errors.Wrap(err, "list app settings")
+	}
+	settings[disabledSetting(triggerName)] = "true"
+	if err := c.updateAppSettings(functionName, settings); err != nil {
+		return errors.Wrap(err, "update app settings")
+	}
+
+	if site.Tags[httpTriggerTag] != triggerName {
+		return nil
+	}
+	delete(site.Tags, httpTriggerTag)
+	resp, err := c.request(http.MethodPatch, c.sitePath(functionName)+"?api-version="+webAPIVersion, map[string]interface{}{
+		"tags": site.Tags,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "update tags")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"github.com/pkg/errors"
+)
+
+// PublishVersion is not supported, the Function Apps on the consumption plan have no versions.
+func (c *Client) PublishVersion(string, string) (string, error) {
+	return "", errors.New("versions are not supported on azure")
+}
+
+func (c *Client) UpdateAlias(string, string, string) error {
+	return errors.New("aliases are not supported on azure")
+}
@@ -5,6 +5,7 @@
 package platform
 
 import (
+	"strings"
 	"time"
 )
 
@@ -16,8 +17,137 @@ type CreateFunctionOptions struct {
 	InitializationTimeout time.Duration
 	RuntimeTimeout        time.Duration
 	File                  string
+	// Package is the directory of the files packed with the binary, e.g. the
+	// templates and the certificates. It is nil if only the binary is packed.
+	Package *PackageSpec
+	// Image is the container image reference, the container platforms deploy
+	// it instead of building an image from the binary if it is set.
+	Image string
 
-	TriggerType string
-	CronString  string
-	HTTPPort    int
+	// Triggers are created or updated on the platform, the other triggers
+	// under the function are kept.
+	Triggers []TriggerSpec
+	HTTPPort int
+
+	// Alias is the alias the triggers are bound to. A new version is published
+	// and the alias is pointed to it on each deployment if it is set.
+	Alias string
+}
+
+// PackageSpec describes the files in the directory packed with the binary.
+type PackageSpec struct {
+	Dir string `json:"dir" yaml:"dir"`
+	// Include are the globs of the files to pack, all the files are packed if
+	// it is empty.
+	Include []string `json:"include,omitempty" yaml:"include"`
+	// Exclude are the globs of the files not to pack, the ones in the
+	// `.raikaignore` file of the directory are also excluded.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude"`
+}
+
+// FunctionInfo contains the live information of a function on the cloud platform.
+type FunctionInfo struct {
+	Name string
+	// Namespace is the service or the namespace the function belongs to on
+	// the platform, it is empty if the platform has no such concept.
+	Namespace             string
+	Description           string
+	Status                string
+	Active                bool
+	MemorySize            int64
+	EnvironmentVariables  map[string]string
+	InitializationTimeout time.Duration
+	RuntimeTimeout        time.Duration
+	// HTTPPort is the port the function binary listens on, it is zero if the
+	// platform does not report it.
+	HTTPPort     int
+	CodeChecksum string
+	UpdatedAt    time.Time
+	// URL is the HTTP trigger URL, it is only set when listing the functions.
+	URL string
+}
+
+// InvokeResponse contains the result of a function invocation.
+type InvokeResponse struct {
+	StatusCode int
+	Body       []byte
+	// Error is the error reported by the function runtime, it is empty if
+	// the invocation succeeded.
+	Error string
+}
+
+// LogOptions contains the options for fetching the function logs.
+type LogOptions struct {
+	// Since is the start time of the logs, inclusive.
+	Since time.Time
+	// Until is the end time of the logs, defaults to now.
+	Until time.Time
+}
+
+// LogEntry is a log line printed by the function.
+type LogEntry struct {
+	// ID identifies the log line on the platform, e.g. the event ID of
+	// CloudWatch Logs. It is empty if the platform has no such ID.
+	ID      string
+	Time    time.Time
+	Message string
+}
+
+// Deployment is the result of deploying a function.
+type Deployment struct {
+	// URL is the HTTP trigger URL, it is empty if the function has no HTTP trigger.
+	URL string
+	// Version is the version published for the alias, it is empty if the
+	// function is deployed without alias.
+	Version string
+	// Skipped are the update steps skipped as the function already matches,
+	// e.g. SkippedCodeUpload.
+	Skipped []string
+}
+
+// The update steps skipped by the platforms.
+const (
+	SkippedCodeUpload          = "code upload"
+	SkippedConfigurationUpdate = "configuration update"
+)
+
+// CodeChecksumPlatform is implemented by the platforms which report the
+// CodeChecksum of the functions, so that the code changes are told before
+// deploying.
+type CodeChecksumPlatform interface {
+	// CodeChecksum returns the checksum of the code package built from the
+	// options, in the format of the CodeChecksum reported by the platform.
+	CodeChecksum(opts CreateFunctionOptions) (string, error)
+}
+
+// SplitName splits the name of the imported function into its namespace and
+// the function name, e.g. "my-service/hello". The default namespace is
+// returned if the name has no namespace.
+func SplitName(name, defaultNamespace string) (namespace, function string) {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return defaultNamespace, name
+}
+
+// ConfigurationChanged reports whether the configuration of the function on the
+// platform differs from the options. The initialization timeout is only
+// compared if the platform has it.
+func ConfigurationChanged(info *FunctionInfo, opts CreateFunctionOptions) bool {
+	if info.Description != opts.Description ||
+		info.MemorySize != opts.MemorySize ||
+		info.RuntimeTimeout != opts.RuntimeTimeout ||
+		(info.InitializationTimeout != 0 && info.InitializationTimeout != opts.InitializationTimeout) {
+		return true
+	}
+
+	if len(info.EnvironmentVariables) != len(opts.EnvironmentVariables) {
+		return true
+	}
+	for k, v := range opts.EnvironmentVariables {
+		if live, ok := info.EnvironmentVariables[k]; !ok || live != v {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,323 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+type Client struct {
+	id                  string
+	regionID, projectID string
+	credentials         string
+	httpClient          *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		id:          opts["id"],
+		regionID:    opts[RegionIDField],
+		projectID:   opts[ProjectIDField],
+		credentials: opts[CredentialsField],
+		httpClient:  httpClient,
+	}, nil
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.GCP
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+func (c *Client) Authenticate() error {
+	_, err := c.accessToken()
+	return err
+}
+
+// ServiceAccount is the service account JSON key.
+type ServiceAccount struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseServiceAccount parses the service account JSON key.
+func ParseServiceAccount(credentials string) (*ServiceAccount, error) {
+	var account ServiceAccount
+	if err := json.Unmarshal([]byte(credentials), &account); err != nil {
+		return nil, errors.Wrap(err, "parse service account key")
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("invalid service account key: client_email and private_key are required")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &account, nil
+}
+
+// accessToken returns the OAuth 2.0 access token of the service account,
+// it is exchanged with a signed JWT and cached until it expires.
+func (c *Client) accessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	token, err := c.exchangeJWT(map[string]interface{}{"scope": scope})
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token.AccessToken
+	// Refresh the token a minute before it expires.
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// identityToken returns the OpenID Connect token of the service account for
+// the audience, it is used to call the private services.
+func (c *Client) identityToken(audience string) (string, error) {
+	token, err := c.exchangeJWT(map[string]interface{}{"target_audience": audience})
+	if err != nil {
+		return "", err
+	}
+	return token.IDToken, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// exchangeJWT signs a JWT with the given claims by the service account key,
+// and exchanges it for the token.
+func (c *Client) exchangeJWT(claims map[string]interface{}) (*tokenResponse, error) {
+	account, err := ParseServiceAccount(c.credentials)
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := signJWT(account, claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign JWT")
+	}
+
+	resp, err := c.httpClient.PostForm(account.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "request token")
+	}
+	r := &response{Response: resp}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("request token: %s", r.ToString())
+	}
+
+	var token tokenResponse
+	if err := r.ToJSON(&token); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &token, nil
+}
+
+func signJWT(account *ServiceAccount, claims map[string]interface{}) (string, error) {
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", errors.Wrap(err, "parse private key")
+		}
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("private key is not RSA")
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": account.PrivateKeyID,
+	})
+	claims["iss"] = account.ClientEmail
+	claims["aud"] = account.TokenURI
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// request sends the API request with the access token, the URL is absolute.
+func (c *Client) request(method, u string, requestBody ...interface{}) (*response, error) {
+	token, err := c.accessToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "get access token")
+	}
+
+	var body io.Reader
+	if len(requestBody) == 1 {
+		reqBody, err := json.Marshal(requestBody[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "JSON encode")
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+
+	return &response{
+		Response: resp,
+	}, nil
+}
+
+// apiError is the error returned by the Google APIs.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Code, e.Status, e.Message)
+}
+
+// Operation is a long-running operation.
+type Operation struct {
+	Name  string    `json:"name"`
+	Done  bool      `json:"done"`
+	Error *apiError `json:"error"`
+}
+
+// waitOperation waits until the long-running operation is done, the base URL
+// is the API endpoint with version, e.g. `https://run.googleapis.com/v2`.
+func (c *Client) waitOperation(baseURL string, operation *Operation) error {
+	deadline := time.Now().Add(10 * time.Minute)
+	for !operation.Done {
+		if time.Now().After(deadline) {
+			return errors.Errorf("operation %q timed out", operation.Name)
+		}
+		time.Sleep(2 * time.Second)
+
+		resp, err := c.request(http.MethodGet, baseURL+"/"+operation.Name)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return resp.ToError()
+		}
+		operation = new(Operation)
+		if err := resp.ToJSON(operation); err != nil {
+			return errors.Wrap(err, "json decode")
+		}
+	}
+
+	if operation.Error != nil {
+		return operation.Error
+	}
+	return nil
+}
+
+type response struct {
+	*http.Response
+}
+
+func (r *response) ToJSON(v interface{}) error {
+	defer func() { _ = r.Body.Close() }()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (r *response) ToString() string {
+	defer func() { _ = r.Body.Close() }()
+	resp, _ := io.ReadAll(r.Body)
+	return string(resp)
+}
+
+// ToError returns the error in the response body.
+func (r *response) ToError() error {
+	body := r.ToString()
+	var respJSON struct {
+		Error *apiError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &respJSON); err != nil || respJSON.Error == nil {
+		return errors.Errorf("%d: %s", r.StatusCode, strings.TrimSpace(body))
+	}
+	return respJSON.Error
+}
+
+// functionError returns platform.ErrFunctionNotExists if the service is not found.
+func (r *response) functionError() error {
+	if r.StatusCode == http.StatusNotFound {
+		_ = r.Body.Close()
+		return platform.ErrFunctionNotExists
+	}
+	return r.ToError()
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+const (
+	RegionIDField  = "region_id"
+	ProjectIDField = "project_id"
+	// CredentialsField is the content of the service account JSON key.
+	CredentialsField = "credentials"
+)
+
+// RepositoryName is the Artifact Registry repository the function images are pushed to.
+const RepositoryName = "raika"
+
+// functionNameAnnotation is the service annotation which keeps the function
+// name, as the service name can't contain `_`.
+const functionNameAnnotation = "raika/function-name"
+
+const scope = "https://www.googleapis.com/auth/cloud-platform"
+
+// httpTriggerAnnotation is the service annotation which keeps the name of the
+// HTTP trigger, the service is public if it is set.
+const httpTriggerAnnotation = "raika/http-trigger"
@@ -0,0 +1,437 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const cloudRunAPI = "https://run.googleapis.com/v2"
+
+const defaultPort = 8080
+
+// serviceName returns the Cloud Run service name of the function, it only
+// contains lowercase letters, digits and hyphens.
+func serviceName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+func (c *Client) locationPath() string {
+	return fmt.Sprintf("projects/%s/locations/%s", c.projectID, c.regionID)
+}
+
+// servicePath returns the resource name of the function service.
+func (c *Client) servicePath(name string) string {
+	return c.locationPath() + "/services/" + serviceName(name)
+}
+
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ContainerPort struct {
+	ContainerPort int `json:"containerPort"`
+}
+
+type ResourceRequirements struct {
+	Limits map[string]string `json:"limits,omitempty"`
+}
+
+type Container struct {
+	Image     string               `json:"image"`
+	Env       []EnvVar             `json:"env,omitempty"`
+	Ports     []ContainerPort      `json:"ports,omitempty"`
+	Resources ResourceRequirements `json:"resources"`
+}
+
+type RevisionTemplate struct {
+	Timeout    string      `json:"timeout,omitempty"`
+	Containers []Container `json:"containers"`
+}
+
+const (
+	trafficLatest   = "TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST"
+	trafficRevision = "TRAFFIC_TARGET_ALLOCATION_TYPE_REVISION"
+)
+
+type TrafficTarget struct {
+	Type     string `json:"type"`
+	Revision string `json:"revision,omitempty"`
+	Percent  int    `json:"percent"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+type TrafficTargetStatus struct {
+	TrafficTarget
+	URI string `json:"uri"`
+}
+
+type Condition struct {
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+type Service struct {
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Ingress     string            `json:"ingress,omitempty"`
+	Template    RevisionTemplate  `json:"template"`
+	Traffic     []TrafficTarget   `json:"traffic,omitempty"`
+
+	// The following fields are output only.
+	URI                 string                `json:"uri,omitempty"`
+	UpdateTime          string                `json:"updateTime,omitempty"`
+	LatestReadyRevision string                `json:"latestReadyRevision,omitempty"`
+	TerminalCondition   *Condition            `json:"terminalCondition,omitempty"`
+	TrafficStatuses     []TrafficTargetStatus `json:"trafficStatuses,omitempty"`
+}
+
+// functionName returns the function name kept in the service annotations.
+func (s *Service) functionName() string {
+	if name := s.Annotations[functionNameAnnotation]; name != "" {
+		return name
+	}
+	return s.Name[strings.LastIndex(s.Name, "/")+1:]
+}
+
+// tagURI returns the URL of the traffic tag, it is empty if the tag does not exist.
+func (s *Service) tagURI(tag string) string {
+	for _, status := range s.TrafficStatuses {
+		if status.Tag == tag {
+			return status.URI
+		}
+	}
+	return ""
+}
+
+// newService returns the service of the function with the image. The existing
+// traffic tags are kept, the untagged traffic is sent to the latest revision.
+func newService(opts platform.CreateFunctionOptions, image string, current *Service) *Service {
+	environment := make([]EnvVar, 0, len(opts.EnvironmentVariables))
+	for k, v := range opts.EnvironmentVariables {
+		environment = append(environment, EnvVar{Name: k, Value: v})
+	}
+	port := opts.HTTPPort
+	if port == 0 {
+		port = defaultPort
+	}
+
+	annotations := map[string]string{
+		functionNameAnnotation: opts.Name,
+	}
+	traffic := []TrafficTarget{{Type: trafficLatest, Percent: 100}}
+	if current != nil {
+		for k, v := range current.Annotations {
+			// The system annotations are rejected by the API.
+			if _, ok := annotations[k]; !ok && !strings.Contains(k, "googleapis.com/") {
+				annotations[k] = v
+			}
+		}
+		for _, target := range current.Traffic {
+			if target.Tag != "" {
+				target.Percent = 0
+				traffic = append(traffic, target)
+			}
+		}
+	}
+
+	service := &Service{
+		Description: opts.Description,
+		Annotations: annotations,
+		Ingress:     "INGRESS_TRAFFIC_ALL",
+		Template: RevisionTemplate{
+			Containers: []Container{{
+				Image: image,
+				Env:   environment,
+				Ports: []ContainerPort{{ContainerPort: port}},
+				Resources: ResourceRequirements{
+					Limits: map[string]string{"memory": strconv.FormatInt(opts.MemorySize, 10) + "Mi"},
+				},
+			}},
+		},
+		Traffic: traffic,
+	}
+	if opts.RuntimeTimeout > 0 {
+		service.Template.Timeout = strconv.Itoa(int(opts.RuntimeTimeout/time.Second)) + "s"
+	}
+	return service
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	// Check current function name exists.
+	_, err := c.getService(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on gcp, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	image, err := c.pushImage(serviceName(opts.Name), opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "push image")
+	}
+
+	log.Trace("Deploy service %q...", serviceName(opts.Name))
+	resp, err := c.request(http.MethodPost, cloudRunAPI+"/"+c.locationPath()+"/services?serviceId="+serviceName(opts.Name), newService(opts, image, nil))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.ToError(), "create service")
+	}
+	var operation Operation
+	if err := resp.ToJSON(&operation); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	if err := c.waitOperation(cloudRunAPI, &operation); err != nil {
+		return nil, errors.Wrap(err, "wait service ready")
+	}
+
+	return c.release(opts)
+}
+
+// UpdateFunction deploys a new revision of the service with the new image and
+// configuration. The triggers and the service URL are kept.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	current, err := c.getService(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	image, err := c.pushImage(serviceName(opts.Name), opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "push image")
+	}
+
+	log.Trace("Update service %q...", serviceName(opts.Name))
+	if err := c.patchService(opts.Name, newService(opts, image, current)); err != nil {
+		return nil, errors.Wrap(err, "update service")
+	}
+	return c.release(opts)
+}
+
+// release points the alias to the latest revision if the alias is set, and
+// ensures the triggers of the function.
+func (c *Client) release(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
+	}
+
+	service, err := c.getService(opts.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get service")
+	}
+	uri := service.URI
+	if opts.Alias != "" {
+		uri = service.tagURI(opts.Alias)
+	}
+
+	triggerURL, err := c.ensureTriggers(opts, service, uri)
+	if err != nil {
+		return nil, err
+	}
+	deployment.URL = triggerURL
+	return deployment, nil
+}
+
+func (c *Client) getService(name string) (*Service, error) {
+	resp, err := c.request(http.MethodGet, cloudRunAPI+"/"+c.servicePath(name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var service Service
+	if err := resp.ToJSON(&service); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &service, nil
+}
+
+// patchService replaces the service and waits until the new revision is ready.
+func (c *Client) patchService(name string, service *Service) error {
+	resp, err := c.request(http.MethodPatch, cloudRunAPI+"/"+c.servicePath(name), service)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.functionError()
+	}
+	var operation Operation
+	if err := resp.ToJSON(&operation); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+	return c.waitOperation(cloudRunAPI, &operation)
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(service), nil
+}
+
+type ListServicesResponse struct {
+	Services      []*Service `json:"services"`
+	NextPageToken string     `json:"nextPageToken"`
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var functions []*platform.FunctionInfo
+	pageToken := ""
+	for {
+		resp, err := c.request(http.MethodGet, cloudRunAPI+"/"+c.locationPath()+"/services?pageToken="+url.QueryEscape(pageToken))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "list services")
+		}
+
+		var respJSON ListServicesResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, service := range respJSON.Services {
+			info := toFunctionInfo(service)
+			info.URL = service.URI
+			functions = append(functions, info)
+		}
+
+		if respJSON.NextPageToken == "" {
+			return functions, nil
+		}
+		pageToken = respJSON.NextPageToken
+	}
+}
+
+func toFunctionInfo(service *Service) *platform.FunctionInfo {
+	info := &platform.FunctionInfo{
+		Name:                 service.functionName(),
+		Description:          service.Description,
+		Status:               "Unknown",
+		EnvironmentVariables: make(map[string]string),
+	}
+	if service.TerminalCondition != nil {
+		info.Status = service.TerminalCondition.State
+		info.Active = service.TerminalCondition.State == "CONDITION_SUCCEEDED"
+	}
+	info.UpdatedAt, _ = time.Parse(time.RFC3339Nano, service.UpdateTime)
+	info.RuntimeTimeout, _ = time.ParseDuration(service.Template.Timeout)
+
+	if len(service.Template.Containers) > 0 {
+		container := service.Template.Containers[0]
+		for _, env := range container.Env {
+			info.EnvironmentVariables[env.Name] = env.Value
+		}
+		memory := strings.TrimSuffix(container.Resources.Limits["memory"], "Mi")
+		info.MemorySize, _ = strconv.ParseInt(memory, 10, 64)
+		// The image is referenced by its digest.
+		if i := strings.LastIndex(container.Image, "@"); i != -1 {
+			info.CodeChecksum = container.Image[i+1:]
+		}
+	}
+	return info
+}
+
+// Invoke posts the payload to the service URL with the identity token of the
+// service account, so the private services can be invoked as well.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.identityToken(service.URI)
+	if err != nil {
+		return nil, errors.Wrap(err, "get identity token")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, service.URI, bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+// DeleteFunction deletes the scheduler jobs of the function, then the service with all its revisions.
+func (c *Client) DeleteFunction(name string) error {
+	if _, err := c.getService(name); err != nil {
+		return err
+	}
+
+	jobs, err := c.listJobs(name)
+	if err != nil {
+		return errors.Wrap(err, "list jobs")
+	}
+	for _, job := range jobs {
+		log.Trace("Delete scheduler job %q...", job.Name)
+		if err := c.deleteJob(job.Name); err != nil {
+			return errors.Wrapf(err, "delete job: %q", job.Name)
+		}
+	}
+
+	log.Trace("Delete service %q...", serviceName(name))
+	resp, err := c.request(http.MethodDelete, cloudRunAPI+"/"+c.servicePath(name))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.functionError()
+	}
+	var operation Operation
+	if err := resp.ToJSON(&operation); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+	return c.waitOperation(cloudRunAPI, &operation)
+}
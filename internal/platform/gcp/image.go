@@ -0,0 +1,89 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+	"github.com/wuhan005/Raika/internal/platform/registry"
+)
+
+const artifactRegistryAPI = "https://artifactregistry.googleapis.com/v1"
+
+// registryHost returns the Artifact Registry Docker host of the region.
+func (c *Client) registryHost() string {
+	return c.regionID + "-docker.pkg.dev"
+}
+
+// ensureRepository creates the Docker repository for the function images if it does not exist.
+func (c *Client) ensureRepository() error {
+	parent := fmt.Sprintf("projects/%s/locations/%s", c.projectID, c.regionID)
+	resp, err := c.request(http.MethodGet, artifactRegistryAPI+"/"+parent+"/repositories/"+RepositoryName)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		_ = resp.Body.Close()
+		return nil
+	} else if resp.StatusCode != http.StatusNotFound {
+		return errors.Wrap(resp.ToError(), "get repository")
+	}
+	_ = resp.Body.Close()
+
+	log.Trace("Create repository %q...", RepositoryName)
+	resp, err = c.request(http.MethodPost, artifactRegistryAPI+"/"+parent+"/repositories?repositoryId="+RepositoryName, map[string]string{
+		"format":      "DOCKER",
+		"description": "Raika function images",
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "create repository")
+	}
+	var operation Operation
+	if err := resp.ToJSON(&operation); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+	return c.waitOperation(artifactRegistryAPI, &operation)
+}
+
+// pushImage builds a single layer image of the function package which runs the
+// binary as `/bootstrap`, pushes it to the Artifact Registry, and returns the image
+// reference with its digest.
+func (c *Client) pushImage(name string, opts platform.CreateFunctionOptions) (string, error) {
+	if err := c.ensureRepository(); err != nil {
+		return "", errors.Wrap(err, "ensure repository")
+	}
+
+	entries, err := pack.Entries(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "list package entries")
+	}
+	image, err := registry.Build(entries)
+	if err != nil {
+		return "", errors.Wrap(err, "build image")
+	}
+	accessToken, err := c.accessToken()
+	if err != nil {
+		return "", errors.Wrap(err, "get access token")
+	}
+
+	log.Trace("Push image of %q...", name)
+	client := &registry.Client{
+		Host:     c.registryHost(),
+		Username: "oauth2accesstoken",
+		Password: accessToken,
+
+		HTTPClient: c.httpClient,
+	}
+	return client.Push(fmt.Sprintf("%s/%s/%s", c.projectID, RepositoryName, name), "latest", image)
+}
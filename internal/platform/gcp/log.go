@@ -0,0 +1,98 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const cloudLoggingAPI = "https://logging.googleapis.com/v2"
+
+const logPageSize = 1000
+
+type ListLogEntriesRequest struct {
+	ResourceNames []string `json:"resourceNames"`
+	Filter        string   `json:"filter"`
+	OrderBy       string   `json:"orderBy"`
+	PageSize      int      `json:"pageSize"`
+	PageToken     string   `json:"pageToken,omitempty"`
+}
+
+type ListLogEntriesResponse struct {
+	Entries []struct {
+		InsertID    string          `json:"insertId"`
+		Timestamp   string          `json:"timestamp"`
+		TextPayload string          `json:"textPayload"`
+		JSONPayload json.RawMessage `json:"jsonPayload"`
+	} `json:"entries"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// Logs returns the stdout and stderr of the service from Cloud Logging.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	if _, err := c.getService(name); err != nil {
+		return nil, err
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	filter := strings.Join([]string{
+		`resource.type="cloud_run_revision"`,
+		fmt.Sprintf(`resource.labels.service_name=%q`, serviceName(name)),
+		`(log_id("run.googleapis.com/stdout") OR log_id("run.googleapis.com/stderr"))`,
+		fmt.Sprintf(`timestamp>=%q`, opts.Since.UTC().Format(time.RFC3339Nano)),
+		fmt.Sprintf(`timestamp<=%q`, until.UTC().Format(time.RFC3339Nano)),
+	}, " AND ")
+
+	var entries []*platform.LogEntry
+	pageToken := ""
+	for {
+		resp, err := c.request(http.MethodPost, cloudLoggingAPI+"/entries:list", ListLogEntriesRequest{
+			ResourceNames: []string{"projects/" + c.projectID},
+			Filter:        filter,
+			OrderBy:       "timestamp asc",
+			PageSize:      logPageSize,
+			PageToken:     pageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "list log entries")
+		}
+
+		var respJSON ListLogEntriesResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, entry := range respJSON.Entries {
+			t, _ := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			message := entry.TextPayload
+			if message == "" && len(entry.JSONPayload) > 0 {
+				message = string(entry.JSONPayload)
+			}
+			entries = append(entries, &platform.LogEntry{
+				ID:      entry.InsertID,
+				Time:    t,
+				Message: strings.TrimRight(message, "\n"),
+			})
+		}
+
+		if respJSON.NextPageToken == "" {
+			return entries, nil
+		}
+		pageToken = respJSON.NextPageToken
+	}
+}
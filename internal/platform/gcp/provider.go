@@ -0,0 +1,38 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.GCP,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. us-central1", Required: true},
+			{Name: ProjectIDField, Usage: "Project ID, defaults to the project of the service account"},
+			{Name: CredentialsField, Usage: "Path of the service account JSON key", Required: true, Secret: true, File: true},
+		}, platform.NetworkFields...),
+		Prepare: func(opts platform.AuthenticateOptions) error {
+			account, err := ParseServiceAccount(opts[CredentialsField])
+			if err != nil {
+				return err
+			}
+			// The project of the service account is used by default.
+			if opts[ProjectIDField] == "" {
+				opts[ProjectIDField] = account.ProjectID
+			}
+			return nil
+		},
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[ProjectIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
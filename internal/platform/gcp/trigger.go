@@ -0,0 +1,294 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const cloudSchedulerAPI = "https://cloudscheduler.googleapis.com/v1"
+
+const invokerRole = "roles/run.invoker"
+
+// checkTrigger checks the triggers before deploying the function.
+func checkTrigger(opts platform.CreateFunctionOptions) error {
+	if err := platform.ValidateTriggers(opts.Triggers); err != nil {
+		return err
+	}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.CronTrigger {
+			if _, err := toSchedule(trigger.Cron); err != nil {
+				return errors.Wrapf(err, "trigger %q", trigger.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// toSchedule converts the cron expression with seconds to the unix-cron
+// schedule of Cloud Scheduler, which runs at most once per minute.
+func toSchedule(expr string) (string, error) {
+	fields, err := platform.ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	if fields[0] != "0" {
+		return "", errors.Errorf("cron %q: the second field must be 0 on gcp", expr)
+	}
+	return strings.ReplaceAll(strings.Join(fields[1:], " "), "?", "*"), nil
+}
+
+// jobID returns the scheduler job ID of the trigger. The service name contains
+// no `_`, so the jobs of different functions won't collide.
+func jobID(functionName, triggerName string) string {
+	return serviceName(functionName) + "_" + triggerName
+}
+
+func (c *Client) jobPath(functionName, triggerName string) string {
+	return c.locationPath() + "/jobs/" + jobID(functionName, triggerName)
+}
+
+// ensureTriggers makes the service public for the HTTP trigger, and creates or
+// updates the scheduler jobs which post to the URI for the cron triggers. It
+// returns the HTTP trigger URL.
+func (c *Client) ensureTriggers(opts platform.CreateFunctionOptions, service *Service, uri string) (string, error) {
+	var triggerURL string
+	for _, trigger := range opts.Triggers {
+		switch trigger.Type {
+		case platform.HTTPTrigger:
+			log.Trace("Allow public access to %q...", serviceName(opts.Name))
+			if err := c.setPublic(opts.Name, true); err != nil {
+				return "", errors.Wrap(err, "set public access")
+			}
+			if service.Annotations[httpTriggerAnnotation] != trigger.Name {
+				if service.Annotations == nil {
+					service.Annotations = make(map[string]string)
+				}
+				service.Annotations[httpTriggerAnnotation] = trigger.Name
+				if err := c.patchService(opts.Name, service); err != nil {
+					return "", errors.Wrap(err, "update service")
+				}
+			}
+			triggerURL = uri
+
+		case platform.CronTrigger:
+			log.Trace("Put scheduler job of trigger %q...", trigger.Name)
+			if err := c.putJob(opts.Name, trigger, uri); err != nil {
+				return "", errors.Wrapf(err, "put job of trigger %q", trigger.Name)
+			}
+		}
+	}
+	return triggerURL, nil
+}
+
+type OIDCToken struct {
+	ServiceAccountEmail string `json:"serviceAccountEmail"`
+	Audience            string `json:"audience"`
+}
+
+type HTTPTarget struct {
+	URI        string            `json:"uri"`
+	HTTPMethod string            `json:"httpMethod"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// Body is encoded in base64.
+	Body      string    `json:"body,omitempty"`
+	OIDCToken OIDCToken `json:"oidcToken"`
+}
+
+type Job struct {
+	Name       string     `json:"name"`
+	Schedule   string     `json:"schedule"`
+	TimeZone   string     `json:"timeZone"`
+	HTTPTarget HTTPTarget `json:"httpTarget"`
+}
+
+// putJob updates the scheduler job of the cron trigger, the job is created if
+// it does not exist. The job calls the service with the identity token of the
+// service account, so it works for the private services as well.
+func (c *Client) putJob(functionName string, trigger platform.TriggerSpec, uri string) error {
+	schedule, err := toSchedule(trigger.Cron)
+	if err != nil {
+		return err
+	}
+	account, err := ParseServiceAccount(c.credentials)
+	if err != nil {
+		return err
+	}
+
+	job := Job{
+		Name:     c.jobPath(functionName, trigger.Name),
+		Schedule: schedule,
+		TimeZone: "UTC",
+		HTTPTarget: HTTPTarget{
+			URI:        uri,
+			HTTPMethod: http.MethodPost,
+			Headers:    map[string]string{"X-Raika-Trigger": trigger.Name},
+			Body:       base64.StdEncoding.EncodeToString([]byte(trigger.Payload)),
+			OIDCToken: OIDCToken{
+				ServiceAccountEmail: account.ClientEmail,
+				Audience:            strings.TrimSuffix(uri, "/"),
+			},
+		},
+	}
+
+	resp, err := c.request(http.MethodPatch, cloudSchedulerAPI+"/"+job.Name, job)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		_ = resp.Body.Close()
+		return nil
+	} else if resp.StatusCode != http.StatusNotFound {
+		return errors.Wrap(resp.ToError(), "update job")
+	}
+	_ = resp.Body.Close()
+
+	resp, err = c.request(http.MethodPost, cloudSchedulerAPI+"/"+c.locationPath()+"/jobs", job)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "create job")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+type ListJobsResponse struct {
+	Jobs          []*Job `json:"jobs"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// listJobs returns the scheduler jobs of the function.
+func (c *Client) listJobs(functionName string) ([]*Job, error) {
+	prefix := c.locationPath() + "/jobs/" + serviceName(functionName) + "_"
+
+	var jobs []*Job
+	pageToken := ""
+	for {
+		resp, err := c.request(http.MethodGet, cloudSchedulerAPI+"/"+c.locationPath()+"/jobs?pageToken="+url.QueryEscape(pageToken))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.ToError()
+		}
+
+		var respJSON ListJobsResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, job := range respJSON.Jobs {
+			if strings.HasPrefix(job.Name, prefix) {
+				jobs = append(jobs, job)
+			}
+		}
+
+		if respJSON.NextPageToken == "" {
+			return jobs, nil
+		}
+		pageToken = respJSON.NextPageToken
+	}
+}
+
+// deleteJob deletes the scheduler job with the resource name, it is ignored if the job does not exist.
+func (c *Client) deleteJob(name string) error {
+	resp, err := c.request(http.MethodDelete, cloudSchedulerAPI+"/"+name)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return resp.ToError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+type Binding struct {
+	Role    string   `json:"role"`
+	Members []string `json:"members"`
+}
+
+type Policy struct {
+	Version  int       `json:"version,omitempty"`
+	Etag     string    `json:"etag,omitempty"`
+	Bindings []Binding `json:"bindings,omitempty"`
+}
+
+// setPublic grants or revokes the invoker role of `allUsers` on the service.
+func (c *Client) setPublic(functionName string, public bool) error {
+	servicePath := cloudRunAPI + "/" + c.servicePath(functionName)
+	resp, err := c.request(http.MethodGet, servicePath+":getIamPolicy")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.functionError(), "get IAM policy")
+	}
+	var policy Policy
+	if err := resp.ToJSON(&policy); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+
+	bindings := make([]Binding, 0, len(policy.Bindings)+1)
+	for _, binding := range policy.Bindings {
+		if binding.Role != invokerRole {
+			bindings = append(bindings, binding)
+			continue
+		}
+		members := make([]string, 0, len(binding.Members))
+		for _, member := range binding.Members {
+			if member != "allUsers" {
+				members = append(members, member)
+			}
+		}
+		if len(members) > 0 {
+			bindings = append(bindings, Binding{Role: invokerRole, Members: members})
+		}
+	}
+	if public {
+		bindings = append(bindings, Binding{Role: invokerRole, Members: []string{"allUsers"}})
+	}
+	policy.Bindings = bindings
+
+	resp, err = c.request(http.MethodPost, servicePath+":setIamPolicy", map[string]interface{}{
+		"policy": policy,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "set IAM policy")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// RemoveTrigger revokes the public access of the service for the HTTP
+// trigger, or deletes the scheduler job for the cron trigger.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	service, err := c.getService(functionName)
+	if err != nil {
+		return err
+	}
+
+	if service.Annotations[httpTriggerAnnotation] != triggerName {
+		return c.deleteJob(c.jobPath(functionName, triggerName))
+	}
+
+	if err := c.setPublic(functionName, false); err != nil {
+		return errors.Wrap(err, "revoke public access")
+	}
+	delete(service.Annotations, httpTriggerAnnotation)
+	return c.patchService(functionName, service)
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+)
+
+// PublishVersion returns the latest ready revision of the service. Each
+// deployment creates an immutable revision on Cloud Run, so nothing is published.
+func (c *Client) PublishVersion(name, _ string) (string, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return "", err
+	}
+	if service.LatestReadyRevision == "" {
+		return "", errors.Errorf("service %q has no ready revision", serviceName(name))
+	}
+	return service.LatestReadyRevision[strings.LastIndex(service.LatestReadyRevision, "/")+1:], nil
+}
+
+// UpdateAlias points the traffic tag to the revision, the tag receives no
+// traffic of the service URL but has its own URL.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	log.Trace("Point tag %q of %q to revision %q...", alias, serviceName(name), version)
+	service, err := c.getService(name)
+	if err != nil {
+		return err
+	}
+
+	traffic := make([]TrafficTarget, 0, len(service.Traffic)+1)
+	for _, target := range service.Traffic {
+		if target.Tag != alias {
+			traffic = append(traffic, target)
+		}
+	}
+	service.Traffic = append(traffic, TrafficTarget{
+		Type:     trafficRevision,
+		Revision: version,
+		Tag:      alias,
+	})
+	return c.patchService(name, service)
+}
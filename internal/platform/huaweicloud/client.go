@@ -0,0 +1,168 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+type Client struct {
+	id                           string
+	regionID, projectID          string
+	accessKeyID, secretAccessKey string
+
+	// endpoint is the custom endpoint of all the services, the endpoints of
+	// the region are used if it is empty.
+	endpoint   string
+	httpClient *http.Client
+}
+
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	var endpoint string
+	if opts[platform.EndpointField] != "" {
+		u, err := platform.ParseEndpoint(opts[platform.EndpointField])
+		if err != nil {
+			return nil, err
+		}
+		endpoint = u.String()
+	}
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		id:              opts["id"],
+		regionID:        opts[RegionIDField],
+		projectID:       opts[ProjectIDField],
+		accessKeyID:     opts[AccessKeyIDField],
+		secretAccessKey: opts[SecretAccessKeyField],
+		endpoint:        endpoint,
+		httpClient:      httpClient,
+	}, nil
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.HuaweiCloud
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+func (c *Client) Authenticate() error {
+	resp, err := c.request(http.MethodGet, "/fgs/functions?maxitems=1")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.ToError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// request sends the FunctionGraph API request, the path is under `/v2/{project_id}`.
+func (c *Client) request(method, path string, requestBody ...interface{}) (*response, error) {
+	return c.requestService("functiongraph", method, fmt.Sprintf("/v2/%s%s", c.projectID, path), requestBody...)
+}
+
+// requestService sends the API request to the given Huawei Cloud service.
+func (c *Client) requestService(service, method, path string, requestBody ...interface{}) (*response, error) {
+	endpoint := c.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.myhuaweicloud.com", service, c.regionID)
+	}
+	u, err := url.Parse(endpoint + path)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse URL")
+	}
+
+	var reqBody []byte
+	if len(requestBody) == 1 {
+		reqBody, err = json.Marshal(requestBody[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "JSON encode")
+		}
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Project-Id", c.projectID)
+	c.Sign(req, reqBody)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+
+	return &response{
+		Response: resp,
+	}, nil
+}
+
+// apiError is the error returned by the API.
+type apiError struct {
+	StatusCode int
+	Code       string `json:"error_code"`
+	Message    string `json:"error_msg"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type response struct {
+	*http.Response
+}
+
+func (r *response) ToJSON(v interface{}) error {
+	defer func() { _ = r.Body.Close() }()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (r *response) ToString() string {
+	defer func() { _ = r.Body.Close() }()
+	resp, _ := io.ReadAll(r.Body)
+	return string(resp)
+}
+
+// ToError returns the error in the response body.
+func (r *response) ToError() error {
+	body := r.ToString()
+	apiErr := &apiError{StatusCode: r.StatusCode}
+	if err := json.Unmarshal([]byte(body), apiErr); err != nil || apiErr.Code == "" {
+		return errors.Errorf("%d: %s", r.StatusCode, body)
+	}
+	return apiErr
+}
+
+// functionError returns platform.ErrFunctionNotExists if the function is not found.
+func (r *response) functionError() error {
+	if r.StatusCode == http.StatusNotFound {
+		_ = r.Body.Close()
+		return platform.ErrFunctionNotExists
+	}
+	return r.ToError()
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "endpoint without path", endpoint: ""},
+		{name: "endpoint with path", endpoint: "/huaweicloud/"},
+		{name: "bad endpoint", endpoint: "ftp://127.0.0.1", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				_, _ = w.Write([]byte(`{"functions":[]}`))
+			}))
+			defer server.Close()
+
+			endpoint := test.endpoint
+			if !test.wantErr {
+				endpoint = server.URL + endpoint
+			}
+			client, err := New(platform.AuthenticateOptions{
+				RegionIDField:          "cn-north-4",
+				ProjectIDField:         "project",
+				platform.EndpointField: endpoint,
+			})
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.Authenticate(); err != nil {
+				t.Fatal(err)
+			}
+			want := "/v2/project/fgs/functions"
+			if test.endpoint != "" {
+				want = "/huaweicloud" + want
+			}
+			if gotPath != want {
+				t.Fatalf("want path %q, got %q", want, gotPath)
+			}
+		})
+	}
+}
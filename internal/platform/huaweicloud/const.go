@@ -0,0 +1,44 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+const (
+	RegionIDField        = "region_id"
+	ProjectIDField       = "project_id"
+	AccessKeyIDField     = "access_key_id"
+	SecretAccessKeyField = "secret_access_key"
+)
+
+// latestQualifier is the qualifier of the latest version of the function.
+const latestQualifier = "latest"
+
+// PackageName is the function group the functions are created in.
+const PackageName = "default"
+
+// APIGroupName is the APIG API group the HTTP triggers are created in.
+const APIGroupName = "Raika"
+
+const (
+	// functionRuntime is the runtime of the HTTP functions, which run the
+	// bootstrap of the package as an HTTP server.
+	functionRuntime = "http"
+	// httpPort is the port the HTTP functions must listen on.
+	httpPort = "8000"
+	// functionFileName is the name of the function binary in the package, the
+	// bootstrap of the package is the script which starts it.
+	functionFileName = "raika-function"
+)
+
+// bootstrapEntry is the `bootstrap` entry required by the HTTP functions,
+// which starts the binary in the code directory with the port given.
+var bootstrapEntry = pack.Entry{
+	Name: pack.BootstrapName,
+	Mode: 0755,
+	Data: []byte("#!/bin/sh\ncd /opt/function/code\nPORT=" + httpPort + " exec ./" + functionFileName + "\n"),
+}
@@ -0,0 +1,409 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+// functionURN returns the URN of the function, the qualifier is a version or
+// an alias, it is omitted if empty.
+func (c *Client) functionURN(name, qualifier string) string {
+	urn := fmt.Sprintf("urn:fss:%s:%s:function:%s:%s", c.regionID, c.projectID, PackageName, name)
+	if qualifier != "" {
+		urn += ":" + qualifier
+	}
+	return urn
+}
+
+// functionPath returns the API path of the function.
+func (c *Client) functionPath(name, qualifier string) string {
+	return "/fgs/functions/" + c.functionURN(name, qualifier)
+}
+
+type functionCode struct {
+	File string `json:"file"`
+}
+
+type CreateFunctionRequest struct {
+	Name        string       `json:"func_name"`
+	Package     string       `json:"package"`
+	Runtime     string       `json:"runtime"`
+	Handler     string       `json:"handler"`
+	MemorySize  int64        `json:"memory_size"`
+	Timeout     int          `json:"timeout"`
+	CodeType    string       `json:"code_type"`
+	Code        functionCode `json:"func_code"`
+	Description string       `json:"description"`
+	// UserData is the environment variables in JSON.
+	UserData string `json:"user_data"`
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	// Check current function name exists.
+	_, err := c.Describe(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on huaweicloud, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	zipFile, err := packFunction(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
+	}
+	userData, err := json.Marshal(opts.EnvironmentVariables)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode environment variables")
+	}
+
+	log.Trace("Deploy function %q...", opts.Name)
+	resp, err := c.request(http.MethodPost, "/fgs/functions", CreateFunctionRequest{
+		Name:        opts.Name,
+		Package:     PackageName,
+		Runtime:     functionRuntime,
+		Handler:     pack.BootstrapName,
+		MemorySize:  opts.MemorySize,
+		Timeout:     int(opts.RuntimeTimeout / time.Second),
+		CodeType:    "zip",
+		Code:        functionCode{File: base64.StdEncoding.EncodeToString(zipFile)},
+		Description: opts.Description,
+		UserData:    string(userData),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.ToError(), "create function")
+	}
+	_ = resp.Body.Close()
+
+	return c.release(opts)
+}
+
+type UpdateFunctionCodeRequest struct {
+	CodeType string       `json:"code_type"`
+	Code     functionCode `json:"func_code"`
+}
+
+type UpdateFunctionConfigRequest struct {
+	Name        string `json:"func_name"`
+	Runtime     string `json:"runtime"`
+	Handler     string `json:"handler"`
+	MemorySize  int64  `json:"memory_size"`
+	Timeout     int    `json:"timeout"`
+	Description string `json:"description"`
+	UserData    string `json:"user_data"`
+}
+
+// packFunction returns the code package of the function, the binary is started
+// by the bootstrap script.
+func packFunction(opts platform.CreateFunctionOptions) ([]byte, error) {
+	return pack.Zip(opts, bootstrapEntry, pack.Entry{Name: functionFileName, Mode: 0755, Path: opts.File})
+}
+
+// UpdateFunction updates the code and the configuration of the existing function.
+// The triggers under the function are kept, so the trigger URL won't change.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	zipFile, err := packFunction(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
+	}
+	userData, err := json.Marshal(opts.EnvironmentVariables)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode environment variables")
+	}
+
+	log.Trace("Update function code %q...", opts.Name)
+	resp, err := c.request(http.MethodPut, c.functionPath(opts.Name, "")+"/code", UpdateFunctionCodeRequest{
+		CodeType: "zip",
+		Code:     functionCode{File: base64.StdEncoding.EncodeToString(zipFile)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.functionError(), "update function code")
+	}
+	_ = resp.Body.Close()
+
+	log.Trace("Update function configuration %q...", opts.Name)
+	resp, err = c.request(http.MethodPut, c.functionPath(opts.Name, "")+"/config", UpdateFunctionConfigRequest{
+		Name:        opts.Name,
+		Runtime:     functionRuntime,
+		Handler:     pack.BootstrapName,
+		MemorySize:  opts.MemorySize,
+		Timeout:     int(opts.RuntimeTimeout / time.Second),
+		Description: opts.Description,
+		UserData:    string(userData),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.functionError(), "update function configuration")
+	}
+	_ = resp.Body.Close()
+
+	return c.release(opts)
+}
+
+// release points the alias to a new version if the alias is set, and ensures
+// the triggers of the function.
+func (c *Client) release(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	qualifier := latestQualifier
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
+		qualifier = opts.Alias
+	}
+
+	triggerURL, err := c.ensureTriggers(opts, qualifier)
+	if err != nil {
+		return nil, err
+	}
+	deployment.URL = triggerURL
+	return deployment, nil
+}
+
+type GetFunctionResponse struct {
+	FunctionURN  string `json:"func_urn"`
+	Name         string `json:"func_name"`
+	Package      string `json:"package"`
+	Runtime      string `json:"runtime"`
+	Timeout      int    `json:"timeout"`
+	MemorySize   int64  `json:"memory_size"`
+	Description  string `json:"description"`
+	UserData     string `json:"user_data"`
+	Digest       string `json:"digest"`
+	LastModified string `json:"last_modified"`
+	Status       string `json:"status"`
+}
+
+// Describe returns the live information of the latest version of the function.
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	return c.DescribeQualifier(name, latestQualifier)
+}
+
+// DescribeQualifier returns the live information of the version or the alias
+// of the function.
+func (c *Client) DescribeQualifier(name, qualifier string) (*platform.FunctionInfo, error) {
+	resp, err := c.request(http.MethodGet, c.functionPath(name, qualifier)+"/config")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var respJSON GetFunctionResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return toFunctionInfo(&respJSON), nil
+}
+
+type ListFunctionsResponse struct {
+	Functions  []*GetFunctionResponse `json:"functions"`
+	NextMarker int                    `json:"next_marker"`
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var functions []*platform.FunctionInfo
+	marker := 0
+	for {
+		resp, err := c.request(http.MethodGet, "/fgs/functions?maxitems=400&marker="+strconv.Itoa(marker))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "list functions")
+		}
+
+		var respJSON ListFunctionsResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, function := range respJSON.Functions {
+			info := toFunctionInfo(function)
+			info.Namespace = function.Package
+
+			triggers, err := c.listTriggers(function.Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "list triggers of %q", function.Name)
+			}
+			for _, trigger := range triggers {
+				if trigger.Type == apigTriggerType {
+					info.URL = trigger.EventData.InvokeURL
+				}
+			}
+			functions = append(functions, info)
+		}
+
+		if respJSON.NextMarker == 0 || respJSON.NextMarker <= marker {
+			return functions, nil
+		}
+		marker = respJSON.NextMarker
+	}
+}
+
+func toFunctionInfo(function *GetFunctionResponse) *platform.FunctionInfo {
+	environment := make(map[string]string)
+	_ = json.Unmarshal([]byte(function.UserData), &environment)
+	updatedAt, _ := time.Parse("2006-01-02T15:04:05Z0700", function.LastModified)
+
+	status := function.Status
+	if status == "" {
+		status = "Active"
+	}
+
+	return &platform.FunctionInfo{
+		Name:                 function.Name,
+		Description:          function.Description,
+		Status:               status,
+		Active:               status == "Active" || status == "Normal",
+		MemorySize:           function.MemorySize,
+		EnvironmentVariables: environment,
+		RuntimeTimeout:       time.Duration(function.Timeout) * time.Second,
+		CodeChecksum:         function.Digest,
+		UpdatedAt:            updatedAt,
+	}
+}
+
+// apigEvent is the APIG event which the HTTP function converts to the HTTP
+// request to the binary.
+type apigEvent struct {
+	HTTPMethod      string            `json:"httpMethod"`
+	Path            string            `json:"path"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// apigResponse is the result of the HTTP function invoked by the APIG event.
+type apigResponse struct {
+	StatusCode      int    `json:"statusCode"`
+	Body            string `json:"body"`
+	IsBase64Encoded bool   `json:"isBase64Encoded"`
+}
+
+type InvokeResponse struct {
+	RequestID string `json:"request_id"`
+	Result    string `json:"result"`
+	Log       string `json:"log"`
+	Status    int    `json:"status"`
+}
+
+// Invoke calls the latest version of the function synchronously.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	return c.InvokeQualifier(name, latestQualifier, payload)
+}
+
+// InvokeQualifier calls the version or the alias of the function synchronously.
+// The payload is posted to `/` of the binary through the APIG event.
+func (c *Client) InvokeQualifier(name, qualifier string, payload []byte) (*platform.InvokeResponse, error) {
+	contentType := "application/octet-stream"
+	if json.Valid(payload) {
+		contentType = "application/json"
+	}
+	event := apigEvent{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/",
+		Headers:         map[string]string{"content-type": contentType},
+		Body:            base64.StdEncoding.EncodeToString(payload),
+		IsBase64Encoded: true,
+	}
+
+	resp, err := c.request(http.MethodPost, c.functionPath(name, qualifier)+"/invocations", event)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var respJSON InvokeResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: respJSON.Status,
+		Body:       []byte(respJSON.Result),
+	}
+	if respJSON.Status != http.StatusOK {
+		invokeResponse.Error = respJSON.Result
+		return invokeResponse, nil
+	}
+
+	var result apigResponse
+	if err := json.Unmarshal([]byte(respJSON.Result), &result); err != nil || result.StatusCode == 0 {
+		return invokeResponse, nil
+	}
+	invokeResponse.StatusCode = result.StatusCode
+	invokeResponse.Body = []byte(result.Body)
+	if result.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(result.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode body")
+		}
+		invokeResponse.Body = body
+	}
+	return invokeResponse, nil
+}
+
+// DeleteFunction deletes the triggers under the function, then the function
+// itself with all its versions.
+func (c *Client) DeleteFunction(name string) error {
+	if _, err := c.Describe(name); err != nil {
+		return err
+	}
+
+	triggers, err := c.listTriggers(name)
+	if err != nil {
+		return errors.Wrap(err, "list triggers")
+	}
+	for _, trigger := range triggers {
+		log.Trace("Delete trigger %q...", trigger.EventData.Name)
+		if err := c.deleteTrigger(name, trigger); err != nil {
+			return errors.Wrapf(err, "delete trigger: %q", trigger.EventData.Name)
+		}
+	}
+
+	log.Trace("Delete function %q...", name)
+	resp, err := c.request(http.MethodDelete, c.functionPath(name, ""))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return resp.functionError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
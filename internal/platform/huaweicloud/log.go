@@ -0,0 +1,106 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const logPageSize = 1000
+
+type logDetail struct {
+	GroupID  string `json:"group_id"`
+	StreamID string `json:"stream_id"`
+}
+
+type QueryLogsRequest struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Limit     int    `json:"limit"`
+	LineNum   string `json:"line_num,omitempty"`
+	IsDesc    bool   `json:"is_desc"`
+}
+
+type QueryLogsResponse struct {
+	Logs []struct {
+		Content string `json:"content"`
+		// LineNum is the nanoseconds timestamp of the log line.
+		LineNum string `json:"line_num"`
+	} `json:"logs"`
+	Count int `json:"count"`
+}
+
+// Logs returns the function logs from LTS, the log group of the function is
+// configured on FunctionGraph.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	resp, err := c.request(http.MethodGet, c.functionPath(name, "")+"/lts-log-detail")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+	var detail logDetail
+	if err := resp.ToJSON(&detail); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	if detail.GroupID == "" || detail.StreamID == "" {
+		// The log group is not configured.
+		return nil, nil
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	request := QueryLogsRequest{
+		StartTime: strconv.FormatInt(opts.Since.UnixNano()/int64(time.Millisecond), 10),
+		EndTime:   strconv.FormatInt(until.UnixNano()/int64(time.Millisecond), 10),
+		Limit:     logPageSize,
+	}
+
+	var entries []*platform.LogEntry
+	for {
+		resp, err := c.requestService("lts", http.MethodPost, fmt.Sprintf("/v2/%s/groups/%s/streams/%s/content/query", c.projectID, detail.GroupID, detail.StreamID), request)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "query logs")
+		}
+
+		var respJSON QueryLogsResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, line := range respJSON.Logs {
+			lineNum, _ := strconv.ParseInt(line.LineNum, 10, 64)
+			entries = append(entries, &platform.LogEntry{
+				ID:      line.LineNum,
+				Time:    time.Unix(0, lineNum),
+				Message: line.Content,
+			})
+		}
+
+		if len(respJSON.Logs) < logPageSize {
+			break
+		}
+		request.LineNum = respJSON.Logs[len(respJSON.Logs)-1].LineNum
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
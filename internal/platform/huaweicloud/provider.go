@@ -0,0 +1,29 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.HuaweiCloud,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. cn-north-4", Required: true},
+			{Name: ProjectIDField, Usage: "Project ID of the region", Required: true},
+			{Name: AccessKeyIDField, Usage: "Access key ID", Required: true},
+			{Name: SecretAccessKeyField, Usage: "Secret access key", Required: true, Secret: true},
+			{Name: platform.EndpointField, Usage: "API endpoint of all the services, e.g. the endpoint of a proxy to FunctionGraph, LTS and APIG"},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[ProjectIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
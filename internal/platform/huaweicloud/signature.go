@@ -0,0 +1,96 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const signAlgorithm = "SDK-HMAC-SHA256"
+
+// Sign signs the request with the AK/SK SDK-HMAC-SHA256 algorithm, and sets the
+// `X-Sdk-Date` and `Authorization` headers.
+func (c *Client) Sign(req *http.Request, body []byte) {
+	req.Header.Set("X-Sdk-Date", time.Now().UTC().Format("20060102T150405Z"))
+	req.Header.Set("Host", req.URL.Host)
+
+	var headerKeys []string
+	for k := range req.Header {
+		headerKeys = append(headerKeys, strings.ToLower(k))
+	}
+	sort.Strings(headerKeys)
+	signedHeaders := strings.Join(headerKeys, ";")
+
+	var canonicalHeaders string
+	for _, k := range headerKeys {
+		canonicalHeaders += k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n"
+	}
+
+	canonicalRequest := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQueryString(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		sha256Hex(body),
+	)
+	stringToSign := fmt.Sprintf("%s\n%s\n%s", signAlgorithm, req.Header.Get("X-Sdk-Date"), sha256Hex([]byte(canonicalRequest)))
+
+	hashed := hmac.New(sha256.New, []byte(c.secretAccessKey))
+	_, _ = hashed.Write([]byte(stringToSign))
+	signature := hex.EncodeToString(hashed.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Access=%s, SignedHeaders=%s, Signature=%s", signAlgorithm, c.accessKeyID, signedHeaders, signature))
+}
+
+// canonicalURI returns the escaped path which always ends with `/`.
+func canonicalURI(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	uri := strings.Join(segments, "/")
+	if !strings.HasSuffix(uri, "/") {
+		uri += "/"
+	}
+	return uri
+}
+
+func canonicalQueryString(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape encodes the string as RFC 3986 requires.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(s []byte) string {
+	b := sha256.Sum256(s)
+	return hex.EncodeToString(b[:])
+}
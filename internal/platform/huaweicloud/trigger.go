@@ -0,0 +1,277 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const (
+	apigTriggerType  = "APIG"
+	timerTriggerType = "TIMER"
+)
+
+type TriggerEventData struct {
+	Name string `json:"name"`
+
+	// Timer trigger
+	ScheduleType string `json:"schedule_type,omitempty"`
+	Schedule     string `json:"schedule,omitempty"`
+	UserEvent    string `json:"user_event,omitempty"`
+
+	// APIG trigger
+	GroupID     string `json:"group_id,omitempty"`
+	EnvID       string `json:"env_id,omitempty"`
+	EnvName     string `json:"env_name,omitempty"`
+	Auth        string `json:"auth,omitempty"`
+	Protocol    string `json:"protocol,omitempty"`
+	Path        string `json:"path,omitempty"`
+	MatchMode   string `json:"match_mode,omitempty"`
+	Method      string `json:"req_method,omitempty"`
+	BackendType string `json:"backend_type,omitempty"`
+	Type        int    `json:"type,omitempty"`
+	SubDomain   string `json:"sl_domain,omitempty"`
+	InvokeURL   string `json:"invoke_url,omitempty"`
+}
+
+type CreateTriggerRequest struct {
+	Type      string           `json:"trigger_type_code"`
+	EventType string           `json:"event_type_code,omitempty"`
+	Status    string           `json:"trigger_status"`
+	EventData TriggerEventData `json:"event_data"`
+}
+
+type Trigger struct {
+	ID        string           `json:"trigger_id"`
+	Type      string           `json:"trigger_type_code"`
+	Status    string           `json:"trigger_status"`
+	EventData TriggerEventData `json:"event_data"`
+}
+
+// checkTrigger checks the trigger options before the function is uploaded.
+func checkTrigger(opts platform.CreateFunctionOptions) error {
+	if err := platform.ValidateTriggers(opts.Triggers); err != nil {
+		return err
+	}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+		if _, err := platform.QuartzCron(trigger.Cron); err != nil {
+			return errors.Wrapf(err, "trigger %q", trigger.Name)
+		}
+	}
+	return nil
+}
+
+// ensureTriggers creates the triggers of the function bound to the qualifier,
+// the existing ones are re-created if they are changed. It returns the URL of the HTTP trigger.
+func (c *Client) ensureTriggers(opts platform.CreateFunctionOptions, qualifier string) (string, error) {
+	triggers, err := c.listTriggers(opts.Name)
+	if err != nil {
+		return "", errors.Wrap(err, "list triggers")
+	}
+	existingTriggers := make(map[string]*Trigger, len(triggers))
+	for _, trigger := range triggers {
+		existingTriggers[trigger.EventData.Name] = trigger
+	}
+
+	var triggerURL string
+	for _, spec := range opts.Triggers {
+		var request CreateTriggerRequest
+		switch spec.Type {
+		case platform.HTTPTrigger:
+			group, err := c.ensureAPIGroup()
+			if err != nil {
+				return "", errors.Wrap(err, "ensure API group")
+			}
+			request = CreateTriggerRequest{
+				Type:      apigTriggerType,
+				EventType: "APICreated",
+				Status:    "ACTIVE",
+				EventData: TriggerEventData{
+					Name:        apiName(opts.Name, spec.Name),
+					GroupID:     group.ID,
+					EnvID:       "DEFAULT_ENVIRONMENT_RELEASE_ID",
+					EnvName:     "RELEASE",
+					Auth:        "NONE",
+					Protocol:    "HTTPS",
+					Path:        "/" + opts.Name,
+					MatchMode:   "SWA",
+					Method:      "ANY",
+					BackendType: "FUNCTION",
+					Type:        1,
+					SubDomain:   group.SubDomain,
+				},
+			}
+		case platform.CronTrigger:
+			fields, err := platform.QuartzCron(spec.Cron)
+			if err != nil {
+				return "", errors.Wrapf(err, "trigger %q", spec.Name)
+			}
+			request = CreateTriggerRequest{
+				Type:   timerTriggerType,
+				Status: "ACTIVE",
+				EventData: TriggerEventData{
+					Name:         spec.Name,
+					ScheduleType: "Cron",
+					Schedule:     strings.Join(fields, " "),
+					UserEvent:    spec.Payload,
+				},
+			}
+		default:
+			return "", errors.Errorf("unexpected trigger type %q", spec.Type)
+		}
+
+		if trigger, ok := existingTriggers[request.EventData.Name]; ok {
+			// The qualifier of the trigger is not returned, the triggers bound
+			// to an alias are always re-created to follow the alias.
+			if trigger.Type == request.Type && trigger.EventData.Schedule == request.EventData.Schedule &&
+				trigger.EventData.UserEvent == request.EventData.UserEvent && opts.Alias == "" {
+				if trigger.Type == apigTriggerType {
+					triggerURL = trigger.EventData.InvokeURL
+				}
+				continue
+			}
+
+			// The triggers can't be updated, re-create it.
+			log.Trace("Re-create trigger %q...", spec.Name)
+			if err := c.deleteTrigger(opts.Name, trigger); err != nil {
+				return "", errors.Wrapf(err, "delete trigger %q", spec.Name)
+			}
+		}
+
+		log.Trace("Create trigger %q...", spec.Name)
+		trigger, err := c.createTrigger(c.functionURN(opts.Name, qualifier), request)
+		if err != nil {
+			return "", errors.Wrapf(err, "create trigger %q", spec.Name)
+		}
+		if trigger.Type == apigTriggerType {
+			triggerURL = trigger.EventData.InvokeURL
+			if triggerURL == "" {
+				triggerURL = fmt.Sprintf("https://%s/%s", request.EventData.SubDomain, opts.Name)
+			}
+		}
+	}
+	return triggerURL, nil
+}
+
+// apiName returns the name of the APIG API, which only contains letters, digits and `_`.
+func apiName(functionName, triggerName string) string {
+	return strings.ReplaceAll(functionName+"_"+triggerName, "-", "_")
+}
+
+func (c *Client) createTrigger(functionURN string, request CreateTriggerRequest) (*Trigger, error) {
+	resp, err := c.request(http.MethodPost, "/fgs/triggers/"+functionURN, request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, resp.ToError()
+	}
+
+	var trigger Trigger
+	if err := resp.ToJSON(&trigger); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &trigger, nil
+}
+
+func (c *Client) listTriggers(functionName string) ([]*Trigger, error) {
+	resp, err := c.request(http.MethodGet, "/fgs/triggers/"+c.functionURN(functionName, ""))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var triggers []*Trigger
+	if err := resp.ToJSON(&triggers); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return triggers, nil
+}
+
+func (c *Client) deleteTrigger(functionName string, trigger *Trigger) error {
+	resp, err := c.request(http.MethodDelete, fmt.Sprintf("/fgs/triggers/%s/%s/%s", c.functionURN(functionName, ""), trigger.Type, trigger.ID))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return resp.ToError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// RemoveTrigger deletes the trigger under the function.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	triggers, err := c.listTriggers(functionName)
+	if err != nil {
+		return errors.Wrap(err, "list triggers")
+	}
+	for _, trigger := range triggers {
+		if trigger.EventData.Name == triggerName || trigger.EventData.Name == apiName(functionName, triggerName) {
+			log.Trace("Delete trigger %q...", triggerName)
+			return c.deleteTrigger(functionName, trigger)
+		}
+	}
+	return errors.Errorf("trigger %q not found", triggerName)
+}
+
+type APIGroup struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	SubDomain string `json:"sl_domain"`
+}
+
+// ensureAPIGroup returns the APIG API group of Raika, it is created if it does not exist.
+func (c *Client) ensureAPIGroup() (*APIGroup, error) {
+	resp, err := c.requestService("apig", http.MethodGet, "/v1.0/apigw/api-groups?name="+APIGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.ToError()
+	}
+
+	var respJSON struct {
+		Groups []*APIGroup `json:"groups"`
+	}
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	for _, group := range respJSON.Groups {
+		if group.Name == APIGroupName {
+			return group, nil
+		}
+	}
+
+	log.Trace("Create API group %q...", APIGroupName)
+	resp, err = c.requestService("apig", http.MethodPost, "/v1.0/apigw/api-groups", map[string]string{
+		"name":   APIGroupName,
+		"remark": "Created by Raika",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, resp.ToError()
+	}
+
+	var group APIGroup
+	if err := resp.ToJSON(&group); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &group, nil
+}
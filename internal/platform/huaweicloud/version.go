@@ -0,0 +1,74 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package huaweicloud
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+)
+
+type PublishVersionRequest struct {
+	Description string `json:"description"`
+}
+
+// PublishVersion publishes a version from the latest code and configuration of the function.
+func (c *Client) PublishVersion(name, description string) (string, error) {
+	log.Trace("Publish version of %q...", name)
+	resp, err := c.request(http.MethodPost, c.functionPath(name, "")+"/versions", PublishVersionRequest{
+		Description: description,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", resp.functionError()
+	}
+
+	var respJSON struct {
+		Version string `json:"version"`
+	}
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "json decode")
+	}
+	return respJSON.Version, nil
+}
+
+type AliasRequest struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version"`
+}
+
+// UpdateAlias points the alias to the version, the alias is created if it does not exist.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	log.Trace("Point alias %q of %q to version %q...", alias, name, version)
+	resp, err := c.request(http.MethodPut, c.functionPath(name, "")+"/aliases/"+alias, AliasRequest{
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		_ = resp.Body.Close()
+		return nil
+	} else if resp.StatusCode != http.StatusNotFound {
+		return errors.Wrap(resp.ToError(), "update alias")
+	}
+	_ = resp.Body.Close()
+
+	resp, err = c.request(http.MethodPost, c.functionPath(name, "")+"/aliases", AliasRequest{
+		Name:    alias,
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return errors.Wrap(resp.ToError(), "create alias")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+type Client struct {
+	id                  string
+	kubeConfig, context string
+	namespace           string
+
+	registry                           string
+	registryUsername, registryPassword string
+
+	once       sync.Once
+	cluster    *cluster
+	clusterErr error
+}
+
+func New(opts platform.AuthenticateOptions) *Client {
+	return &Client{
+		id:               opts["id"],
+		kubeConfig:       opts[KubeConfigField],
+		context:          opts[ContextField],
+		namespace:        opts[NamespaceField],
+		registry:         opts[RegistryField],
+		registryUsername: opts[RegistryUsernameField],
+		registryPassword: opts[RegistryPasswordField],
+	}
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.Knative
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+// Authenticate checks the Knative Serving API is available in the namespace.
+func (c *Client) Authenticate() error {
+	resp, err := c.request(http.MethodGet, c.servicesPath()+"?limit=1")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "list services")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// getCluster loads the kubeconfig once, the namespace of the context is used
+// if the namespace is not given.
+func (c *Client) getCluster() (*cluster, error) {
+	c.once.Do(func() {
+		c.cluster, c.clusterErr = loadKubeConfig(c.kubeConfig, c.context)
+		if c.clusterErr != nil {
+			return
+		}
+		if c.namespace == "" {
+			c.namespace = c.cluster.namespace
+		}
+		if c.namespace == "" {
+			c.namespace = DefaultNamespace
+		}
+	})
+	return c.cluster, c.clusterErr
+}
+
+// request sends the request to the Kubernetes API server.
+func (c *Client) request(method, path string, requestBody ...interface{}) (*response, error) {
+	cluster, err := c.getCluster()
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if len(requestBody) == 1 {
+		reqBody, err := json.Marshal(requestBody[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "JSON encode")
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequest(method, cluster.server+path, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	cluster.authenticate(req)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cluster.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+
+	return &response{
+		Response: resp,
+	}, nil
+}
+
+// servicesPath returns the API path of the Knative services in the namespace.
+func (c *Client) servicesPath() string {
+	_, _ = c.getCluster()
+	return fmt.Sprintf("/apis/serving.knative.dev/v1/namespaces/%s/services", c.namespace)
+}
+
+// pingSourcesPath returns the API path of the ping sources in the namespace.
+func (c *Client) pingSourcesPath() string {
+	_, _ = c.getCluster()
+	return fmt.Sprintf("/apis/sources.knative.dev/v1/namespaces/%s/pingsources", c.namespace)
+}
+
+// apiError is the Status object returned by the Kubernetes API server.
+type apiError struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Code, e.Reason, e.Message)
+}
+
+type response struct {
+	*http.Response
+}
+
+func (r *response) ToJSON(v interface{}) error {
+	defer func() { _ = r.Body.Close() }()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (r *response) ToString() string {
+	defer func() { _ = r.Body.Close() }()
+	resp, _ := io.ReadAll(r.Body)
+	return string(resp)
+}
+
+// ToError returns the error in the response body.
+func (r *response) ToError() error {
+	body := r.ToString()
+	var status apiError
+	if err := json.Unmarshal([]byte(body), &status); err != nil || status.Message == "" {
+		return errors.Errorf("%d: %s", r.StatusCode, strings.TrimSpace(body))
+	}
+	return &status
+}
+
+// functionError returns platform.ErrFunctionNotExists if the service is not found.
+func (r *response) functionError() error {
+	if r.StatusCode == http.StatusNotFound {
+		_ = r.Body.Close()
+		return platform.ErrFunctionNotExists
+	}
+	return r.ToError()
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+const (
+	KubeConfigField = "kubeconfig"
+	ContextField    = "context"
+	NamespaceField  = "namespace"
+	// RegistryField is the repository prefix in `[http://]host[/namespace]`
+	// format, the images built from the binary are pushed to it.
+	RegistryField         = "registry"
+	RegistryUsernameField = "registry_username"
+	RegistryPasswordField = "registry_password"
+)
+
+const DefaultNamespace = "default"
+
+// The annotations and the labels of the objects.
+const (
+	functionNameAnnotation = "raika/function-name"
+	descriptionAnnotation  = "raika/description"
+	httpTriggerAnnotation  = "raika/http-trigger"
+	triggerNameAnnotation  = "raika/trigger-name"
+	functionLabel          = "raika/function"
+	visibilityLabel        = "networking.knative.dev/visibility"
+	serviceLabel           = "serving.knative.dev/service"
+	clusterLocalVisibility = "cluster-local"
+	userContainerName      = "user-container"
+)
@@ -0,0 +1,521 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+	"github.com/wuhan005/Raika/internal/platform/registry"
+)
+
+const (
+	defaultPort         = 8080
+	defaultReadyTimeout = 2 * time.Minute
+)
+
+// serviceName returns the Knative service name of the function, it only
+// contains lowercase letters, digits and hyphens.
+func serviceName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+type ObjectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	Generation        int64             `json:"generation,omitempty"`
+	CreationTimestamp *time.Time        `json:"creationTimestamp,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ContainerPort struct {
+	ContainerPort int `json:"containerPort"`
+}
+
+type ResourceRequirements struct {
+	Limits map[string]string `json:"limits,omitempty"`
+}
+
+type Container struct {
+	Name      string               `json:"name,omitempty"`
+	Image     string               `json:"image"`
+	Env       []EnvVar             `json:"env,omitempty"`
+	Ports     []ContainerPort      `json:"ports,omitempty"`
+	Resources ResourceRequirements `json:"resources"`
+}
+
+type RevisionSpec struct {
+	TimeoutSeconds int64       `json:"timeoutSeconds,omitempty"`
+	Containers     []Container `json:"containers"`
+}
+
+type RevisionTemplate struct {
+	Metadata ObjectMeta   `json:"metadata"`
+	Spec     RevisionSpec `json:"spec"`
+}
+
+type TrafficTarget struct {
+	Tag            string `json:"tag,omitempty"`
+	RevisionName   string `json:"revisionName,omitempty"`
+	LatestRevision *bool  `json:"latestRevision,omitempty"`
+	Percent        *int64 `json:"percent,omitempty"`
+	// URL is output only.
+	URL string `json:"url,omitempty"`
+}
+
+type ServiceSpec struct {
+	Template RevisionTemplate `json:"template"`
+	Traffic  []TrafficTarget  `json:"traffic,omitempty"`
+}
+
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ServiceStatus struct {
+	ObservedGeneration      int64           `json:"observedGeneration,omitempty"`
+	URL                     string          `json:"url,omitempty"`
+	LatestReadyRevisionName string          `json:"latestReadyRevisionName,omitempty"`
+	Conditions              []Condition     `json:"conditions,omitempty"`
+	Traffic                 []TrafficTarget `json:"traffic,omitempty"`
+}
+
+type Service struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   ObjectMeta    `json:"metadata"`
+	Spec       ServiceSpec   `json:"spec"`
+	Status     ServiceStatus `json:"status,omitempty"`
+}
+
+// condition returns the condition of the given type.
+func (s *Service) condition(conditionType string) *Condition {
+	for i := range s.Status.Conditions {
+		if s.Status.Conditions[i].Type == conditionType {
+			return &s.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// tagURL returns the URL of the traffic tag, it is empty if the tag does not exist.
+func (s *Service) tagURL(tag string) string {
+	for _, target := range s.Status.Traffic {
+		if target.Tag == tag {
+			return target.URL
+		}
+	}
+	return ""
+}
+
+func (s *Service) functionName() string {
+	if name := s.Metadata.Annotations[functionNameAnnotation]; name != "" {
+		return name
+	}
+	return s.Metadata.Name
+}
+
+func (c *Client) servicePath(name string) string {
+	return c.servicesPath() + "/" + serviceName(name)
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	// Check current function name exists.
+	_, err := c.getService(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on knative, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+	return c.deploy(nil, opts)
+}
+
+// UpdateFunction creates a new revision of the existing service, the route
+// URL and the traffic tags are kept.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	current, err := c.getService(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	return c.deploy(current, opts)
+}
+
+func (c *Client) deploy(current *Service, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if err := checkTrigger(opts); err != nil {
+		return nil, err
+	}
+
+	image := opts.Image
+	if image == "" {
+		var err error
+		image, err = c.pushImage(opts.Name, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "push image")
+		}
+	}
+
+	service := newService(opts, image)
+	if current != nil {
+		service.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+		for _, target := range current.Spec.Traffic {
+			if target.Tag != "" {
+				service.Spec.Traffic = append(service.Spec.Traffic, target)
+			}
+		}
+	}
+	service.Metadata.Namespace = c.namespace
+
+	log.Trace("Deploy service %q...", service.Metadata.Name)
+	method, path := http.MethodPost, c.servicesPath()
+	if current != nil {
+		method, path = http.MethodPut, c.servicePath(opts.Name)
+	}
+	if err := c.putService(method, path, service); err != nil {
+		return nil, err
+	}
+	if err := c.waitServiceReady(opts.Name, opts.InitializationTimeout); err != nil {
+		return nil, err
+	}
+	return c.release(opts)
+}
+
+// newService returns the service of the function, the untagged traffic is
+// sent to the latest revision. The service is only visible in the cluster
+// without the HTTP trigger.
+func newService(opts platform.CreateFunctionOptions, image string) *Service {
+	environment := make([]EnvVar, 0, len(opts.EnvironmentVariables))
+	for k, v := range opts.EnvironmentVariables {
+		environment = append(environment, EnvVar{Name: k, Value: v})
+	}
+	port := opts.HTTPPort
+	if port == 0 {
+		port = defaultPort
+	}
+
+	labels := map[string]string{
+		functionLabel: serviceName(opts.Name),
+	}
+	annotations := map[string]string{
+		functionNameAnnotation: opts.Name,
+		descriptionAnnotation:  opts.Description,
+	}
+	labels[visibilityLabel] = clusterLocalVisibility
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.HTTPTrigger {
+			delete(labels, visibilityLabel)
+			annotations[httpTriggerAnnotation] = trigger.Name
+		}
+	}
+
+	container := Container{
+		Name:  userContainerName,
+		Image: image,
+		Env:   environment,
+		Ports: []ContainerPort{{ContainerPort: port}},
+	}
+	if opts.MemorySize > 0 {
+		container.Resources.Limits = map[string]string{
+			"memory": strconv.FormatInt(opts.MemorySize, 10) + "Mi",
+		}
+	}
+
+	latest, percent := true, int64(100)
+	return &Service{
+		APIVersion: "serving.knative.dev/v1",
+		Kind:       "Service",
+		Metadata: ObjectMeta{
+			Name:        serviceName(opts.Name),
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: ServiceSpec{
+			Template: RevisionTemplate{
+				Spec: RevisionSpec{
+					TimeoutSeconds: int64(opts.RuntimeTimeout / time.Second),
+					Containers:     []Container{container},
+				},
+			},
+			Traffic: []TrafficTarget{{LatestRevision: &latest, Percent: &percent}},
+		},
+	}
+}
+
+// release points the alias to the latest ready revision if the alias is set,
+// and ensures the ping sources of the cron triggers.
+func (c *Client) release(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
+	}
+
+	service, err := c.getService(opts.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get service")
+	}
+	routeURL := service.Status.URL
+	if opts.Alias != "" {
+		routeURL = service.tagURL(opts.Alias)
+	}
+
+	if err := c.ensurePingSources(opts, routeURL); err != nil {
+		return nil, err
+	}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.HTTPTrigger {
+			deployment.URL = routeURL
+		}
+	}
+	return deployment, nil
+}
+
+// pushImage builds the image from the function package, and pushes it to the registry.
+func (c *Client) pushImage(name string, opts platform.CreateFunctionOptions) (string, error) {
+	if c.registry == "" {
+		return "", errors.New("registry is required to build the image from the binary, or deploy an image instead")
+	}
+
+	entries, err := pack.Entries(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "list package entries")
+	}
+	image, err := registry.Build(entries)
+	if err != nil {
+		return "", errors.Wrap(err, "build image")
+	}
+
+	client, namespace := registry.ParseRepository(c.registry, c.registryUsername, c.registryPassword)
+	repository := serviceName(name)
+	if namespace != "" {
+		repository = namespace + "/" + repository
+	}
+	log.Trace("Push image to %s/%s...", client.Host, repository)
+	return client.Push(repository, "latest", image)
+}
+
+func (c *Client) getService(name string) (*Service, error) {
+	resp, err := c.request(http.MethodGet, c.servicePath(name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var service Service
+	if err := resp.ToJSON(&service); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &service, nil
+}
+
+// putService creates the service with POST, or replaces it with PUT.
+func (c *Client) putService(method, path string, service *Service) error {
+	// The status is ignored by the API server.
+	service.Status = ServiceStatus{}
+	resp, err := c.request(method, path, service)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return errors.Wrap(resp.functionError(), "put service")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// waitServiceReady waits until the latest generation of the service is ready.
+func (c *Client) waitServiceReady(name string, timeout time.Duration) error {
+	if timeout < defaultReadyTimeout {
+		timeout = defaultReadyTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		service, err := c.getService(name)
+		if err != nil {
+			return errors.Wrap(err, "get service")
+		}
+
+		if service.Status.ObservedGeneration >= service.Metadata.Generation {
+			if ready := service.condition("Ready"); ready != nil {
+				switch ready.Status {
+				case "True":
+					return nil
+				case "False":
+					return errors.Errorf("service %q is not ready: %s: %s", service.Metadata.Name, ready.Reason, ready.Message)
+				}
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return errors.Errorf("service %q is not ready after %s", serviceName(name), timeout)
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(service), nil
+}
+
+type ServiceList struct {
+	Metadata struct {
+		Continue string `json:"continue"`
+	} `json:"metadata"`
+	Items []*Service `json:"items"`
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var functions []*platform.FunctionInfo
+	continueToken := ""
+	for {
+		resp, err := c.request(http.MethodGet, c.servicesPath()+"?continue="+url.QueryEscape(continueToken))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrap(resp.ToError(), "list services")
+		}
+
+		var list ServiceList
+		if err := resp.ToJSON(&list); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		for _, service := range list.Items {
+			info := toFunctionInfo(service)
+			info.Namespace = service.Metadata.Namespace
+			info.URL = service.Status.URL
+			functions = append(functions, info)
+		}
+
+		if list.Metadata.Continue == "" {
+			return functions, nil
+		}
+		continueToken = list.Metadata.Continue
+	}
+}
+
+func toFunctionInfo(service *Service) *platform.FunctionInfo {
+	info := &platform.FunctionInfo{
+		Name:                 service.functionName(),
+		Description:          service.Metadata.Annotations[descriptionAnnotation],
+		Status:               "Unknown",
+		EnvironmentVariables: make(map[string]string),
+		RuntimeTimeout:       time.Duration(service.Spec.Template.Spec.TimeoutSeconds) * time.Second,
+	}
+	if ready := service.condition("Ready"); ready != nil {
+		info.Status = "Ready"
+		if ready.Status != "True" {
+			info.Status = "NotReady"
+			if ready.Reason != "" {
+				info.Status = ready.Reason
+			}
+		}
+		info.Active = ready.Status == "True"
+	}
+	if service.Metadata.CreationTimestamp != nil {
+		info.UpdatedAt = *service.Metadata.CreationTimestamp
+	}
+
+	if len(service.Spec.Template.Spec.Containers) > 0 {
+		container := service.Spec.Template.Spec.Containers[0]
+		for _, env := range container.Env {
+			info.EnvironmentVariables[env.Name] = env.Value
+		}
+		memory := strings.TrimSuffix(container.Resources.Limits["memory"], "Mi")
+		info.MemorySize, _ = strconv.ParseInt(memory, 10, 64)
+		// The image is referenced by its digest if it is built by Raika.
+		if i := strings.LastIndex(container.Image, "@"); i != -1 {
+			info.CodeChecksum = container.Image[i+1:]
+		}
+	}
+	return info
+}
+
+// Invoke posts the payload to the route URL of the service. The cluster-local
+// services can only be invoked inside the cluster.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(service.Status.URL, "application/octet-stream", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+// DeleteFunction deletes the ping sources of the function, then the service
+// with its revisions and routes.
+func (c *Client) DeleteFunction(name string) error {
+	if _, err := c.getService(name); err != nil {
+		return err
+	}
+
+	sources, err := c.listPingSources(name)
+	if err != nil {
+		return errors.Wrap(err, "list ping sources")
+	}
+	for _, source := range sources {
+		log.Trace("Delete ping source %q...", source.Metadata.Name)
+		if err := c.deletePingSource(source.Metadata.Name); err != nil {
+			return errors.Wrapf(err, "delete ping source: %q", source.Metadata.Name)
+		}
+	}
+
+	log.Trace("Delete service %q...", serviceName(name))
+	resp, err := c.request(http.MethodDelete, c.servicePath(name))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return resp.functionError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
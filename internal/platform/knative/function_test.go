@@ -0,0 +1,641 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const (
+	testNamespace = "raika"
+	testToken     = "secret"
+)
+
+// stubAPIServer is the Kubernetes API server serving the Knative services and
+// the ping sources in memory. The services are reconciled on the second get
+// after they are put, so that the ready polling is exercised.
+type stubAPIServer struct {
+	url string
+
+	mu          sync.Mutex
+	services    map[string]*Service
+	pingSources map[string]*PingSource
+	// pending is the number of gets before the service is reconciled.
+	pending map[string]int
+	// notReady is the reason of the services failed to be ready.
+	notReady string
+	// requests are the method and the path of the requests.
+	requests []string
+}
+
+func newStubAPIServer(t *testing.T) (*stubAPIServer, *Client) {
+	apiServer := &stubAPIServer{
+		services:    make(map[string]*Service),
+		pingSources: make(map[string]*PingSource),
+		pending:     make(map[string]int),
+	}
+	server := httptest.NewServer(apiServer)
+	t.Cleanup(server.Close)
+	apiServer.url = server.URL
+
+	kubeConfig := writeKubeConfig(t, `
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: `+server.URL+`/
+users:
+- name: test
+  user:
+    token: `+testToken+`
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: `+testNamespace+`
+`)
+	return apiServer, New(platform.AuthenticateOptions{
+		"id":            "knative-test",
+		KubeConfigField: kubeConfig,
+	})
+}
+
+func writeKubeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func (s *stubAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The routes of the services are invoked without auth.
+	if strings.HasPrefix(r.URL.Path, "/route/") {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte(strings.TrimPrefix(r.URL.Path, "/route/")+": "), body...))
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		writeStatus(w, http.StatusUnauthorized, "Unauthorized", "Unauthorized")
+		return
+	}
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	servicesPath := "/apis/serving.knative.dev/v1/namespaces/" + testNamespace + "/services"
+	pingSourcesPath := "/apis/sources.knative.dev/v1/namespaces/" + testNamespace + "/pingsources"
+	switch {
+	case r.URL.Path == servicesPath && r.Method == http.MethodGet:
+		list := ServiceList{Items: []*Service{}}
+		for _, service := range s.services {
+			list.Items = append(list.Items, service)
+		}
+		_ = json.NewEncoder(w).Encode(list)
+
+	case r.URL.Path == servicesPath && r.Method == http.MethodPost:
+		var service Service
+		if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+			writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		if _, ok := s.services[service.Metadata.Name]; ok {
+			writeStatus(w, http.StatusConflict, "AlreadyExists", "services.serving.knative.dev \""+service.Metadata.Name+"\" already exists")
+			return
+		}
+		now := time.Now()
+		service.Metadata.CreationTimestamp = &now
+		s.putService(&service)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(service)
+
+	case strings.HasPrefix(r.URL.Path, servicesPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, servicesPath+"/")
+		current, ok := s.services[name]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, "NotFound", "services.serving.knative.dev \""+name+"\" not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.reconcile(current)
+			_ = json.NewEncoder(w).Encode(current)
+
+		case http.MethodPut:
+			var service Service
+			if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+				writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+				return
+			}
+			if service.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+				writeStatus(w, http.StatusConflict, "Conflict", "the object has been modified")
+				return
+			}
+			service.Metadata.Generation = current.Metadata.Generation
+			service.Metadata.CreationTimestamp = current.Metadata.CreationTimestamp
+			service.Status = current.Status
+			s.putService(&service)
+			_ = json.NewEncoder(w).Encode(service)
+
+		case http.MethodDelete:
+			delete(s.services, name)
+			writeStatus(w, http.StatusOK, "", "")
+		}
+
+	case r.URL.Path == pingSourcesPath && r.Method == http.MethodGet:
+		list := struct {
+			Items []*PingSource `json:"items"`
+		}{Items: []*PingSource{}}
+		selector := r.URL.Query().Get("labelSelector")
+		for _, source := range s.pingSources {
+			if selector == functionLabel+"="+source.Metadata.Labels[functionLabel] {
+				list.Items = append(list.Items, source)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+
+	case r.URL.Path == pingSourcesPath && r.Method == http.MethodPost:
+		var source PingSource
+		if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+			writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		source.Metadata.ResourceVersion = "1"
+		s.pingSources[source.Metadata.Name] = &source
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(source)
+
+	case strings.HasPrefix(r.URL.Path, pingSourcesPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, pingSourcesPath+"/")
+		current, ok := s.pingSources[name]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, "NotFound", "pingsources.sources.knative.dev \""+name+"\" not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(current)
+
+		case http.MethodPut:
+			var source PingSource
+			if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+				writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+				return
+			}
+			if source.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+				writeStatus(w, http.StatusConflict, "Conflict", "the object has been modified")
+				return
+			}
+			version, _ := strconv.Atoi(current.Metadata.ResourceVersion)
+			source.Metadata.ResourceVersion = strconv.Itoa(version + 1)
+			s.pingSources[name] = &source
+			_ = json.NewEncoder(w).Encode(source)
+
+		case http.MethodDelete:
+			delete(s.pingSources, name)
+			writeStatus(w, http.StatusOK, "", "")
+		}
+
+	default:
+		writeStatus(w, http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+	}
+}
+
+// putService saves the service as a new generation, which is not ready until
+// it is reconciled.
+func (s *stubAPIServer) putService(service *Service) {
+	service.Metadata.Namespace = testNamespace
+	service.Metadata.Generation++
+	service.Metadata.ResourceVersion = strconv.FormatInt(service.Metadata.Generation, 10)
+	s.services[service.Metadata.Name] = service
+	s.pending[service.Metadata.Name] = 1
+}
+
+// reconcile marks the latest generation of the service as observed.
+func (s *stubAPIServer) reconcile(service *Service) {
+	name := service.Metadata.Name
+	if s.pending[name] > 0 {
+		s.pending[name]--
+		return
+	}
+
+	revision := name + "-" + strconv.FormatInt(service.Metadata.Generation, 10)
+	service.Status.ObservedGeneration = service.Metadata.Generation
+	service.Status.URL = s.url + "/route/" + name
+	service.Status.Traffic = nil
+	for _, target := range service.Spec.Traffic {
+		if target.Tag != "" {
+			target.URL = s.url + "/route/" + target.Tag + "-" + name
+		}
+		service.Status.Traffic = append(service.Status.Traffic, target)
+	}
+
+	if s.notReady != "" {
+		service.Status.Conditions = []Condition{{Type: "Ready", Status: "False", Reason: s.notReady, Message: "failed to pull the image"}}
+		return
+	}
+	service.Status.LatestReadyRevisionName = revision
+	service.Status.Conditions = []Condition{{Type: "Ready", Status: "True"}}
+}
+
+func (s *stubAPIServer) service(name string) *Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.services[name]
+}
+
+func (s *stubAPIServer) pingSource(name string) *PingSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pingSources[name]
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason, message string) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(apiError{Message: message, Reason: reason, Code: code})
+}
+
+// testFunctionOptions returns the options of the function with an HTTP and a cron trigger.
+func testFunctionOptions() platform.CreateFunctionOptions {
+	return platform.CreateFunctionOptions{
+		Name:                 "hello_raika",
+		Description:          "Hello",
+		MemorySize:           128,
+		EnvironmentVariables: map[string]string{"GREETING": "hi"},
+		RuntimeTimeout:       10 * time.Second,
+		Image:                "registry.example.com/raika/hello@sha256:v1",
+		Triggers: []platform.TriggerSpec{
+			{Name: platform.HTTPTriggerName, Type: platform.HTTPTrigger},
+			{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *", Payload: "tick"},
+		},
+	}
+}
+
+// deploy deploys the function with the options to the API server.
+func deploy(t *testing.T, client *Client, opts platform.CreateFunctionOptions) {
+	if _, err := client.CreateFunction(opts); err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+}
+
+// writes returns the requests which are not gets.
+func (s *stubAPIServer) writes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var writes []string
+	for _, request := range s.requests {
+		if !strings.HasPrefix(request, http.MethodGet+" ") {
+			writes = append(writes, request)
+		}
+	}
+	return writes
+}
+
+func TestCreateFunction(t *testing.T) {
+	const (
+		servicesPath    = "/apis/serving.knative.dev/v1/namespaces/raika/services"
+		pingSourcesPath = "/apis/sources.knative.dev/v1/namespaces/raika/pingsources"
+	)
+	tests := []struct {
+		name       string
+		existing   bool
+		update     func(opts *platform.CreateFunctionOptions)
+		wantWrites []string
+		check      func(t *testing.T, service *Service, source *PingSource)
+	}{
+		{
+			name:       "create",
+			update:     func(*platform.CreateFunctionOptions) {},
+			wantWrites: []string{"POST " + servicesPath, "POST " + pingSourcesPath},
+			check: func(t *testing.T, service *Service, source *PingSource) {
+				container := service.Spec.Template.Spec.Containers[0]
+				if container.Image != "registry.example.com/raika/hello@sha256:v1" || container.Resources.Limits["memory"] != "128Mi" ||
+					container.Ports[0].ContainerPort != defaultPort || service.Spec.Template.Spec.TimeoutSeconds != 10 {
+					t.Fatalf("unexpected service: %+v", service)
+				}
+				if _, ok := service.Metadata.Labels[visibilityLabel]; ok {
+					t.Fatalf("want the service visible outside the cluster, got labels %v", service.Metadata.Labels)
+				}
+				if source.Spec.Schedule != "0 * * * *" || source.Spec.Data != "tick" ||
+					source.Spec.Sink.Ref == nil || source.Spec.Sink.Ref.Name != "hello-raika" ||
+					source.Metadata.Annotations[triggerNameAnnotation] != "hourly" {
+					t.Fatalf("unexpected ping source: %+v", source)
+				}
+			},
+		},
+		{
+			// A new generation is put with the resource version, and the ping
+			// source is replaced.
+			name:     "update",
+			existing: true,
+			update: func(opts *platform.CreateFunctionOptions) {
+				opts.Image = "registry.example.com/raika/hello@sha256:v2"
+				opts.Triggers[1].Cron = "0 30 2 * * ?"
+			},
+			wantWrites: []string{"PUT " + servicesPath + "/hello-raika", "PUT " + pingSourcesPath + "/hello-raika-hourly"},
+			check: func(t *testing.T, service *Service, source *PingSource) {
+				if service.Metadata.Generation != 2 || service.Spec.Template.Spec.Containers[0].Image != "registry.example.com/raika/hello@sha256:v2" {
+					t.Fatalf("unexpected service: %+v", service)
+				}
+				if source.Spec.Schedule != "30 2 * * *" || source.Metadata.ResourceVersion != "2" {
+					t.Fatalf("unexpected ping source: %+v", source)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiServer, client := newStubAPIServer(t)
+			opts := testFunctionOptions()
+			if test.existing {
+				deploy(t, client, opts)
+			}
+			apiServer.requests = nil
+
+			// The service is polled until it is ready.
+			test.update(&opts)
+			deployment, err := client.CreateFunction(opts)
+			if err != nil {
+				t.Fatalf("create function: %v", err)
+			}
+			if want := apiServer.url + "/route/hello-raika"; deployment.URL != want {
+				t.Fatalf("want URL %q, got %q", want, deployment.URL)
+			}
+			if got := apiServer.writes(); strings.Join(got, "\n") != strings.Join(test.wantWrites, "\n") {
+				t.Fatalf("want writes %q, got %q", test.wantWrites, got)
+			}
+			service := apiServer.service("hello-raika")
+			source := apiServer.pingSource("hello-raika-hourly")
+			if service == nil || source == nil {
+				t.Fatalf("want the service and the ping source, got %+v and %+v", service, source)
+			}
+			test.check(t, service, source)
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		wantErr  error
+	}{
+		{name: "deployed", deployed: true},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newStubAPIServer(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+
+			info, err := client.Describe(opts.Name)
+			if err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if info.Name != opts.Name || info.Description != opts.Description || info.MemorySize != 128 ||
+				info.RuntimeTimeout != opts.RuntimeTimeout || info.CodeChecksum != "sha256:v1" || !info.Active ||
+				info.EnvironmentVariables["GREETING"] != "hi" {
+				t.Fatalf("unexpected function info: %+v", info)
+			}
+		})
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		wantBody string
+		wantErr  error
+	}{
+		{name: "deployed", deployed: true, wantBody: "hello-raika: ping"},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newStubAPIServer(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+
+			resp, err := client.Invoke(opts.Name, []byte("ping"))
+			if err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.StatusCode != http.StatusOK || string(resp.Body) != test.wantBody || resp.Error != "" {
+				t.Fatalf("unexpected invoke response: %d %q %q", resp.StatusCode, resp.Body, resp.Error)
+			}
+		})
+	}
+}
+
+func TestDeleteFunction(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployed   bool
+		wantWrites []string
+		wantErr    error
+	}{
+		// The ping sources are deleted with the service.
+		{
+			name:     "deployed",
+			deployed: true,
+			wantWrites: []string{
+				"DELETE /apis/sources.knative.dev/v1/namespaces/raika/pingsources/hello-raika-hourly",
+				"DELETE /apis/serving.knative.dev/v1/namespaces/raika/services/hello-raika",
+			},
+		},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiServer, client := newStubAPIServer(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+			apiServer.requests = nil
+
+			if err := client.DeleteFunction(opts.Name); err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if got := apiServer.writes(); strings.Join(got, "\n") != strings.Join(test.wantWrites, "\n") {
+				t.Fatalf("want writes %q, got %q", test.wantWrites, got)
+			}
+			if apiServer.service("hello-raika") != nil || apiServer.pingSource("hello-raika-hourly") != nil {
+				t.Fatal("function is not deleted")
+			}
+		})
+	}
+}
+
+func TestClientNotReady(t *testing.T) {
+	apiServer, client := newStubAPIServer(t)
+	apiServer.notReady = "RevisionFailed"
+
+	_, err := client.CreateFunction(platform.CreateFunctionOptions{
+		Name:  "hello",
+		Image: "registry.example.com/raika/hello:v1",
+	})
+	want := `service "hello" is not ready: RevisionFailed: failed to pull the image`
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	apiServer, client := newStubAPIServer(t)
+	if err := client.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "GET /apis/serving.knative.dev/v1/namespaces/raika/services"; len(apiServer.requests) != 1 || apiServer.requests[0] != want {
+		t.Fatalf("want %q, got %q", want, apiServer.requests)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	_, client := newStubAPIServer(t)
+	cluster, err := client.getCluster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.authenticate = func(*http.Request) {}
+
+	if err := client.Authenticate(); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("want 401 error, got %v", err)
+	}
+}
+
+func TestLoadKubeConfig(t *testing.T) {
+	path := writeKubeConfig(t, `
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com/
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: dev
+  user:
+    token: dev-token
+- name: prod
+  user:
+    username: admin
+    password: secret
+- name: plugin
+  user:
+    exec:
+      command: kubectl-login
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+    namespace: raika
+- name: prod
+  context:
+    cluster: prod
+    user: prod
+- name: plugin
+  context:
+    cluster: prod
+    user: plugin
+- name: missing-cluster
+  context:
+    cluster: staging
+    user: dev
+`)
+
+	tests := []struct {
+		name          string
+		context       string
+		wantContext   string
+		wantNamespace string
+		wantServer    string
+		wantAuth      string
+		wantErr       string
+	}{
+		{
+			name:          "current context",
+			wantContext:   "dev",
+			wantNamespace: "raika",
+			wantServer:    "https://dev.example.com",
+			wantAuth:      "Bearer dev-token",
+		},
+		{
+			name:        "named context",
+			context:     "prod",
+			wantContext: "prod",
+			wantServer:  "https://prod.example.com",
+			wantAuth:    "Basic YWRtaW46c2VjcmV0",
+		},
+		{
+			name:    "context not found",
+			context: "staging",
+			wantErr: `context "staging" not found in kubeconfig`,
+		},
+		{
+			name:    "cluster not found",
+			context: "missing-cluster",
+			wantErr: `cluster "staging" not found in kubeconfig`,
+		},
+		{
+			name:    "exec plugin",
+			context: "plugin",
+			wantErr: "exec and auth provider plugins are not supported",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster, err := loadKubeConfig(path, test.context)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cluster.context != test.wantContext || cluster.namespace != test.wantNamespace || cluster.server != test.wantServer {
+				t.Fatalf("unexpected cluster: %q %q %q", cluster.context, cluster.namespace, cluster.server)
+			}
+			req := httptest.NewRequest(http.MethodGet, cluster.server, nil)
+			cluster.authenticate(req)
+			if got := req.Header.Get("Authorization"); got != test.wantAuth {
+				t.Fatalf("want authorization %q, got %q", test.wantAuth, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Username              string      `yaml:"username"`
+			Password              string      `yaml:"password"`
+			Exec                  interface{} `yaml:"exec"`
+			AuthProvider          interface{} `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// cluster is the API server connection of a kubeconfig context.
+type cluster struct {
+	// context is the name of the resolved context.
+	context   string
+	server    string
+	namespace string
+	client    *http.Client
+	// authenticate sets the credentials of the user to the request.
+	authenticate func(req *http.Request)
+}
+
+// loadKubeConfig loads the connection of the context in the kubeconfig file,
+// the current context is used if the context is empty. The exec and the auth
+// provider plugins are not supported.
+func loadKubeConfig(path, context string) (*cluster, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read kubeconfig")
+	}
+	var config kubeConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "parse kubeconfig")
+	}
+	// The file paths are relative to the kubeconfig file.
+	resolve := func(name string) string {
+		if name == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(filepath.Dir(path), name)
+	}
+
+	if context == "" {
+		context = config.CurrentContext
+	}
+	var clusterName, userName, namespace string
+	found := false
+	for _, c := range config.Contexts {
+		if c.Name == context {
+			clusterName, userName, namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("context %q not found in kubeconfig", context)
+	}
+
+	result := &cluster{context: context, namespace: namespace}
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		result.server = strings.TrimRight(c.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := dataOrFile(c.Cluster.CertificateAuthorityData, resolve(c.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, errors.Wrap(err, "load certificate authority")
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, errors.New("invalid certificate authority")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		break
+	}
+	if !found {
+		return nil, errors.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+
+	result.authenticate = func(*http.Request) {}
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		user := u.User
+		if user.Exec != nil || user.AuthProvider != nil {
+			return nil, errors.Errorf("user %q: exec and auth provider plugins are not supported, use a token or a client certificate", userName)
+		}
+
+		certificate, err := dataOrFile(user.ClientCertificateData, resolve(user.ClientCertificate))
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+		key, err := dataOrFile(user.ClientKeyData, resolve(user.ClientKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "load client key")
+		}
+		if certificate != nil && key != nil {
+			pair, err := tls.X509KeyPair(certificate, key)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse client certificate")
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+
+		token := user.Token
+		if token == "" && user.TokenFile != "" {
+			data, err := os.ReadFile(resolve(user.TokenFile))
+			if err != nil {
+				return nil, errors.Wrap(err, "read token file")
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		switch {
+		case token != "":
+			result.authenticate = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+		case user.Username != "":
+			result.authenticate = func(req *http.Request) { req.SetBasicAuth(user.Username, user.Password) }
+		}
+		break
+	}
+
+	result.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return result, nil
+}
+
+// dataOrFile returns the base64 decoded data, or the content of the file.
+func dataOrFile(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// Logs returns the logs of the user containers in the running pods of the
+// service, the logs of the terminated pods are gone as the service scales to zero.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	if _, err := c.getService(name); err != nil {
+		return nil, err
+	}
+
+	selector := serviceLabel + "=" + serviceName(name)
+	resp, err := c.request(http.MethodGet, fmt.Sprintf("/api/v1/namespaces/%s/pods?labelSelector=%s", c.namespace, url.QueryEscape(selector)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.ToError(), "list pods")
+	}
+	var pods struct {
+		Items []struct {
+			Metadata ObjectMeta `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := resp.ToJSON(&pods); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	var entries []*platform.LogEntry
+	for _, pod := range pods.Items {
+		query := url.Values{
+			"container":  {userContainerName},
+			"timestamps": {"true"},
+		}
+		if !opts.Since.IsZero() {
+			query.Set("sinceTime", opts.Since.UTC().Format(time.RFC3339))
+		}
+		resp, err := c.request(http.MethodGet, fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/log?%s", c.namespace, pod.Metadata.Name, query.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Wrapf(resp.ToError(), "get logs of pod %q", pod.Metadata.Name)
+		}
+
+		// Each line is prefixed with the RFC3339 timestamp.
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			parts := strings.SplitN(scanner.Text(), " ", 2)
+			t, err := time.Parse(time.RFC3339Nano, parts[0])
+			if err != nil || len(parts) != 2 {
+				continue
+			}
+			if !opts.Until.IsZero() && t.After(opts.Until) {
+				continue
+			}
+			entries = append(entries, &platform.LogEntry{Time: t, Message: parts[1]})
+		}
+		_ = resp.Body.Close()
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.Knative,
+		Fields: []platform.Field{
+			{Name: KubeConfigField, Usage: "Path of the kubeconfig file, defaults to ~/.kube/config"},
+			{Name: ContextField, Usage: "Context in the kubeconfig file, defaults to the current context"},
+			{Name: NamespaceField, Usage: "Namespace of the Knative services, defaults to the namespace of the context"},
+			{Name: RegistryField, Usage: "Registry repository prefix to push the function images to"},
+			{Name: RegistryUsernameField, Usage: "Registry username"},
+			{Name: RegistryPasswordField, Usage: "Registry password", Secret: true},
+		},
+		Prepare: func(opts platform.AuthenticateOptions) error {
+			if opts[KubeConfigField] == "" {
+				homePath, _ := os.UserHomeDir()
+				opts[KubeConfigField] = filepath.Join(homePath, ".kube", "config")
+			}
+
+			// The context and the namespace are pinned, so that the account is
+			// not changed with the current context of the kubeconfig.
+			cluster, err := loadKubeConfig(opts[KubeConfigField], opts[ContextField])
+			if err != nil {
+				return err
+			}
+			opts[ContextField] = cluster.context
+			if opts[NamespaceField] == "" {
+				opts[NamespaceField] = cluster.namespace
+			}
+			if opts[NamespaceField] == "" {
+				opts[NamespaceField] = DefaultNamespace
+			}
+			return nil
+		},
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[ContextField] + "@" + opts[NamespaceField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts), nil
+		},
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// checkTrigger checks the triggers before deploying the function.
+func checkTrigger(opts platform.CreateFunctionOptions) error {
+	if err := platform.ValidateTriggers(opts.Triggers); err != nil {
+		return err
+	}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.CronTrigger {
+			if _, err := toSchedule(trigger.Cron); err != nil {
+				return errors.Wrapf(err, "trigger %q", trigger.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// toSchedule converts the cron expression with seconds to the schedule of
+// the ping source, which runs at most once per minute.
+func toSchedule(expr string) (string, error) {
+	fields, err := platform.ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	if fields[0] != "0" {
+		return "", errors.Errorf("cron %q: the second field must be 0 on knative", expr)
+	}
+	return strings.ReplaceAll(strings.Join(fields[1:], " "), "?", "*"), nil
+}
+
+// pingSourceName returns the name of the ping source of the trigger. The
+// trigger name is kept in the annotations.
+func pingSourceName(functionName, triggerName string) string {
+	return serviceName(functionName) + "-" + strings.ToLower(strings.ReplaceAll(triggerName, "_", "-"))
+}
+
+type KReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+type Destination struct {
+	Ref *KReference `json:"ref,omitempty"`
+	URI string      `json:"uri,omitempty"`
+}
+
+type PingSourceSpec struct {
+	Schedule    string      `json:"schedule"`
+	Timezone    string      `json:"timezone,omitempty"`
+	ContentType string      `json:"contentType,omitempty"`
+	Data        string      `json:"data,omitempty"`
+	Sink        Destination `json:"sink"`
+}
+
+type PingSource struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   ObjectMeta     `json:"metadata"`
+	Spec       PingSourceSpec `json:"spec"`
+}
+
+// ensurePingSources creates or updates the ping sources of the cron
+// triggers. The ping sources post to the service, or the URL of the alias if
+// the alias is set.
+func (c *Client) ensurePingSources(opts platform.CreateFunctionOptions, aliasURL string) error {
+	for _, trigger := range opts.Triggers {
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+
+		schedule, err := toSchedule(trigger.Cron)
+		if err != nil {
+			return err
+		}
+		sink := Destination{Ref: &KReference{
+			APIVersion: "serving.knative.dev/v1",
+			Kind:       "Service",
+			Name:       serviceName(opts.Name),
+		}}
+		if opts.Alias != "" {
+			sink = Destination{URI: aliasURL}
+		}
+
+		source := &PingSource{
+			APIVersion: "sources.knative.dev/v1",
+			Kind:       "PingSource",
+			Metadata: ObjectMeta{
+				Name:        pingSourceName(opts.Name, trigger.Name),
+				Namespace:   c.namespace,
+				Labels:      map[string]string{functionLabel: serviceName(opts.Name)},
+				Annotations: map[string]string{triggerNameAnnotation: trigger.Name},
+			},
+			Spec: PingSourceSpec{
+				Schedule:    schedule,
+				Timezone:    "UTC",
+				ContentType: "text/plain",
+				Data:        trigger.Payload,
+				Sink:        sink,
+			},
+		}
+
+		log.Trace("Put ping source of trigger %q...", trigger.Name)
+		if err := c.putPingSource(source); err != nil {
+			return errors.Wrapf(err, "put ping source of trigger %q", trigger.Name)
+		}
+	}
+	return nil
+}
+
+// putPingSource replaces the ping source, it is created if it does not exist.
+func (c *Client) putPingSource(source *PingSource) error {
+	path := c.pingSourcesPath() + "/" + source.Metadata.Name
+	resp, err := c.request(http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		var current PingSource
+		if err := resp.ToJSON(&current); err != nil {
+			return errors.Wrap(err, "json decode")
+		}
+		source.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+
+		resp, err = c.request(http.MethodPut, path, source)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Wrap(resp.ToError(), "update ping source")
+		}
+		_ = resp.Body.Close()
+		return nil
+	} else if resp.StatusCode != http.StatusNotFound {
+		return errors.Wrap(resp.ToError(), "get ping source")
+	}
+	_ = resp.Body.Close()
+
+	resp, err = c.request(http.MethodPost, c.pingSourcesPath(), source)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "create ping source")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// listPingSources returns the ping sources of the function.
+func (c *Client) listPingSources(functionName string) ([]*PingSource, error) {
+	selector := functionLabel + "=" + serviceName(functionName)
+	resp, err := c.request(http.MethodGet, c.pingSourcesPath()+"?labelSelector="+url.QueryEscape(selector))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// Knative Eventing is not installed.
+		_ = resp.Body.Close()
+		return nil, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, resp.ToError()
+	}
+
+	var list struct {
+		Items []*PingSource `json:"items"`
+	}
+	if err := resp.ToJSON(&list); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return list.Items, nil
+}
+
+// deletePingSource deletes the ping source, it is ignored if the ping source does not exist.
+func (c *Client) deletePingSource(name string) error {
+	resp, err := c.request(http.MethodDelete, c.pingSourcesPath()+"/"+name)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNotFound {
+		return resp.ToError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// RemoveTrigger makes the service cluster-local for the HTTP trigger, or
+// deletes the ping source for the cron trigger.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	service, err := c.getService(functionName)
+	if err != nil {
+		return err
+	}
+
+	if service.Metadata.Annotations[httpTriggerAnnotation] != triggerName {
+		sources, err := c.listPingSources(functionName)
+		if err != nil {
+			return errors.Wrap(err, "list ping sources")
+		}
+		for _, source := range sources {
+			if source.Metadata.Annotations[triggerNameAnnotation] == triggerName {
+				return c.deletePingSource(source.Metadata.Name)
+			}
+		}
+		return nil
+	}
+
+	delete(service.Metadata.Annotations, httpTriggerAnnotation)
+	if service.Metadata.Labels == nil {
+		service.Metadata.Labels = make(map[string]string)
+	}
+	service.Metadata.Labels[visibilityLabel] = clusterLocalVisibility
+	return c.putService(http.MethodPut, c.servicePath(functionName), service)
+}
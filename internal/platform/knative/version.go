@@ -0,0 +1,53 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package knative
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+)
+
+// PublishVersion returns the latest ready revision of the service. Each
+// deployment creates an immutable revision on Knative, so nothing is published.
+func (c *Client) PublishVersion(name, _ string) (string, error) {
+	service, err := c.getService(name)
+	if err != nil {
+		return "", err
+	}
+	if service.Status.LatestReadyRevisionName == "" {
+		return "", errors.Errorf("service %q has no ready revision", serviceName(name))
+	}
+	return service.Status.LatestReadyRevisionName, nil
+}
+
+// UpdateAlias points the traffic tag to the revision, the tag receives no
+// traffic of the route URL but has its own URL.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	log.Trace("Point tag %q of %q to revision %q...", alias, serviceName(name), version)
+	service, err := c.getService(name)
+	if err != nil {
+		return err
+	}
+
+	traffic := make([]TrafficTarget, 0, len(service.Spec.Traffic)+1)
+	for _, target := range service.Spec.Traffic {
+		if target.Tag != alias {
+			traffic = append(traffic, target)
+		}
+	}
+	percent := int64(0)
+	service.Spec.Traffic = append(traffic, TrafficTarget{
+		Tag:          alias,
+		RevisionName: version,
+		Percent:      &percent,
+	})
+
+	if err := c.putService(http.MethodPut, c.servicePath(name), service); err != nil {
+		return err
+	}
+	return c.waitServiceReady(name, 0)
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+// Client runs the functions as the supervised processes on the local machine.
+type Client struct {
+	id   string
+	root string
+}
+
+func New(opts platform.AuthenticateOptions) *Client {
+	root := opts[RootField]
+	if root == "" {
+		homePath, _ := os.UserHomeDir()
+		root = filepath.Join(homePath, ".raika", "local")
+	}
+
+	return &Client{
+		id:   opts["id"],
+		root: root,
+	}
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.Local
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+// Target returns the target of the local machine.
+func (c *Client) Target() platform.Target {
+	return platform.Target{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+}
+
+func (c *Client) Authenticate() error {
+	if err := os.MkdirAll(c.root, 0755); err != nil {
+		return errors.Wrap(err, "mkdir")
+	}
+	return nil
+}
+
+// functionDir returns the directory of the function.
+func (c *Client) functionDir(name string) string {
+	return filepath.Join(c.root, name)
+}
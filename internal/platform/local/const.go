@@ -0,0 +1,14 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+const (
+	// RootField is the directory the functions are stored in, defaults to `~/.raika/local`.
+	RootField = "root"
+)
+
+// SupervisorCommand is the Raika command which runs the supervisor of a function,
+// the function directory is appended to it.
+var SupervisorCommand = []string{"platform", "local-supervise", "--dir"}
@@ -0,0 +1,371 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+const (
+	functionFileName   = "function.json"
+	bootstrapFileName  = "bootstrap"
+	codeDirName        = "code"
+	pidFileName        = "supervisor.pid"
+	outputFileName     = "output.log"
+	defaultInitTimeout = 10 * time.Second
+)
+
+// function is the state of a local function, it is stored in the function directory.
+type function struct {
+	Name                  string                 `json:"name"`
+	Description           string                 `json:"description"`
+	MemorySize            int64                  `json:"memory_size"`
+	Environment           map[string]string      `json:"environment"`
+	InitializationTimeout time.Duration          `json:"initialization_timeout"`
+	RuntimeTimeout        time.Duration          `json:"runtime_timeout"`
+	Triggers              []platform.TriggerSpec `json:"triggers"`
+	// Port is the loopback port the supervisor listens on, the requests are
+	// proxied to the function process.
+	Port         int       `json:"port"`
+	CodeChecksum string    `json:"code_checksum"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (f *function) url() string {
+	return fmt.Sprintf("http://127.0.0.1:%d/", f.Port)
+}
+
+func loadFunction(dir string) (*function, error) {
+	data, err := os.ReadFile(filepath.Join(dir, functionFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	var f function
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &f, nil
+}
+
+func saveFunction(dir string, f *function) error {
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json encode")
+	}
+	return os.WriteFile(filepath.Join(dir, functionFileName), data, 0644)
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if _, err := loadFunction(c.functionDir(opts.Name)); err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on local, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	} else if err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "load function")
+	}
+
+	if err := os.MkdirAll(c.functionDir(opts.Name), 0755); err != nil {
+		return nil, errors.Wrap(err, "mkdir")
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, errors.Wrap(err, "get free port")
+	}
+	return c.deploy(&function{Port: port}, opts)
+}
+
+// UpdateFunction replaces the binary and the configuration of the existing
+// function, and restarts its process. The URL of the function won't change.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	f, err := loadFunction(c.functionDir(opts.Name))
+	if err != nil {
+		return nil, err
+	}
+	return c.deploy(f, opts)
+}
+
+func (c *Client) deploy(f *function, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if opts.Alias != "" {
+		return nil, errors.New("alias is not supported on local")
+	}
+
+	dir := c.functionDir(opts.Name)
+	if err := stopSupervisor(dir); err != nil {
+		return nil, errors.Wrap(err, "stop supervisor")
+	}
+
+	checksum, err := unpack(opts, filepath.Join(dir, codeDirName))
+	if err != nil {
+		return nil, errors.Wrap(err, "unpack package")
+	}
+	// Remove the binary of the layout before the code directory.
+	_ = os.Remove(filepath.Join(dir, bootstrapFileName))
+
+	f.Name = opts.Name
+	f.Description = opts.Description
+	f.MemorySize = opts.MemorySize
+	f.Environment = opts.EnvironmentVariables
+	f.InitializationTimeout = opts.InitializationTimeout
+	f.RuntimeTimeout = opts.RuntimeTimeout
+	f.Triggers = opts.Triggers
+	f.CodeChecksum = checksum
+	f.UpdatedAt = time.Now()
+	if err := saveFunction(dir, f); err != nil {
+		return nil, errors.Wrap(err, "save function")
+	}
+
+	log.Trace("Start function %q on port %d...", opts.Name, f.Port)
+	if err := startSupervisor(dir); err != nil {
+		return nil, errors.Wrap(err, "start supervisor")
+	}
+	if err := waitFunctionReady(f); err != nil {
+		return nil, err
+	}
+
+	deployment := &platform.Deployment{}
+	for _, trigger := range opts.Triggers {
+		if trigger.Type == platform.HTTPTrigger {
+			deployment.URL = f.url()
+		}
+	}
+	return deployment, nil
+}
+
+// waitFunctionReady waits until the function process accepts the requests.
+func waitFunctionReady(f *function) error {
+	timeout := f.InitializationTimeout
+	if timeout <= 0 {
+		timeout = defaultInitTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		// The supervisor responds 502 before the function process is ready.
+		resp, err := http.Get(f.url() + readinessPath)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return errors.Errorf("function %q is not ready after %s", f.Name, timeout)
+}
+
+// unpack writes the files of the function package to the code directory, the
+// files of the previous package are removed. It returns the SHA-256 checksum
+// of the binary.
+func unpack(opts platform.CreateFunctionOptions, codeDir string) (string, error) {
+	entries, err := pack.Entries(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(codeDir); err != nil {
+		return "", errors.Wrap(err, "remove code directory")
+	}
+	var checksum string
+	for _, entry := range entries {
+		sum, err := writeEntry(entry, filepath.Join(codeDir, filepath.FromSlash(entry.Name)))
+		if err != nil {
+			return "", errors.Wrapf(err, "write %q", entry.Name)
+		}
+		if entry.Name == pack.BootstrapName {
+			checksum = sum
+		}
+	}
+	return checksum, nil
+}
+
+// writeEntry writes the package entry to the file, and returns its SHA-256 checksum.
+func writeEntry(entry pack.Entry, dst string) (string, error) {
+	source, err := entry.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "open file")
+	}
+	defer func() { _ = source.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", errors.Wrap(err, "mkdir")
+	}
+	target, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, entry.Mode)
+	if err != nil {
+		return "", errors.Wrap(err, "create file")
+	}
+	defer func() { _ = target.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(target, hash), source); err != nil {
+		return "", errors.Wrap(err, "copy")
+	}
+	return hex.EncodeToString(hash.Sum(nil)), target.Close()
+}
+
+// freePort returns a free loopback TCP port.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (c *Client) DeleteFunction(name string) error {
+	dir := c.functionDir(name)
+	if _, err := loadFunction(dir); err != nil {
+		return err
+	}
+
+	log.Trace("Delete function %q...", name)
+	if err := stopSupervisor(dir); err != nil {
+		return errors.Wrap(err, "stop supervisor")
+	}
+	return os.RemoveAll(dir)
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	dir := c.functionDir(name)
+	f, err := loadFunction(dir)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(dir, f), nil
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read dir")
+	}
+
+	functions := make([]*platform.FunctionInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(c.root, entry.Name())
+		f, err := loadFunction(dir)
+		if err != nil {
+			continue
+		}
+		info := toFunctionInfo(dir, f)
+		info.URL = f.url()
+		functions = append(functions, info)
+	}
+	return functions, nil
+}
+
+func toFunctionInfo(dir string, f *function) *platform.FunctionInfo {
+	status := "Stopped"
+	if supervisorRunning(dir) {
+		status = "Running"
+	}
+
+	return &platform.FunctionInfo{
+		Name:                  f.Name,
+		Description:           f.Description,
+		Status:                status,
+		Active:                status == "Running",
+		MemorySize:            f.MemorySize,
+		EnvironmentVariables:  f.Environment,
+		InitializationTimeout: f.InitializationTimeout,
+		RuntimeTimeout:        f.RuntimeTimeout,
+		CodeChecksum:          f.CodeChecksum,
+		UpdatedAt:             f.UpdatedAt,
+	}
+}
+
+// Invoke posts the payload to the function process.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	f, err := loadFunction(c.functionDir(name))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(f.url(), "application/octet-stream", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+// RemoveTrigger removes the trigger from the function, and restarts the
+// supervisor to stop the cron trigger.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	dir := c.functionDir(functionName)
+	f, err := loadFunction(dir)
+	if err != nil {
+		return err
+	}
+
+	triggers := make([]platform.TriggerSpec, 0, len(f.Triggers))
+	for _, trigger := range f.Triggers {
+		if trigger.Name != triggerName {
+			triggers = append(triggers, trigger)
+		}
+	}
+	if len(triggers) == len(f.Triggers) {
+		// The triggers are replaced on each deployment.
+		return nil
+	}
+	f.Triggers = triggers
+	if err := saveFunction(dir, f); err != nil {
+		return errors.Wrap(err, "save function")
+	}
+
+	if err := stopSupervisor(dir); err != nil {
+		return errors.Wrap(err, "stop supervisor")
+	}
+	return startSupervisor(dir)
+}
+
+func (c *Client) PublishVersion(string, string) (string, error) {
+	return "", errors.New("versions are not supported on local")
+}
+
+func (c *Client) UpdateAlias(string, string, string) error {
+	return errors.New("aliases are not supported on local")
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// testFunctionEnv makes the test binary run as the function, it is set in the
+// environment of the test functions.
+const testFunctionEnv = "RAIKA_LOCAL_TEST_FUNCTION"
+
+// TestMain runs the test binary as the supervisor or the function when it is
+// started by the local platform, so that no other binary is needed.
+func TestMain(m *testing.M) {
+	if len(os.Args) == len(SupervisorCommand)+2 && strings.Join(os.Args[1:len(os.Args)-1], " ") == strings.Join(SupervisorCommand, " ") {
+		if err := Supervise(os.Args[len(os.Args)-1]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if os.Getenv(testFunctionEnv) != "" {
+		serveTestFunction()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// serveTestFunction responds the PID of the function process and the payload.
+// The payload `sleep` sleeps for a while, and `exit` exits the process.
+func serveTestFunction() {
+	_ = http.ListenAndServe("127.0.0.1:"+os.Getenv("PORT"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		switch string(payload) {
+		case "sleep":
+			time.Sleep(5 * time.Second)
+		case "exit":
+			os.Exit(1)
+		}
+		_, _ = fmt.Fprintf(w, "%d %s", os.Getpid(), payload)
+	}))
+}
+
+// newTestClient returns the client with the functions stored in a temporary
+// directory, the functions are deleted after the test.
+func newTestClient(t *testing.T) *Client {
+	c := New(platform.AuthenticateOptions{RootField: t.TempDir()})
+	t.Cleanup(func() {
+		functions, _ := c.ListFunctions()
+		for _, f := range functions {
+			_ = c.DeleteFunction(f.Name)
+		}
+	})
+	return c
+}
+
+// testFunctionOptions returns the options to deploy the test binary as the function.
+func testFunctionOptions(t *testing.T, name string) platform.CreateFunctionOptions {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The memory is not limited, as the race detector reserves more than the
+	// data segment limit of a function. The limit is tested by TestCommandMemoryLimit.
+	return platform.CreateFunctionOptions{
+		Name:                  name,
+		EnvironmentVariables:  map[string]string{testFunctionEnv: "1"},
+		InitializationTimeout: 10 * time.Second,
+		RuntimeTimeout:        time.Second,
+		File:                  executable,
+		Triggers:              []platform.TriggerSpec{{Name: "http", Type: platform.HTTPTrigger}},
+	}
+}
+
+// invokePID invokes the function and returns the PID of the function process.
+func invokePID(t *testing.T, c *Client, name string) int {
+	resp, err := c.Invoke(name, []byte("ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(resp.Body))
+	if resp.StatusCode != http.StatusOK || len(fields) != 2 || fields[1] != "ping" {
+		t.Fatalf("unexpected response: %d %q", resp.StatusCode, resp.Body)
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pid
+}
+
+func TestFunction(t *testing.T) {
+	c := newTestClient(t)
+	opts := testFunctionOptions(t, "hello")
+
+	deployment, err := c.CreateFunction(opts)
+	if err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+	if deployment.URL == "" {
+		t.Fatal("want the URL of the HTTP trigger")
+	}
+	info, err := c.Describe(opts.Name)
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	if !info.Active || info.RuntimeTimeout != opts.RuntimeTimeout || info.CodeChecksum == "" {
+		t.Fatalf("unexpected function info: %+v", info)
+	}
+	pid := invokePID(t, c, opts.Name)
+
+	// The function is restarted on update, the URL is kept.
+	opts.Description = "Updated"
+	updated, err := c.CreateFunction(opts)
+	if err != nil {
+		t.Fatalf("update function: %v", err)
+	}
+	if updated.URL != deployment.URL {
+		t.Fatalf("want URL %q, got %q", deployment.URL, updated.URL)
+	}
+	if got := invokePID(t, c, opts.Name); got == pid {
+		t.Fatalf("want the function process restarted, got the same PID %d", got)
+	}
+
+	if err := c.DeleteFunction(opts.Name); err != nil {
+		t.Fatalf("delete function: %v", err)
+	}
+	if supervisorRunning(c.functionDir(opts.Name)) {
+		t.Fatal("want the supervisor stopped")
+	}
+	if _, err := http.Get(deployment.URL); err == nil {
+		t.Fatal("want the port of the function closed")
+	}
+	if _, err := c.Describe(opts.Name); err != platform.ErrFunctionNotExists {
+		t.Fatalf("want ErrFunctionNotExists, got %v", err)
+	}
+}
+
+func TestFunctionRestart(t *testing.T) {
+	c := newTestClient(t)
+	opts := testFunctionOptions(t, "crash")
+	if _, err := c.CreateFunction(opts); err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+	pid := invokePID(t, c, opts.Name)
+
+	if _, err := c.Invoke(opts.Name, []byte("exit")); err != nil {
+		t.Fatal(err)
+	}
+	// The process is restarted after the minimum restart delay.
+	deadline := time.Now().Add(minRestartDelay + 10*time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := c.Invoke(opts.Name, []byte("ping"))
+		if err == nil && resp.StatusCode == http.StatusOK {
+			if got := invokePID(t, c, opts.Name); got == pid {
+				t.Fatalf("want a new function process, got the same PID %d", got)
+			}
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	t.Fatal("the function is not restarted")
+}
+
+func TestFunctionTimeout(t *testing.T) {
+	c := newTestClient(t)
+	opts := testFunctionOptions(t, "slow")
+	if _, err := c.CreateFunction(opts); err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := c.Invoke(opts.Name, []byte("sleep"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout || resp.Error == "" {
+		t.Fatalf("want status %d with error, got %d %q", http.StatusGatewayTimeout, resp.StatusCode, resp.Body)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("want the request cancelled after %s, got %s", opts.RuntimeTimeout, elapsed)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// logWriter prefixes each line written to it with the current time.
+type logWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		index := bytes.IndexByte(w.buf, '\n')
+		if index == -1 {
+			break
+		}
+		line := w.buf[:index+1]
+		if _, err := io.WriteString(w.w, time.Now().Format(time.RFC3339Nano)+" "+string(line)); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[index+1:]
+	}
+	return len(p), nil
+}
+
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	dir := c.functionDir(name)
+	if _, err := loadFunction(dir); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, outputFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "open file")
+	}
+	defer func() { _ = file.Close() }()
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var entries []*platform.LogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, parts[0])
+		if err != nil {
+			continue
+		}
+		if t.Before(opts.Since) || t.After(until) {
+			continue
+		}
+		entries = append(entries, &platform.LogEntry{
+			Time:    t,
+			Message: parts[1],
+		})
+	}
+	return entries, scanner.Err()
+}
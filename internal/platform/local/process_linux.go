@@ -0,0 +1,41 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// command returns the command to run the function binary, the data segment
+// of the process is limited to the memory size in MB. The virtual memory is
+// not limited as the Go runtime reserves a large address space on start.
+func command(path string, memorySize int64) *exec.Cmd {
+	var cmd *exec.Cmd
+	if memorySize > 0 {
+		// The limit is set by the shell, so it won't affect the supervisor itself.
+		cmd = exec.Command("/bin/sh", "-c", fmt.Sprintf(`ulimit -d %d && exec "$0"`, memorySize*1024), path)
+	} else {
+		cmd = exec.Command(path)
+	}
+	// Kill the function process once the supervisor exits.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	return cmd
+}
+
+// detachAttr detaches the supervisor from the terminal session.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+func alive(process *os.Process) bool {
+	return process.Signal(syscall.Signal(0)) == nil
+}
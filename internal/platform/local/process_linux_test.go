@@ -0,0 +1,96 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCommandMemoryLimit(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "bootstrap")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nulimit -d\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		memorySize int64
+		want       string
+	}{
+		{name: "limited", memorySize: 128, want: "131072"},
+		{name: "unlimited", memorySize: 0, want: "unlimited"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The limit of the test process is inherited if it is not set.
+			var limit syscall.Rlimit
+			if err := syscall.Getrlimit(syscall.RLIMIT_DATA, &limit); err != nil {
+				t.Fatal(err)
+			}
+			if test.memorySize == 0 && limit.Cur != ^uint64(0) {
+				t.Skipf("the data segment of the test process is limited to %d", limit.Cur)
+			}
+
+			output, err := command(script, test.memorySize).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(output)); got != test.want {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFunctionKilledWithSupervisor(t *testing.T) {
+	c := newTestClient(t)
+	opts := testFunctionOptions(t, "orphan")
+	if _, err := c.CreateFunction(opts); err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+	pid := invokePID(t, c, opts.Name)
+
+	supervisorPID, err := readPID(c.functionDir(opts.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The supervisor can't stop the function process when it is killed.
+	if err := syscall.Kill(supervisorPID, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	// The supervisor is started by the test process, reap it so that it is
+	// not taken as running.
+	if _, err := syscall.Wait4(supervisorPID, nil, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if !processRunning(pid) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("want the function process %d killed with the supervisor", pid)
+}
+
+// processRunning returns true if the process is running, the zombie process
+// not reaped yet is not running.
+func processRunning(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name in parentheses.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	return len(fields) != 0 && fields[0] != "Z" && fields[0] != "X"
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package local
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// command returns the command to run the function binary, the memory limit
+// is only enforced on Linux.
+func command(path string, _ int64) *exec.Cmd {
+	return exec.Command(path)
+}
+
+func detachAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func terminate(process *os.Process) error {
+	if err := process.Signal(os.Interrupt); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+func alive(process *os.Process) bool {
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.Local,
+		Fields: []platform.Field{
+			{Name: RootField, Usage: "Directory the local functions are stored in, defaults to ~/.raika/local"},
+		},
+		AccountID: func(platform.AuthenticateOptions) string {
+			return "127.0.0.1"
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts), nil
+		},
+	})
+}
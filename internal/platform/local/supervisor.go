@@ -0,0 +1,297 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package local
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// readinessPath is handled by the supervisor, it responds 204 once the function process accepts connections.
+const readinessPath = "__raika/ready"
+
+const (
+	minRestartDelay = time.Second
+	maxRestartDelay = 30 * time.Second
+)
+
+// startSupervisor starts the supervisor of the function in background.
+func startSupervisor(dir string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "get executable")
+	}
+
+	output, err := os.OpenFile(filepath.Join(dir, outputFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "open output file")
+	}
+	defer func() { _ = output.Close() }()
+
+	cmd := exec.Command(executable, append(SupervisorCommand, dir)...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// stopSupervisor stops the supervisor of the function and the function process.
+func stopSupervisor(dir string) error {
+	pid, err := readPID(dir)
+	if err != nil || pid == 0 {
+		return nil
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	if err := terminate(process); err != nil {
+		return nil
+	}
+
+	// Wait for the supervisor releasing the port.
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if !supervisorRunning(dir) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return errors.Errorf("supervisor %d is still running", pid)
+}
+
+// supervisorRunning returns true if the supervisor of the function is running.
+func supervisorRunning(dir string) bool {
+	pid, err := readPID(dir)
+	if err != nil || pid == 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return alive(process)
+}
+
+func readPID(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, pidFileName))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// Supervise runs the function in the given directory in foreground. It
+// proxies the requests on the function port to the function process, fires
+// the cron triggers, and restarts the process if it exits.
+func Supervise(dir string) error {
+	f, err := loadFunction(dir)
+	if err != nil {
+		return errors.Wrap(err, "load function")
+	}
+
+	pidFile := filepath.Join(dir, pidFileName)
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return errors.Wrap(err, "write pid file")
+	}
+	defer func() { _ = os.Remove(pidFile) }()
+
+	s := &supervisor{
+		dir:      dir,
+		function: f,
+		output:   &logWriter{w: os.Stdout},
+		stopped:  make(chan struct{}),
+	}
+	return s.run()
+}
+
+type supervisor struct {
+	dir      string
+	function *function
+	output   io.Writer
+
+	mu      sync.Mutex
+	port    int // The port of the function process.
+	cmd     *exec.Cmd
+	stopped chan struct{}
+}
+
+func (s *supervisor) run() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.function.Port))
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+	server := &http.Server{Handler: s}
+	go func() { _ = server.Serve(listener) }()
+
+	c := cron.New(cron.WithSeconds())
+	for _, trigger := range s.function.Triggers {
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+		trigger := trigger
+		if _, err := c.AddFunc(trigger.Cron, func() { s.fire(trigger) }); err != nil {
+			return errors.Wrapf(err, "add cron trigger %q", trigger.Name)
+		}
+	}
+	c.Start()
+
+	go s.keepAlive()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	close(s.stopped)
+	c.Stop()
+	_ = server.Close()
+
+	s.mu.Lock()
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// keepAlive starts the function process, and restarts it once it exits.
+func (s *supervisor) keepAlive() {
+	delay := minRestartDelay
+	for {
+		startAt := time.Now()
+		err := s.start()
+		if err == nil {
+			err = s.cmd.Wait()
+		}
+
+		select {
+		case <-s.stopped:
+			return
+		default:
+		}
+
+		// Reset the delay if the process has run for a while.
+		if time.Since(startAt) > maxRestartDelay {
+			delay = minRestartDelay
+		}
+		_, _ = fmt.Fprintf(s.output, "Function process exited: %v, restart in %s\n", err, delay)
+		time.Sleep(delay)
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+func (s *supervisor) start() error {
+	port, err := freePort()
+	if err != nil {
+		return errors.Wrap(err, "get free port")
+	}
+
+	// The functions deployed before the code directory have the binary in
+	// the function directory.
+	codeDir := filepath.Join(s.dir, codeDirName)
+	if _, err := os.Stat(codeDir); os.IsNotExist(err) {
+		codeDir = s.dir
+	}
+	cmd := command(filepath.Join(codeDir, bootstrapFileName), s.function.MemorySize)
+	cmd.Dir = codeDir
+	cmd.Stdout = s.output
+	cmd.Stderr = s.output
+	cmd.Env = os.Environ()
+	for k, v := range s.function.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Env = append(cmd.Env,
+		"PORT="+strconv.Itoa(port),
+		"FC_SERVER_PORT="+strconv.Itoa(port),
+		"FUNCTIONS_CUSTOMHANDLER_PORT="+strconv.Itoa(port),
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	s.port, s.cmd = port, cmd
+	return nil
+}
+
+func (s *supervisor) target() *url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", s.port)}
+}
+
+func (s *supervisor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := s.target()
+
+	if r.URL.Path == "/"+readinessPath {
+		conn, err := net.DialTimeout("tcp", target.Host, time.Second)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = conn.Close()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if s.function.RuntimeTimeout > 0 {
+		ctx, cancel := gocontext.WithTimeout(r.Context(), s.function.RuntimeTimeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if errors.Is(r.Context().Err(), gocontext.DeadlineExceeded) {
+			http.Error(w, "function timed out", http.StatusGatewayTimeout)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// fire posts the payload of the cron trigger to the function process.
+func (s *supervisor) fire(trigger platform.TriggerSpec) {
+	req, err := http.NewRequest(http.MethodPost, s.function.url(), bytes.NewReader([]byte(trigger.Payload)))
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-Raika-Trigger", trigger.Name)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		_, _ = fmt.Fprintf(s.output, "Failed to fire trigger %q: %v\n", trigger.Name, err)
+		return
+	}
+	_ = resp.Body.Close()
+}
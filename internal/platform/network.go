@@ -0,0 +1,88 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package platform
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The network fields shared by the platforms calling the cloud APIs over HTTP.
+const (
+	// EndpointField overrides the API endpoint of the platform, e.g. the
+	// VPC endpoint or the `Raika emulator`.
+	EndpointField = "endpoint"
+	ProxyField    = "proxy"
+	CABundleField = "ca_bundle"
+)
+
+// NetworkFields are the proxy and the CA bundle fields, they are appended to
+// the schemas of the platforms.
+var NetworkFields = []Field{
+	{Name: ProxyField, Usage: "Proxy URL of the API requests, e.g. socks5://127.0.0.1:1080"},
+	{Name: CABundleField, Usage: "Path of the PEM encoded CA certificates trusted besides the system ones", File: true},
+}
+
+// ParseEndpoint parses the endpoint URL in `http[s]://host[:port][/path]`
+// format, the trailing slash of the path is removed.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse endpoint")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unsupported endpoint scheme %q, it should be http or https", u.Scheme)
+	} else if u.Host == "" {
+		return nil, errors.Errorf("endpoint %q has no host", endpoint)
+	} else if u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.Errorf("endpoint %q should not have query or fragment", endpoint)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// NewHTTPClient returns the HTTP client with the proxy and the CA bundle in the
+// options, it is http.DefaultClient if neither of them is set.
+func NewHTTPClient(opts AuthenticateOptions) (*http.Client, error) {
+	proxy, caBundle := opts[ProxyField], opts[CABundleField]
+	if proxy == "" && caBundle == "" {
+		return http.DefaultClient, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse proxy")
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, errors.Errorf("unsupported proxy scheme %q, it should be http, https or socks5", u.Scheme)
+		}
+		if u.Host == "" {
+			return nil, errors.Errorf("proxy %q has no host", proxy)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if caBundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, errors.New("no PEM encoded certificate is found in the CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport}, nil
+}
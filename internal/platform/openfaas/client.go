@@ -0,0 +1,136 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+type Client struct {
+	id                 string
+	gateway            string
+	username, password string
+
+	registry                           string
+	registryUsername, registryPassword string
+
+	httpClient *http.Client
+}
+
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	username := opts[UsernameField]
+	if username == "" {
+		username = DefaultUsername
+	}
+
+	return &Client{
+		id:               opts["id"],
+		gateway:          strings.TrimRight(opts[GatewayField], "/"),
+		username:         username,
+		password:         opts[PasswordField],
+		registry:         opts[RegistryField],
+		registryUsername: opts[RegistryUsernameField],
+		registryPassword: opts[RegistryPasswordField],
+		httpClient:       httpClient,
+	}, nil
+}
+
+func (c *Client) String() string {
+	return string(c.Platform())
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.OpenFaaS
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+// Authenticate checks the credentials with the system API of the gateway.
+func (c *Client) Authenticate() error {
+	resp, err := c.request(http.MethodGet, "/system/info")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(resp.ToError(), "get system info")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// request sends the request to the gateway with basic auth.
+func (c *Client) request(method, path string, requestBody ...interface{}) (*response, error) {
+	var body io.Reader
+	if len(requestBody) == 1 {
+		reqBody, err := json.Marshal(requestBody[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "JSON encode")
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequest(method, c.gateway+path, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request")
+	}
+
+	return &response{
+		Response: resp,
+	}, nil
+}
+
+type response struct {
+	*http.Response
+}
+
+func (r *response) ToJSON(v interface{}) error {
+	defer func() { _ = r.Body.Close() }()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (r *response) ToString() string {
+	defer func() { _ = r.Body.Close() }()
+	resp, _ := io.ReadAll(r.Body)
+	return string(resp)
+}
+
+// ToError returns the error in the response body, the gateway responds the error in plain text.
+func (r *response) ToError() error {
+	return errors.Errorf("%d: %s", r.StatusCode, strings.TrimSpace(r.ToString()))
+}
+
+// functionError returns platform.ErrFunctionNotExists if the function is not found.
+func (r *response) functionError() error {
+	if r.StatusCode == http.StatusNotFound {
+		_ = r.Body.Close()
+		return platform.ErrFunctionNotExists
+	}
+	return r.ToError()
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+const (
+	GatewayField  = "gateway"
+	UsernameField = "username"
+	PasswordField = "password"
+	// RegistryField is the repository prefix in `[http://]host[/namespace]`
+	// format, the images built from the binary are pushed to it.
+	RegistryField         = "registry"
+	RegistryUsernameField = "registry_username"
+	RegistryPasswordField = "registry_password"
+)
+
+// DefaultUsername is the default user of the OpenFaaS gateway.
+const DefaultUsername = "admin"
+
+// Port is the port the function container listens on, it is the port of
+// the OpenFaaS watchdog.
+const Port = 8080
+
+// The annotations of the function.
+const (
+	functionNameAnnotation   = "com.raika.function-name"
+	descriptionAnnotation    = "com.raika.description"
+	runtimeTimeoutAnnotation = "com.raika.runtime-timeout"
+	httpTriggerAnnotation    = "com.raika.http-trigger"
+	cronTriggerAnnotation    = "com.raika.cron-trigger"
+	// The annotations of the cron-connector.
+	topicAnnotation    = "topic"
+	scheduleAnnotation = "schedule"
+)
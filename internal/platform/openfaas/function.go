@@ -0,0 +1,348 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+	"github.com/wuhan005/Raika/internal/platform/registry"
+)
+
+const defaultReadyTimeout = 2 * time.Minute
+
+// serviceName returns the OpenFaaS function name, it only contains lowercase
+// letters, digits and hyphens.
+func serviceName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+type FunctionResources struct {
+	Memory string `json:"memory,omitempty"`
+	CPU    string `json:"cpu,omitempty"`
+}
+
+type FunctionDeployment struct {
+	Service     string             `json:"service"`
+	Image       string             `json:"image"`
+	EnvVars     map[string]string  `json:"envVars,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+	Limits      *FunctionResources `json:"limits,omitempty"`
+}
+
+type FunctionStatus struct {
+	Name              string             `json:"name"`
+	Image             string             `json:"image"`
+	InvocationCount   float64            `json:"invocationCount"`
+	Replicas          uint64             `json:"replicas"`
+	AvailableReplicas uint64             `json:"availableReplicas"`
+	EnvVars           map[string]string  `json:"envVars"`
+	Labels            map[string]string  `json:"labels"`
+	Annotations       map[string]string  `json:"annotations"`
+	Limits            *FunctionResources `json:"limits"`
+	CreatedAt         time.Time          `json:"createdAt"`
+}
+
+// deployment returns the deployment of the current function.
+func (s *FunctionStatus) deployment() *FunctionDeployment {
+	annotations := make(map[string]string, len(s.Annotations))
+	for k, v := range s.Annotations {
+		annotations[k] = v
+	}
+	return &FunctionDeployment{
+		Service:     s.Name,
+		Image:       s.Image,
+		EnvVars:     s.EnvVars,
+		Labels:      s.Labels,
+		Annotations: annotations,
+		Limits:      s.Limits,
+	}
+}
+
+func (c *Client) functionURL(name string) string {
+	return c.gateway + "/function/" + serviceName(name) + "/"
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	// Check current function name exists.
+	_, err := c.getFunction(opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on openfaas, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+	return c.deploy(http.MethodPost, opts)
+}
+
+// UpdateFunction replaces the image and the configuration of the existing
+// function, the function URL won't change.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if _, err := c.getFunction(opts.Name); err != nil {
+		return nil, err
+	}
+	return c.deploy(http.MethodPut, opts)
+}
+
+func (c *Client) deploy(method string, opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if opts.Alias != "" {
+		return nil, errors.New("alias is not supported on openfaas")
+	}
+	if err := c.CheckTriggers(opts.Triggers); err != nil {
+		return nil, err
+	}
+
+	image := opts.Image
+	if image == "" {
+		var err error
+		image, err = c.pushImage(opts.Name, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "push image")
+		}
+	}
+
+	environment := map[string]string{
+		// The function binary listens on the port of the watchdog.
+		"PORT": strconv.Itoa(Port),
+	}
+	for k, v := range opts.EnvironmentVariables {
+		environment[k] = v
+	}
+
+	deployment := &FunctionDeployment{
+		Service: serviceName(opts.Name),
+		Image:   image,
+		EnvVars: environment,
+		Annotations: map[string]string{
+			functionNameAnnotation:   opts.Name,
+			descriptionAnnotation:    opts.Description,
+			runtimeTimeoutAnnotation: opts.RuntimeTimeout.String(),
+		},
+	}
+	if opts.MemorySize > 0 {
+		deployment.Limits = &FunctionResources{Memory: strconv.FormatInt(opts.MemorySize, 10) + "Mi"}
+	}
+
+	var triggerURL string
+	for _, trigger := range opts.Triggers {
+		switch trigger.Type {
+		case platform.HTTPTrigger:
+			deployment.Annotations[httpTriggerAnnotation] = trigger.Name
+			triggerURL = c.functionURL(opts.Name)
+		case platform.CronTrigger:
+			schedule, _ := toSchedule(trigger.Cron)
+			deployment.Annotations[cronTriggerAnnotation] = trigger.Name
+			deployment.Annotations[topicAnnotation] = cronTopic
+			deployment.Annotations[scheduleAnnotation] = schedule
+		}
+	}
+
+	log.Trace("Deploy function %q with image %q...", deployment.Service, image)
+	if err := c.putFunction(method, deployment); err != nil {
+		return nil, err
+	}
+	if err := c.waitFunctionReady(opts); err != nil {
+		return nil, err
+	}
+	return &platform.Deployment{URL: triggerURL}, nil
+}
+
+// pushImage builds the image from the function package, and pushes it to the registry.
+func (c *Client) pushImage(name string, opts platform.CreateFunctionOptions) (string, error) {
+	if c.registry == "" {
+		return "", errors.New("registry is required to build the image from the binary, or deploy an image instead")
+	}
+
+	entries, err := pack.Entries(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "list package entries")
+	}
+	image, err := registry.Build(entries)
+	if err != nil {
+		return "", errors.Wrap(err, "build image")
+	}
+
+	client, namespace := registry.ParseRepository(c.registry, c.registryUsername, c.registryPassword)
+	client.HTTPClient = c.httpClient
+	repository := serviceName(name)
+	if namespace != "" {
+		repository = namespace + "/" + repository
+	}
+	log.Trace("Push image to %s/%s...", client.Host, repository)
+	return client.Push(repository, "latest", image)
+}
+
+func (c *Client) putFunction(method string, deployment *FunctionDeployment) error {
+	resp, err := c.request(method, "/system/functions", deployment)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return errors.Wrap(resp.functionError(), "deploy function")
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// waitFunctionReady waits until the function has an available replica.
+func (c *Client) waitFunctionReady(opts platform.CreateFunctionOptions) error {
+	timeout := opts.InitializationTimeout
+	if timeout < defaultReadyTimeout {
+		timeout = defaultReadyTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		status, err := c.getFunction(opts.Name)
+		if err != nil && err != platform.ErrFunctionNotExists {
+			return errors.Wrap(err, "get function")
+		}
+		if status != nil && status.AvailableReplicas > 0 {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return errors.Errorf("function %q is not ready after %s", opts.Name, timeout)
+}
+
+func (c *Client) getFunction(name string) (*FunctionStatus, error) {
+	resp, err := c.request(http.MethodGet, "/system/function/"+serviceName(name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+
+	var status FunctionStatus
+	if err := resp.ToJSON(&status); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	return &status, nil
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	status, err := c.getFunction(name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunctionInfo(status), nil
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	resp, err := c.request(http.MethodGet, "/system/functions")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(resp.ToError(), "list functions")
+	}
+
+	var respJSON []*FunctionStatus
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+
+	functions := make([]*platform.FunctionInfo, 0, len(respJSON))
+	for _, status := range respJSON {
+		info := toFunctionInfo(status)
+		// All the functions are served on the gateway.
+		info.URL = c.gateway + "/function/" + status.Name + "/"
+		functions = append(functions, info)
+	}
+	return functions, nil
+}
+
+func toFunctionInfo(status *FunctionStatus) *platform.FunctionInfo {
+	name := status.Annotations[functionNameAnnotation]
+	if name == "" {
+		name = status.Name
+	}
+
+	environment := make(map[string]string)
+	for k, v := range status.EnvVars {
+		if k != "PORT" {
+			environment[k] = v
+		}
+	}
+
+	var memorySize int64
+	if status.Limits != nil {
+		memorySize, _ = strconv.ParseInt(strings.TrimSuffix(status.Limits.Memory, "Mi"), 10, 64)
+	}
+	runtimeTimeout, _ := time.ParseDuration(status.Annotations[runtimeTimeoutAnnotation])
+
+	info := &platform.FunctionInfo{
+		Name:                 name,
+		Description:          status.Annotations[descriptionAnnotation],
+		Status:               "NotReady",
+		MemorySize:           memorySize,
+		EnvironmentVariables: environment,
+		RuntimeTimeout:       runtimeTimeout,
+		UpdatedAt:            status.CreatedAt,
+	}
+	if status.AvailableReplicas > 0 {
+		info.Status = "Ready"
+		info.Active = true
+	}
+	// The image is referenced by its digest if it is built by Raika.
+	if i := strings.LastIndex(status.Image, "@"); i != -1 {
+		info.CodeChecksum = status.Image[i+1:]
+	}
+	return info
+}
+
+// Invoke posts the payload to the function through the gateway.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	if _, err := c.getFunction(name); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Post(c.functionURL(name), "application/octet-stream", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+func (c *Client) DeleteFunction(name string) error {
+	log.Trace("Delete function %q...", serviceName(name))
+	resp, err := c.request(http.MethodDelete, "/system/functions", map[string]string{
+		"functionName": serviceName(name),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return resp.functionError()
+	}
+	_ = resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,423 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// stubGateway is the OpenFaaS gateway serving the functions in memory, the
+// functions echo the request body.
+type stubGateway struct {
+	mu        sync.Mutex
+	functions map[string]*FunctionStatus
+	// requests are the method and the path of the authorized requests.
+	requests []string
+}
+
+func newStubGateway(t *testing.T) (*stubGateway, *Client) {
+	gateway := &stubGateway{functions: make(map[string]*FunctionStatus)}
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+
+	client, err := New(platform.AuthenticateOptions{
+		"id":          "openfaas-test",
+		GatewayField:  server.URL + "/",
+		PasswordField: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gateway, client
+}
+
+func (g *stubGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// The functions are invoked without auth.
+	if strings.HasPrefix(r.URL.Path, "/function/") {
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/function/"), "/")
+		if _, ok := g.functions[name]; !ok {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		g.requests = append(g.requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte(name+": "), body...))
+		return
+	}
+
+	if username, password, ok := r.BasicAuth(); !ok || username != DefaultUsername || password != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	g.requests = append(g.requests, r.Method+" "+r.URL.Path)
+
+	switch {
+	case r.URL.Path == "/system/info" && r.Method == http.MethodGet:
+		_, _ = w.Write([]byte(`{"provider":{"provider":"stub"}}`))
+
+	case r.URL.Path == "/system/functions" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		var deployment FunctionDeployment
+		if err := json.NewDecoder(r.Body).Decode(&deployment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, exists := g.functions[deployment.Service]
+		if r.Method == http.MethodPost && exists {
+			http.Error(w, "function already exists", http.StatusBadRequest)
+			return
+		} else if r.Method == http.MethodPut && !exists {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		g.functions[deployment.Service] = &FunctionStatus{
+			Name:              deployment.Service,
+			Image:             deployment.Image,
+			Replicas:          1,
+			AvailableReplicas: 1,
+			EnvVars:           deployment.EnvVars,
+			Labels:            deployment.Labels,
+			Annotations:       deployment.Annotations,
+			Limits:            deployment.Limits,
+			CreatedAt:         time.Now(),
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+	case r.URL.Path == "/system/functions" && r.Method == http.MethodGet:
+		functions := make([]*FunctionStatus, 0, len(g.functions))
+		for _, function := range g.functions {
+			functions = append(functions, function)
+		}
+		_ = json.NewEncoder(w).Encode(functions)
+
+	case r.URL.Path == "/system/functions" && r.Method == http.MethodDelete:
+		var request struct {
+			FunctionName string `json:"functionName"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := g.functions[request.FunctionName]; !ok {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		delete(g.functions, request.FunctionName)
+		w.WriteHeader(http.StatusAccepted)
+
+	case strings.HasPrefix(r.URL.Path, "/system/function/") && r.Method == http.MethodGet:
+		function, ok := g.functions[strings.TrimPrefix(r.URL.Path, "/system/function/")]
+		if !ok {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(function)
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (g *stubGateway) function(name string) *FunctionStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.functions[name]
+}
+
+// testFunctionOptions returns the options of the function with an HTTP and a cron trigger.
+func testFunctionOptions() platform.CreateFunctionOptions {
+	return platform.CreateFunctionOptions{
+		Name:                 "hello_raika",
+		Description:          "Hello",
+		MemorySize:           128,
+		EnvironmentVariables: map[string]string{"GREETING": "hi"},
+		RuntimeTimeout:       10 * time.Second,
+		Image:                "registry.example.com/raika/hello:v1",
+		Triggers: []platform.TriggerSpec{
+			{Name: platform.HTTPTriggerName, Type: platform.HTTPTrigger},
+			{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+		},
+	}
+}
+
+// deploy deploys the function with the options to the gateway.
+func deploy(t *testing.T, client *Client, opts platform.CreateFunctionOptions) {
+	if _, err := client.CreateFunction(opts); err != nil {
+		t.Fatalf("create function: %v", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{name: "valid", password: "secret"},
+		{name: "wrong password", password: "wrong", wantErr: "401"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gateway, client := newStubGateway(t)
+			client.password = test.password
+
+			err := client.Authenticate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(gateway.requests) != 1 || gateway.requests[0] != "GET /system/info" {
+					t.Fatalf("unexpected requests: %q", gateway.requests)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateFunction(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    bool
+		update      func(opts *platform.CreateFunctionOptions)
+		wantRequest string
+		check       func(t *testing.T, function *FunctionStatus)
+	}{
+		{
+			name:        "create",
+			update:      func(*platform.CreateFunctionOptions) {},
+			wantRequest: "POST /system/functions",
+			check: func(t *testing.T, function *FunctionStatus) {
+				if function.Image != "registry.example.com/raika/hello:v1" || function.EnvVars["PORT"] != "8080" || function.EnvVars["GREETING"] != "hi" {
+					t.Fatalf("unexpected deployment: %+v", function)
+				}
+				if function.Annotations[scheduleAnnotation] != "0 * * * *" || function.Annotations[topicAnnotation] != cronTopic {
+					t.Fatalf("unexpected cron annotations: %v", function.Annotations)
+				}
+			},
+		},
+		{
+			name:     "update in place",
+			existing: true,
+			update: func(opts *platform.CreateFunctionOptions) {
+				opts.Image = "registry.example.com/raika/hello:v2"
+				opts.Triggers = opts.Triggers[:1]
+			},
+			wantRequest: "PUT /system/functions",
+			check: func(t *testing.T, function *FunctionStatus) {
+				if function.Image != "registry.example.com/raika/hello:v2" {
+					t.Fatalf("want image v2, got %q", function.Image)
+				}
+				if _, ok := function.Annotations[scheduleAnnotation]; ok {
+					t.Fatalf("want cron annotations removed, got %v", function.Annotations)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gateway, client := newStubGateway(t)
+			opts := testFunctionOptions()
+			if test.existing {
+				deploy(t, client, opts)
+			}
+			gateway.requests = nil
+
+			test.update(&opts)
+			deployment, err := client.CreateFunction(opts)
+			if err != nil {
+				t.Fatalf("create function: %v", err)
+			}
+			if want := client.gateway + "/function/hello-raika/"; deployment.URL != want {
+				t.Fatalf("want URL %q, got %q", want, deployment.URL)
+			}
+			var writes []string
+			for _, request := range gateway.requests {
+				if !strings.HasPrefix(request, http.MethodGet+" ") {
+					writes = append(writes, request)
+				}
+			}
+			if len(writes) != 1 || writes[0] != test.wantRequest {
+				t.Fatalf("want the function deployed by %q, got %q", test.wantRequest, writes)
+			}
+			function := gateway.function("hello-raika")
+			if function == nil {
+				t.Fatal("function is not deployed")
+			}
+			test.check(t, function)
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		wantErr  error
+	}{
+		{name: "deployed", deployed: true},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newStubGateway(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+
+			info, err := client.Describe(opts.Name)
+			if err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if info.Name != opts.Name || info.Description != opts.Description || info.MemorySize != 128 ||
+				info.RuntimeTimeout != opts.RuntimeTimeout || !info.Active || platform.ConfigurationChanged(info, opts) {
+				t.Fatalf("unexpected function info: %+v", info)
+			}
+		})
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		wantBody string
+		wantErr  error
+	}{
+		{name: "deployed", deployed: true, wantBody: "hello-raika: ping"},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newStubGateway(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+
+			resp, err := client.Invoke(opts.Name, []byte("ping"))
+			if err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.StatusCode != http.StatusOK || string(resp.Body) != test.wantBody || resp.Error != "" {
+				t.Fatalf("unexpected invoke response: %d %q %q", resp.StatusCode, resp.Body, resp.Error)
+			}
+		})
+	}
+}
+
+func TestDeleteFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		wantErr  error
+	}{
+		{name: "deployed", deployed: true},
+		{name: "not deployed", wantErr: platform.ErrFunctionNotExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gateway, client := newStubGateway(t)
+			opts := testFunctionOptions()
+			if test.deployed {
+				deploy(t, client, opts)
+			}
+
+			if err := client.DeleteFunction(opts.Name); err != test.wantErr {
+				t.Fatalf("want %v, got %v", test.wantErr, err)
+			}
+			if gateway.function("hello-raika") != nil {
+				t.Fatal("function is not deleted")
+			}
+		})
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	_, client := newStubGateway(t)
+	client.password = "wrong"
+
+	if err := client.Authenticate(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("want 401 error, got %v", err)
+	}
+	_, err := client.CreateFunction(platform.CreateFunctionOptions{Name: "hello", Image: "hello:v1"})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("want 401 error, got %v", err)
+	}
+}
+
+func TestCheckTriggers(t *testing.T) {
+	tests := []struct {
+		name     string
+		triggers []platform.TriggerSpec
+		wantErr  string
+	}{
+		{
+			name: "http and cron",
+			triggers: []platform.TriggerSpec{
+				{Name: "http", Type: platform.HTTPTrigger},
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+			},
+		},
+		{
+			name: "several cron triggers",
+			triggers: []platform.TriggerSpec{
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+				{Name: "nightly", Type: platform.CronTrigger, Cron: "0 0 2 * * *"},
+			},
+			wantErr: `trigger "nightly": only one cron trigger is supported`,
+		},
+		{
+			name: "payload",
+			triggers: []platform.TriggerSpec{
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *", Payload: "{}"},
+			},
+			wantErr: `trigger "hourly": payload is not supported`,
+		},
+		{
+			name: "seconds",
+			triggers: []platform.TriggerSpec{
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "30 0 * * * *"},
+			},
+			wantErr: "the second field must be 0",
+		},
+	}
+	client := &Client{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := platform.CheckTriggers(client, test.triggers)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+type logMessage struct {
+	Name      string    `json:"name"`
+	Instance  string    `json:"instance"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text"`
+}
+
+// Logs returns the function logs from the log provider of the gateway.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	query := url.Values{
+		"name":   {serviceName(name)},
+		"since":  {opts.Since.UTC().Format(time.RFC3339)},
+		"follow": {"false"},
+	}
+	resp, err := c.request(http.MethodGet, "/system/logs?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.functionError()
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var entries []*platform.LogEntry
+	// The logs are in newline delimited JSON.
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message logMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			continue
+		}
+		if !opts.Until.IsZero() && message.Timestamp.After(opts.Until) {
+			continue
+		}
+		entries = append(entries, &platform.LogEntry{
+			Time:    message.Timestamp,
+			Message: message.Text,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read logs")
+	}
+	return entries, nil
+}
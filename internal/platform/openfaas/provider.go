@@ -0,0 +1,41 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"net/url"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.OpenFaaS,
+		Fields: append([]platform.Field{
+			{Name: GatewayField, Usage: "URL of the OpenFaaS gateway", Required: true},
+			{Name: UsernameField, Usage: "Username of the gateway", Default: DefaultUsername},
+			{Name: PasswordField, Usage: "Password of the gateway", Secret: true},
+			{Name: RegistryField, Usage: "Registry repository prefix to push the function images to"},
+			{Name: RegistryUsernameField, Usage: "Registry username"},
+			{Name: RegistryPasswordField, Usage: "Registry password", Secret: true},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[UsernameField] + "@" + gatewayHost(opts[GatewayField])
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
+
+// gatewayHost returns the host of the gateway URL, it is used in the platform ID.
+func gatewayHost(gateway string) string {
+	u, err := url.Parse(gateway)
+	if err != nil || u.Host == "" {
+		return gateway
+	}
+	return u.Host
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openfaas
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// cronTopic is the topic the OpenFaaS cron-connector subscribes to.
+const cronTopic = "cron-function"
+
+// CheckTriggers checks the triggers before deploying the function. The
+// cron-connector reads the schedule from the single `schedule` annotation of
+// the function, and invokes it with an empty body, so one cron trigger without
+// payload is supported per function.
+func (c *Client) CheckTriggers(specs []platform.TriggerSpec) error {
+	if err := platform.ValidateTriggers(specs); err != nil {
+		return err
+	}
+
+	var cronTrigger string
+	for _, trigger := range specs {
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+		if cronTrigger != "" {
+			return errors.Errorf("trigger %q: only one cron trigger is supported on openfaas, as the cron-connector reads one schedule annotation per function, %q is already given", trigger.Name, cronTrigger)
+		}
+		cronTrigger = trigger.Name
+		if trigger.Payload != "" {
+			return errors.Errorf("trigger %q: payload is not supported on openfaas, as the cron-connector invokes the function with an empty body", trigger.Name)
+		}
+		if _, err := toSchedule(trigger.Cron); err != nil {
+			return errors.Wrapf(err, "trigger %q", trigger.Name)
+		}
+	}
+	return nil
+}
+
+// toSchedule converts the cron expression with seconds to the schedule of
+// the cron-connector, which runs at most once per minute.
+func toSchedule(expr string) (string, error) {
+	fields, err := platform.ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	if fields[0] != "0" {
+		return "", errors.Errorf("cron %q: the second field must be 0 on openfaas", expr)
+	}
+	return strings.ReplaceAll(strings.Join(fields[1:], " "), "?", "*"), nil
+}
+
+// RemoveTrigger removes the annotations of the trigger, and redeploys the
+// function. The function is still served on the gateway without the HTTP
+// trigger, only its URL is not recorded.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	status, err := c.getFunction(functionName)
+	if err != nil {
+		return err
+	}
+
+	deployment := status.deployment()
+	switch triggerName {
+	case deployment.Annotations[httpTriggerAnnotation]:
+		delete(deployment.Annotations, httpTriggerAnnotation)
+	case deployment.Annotations[cronTriggerAnnotation]:
+		delete(deployment.Annotations, cronTriggerAnnotation)
+		delete(deployment.Annotations, topicAnnotation)
+		delete(deployment.Annotations, scheduleAnnotation)
+	default:
+		return nil
+	}
+	return c.putFunction(http.MethodPut, deployment)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pack
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// rule is a glob of the files in the package directory, in the format of the
+// `.gitignore` patterns:
+//
+//   - The pattern without `/` matches the name at any level, e.g. `*.log`.
+//   - The other patterns match the path from the package directory, and `**`
+//     matches any number of directories, e.g. `assets/**/*.png`.
+//   - The pattern ending with `/` only matches the directories.
+//   - The pattern matching a directory matches all the files under it.
+//   - The pattern starting with `!` includes the files excluded by the
+//     previous patterns.
+type rule struct {
+	segments []string
+	anchored bool
+	dirOnly  bool
+	negate   bool
+}
+
+func parseRule(pattern string) (*rule, error) {
+	r := &rule{}
+	if strings.HasPrefix(pattern, "!") {
+		r.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		r.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		r.anchored = true
+		pattern = strings.TrimLeft(pattern, "/")
+	}
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	r.segments = strings.Split(pattern, "/")
+	for _, segment := range r.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, errors.Errorf("bad pattern %q", pattern)
+		}
+	}
+	return r, nil
+}
+
+func parseRules(patterns []string) ([]*rule, error) {
+	rules := make([]*rule, 0, len(patterns))
+	for _, pattern := range patterns {
+		r, err := parseRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// readIgnoreFile reads the rules in the ignore file, the empty lines and the
+// lines starting with `#` are skipped. It returns nil if the file does not exist.
+func readIgnoreFile(name string) ([]*rule, error) {
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var rules []*rule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		r, err := parseRule(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		rules = append(rules, r)
+	}
+	return rules, scanner.Err()
+}
+
+// match reports whether the rule matches the slash separated path, or any of
+// its parent directories.
+func (r *rule) match(name string, isDir bool) bool {
+	segments := strings.Split(name, "/")
+	for i := len(segments); i > 0; i-- {
+		// The parents of the path are always directories.
+		dir := isDir || i < len(segments)
+		if r.dirOnly && !dir {
+			continue
+		}
+
+		if r.anchored {
+			if matchSegments(r.segments, segments[:i]) {
+				return true
+			}
+		} else if ok, _ := path.Match(r.segments[0], segments[i-1]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the path segments with the pattern segments, where
+// `**` matches zero or more segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// matched reports whether the path is matched by the rules, the last matching
+// rule wins.
+func matched(rules []*rule, name string, isDir bool) bool {
+	var ok bool
+	for _, r := range rules {
+		if r.match(name, isDir) {
+			ok = !r.negate
+		}
+	}
+	return ok
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pack
+
+import (
+	"testing"
+)
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		isDir   bool
+		want    bool
+	}{
+		// The pattern without `/` matches the name at any level.
+		{pattern: "*.log", name: "debug.log", want: true},
+		{pattern: "*.log", name: "logs/debug.log", want: true},
+		{pattern: "*.log", name: "debug.log.txt", want: false},
+		{pattern: "tmp", name: "a/b/tmp", isDir: true, want: true},
+
+		// The other patterns match the path from the package directory.
+		{pattern: "assets/*.png", name: "assets/logo.png", want: true},
+		{pattern: "assets/*.png", name: "web/assets/logo.png", want: false},
+		{pattern: "assets/*.png", name: "assets/icons/logo.png", want: false},
+		{pattern: "/config.yml", name: "config.yml", want: true},
+		{pattern: "/config.yml", name: "conf/config.yml", want: false},
+
+		// `**` matches any number of directories.
+		{pattern: "assets/**/*.png", name: "assets/logo.png", want: true},
+		{pattern: "assets/**/*.png", name: "assets/icons/dark/logo.png", want: true},
+		{pattern: "**/testdata", name: "a/b/testdata", isDir: true, want: true},
+		{pattern: "**/testdata", name: "testdata", isDir: true, want: true},
+
+		// The pattern ending with `/` only matches the directories.
+		{pattern: "build/", name: "build", isDir: true, want: true},
+		{pattern: "build/", name: "build", want: false},
+		{pattern: "build/", name: "src/build", isDir: true, want: true},
+
+		// The pattern matching a directory matches all the files under it.
+		{pattern: "build/", name: "build/app/main.js", want: true},
+		{pattern: "templates", name: "templates/mail/welcome.html", want: true},
+		{pattern: "web/static", name: "web/static/css/app.css", want: true},
+		{pattern: "web/static", name: "static/css/app.css", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			r, err := parseRule(test.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.match(test.name, test.isDir); got != test.want {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMatched(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{name: "no rule", patterns: nil, path: "a.txt", want: false},
+		{name: "negated", patterns: []string{"*.txt", "!keep.txt"}, path: "keep.txt", want: false},
+		{name: "not negated", patterns: []string{"*.txt", "!keep.txt"}, path: "drop.txt", want: true},
+		{name: "last rule wins", patterns: []string{"!keep.txt", "*.txt"}, path: "keep.txt", want: true},
+		{name: "negated directory", patterns: []string{"docs/", "!docs/"}, path: "docs/index.md", want: false},
+		{name: "negation only", patterns: []string{"!*.txt"}, path: "a.txt", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := parseRules(test.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matched(rules, test.path, false); got != test.want {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseRuleError(t *testing.T) {
+	for _, pattern := range []string{"", "/", "!", "a/[b"} {
+		t.Run(pattern, func(t *testing.T) {
+			if _, err := parseRule(pattern); err == nil {
+				t.Fatalf("want error for pattern %q", pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package pack builds the code packages of the functions, which contain the
+// binary as `bootstrap`, the files of the package directory and the entries
+// required by the platform.
+package pack
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+const (
+	// BootstrapName is the name of the binary in the package.
+	BootstrapName = "bootstrap"
+	// IgnoreFileName is the file of the exclude globs in the package directory.
+	IgnoreFileName = ".raikaignore"
+)
+
+// modified is the modification time of all the entries, so that the same
+// files result in the same package.
+var modified = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Entry is a file in the package.
+type Entry struct {
+	// Name is the slash separated path in the package.
+	Name string
+	Mode os.FileMode
+	// Path is the file on the disk, the Data is used if it is empty.
+	Path string
+	Data []byte
+}
+
+// Open returns the reader of the entry content.
+func (e Entry) Open() (io.ReadCloser, error) {
+	if e.Path == "" {
+		return io.NopCloser(bytes.NewReader(e.Data)), nil
+	}
+	return os.Open(e.Path)
+}
+
+// Entries returns the entries of the function package in the name order: the
+// binary as `bootstrap`, the files of the package directory, and the extra
+// entries of the platform. The binary and the extra entries replace the files
+// of the package directory with the same names.
+func Entries(opts platform.CreateFunctionOptions, extra ...Entry) ([]Entry, error) {
+	if opts.File == "" {
+		return nil, errors.New("binary file is required")
+	}
+
+	entries := make(map[string]Entry)
+	if opts.Package != nil {
+		files, err := Files(opts.Package)
+		if err != nil {
+			return nil, errors.Wrap(err, "list package files")
+		}
+		for _, file := range files {
+			entries[file.Name] = file
+		}
+	}
+	entries[BootstrapName] = Entry{Name: BootstrapName, Mode: 0755, Path: opts.File}
+	for _, entry := range extra {
+		entries[entry.Name] = entry
+	}
+
+	list := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Zip returns the zip package of the function, see Entries for its content.
+func Zip(opts platform.CreateFunctionOptions, extra ...Entry) ([]byte, error) {
+	entries, err := Entries(opts, extra...)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(output)
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.Name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		}
+		header.SetMode(entry.Mode)
+		w, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create header of %q", entry.Name)
+		}
+
+		r, err := entry.Open()
+		if err != nil {
+			return nil, errors.Wrapf(err, "open %q", entry.Name)
+		}
+		_, err = io.Copy(w, r)
+		_ = r.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "copy %q", entry.Name)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// Files returns the regular files in the package directory filtered by the
+// spec, the symbolic links to the files are followed. The modes of the files
+// are kept.
+func Files(spec *platform.PackageSpec) ([]Entry, error) {
+	include, err := parseRules(spec.Include)
+	if err != nil {
+		return nil, errors.Wrap(err, "include")
+	}
+	exclude, err := parseRules(spec.Exclude)
+	if err != nil {
+		return nil, errors.Wrap(err, "exclude")
+	}
+	ignore, err := readIgnoreFile(filepath.Join(spec.Dir, IgnoreFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "read "+IgnoreFileName)
+	}
+	exclude = append(ignore, exclude...)
+
+	var entries []Entry
+	err = filepath.Walk(spec.Dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(spec.Dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+
+		if fi.IsDir() {
+			// Like `.gitignore`, the files in the excluded directory can't be
+			// included again by the negated patterns.
+			if matched(exclude, name, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == IgnoreFileName {
+			return nil
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			fi, err = os.Stat(path)
+			if err != nil {
+				return errors.Wrapf(err, "follow symbolic link %q", name)
+			}
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		if matched(exclude, name, false) {
+			return nil
+		}
+		if len(include) != 0 && !matched(include, name, false) {
+			return nil
+		}
+		entries = append(entries, Entry{Name: name, Mode: fi.Mode().Perm(), Path: path})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pack
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+// writeFiles writes the files with the modes to the directory, the file names
+// are slash separated.
+func writeFiles(t *testing.T, dir string, files map[string]os.FileMode) {
+	for name, mode := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+		// The modes are not affected by the umask.
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newPackageDir returns the package directory used by the tests.
+func newPackageDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]os.FileMode{
+		"config.yml":                  0644,
+		"templates/index.html":        0644,
+		"templates/mail/welcome.html": 0644,
+		"assets/logo.png":             0644,
+		"assets/icons/dark.png":       0644,
+		"debug.log":                   0644,
+		"logs/app.log":                0644,
+		"secrets/key.pem":             0600,
+		"scripts/run.sh":              0755,
+		IgnoreFileName:                0644,
+	})
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("# Local files\n*.log\n\nsecrets/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("config.yml", filepath.Join(dir, "link.yml")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFiles(t *testing.T) {
+	dir := newPackageDir(t)
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "ignore file",
+			want: []string{
+				"assets/icons/dark.png",
+				"assets/logo.png",
+				"config.yml",
+				"link.yml",
+				"scripts/run.sh",
+				"templates/index.html",
+				"templates/mail/welcome.html",
+			},
+		},
+		{
+			name:    "include",
+			include: []string{"templates/", "*.png"},
+			want: []string{
+				"assets/icons/dark.png",
+				"assets/logo.png",
+				"templates/index.html",
+				"templates/mail/welcome.html",
+			},
+		},
+		{
+			name:    "exclude wins over include",
+			include: []string{"templates/", "config.yml"},
+			exclude: []string{"mail/", "*.yml"},
+			want:    []string{"templates/index.html"},
+		},
+		{
+			name:    "ignore file wins over include",
+			include: []string{"*.log", "config.yml"},
+			want:    []string{"config.yml"},
+		},
+		{
+			name:    "anchored directory",
+			include: []string{"assets/icons"},
+			want:    []string{"assets/icons/dark.png"},
+		},
+		{
+			name:    "double star",
+			include: []string{"templates/**/*.html"},
+			want:    []string{"templates/index.html", "templates/mail/welcome.html"},
+		},
+		{
+			name:    "negation",
+			include: []string{"*.png"},
+			exclude: []string{"*.png", "!logo.png"},
+			want:    []string{"assets/logo.png"},
+		},
+		{
+			name:    "negation of the ignore file",
+			include: []string{"*.log"},
+			exclude: []string{"!debug.log"},
+			want:    []string{"debug.log"},
+		},
+		{
+			name:    "excluded directory is not included again",
+			include: []string{"assets/"},
+			exclude: []string{"assets/", "!assets/logo.png"},
+			want:    nil,
+		},
+		{
+			name:    "negated directory of the ignore file",
+			include: []string{"*.pem"},
+			exclude: []string{"!secrets/"},
+			want:    []string{"secrets/key.pem"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := Files(&platform.PackageSpec{Dir: dir, Include: test.include, Exclude: test.exclude})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFilesBadPattern(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Files(&platform.PackageSpec{Dir: dir, Include: []string{"a/[b"}}); err == nil || !strings.HasPrefix(err.Error(), "include") {
+		t.Fatalf("want include error, got %v", err)
+	}
+	if _, err := Files(&platform.PackageSpec{Dir: dir, Exclude: []string{"!"}}); err == nil || !strings.HasPrefix(err.Error(), "exclude") {
+		t.Fatalf("want exclude error, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("*.log\n[\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Files(&platform.PackageSpec{Dir: dir}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("want error of line 2, got %v", err)
+	}
+}
+
+type zipFile struct {
+	mode os.FileMode
+	data string
+}
+
+// readZip returns the files in the zip package by their names.
+func readZip(t *testing.T, data []byte) map[string]zipFile {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]zipFile, len(reader.File))
+	for _, file := range reader.File {
+		if !file.Modified.Equal(modified) {
+			t.Fatalf("%q: want modified time %v, got %v", file.Name, modified, file.Modified)
+		}
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = zipFile{mode: file.Mode(), data: string(content)}
+	}
+	return files
+}
+
+func TestZip(t *testing.T) {
+	dir := newPackageDir(t)
+	// The file named as the binary in the package directory is replaced.
+	writeFiles(t, dir, map[string]os.FileMode{BootstrapName: 0644})
+	binary := filepath.Join(t.TempDir(), "raika-binary")
+	if err := os.WriteFile(binary, []byte("binary"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := platform.CreateFunctionOptions{
+		File: binary,
+		Package: &platform.PackageSpec{
+			Dir:     dir,
+			Include: []string{"config.yml", "scripts/"},
+		},
+	}
+	// The extra entries are the ones the platforms require, see their packages.
+	tests := []struct {
+		name  string
+		extra []Entry
+		want  map[string]zipFile
+	}{
+		{
+			name: "aliyun",
+			want: map[string]zipFile{
+				BootstrapName:    {mode: 0755, data: "binary"},
+				"config.yml":     {mode: 0644, data: "config.yml"},
+				"scripts/run.sh": {mode: 0755, data: "scripts/run.sh"},
+			},
+		},
+		{
+			name: "tencentcloud",
+			extra: []Entry{
+				{Name: "scf_bootstrap", Mode: 0755, Data: []byte("#!/bin/bash\n./bootstrap")},
+			},
+			want: map[string]zipFile{
+				BootstrapName:    {mode: 0755, data: "binary"},
+				"scf_bootstrap":  {mode: 0755, data: "#!/bin/bash\n./bootstrap"},
+				"config.yml":     {mode: 0644, data: "config.yml"},
+				"scripts/run.sh": {mode: 0755, data: "scripts/run.sh"},
+			},
+		},
+		{
+			name: "aws",
+			extra: []Entry{
+				{Name: BootstrapName, Mode: 0755, Data: []byte("adapter")},
+				{Name: "raika-function", Mode: 0755, Path: binary},
+			},
+			want: map[string]zipFile{
+				BootstrapName:    {mode: 0755, data: "adapter"},
+				"raika-function": {mode: 0755, data: "binary"},
+				"config.yml":     {mode: 0644, data: "config.yml"},
+				"scripts/run.sh": {mode: 0755, data: "scripts/run.sh"},
+			},
+		},
+		{
+			name: "huaweicloud",
+			extra: []Entry{
+				{Name: BootstrapName, Mode: 0755, Data: []byte("#!/bin/sh\ncd /opt/function/code\nPORT=8000 exec ./raika-function\n")},
+				{Name: "raika-function", Mode: 0755, Path: binary},
+			},
+			want: map[string]zipFile{
+				BootstrapName:    {mode: 0755, data: "#!/bin/sh\ncd /opt/function/code\nPORT=8000 exec ./raika-function\n"},
+				"raika-function": {mode: 0755, data: "binary"},
+				"config.yml":     {mode: 0644, data: "config.yml"},
+				"scripts/run.sh": {mode: 0755, data: "scripts/run.sh"},
+			},
+		},
+		{
+			name: "package file replaced",
+			extra: []Entry{
+				{Name: "config.yml", Mode: 0600, Data: []byte("replaced")},
+			},
+			want: map[string]zipFile{
+				BootstrapName:    {mode: 0755, data: "binary"},
+				"config.yml":     {mode: 0600, data: "replaced"},
+				"scripts/run.sh": {mode: 0755, data: "scripts/run.sh"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Zip(opts, test.extra...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := readZip(t, data)
+			if len(got) != len(test.want) {
+				t.Fatalf("want %d files, got %v", len(test.want), got)
+			}
+			for name, want := range test.want {
+				if got[name] != want {
+					t.Fatalf("%q: want %v %q, got %v %q", name, want.mode, want.data, got[name].mode, got[name].data)
+				}
+			}
+
+			// The same files result in the same package.
+			again, err := Zip(opts, test.extra...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, again) {
+				t.Fatal("want the same package")
+			}
+		})
+	}
+}
+
+func TestEntriesWithoutBinary(t *testing.T) {
+	if _, err := Entries(platform.CreateFunctionOptions{}); err == nil {
+		t.Fatal("want error without the binary")
+	}
+}
@@ -5,15 +5,46 @@
 package platform
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/wuhan005/Raika/internal/types"
 )
 
 type AuthenticateOptions map[string]string
 
+var ErrFunctionNotExists = errors.New("function not found")
+
 type Cloud interface {
 	String() string
 	Platform() types.Platform
 	GetID() string
 	Authenticate() error
-	CreateFunction(opts CreateFunctionOptions) (string, error)
+	// CreateFunction creates the function and its triggers. The existing
+	// function is updated in place.
+	CreateFunction(opts CreateFunctionOptions) (*Deployment, error)
+	// UpdateFunction updates the code and the configuration of the existing
+	// function, the triggers under the function are kept.
+	UpdateFunction(opts CreateFunctionOptions) (*Deployment, error)
+	// DeleteFunction removes the function and the triggers under it.
+	// It returns ErrFunctionNotExists if the function does not exist.
+	DeleteFunction(name string) error
+	// Describe returns the live information of the function.
+	// It returns ErrFunctionNotExists if the function does not exist.
+	Describe(name string) (*FunctionInfo, error)
+	// ListFunctions returns all the functions on the platform, including the
+	// ones not deployed by Raika, with their HTTP trigger URLs.
+	ListFunctions() ([]*FunctionInfo, error)
+	// Invoke calls the function synchronously with the given payload
+	// through the invocation API of the platform.
+	Invoke(name string, payload []byte) (*InvokeResponse, error)
+	// Logs returns the logs of the function in time order.
+	Logs(name string, opts LogOptions) ([]*LogEntry, error)
+	// PublishVersion publishes an immutable version from the current code
+	// and configuration of the function, and returns the version ID.
+	PublishVersion(name, description string) (string, error)
+	// UpdateAlias points the alias of the function to the given version,
+	// the alias is created if it does not exist.
+	UpdateAlias(name, alias, version string) error
+	// RemoveTrigger deletes the trigger with the given name under the function.
+	RemoveTrigger(functionName, triggerName string) error
 }
@@ -0,0 +1,178 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+var _ platform.Cloud = (*Client)(nil)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// CheckName checks the plugin name, it must not be a built-in platform.
+func CheckName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return errors.Errorf("invalid plugin name %q, only lowercase letters, digits, `_` and `-` are allowed", name)
+	}
+	if _, ok := platform.Lookup(types.Platform(name)); ok {
+		return errors.Errorf("plugin name %q conflicts with the built-in platform", name)
+	}
+	return nil
+}
+
+// Lookup returns the path of the plugin executable in the PATH.
+func Lookup(name string) (string, error) {
+	path, err := exec.LookPath(ExecutablePrefix + name)
+	if err != nil {
+		return "", errors.Wrapf(err, "look up plugin %q", name)
+	}
+	return path, nil
+}
+
+// Handshake calls the info method of the plugin executable, and checks the
+// protocol version and the required methods.
+func Handshake(path string) (*Info, error) {
+	var info Info
+	if err := call(path, MethodInfo, nil, nil, &info); err != nil {
+		return nil, err
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		return nil, errors.Errorf("unsupported protocol version %d, expected %d", info.ProtocolVersion, ProtocolVersion)
+	}
+
+	methods := make(map[string]struct{}, len(info.Methods))
+	for _, method := range info.Methods {
+		methods[method] = struct{}{}
+	}
+	for _, method := range RequiredMethods {
+		if _, ok := methods[method]; !ok {
+			return nil, errors.Errorf("required method %q is not implemented", method)
+		}
+	}
+	return &info, nil
+}
+
+// Client calls the plugin executable for each method. The options of the
+// account are passed to the plugin on each call.
+type Client struct {
+	id        string
+	name      string
+	path      string
+	options   platform.AuthenticateOptions
+	accountID string
+}
+
+func New(name, path string, opts platform.AuthenticateOptions) *Client {
+	options := make(platform.AuthenticateOptions, len(opts))
+	for k, v := range opts {
+		if k != "id" {
+			options[k] = v
+		}
+	}
+
+	return &Client{
+		id:      opts["id"],
+		name:    name,
+		path:    path,
+		options: options,
+	}
+}
+
+func (c *Client) String() string {
+	return c.name
+}
+
+func (c *Client) Platform() types.Platform {
+	return types.Platform(c.name)
+}
+
+func (c *Client) GetID() string {
+	return c.id
+}
+
+// AccountID returns the account ID reported by the plugin on authentication.
+func (c *Client) AccountID() string {
+	return c.accountID
+}
+
+func (c *Client) Authenticate() error {
+	var result AuthenticateResult
+	if err := c.call(MethodAuthenticate, nil, &result); err != nil {
+		return err
+	}
+	c.accountID = result.AccountID
+	return nil
+}
+
+// call calls the method of the plugin. The not implemented error is reported
+// with the plugin name.
+func (c *Client) call(method string, params, result interface{}) error {
+	err := call(c.path, method, c.options, params, result)
+	if e, ok := err.(*Error); ok && e.Code == ErrorCodeNotImplemented {
+		return errors.Errorf("%s is not supported by the %q plugin", method, c.name)
+	}
+	return err
+}
+
+// call runs the plugin executable with the request on stdin, and decodes the
+// response on stdout. The stderr of the plugin is printed as is.
+func call(path, method string, options platform.AuthenticateOptions, params, result interface{}) error {
+	request, err := json.Marshal(Request{
+		Version: ProtocolVersion,
+		Method:  method,
+		Options: options,
+		Params:  params,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode")
+	}
+
+	log.Trace("Call plugin %q method %q...", path, method)
+	var stdout bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return errors.Wrap(runErr, "run plugin")
+		}
+		return errors.Wrap(err, "decode response")
+	}
+	if response.Version != ProtocolVersion {
+		return errors.Errorf("unsupported protocol version %d of the response, expected %d", response.Version, ProtocolVersion)
+	}
+
+	if response.Error != nil {
+		if response.Error.Code == ErrorCodeFunctionNotFound {
+			return platform.ErrFunctionNotExists
+		}
+		return response.Error
+	}
+	if runErr != nil {
+		return errors.Wrap(runErr, "run plugin")
+	}
+
+	if result != nil && len(response.Result) != 0 {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return errors.Wrap(err, "decode result")
+		}
+	}
+	return nil
+}
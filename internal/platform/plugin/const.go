@@ -0,0 +1,47 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package plugin
+
+// ProtocolVersion is the version of the JSON protocol spoken with the plugins.
+// It is increased on the incompatible changes of the protocol.
+const ProtocolVersion = 1
+
+// ExecutablePrefix is the prefix of the plugin executable name, the plugin
+// of the platform `foo` is the `raika-platform-foo` executable.
+const ExecutablePrefix = "raika-platform-"
+
+// The methods of the protocol. The info, authenticate, create_function,
+// delete_function and invoke methods must be implemented by the plugins.
+const (
+	MethodInfo           = "info"
+	MethodAuthenticate   = "authenticate"
+	MethodCreateFunction = "create_function"
+	MethodUpdateFunction = "update_function"
+	MethodDeleteFunction = "delete_function"
+	MethodDescribe       = "describe"
+	MethodListFunctions  = "list_functions"
+	MethodInvoke         = "invoke"
+	MethodLogs           = "logs"
+	MethodPublishVersion = "publish_version"
+	MethodUpdateAlias    = "update_alias"
+	MethodRemoveTrigger  = "remove_trigger"
+)
+
+// RequiredMethods are the methods every plugin must implement.
+var RequiredMethods = []string{
+	MethodAuthenticate,
+	MethodCreateFunction,
+	MethodDeleteFunction,
+	MethodInvoke,
+}
+
+// The error codes of the protocol.
+const (
+	// ErrorCodeFunctionNotFound is mapped to platform.ErrFunctionNotExists.
+	ErrorCodeFunctionNotFound = "function_not_found"
+	// ErrorCodeNotImplemented is returned for the optional methods the plugin
+	// does not implement.
+	ErrorCodeNotImplemented = "not_implemented"
+)
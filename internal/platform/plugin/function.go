@@ -0,0 +1,114 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package plugin
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	params, err := toCreateFunctionParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	var deployment Deployment
+	if err := c.call(MethodCreateFunction, params, &deployment); err != nil {
+		return nil, err
+	}
+	return &platform.Deployment{URL: deployment.URL, Version: deployment.Version}, nil
+}
+
+// UpdateFunction falls back to the create_function method, which updates the
+// existing function in place, if the plugin does not implement it.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	params, err := toCreateFunctionParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	var deployment Deployment
+	err = call(c.path, MethodUpdateFunction, c.options, params, &deployment)
+	if e, ok := err.(*Error); ok && e.Code == ErrorCodeNotImplemented {
+		if _, err := c.Describe(opts.Name); err != nil {
+			return nil, err
+		}
+		return c.CreateFunction(opts)
+	} else if err != nil {
+		return nil, err
+	}
+	return &platform.Deployment{URL: deployment.URL, Version: deployment.Version}, nil
+}
+
+func (c *Client) DeleteFunction(name string) error {
+	return c.call(MethodDeleteFunction, &FunctionParams{Name: name}, nil)
+}
+
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	var info FunctionInfo
+	if err := c.call(MethodDescribe, &FunctionParams{Name: name}, &info); err != nil {
+		return nil, err
+	}
+	return info.toFunctionInfo(), nil
+}
+
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var list []*FunctionInfo
+	if err := c.call(MethodListFunctions, nil, &list); err != nil {
+		return nil, err
+	}
+
+	functions := make([]*platform.FunctionInfo, 0, len(list))
+	for _, info := range list {
+		functions = append(functions, info.toFunctionInfo())
+	}
+	return functions, nil
+}
+
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	var response InvokeResponse
+	if err := c.call(MethodInvoke, &InvokeParams{Name: name, Payload: payload}, &response); err != nil {
+		return nil, err
+	}
+	return &platform.InvokeResponse{
+		StatusCode: response.StatusCode,
+		Body:       response.Body,
+		Error:      response.Error,
+	}, nil
+}
+
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	params := &LogsParams{Name: name}
+	if !opts.Since.IsZero() {
+		params.Since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		params.Until = &opts.Until
+	}
+
+	var entries []*LogEntry
+	if err := c.call(MethodLogs, params, &entries); err != nil {
+		return nil, err
+	}
+	logs := make([]*platform.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		logs = append(logs, &platform.LogEntry{ID: entry.ID, Time: entry.Time, Message: entry.Message})
+	}
+	return logs, nil
+}
+
+func (c *Client) PublishVersion(name, description string) (string, error) {
+	var result PublishVersionResult
+	if err := c.call(MethodPublishVersion, &PublishVersionParams{Name: name, Description: description}, &result); err != nil {
+		return "", err
+	}
+	return result.Version, nil
+}
+
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	return c.call(MethodUpdateAlias, &UpdateAliasParams{Name: name, Alias: alias, Version: version}, nil)
+}
+
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	return c.call(MethodRemoveTrigger, &RemoveTriggerParams{Name: functionName, Trigger: triggerName}, nil)
+}
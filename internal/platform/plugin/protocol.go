@@ -0,0 +1,202 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+// Request is written to the stdin of the plugin, one request per process.
+type Request struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+	// Options are the account options given by `--option` on login.
+	Options platform.AuthenticateOptions `json:"options"`
+	Params  interface{}                  `json:"params,omitempty"`
+}
+
+// Response is written to the stdout of the plugin. The error is set if the
+// method failed, otherwise the result is set.
+type Response struct {
+	Version int             `json:"version"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Info is the result of the info method.
+type Info struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description,omitempty"`
+	Methods         []string `json:"methods"`
+}
+
+// AuthenticateResult is the result of the authenticate method.
+type AuthenticateResult struct {
+	// AccountID identifies the account in the platform ID, the account name
+	// is used if it is empty.
+	AccountID string `json:"account_id,omitempty"`
+}
+
+// The durations are in seconds and the times are in RFC 3339 format on the wire.
+
+type FunctionParams struct {
+	Name string `json:"name"`
+}
+
+type CreateFunctionParams struct {
+	Name                  string                 `json:"name"`
+	Description           string                 `json:"description,omitempty"`
+	MemorySize            int64                  `json:"memory_size,omitempty"`
+	EnvironmentVariables  map[string]string      `json:"environment_variables,omitempty"`
+	InitializationTimeout int64                  `json:"initialization_timeout,omitempty"`
+	RuntimeTimeout        int64                  `json:"runtime_timeout,omitempty"`
+	File                  string                 `json:"file,omitempty"`
+	Files                 []PackageFile          `json:"files,omitempty"`
+	Image                 string                 `json:"image,omitempty"`
+	Triggers              []platform.TriggerSpec `json:"triggers,omitempty"`
+	HTTPPort              int                    `json:"http_port,omitempty"`
+	Alias                 string                 `json:"alias,omitempty"`
+}
+
+// PackageFile is a file of the package directory to pack with the binary, the
+// mode is the Unix permission bits.
+type PackageFile struct {
+	// Name is the slash separated path in the package.
+	Name string      `json:"name"`
+	Path string      `json:"path"`
+	Mode os.FileMode `json:"mode"`
+}
+
+type Deployment struct {
+	URL     string `json:"url,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type FunctionInfo struct {
+	Name                  string            `json:"name"`
+	Namespace             string            `json:"namespace,omitempty"`
+	Description           string            `json:"description,omitempty"`
+	Status                string            `json:"status,omitempty"`
+	Active                bool              `json:"active"`
+	MemorySize            int64             `json:"memory_size,omitempty"`
+	EnvironmentVariables  map[string]string `json:"environment_variables,omitempty"`
+	InitializationTimeout int64             `json:"initialization_timeout,omitempty"`
+	RuntimeTimeout        int64             `json:"runtime_timeout,omitempty"`
+	CodeChecksum          string            `json:"code_checksum,omitempty"`
+	UpdatedAt             time.Time         `json:"updated_at"`
+	URL                   string            `json:"url,omitempty"`
+}
+
+type InvokeParams struct {
+	Name string `json:"name"`
+	// Payload is base64 encoded.
+	Payload []byte `json:"payload"`
+}
+
+type InvokeResponse struct {
+	StatusCode int `json:"status_code"`
+	// Body is base64 encoded.
+	Body  []byte `json:"body"`
+	Error string `json:"error,omitempty"`
+}
+
+type LogsParams struct {
+	Name  string     `json:"name"`
+	Since *time.Time `json:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type LogEntry struct {
+	ID      string    `json:"id,omitempty"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+type PublishVersionParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type PublishVersionResult struct {
+	Version string `json:"version"`
+}
+
+type UpdateAliasParams struct {
+	Name    string `json:"name"`
+	Alias   string `json:"alias"`
+	Version string `json:"version"`
+}
+
+type RemoveTriggerParams struct {
+	Name    string `json:"name"`
+	Trigger string `json:"trigger"`
+}
+
+func toCreateFunctionParams(opts platform.CreateFunctionOptions) (*CreateFunctionParams, error) {
+	var files []PackageFile
+	if opts.Package != nil {
+		entries, err := pack.Files(opts.Package)
+		if err != nil {
+			return nil, errors.Wrap(err, "list package files")
+		}
+		files = make([]PackageFile, 0, len(entries))
+		for _, entry := range entries {
+			files = append(files, PackageFile{Name: entry.Name, Path: entry.Path, Mode: entry.Mode})
+		}
+	}
+
+	return &CreateFunctionParams{
+		Name:                  opts.Name,
+		Description:           opts.Description,
+		MemorySize:            opts.MemorySize,
+		EnvironmentVariables:  opts.EnvironmentVariables,
+		InitializationTimeout: int64(opts.InitializationTimeout / time.Second),
+		RuntimeTimeout:        int64(opts.RuntimeTimeout / time.Second),
+		File:                  opts.File,
+		Files:                 files,
+		Image:                 opts.Image,
+		Triggers:              opts.Triggers,
+		HTTPPort:              opts.HTTPPort,
+		Alias:                 opts.Alias,
+	}, nil
+}
+
+func (i *FunctionInfo) toFunctionInfo() *platform.FunctionInfo {
+	environmentVariables := i.EnvironmentVariables
+	if environmentVariables == nil {
+		environmentVariables = make(map[string]string)
+	}
+	return &platform.FunctionInfo{
+		Name:                  i.Name,
+		Namespace:             i.Namespace,
+		Description:           i.Description,
+		Status:                i.Status,
+		Active:                i.Active,
+		MemorySize:            i.MemorySize,
+		EnvironmentVariables:  environmentVariables,
+		InitializationTimeout: time.Duration(i.InitializationTimeout) * time.Second,
+		RuntimeTimeout:        time.Duration(i.RuntimeTimeout) * time.Second,
+		CodeChecksum:          i.CodeChecksum,
+		UpdatedAt:             i.UpdatedAt,
+		URL:                   i.URL,
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+// Field is a credential field in the schema of a platform.
+type Field struct {
+	// Name is the key of the field in the AuthenticateOptions, the login flag
+	// is the name with `_` replaced by `-`.
+	Name  string
+	Usage string
+	// Required fields must be given on login.
+	Required bool
+	// Secret fields can also be given by the `RAIKA_<FLAG>` environment
+	// variables, so that they are not left in the shell history.
+	Secret bool
+	// File fields are read from the file given by the `--<flag>-file` flag.
+	File bool
+	// Default is used if the field is not given.
+	Default string
+}
+
+// Flag returns the login flag name of the field.
+func (f Field) Flag() string {
+	flag := strings.ReplaceAll(f.Name, "_", "-")
+	if f.File {
+		flag += "-file"
+	}
+	return flag
+}
+
+// EnvVar returns the environment variable of the secret field.
+func (f Field) EnvVar() string {
+	return "RAIKA_" + strings.ToUpper(strings.ReplaceAll(f.Flag(), "-", "_"))
+}
+
+// Provider declares how to build the client of a platform.
+type Provider struct {
+	Platform types.Platform
+	Fields   []Field
+	// Prepare fills the fields derived from the other fields on login, it is optional.
+	Prepare func(opts AuthenticateOptions) error
+	// AccountID returns the account part of the platform ID, e.g. `<account>@<region>`.
+	AccountID func(opts AuthenticateOptions) string
+	// New creates the client from the options, the `id` option is the platform ID.
+	New func(opts AuthenticateOptions) (Cloud, error)
+}
+
+// Validate fills the defaults of the options, and checks the required fields
+// are given and no unknown field is given.
+func (p *Provider) Validate(opts AuthenticateOptions) error {
+	known := make(map[string]struct{}, len(p.Fields))
+	var missing []string
+	for _, field := range p.Fields {
+		known[field.Name] = struct{}{}
+		if opts[field.Name] == "" && field.Default != "" {
+			opts[field.Name] = field.Default
+		}
+		if opts[field.Name] == "" && field.Required {
+			missing = append(missing, "--"+field.Flag())
+		}
+	}
+
+	var unknown []string
+	for name := range opts {
+		if _, ok := known[name]; !ok && name != "id" {
+			unknown = append(unknown, "--"+Field{Name: name}.Flag())
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(missing) != 0 {
+		return errors.Errorf("missing required flags of %s: %s", p.Platform, strings.Join(missing, ", "))
+	}
+	if len(unknown) != 0 {
+		return errors.Errorf("flags not used by %s: %s", p.Platform, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Build validates the options and creates the client with the platform ID.
+func (p *Provider) Build(opts AuthenticateOptions) (Cloud, error) {
+	// The fields not in the schema are ignored.
+	options := make(AuthenticateOptions, len(p.Fields)+1)
+	for _, field := range p.Fields {
+		if value, ok := opts[field.Name]; ok {
+			options[field.Name] = value
+		}
+	}
+	if err := p.Validate(options); err != nil {
+		return nil, err
+	}
+	options["id"] = fmt.Sprintf("%s@%s", p.Platform, p.AccountID(options))
+	return p.New(options)
+}
+
+var providers = make(map[types.Platform]*Provider)
+
+// Register registers the provider of the platform, it is called in the init
+// functions of the platform packages.
+func Register(p *Provider) {
+	if _, ok := providers[p.Platform]; ok {
+		panic(fmt.Sprintf("platform %q is registered twice", p.Platform))
+	}
+	providers[p.Platform] = p
+}
+
+// Lookup returns the provider of the platform.
+func Lookup(p types.Platform) (*Provider, bool) {
+	provider, ok := providers[p]
+	return provider, ok
+}
+
+// Providers returns the registered providers sorted by the platform name.
+func Providers() []*Provider {
+	list := make([]*Provider, 0, len(providers))
+	for _, provider := range providers {
+		list = append(list, provider)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Platform < list[j].Platform
+	})
+	return list
+}
@@ -0,0 +1,375 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package registry builds the container images from the function package, and
+// pushes them to the Docker registries.
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
+const (
+	ManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	ConfigMediaType   = "application/vnd.docker.container.image.v1+json"
+	LayerMediaType    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Size      int    `json:"size"`
+	Digest    string `json:"digest"`
+}
+
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Image is a single layer image which runs the binary as `/bootstrap`.
+type Image struct {
+	Layer    []byte
+	Config   []byte
+	Manifest Manifest
+}
+
+// Build builds the linux/amd64 image of the package entries, which runs the
+// binary as `/bootstrap`. The modification times of the files are left empty,
+// so the same files result in the same image.
+func Build(entries []pack.Entry) (*Image, error) {
+	layer, diffID, err := buildLayer(entries)
+	if err != nil {
+		return nil, errors.Wrap(err, "build layer")
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"config": map[string]interface{}{
+			"Entrypoint": []string{"/bootstrap"},
+		},
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": []string{diffID},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "encode config")
+	}
+
+	return &Image{
+		Layer:  layer,
+		Config: config,
+		Manifest: Manifest{
+			SchemaVersion: 2,
+			MediaType:     ManifestMediaType,
+			Config:        Descriptor{MediaType: ConfigMediaType, Size: len(config), Digest: Digest(config)},
+			Layers:        []Descriptor{{MediaType: LayerMediaType, Size: len(layer), Digest: Digest(layer)}},
+		},
+	}, nil
+}
+
+// buildLayer returns the gzipped tar layer with the entries, and the digest of the uncompressed tar.
+func buildLayer(entries []pack.Entry) ([]byte, string, error) {
+	output := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(output)
+	diffHash := sha256.New()
+	tarWriter := tar.NewWriter(io.MultiWriter(gzipWriter, diffHash))
+	for _, entry := range entries {
+		if err := writeEntry(tarWriter, entry); err != nil {
+			return nil, "", errors.Wrapf(err, "write %q", entry.Name)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, "", err
+	}
+	return output.Bytes(), "sha256:" + hex.EncodeToString(diffHash.Sum(nil)), nil
+}
+
+func writeEntry(tarWriter *tar.Writer, entry pack.Entry) error {
+	size := int64(len(entry.Data))
+	if entry.Path != "" {
+		stat, err := os.Stat(entry.Path)
+		if err != nil {
+			return errors.Wrap(err, "stat file")
+		}
+		size = stat.Size()
+	}
+
+	r, err := entry.Open()
+	if err != nil {
+		return errors.Wrap(err, "open file")
+	}
+	defer func() { _ = r.Close() }()
+
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Mode:     int64(entry.Mode),
+		Size:     size,
+	}); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+	if _, err := io.Copy(tarWriter, r); err != nil {
+		return errors.Wrap(err, "copy")
+	}
+	return nil
+}
+
+// Digest returns the content digest of the data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Client is a Docker registry V2 client. It supports the anonymous, the basic
+// and the token authentication.
+type Client struct {
+	// Host is the registry host with the optional port.
+	Host string
+	// Insecure uses HTTP rather than HTTPS.
+	Insecure bool
+	Username string
+	Password string
+	// HTTPClient sends the requests to the registry, http.DefaultClient is
+	// used if it is nil.
+	HTTPClient *http.Client
+
+	mu            sync.Mutex
+	authorization map[string]string // Repository => Authorization header
+}
+
+// ParseRepository parses the repository prefix in `[http://]host[/namespace]`
+// format, and returns the registry client and the namespace.
+func ParseRepository(repository, username, password string) (*Client, string) {
+	client := &Client{Username: username, Password: password}
+	if strings.HasPrefix(repository, "http://") {
+		client.Insecure = true
+	}
+	repository = strings.TrimPrefix(strings.TrimPrefix(repository, "http://"), "https://")
+
+	parts := strings.SplitN(strings.Trim(repository, "/"), "/", 2)
+	client.Host = parts[0]
+	if len(parts) == 2 {
+		return client, parts[1]
+	}
+	return client, ""
+}
+
+// Push pushes the image to the repository with the tag, and returns the image
+// reference with its digest.
+func (c *Client) Push(repository, tag string, image *Image) (string, error) {
+	if err := c.pushBlob(repository, image.Layer, image.Manifest.Layers[0].Digest); err != nil {
+		return "", errors.Wrap(err, "push layer")
+	}
+	if err := c.pushBlob(repository, image.Config, image.Manifest.Config.Digest); err != nil {
+		return "", errors.Wrap(err, "push config")
+	}
+
+	body, err := json.Marshal(image.Manifest)
+	if err != nil {
+		return "", errors.Wrap(err, "json encode")
+	}
+	resp, err := c.do(repository, http.MethodPut, "/v2/"+repository+"/manifests/"+tag, ManifestMediaType, body)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		return "", errors.Errorf("push manifest: %s", responseError(resp))
+	}
+	return c.Host + "/" + repository + "@" + Digest(body), nil
+}
+
+// pushBlob uploads the blob in a single request, it is skipped if the blob exists.
+func (c *Client) pushBlob(repository string, blob []byte, digest string) error {
+	resp, err := c.do(repository, http.MethodHead, "/v2/"+repository+"/blobs/"+digest, "", nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = c.do(repository, http.MethodPost, "/v2/"+repository+"/blobs/uploads/", "", nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return errors.Errorf("start upload: %s", responseError(resp))
+	}
+
+	location := resp.Header.Get("Location")
+	if strings.Contains(location, "?") {
+		location += "&digest=" + url.QueryEscape(digest)
+	} else {
+		location += "?digest=" + url.QueryEscape(digest)
+	}
+	resp, err = c.do(repository, http.MethodPut, location, "application/octet-stream", blob)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Errorf("upload: %s", responseError(resp))
+	}
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) baseURL() string {
+	if c.Insecure {
+		return "http://" + c.Host
+	}
+	return "https://" + c.Host
+}
+
+// do sends the request to the registry, it authenticates with the challenge
+// of the registry if the request is unauthorized.
+func (c *Client) do(repository, method, u, contentType string, body []byte) (*http.Response, error) {
+	if strings.HasPrefix(u, "/") {
+		u = c.baseURL() + u
+	}
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "new request")
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		c.mu.Lock()
+		if authorization := c.authorization[repository]; authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		c.mu.Unlock()
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "do request")
+		}
+		return resp, nil
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	_ = resp.Body.Close()
+
+	authorization, err := c.authenticate(repository, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, errors.Wrap(err, "authenticate")
+	}
+	c.mu.Lock()
+	if c.authorization == nil {
+		c.authorization = make(map[string]string)
+	}
+	c.authorization[repository] = authorization
+	c.mu.Unlock()
+	return send()
+}
+
+// authenticate returns the Authorization header for the challenge.
+func (c *Client) authenticate(repository, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(c.Username, c.Password)
+		return req.Header.Get("Authorization"), nil
+
+	case "bearer":
+		query := url.Values{"scope": {"repository:" + repository + ":push,pull"}}
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		realm := params["realm"]
+		if realm == "" {
+			return "", errors.New("empty realm of the bearer challenge")
+		}
+		separator := "?"
+		if strings.Contains(realm, "?") {
+			separator = "&"
+		}
+		req, err := http.NewRequest(http.MethodGet, realm+separator+query.Encode(), nil)
+		if err != nil {
+			return "", errors.Wrap(err, "new request")
+		}
+		if c.Username != "" {
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return "", errors.Wrap(err, "request token")
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return "", errors.Errorf("request token: %s", responseError(resp))
+		}
+
+		var respJSON struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&respJSON); err != nil {
+			return "", errors.Wrap(err, "json decode")
+		}
+		if respJSON.Token == "" {
+			respJSON.Token = respJSON.AccessToken
+		}
+		return "Bearer " + respJSON.Token, nil
+	}
+	return "", errors.Errorf("unsupported challenge %q", challenge)
+}
+
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseChallenge parses the WWW-Authenticate header, e.g.
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	for _, match := range challengeParamRegexp.FindAllStringSubmatch(parts[1], -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	return parts[0], params
+}
+
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(resp.Body)
+	return strings.TrimSpace(resp.Status + " " + string(body))
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package platform
+
+// Target is the OS and the architecture the function binary runs on.
+type Target struct {
+	GOOS   string
+	GOARCH string
+}
+
+func (t Target) String() string {
+	return t.GOOS + "/" + t.GOARCH
+}
+
+// DefaultTarget is the target of the platforms not implementing TargetPlatform.
+var DefaultTarget = Target{GOOS: "linux", GOARCH: "amd64"}
+
+// TargetPlatform is implemented by the platforms whose function binary is not
+// built for the DefaultTarget, or depends on the account.
+type TargetPlatform interface {
+	Target() Target
+}
+
+// TargetOf returns the target of the function binary on the platform.
+func TargetOf(p Cloud) Target {
+	if t, ok := p.(TargetPlatform); ok {
+		return t.Target()
+	}
+	return DefaultTarget
+}
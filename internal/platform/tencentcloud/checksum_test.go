@@ -0,0 +1,29 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tencentcloud
+
+import (
+	"testing"
+)
+
+// The checksums are compared with the ones reported by the platform to skip
+// the unchanged code, which are the hex encoded SHA-256 on Tencent cloud.
+func TestCodeChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		zipFile string
+		want    string
+	}{
+		{name: "empty", zipFile: "", want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{name: "check", zipFile: "123456789", want: "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := codeChecksum([]byte(test.zipFile)); got != test.want {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
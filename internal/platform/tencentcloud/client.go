@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -23,15 +24,34 @@ type Client struct {
 	id                  string
 	regionID            string
 	secretID, secretKey string
+
+	// endpoint is the custom SCF endpoint, the public endpoint is used if it is nil.
+	endpoint   *url.URL
+	httpClient *http.Client
 }
 
-func New(opts platform.AuthenticateOptions) *Client {
-	return &Client{
-		id:        opts["id"],
-		regionID:  opts[RegionIDField],
-		secretID:  opts[SecretIDField],
-		secretKey: opts[SecretKeyField],
+func New(opts platform.AuthenticateOptions) (*Client, error) {
+	var endpoint *url.URL
+	if opts[platform.EndpointField] != "" {
+		var err error
+		endpoint, err = platform.ParseEndpoint(opts[platform.EndpointField])
+		if err != nil {
+			return nil, err
+		}
+	}
+	httpClient, err := platform.NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Client{
+		id:         opts["id"],
+		regionID:   opts[RegionIDField],
+		secretID:   opts[SecretIDField],
+		secretKey:  opts[SecretKeyField],
+		endpoint:   endpoint,
+		httpClient: httpClient,
+	}, nil
 }
 
 func (c *Client) String() string {
@@ -71,7 +91,18 @@ func (c *Client) Authenticate() error {
 }
 
 func (c *Client) request(method, action string, requestBody ...interface{}) (*response, error) {
-	u := "https://scf.tencentcloudapi.com/"
+	return c.requestService("scf", "2018-04-16", method, action, requestBody...)
+}
+
+// requestService sends the API request to the given Tencent Cloud service.
+func (c *Client) requestService(service, version, method, action string, requestBody ...interface{}) (*response, error) {
+	host := service + ".tencentcloudapi.com"
+	u := "https://" + host + "/"
+	// The custom endpoint only serves the SCF API.
+	if c.endpoint != nil && service == "scf" {
+		host = c.endpoint.Host
+		u = c.endpoint.String() + "/"
+	}
 
 	var err error
 	var body io.Reader
@@ -103,16 +134,16 @@ func (c *Client) request(method, action string, requestBody ...interface{}) (*re
 	}
 	req.Header.Set("x-tc-action", action)
 	req.Header.Set("x-tc-region", c.regionID)
-	req.Header.Set("x-tc-version", "2018-04-16")
+	req.Header.Set("x-tc-version", version)
 	if req.Method == http.MethodGet {
 		req.Header.Set("content-type", "application/x-www-form-urlencoded")
 	} else {
 		req.Header.Set("content-type", "application/json")
 	}
-	req.Header.Set("host", "scf.tencentcloudapi.com")
-	req.Header.Set("Authorization", c.GetAuthorizationHeader(req, reqBody))
+	req.Header.Set("host", host)
+	req.Header.Set("Authorization", c.GetAuthorizationHeader(req, service, reqBody))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "do request")
 	}
@@ -122,6 +153,49 @@ func (c *Client) request(method, action string, requestBody ...interface{}) (*re
 	}, nil
 }
 
+type ActionResponse struct {
+	Response struct {
+		Error struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// apiError is the error returned by the API.
+type apiError struct {
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// action calls the API action, and returns the error in the response.
+func (c *Client) action(action string, requestBody interface{}) error {
+	resp, err := c.request(http.MethodPost, action, requestBody)
+	if err != nil {
+		return err
+	}
+
+	var respJSON ActionResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return errors.Wrap(err, "json decode")
+	}
+	if respJSON.Response.Error.Code != "" {
+		if strings.HasPrefix(respJSON.Response.Error.Code, "ResourceNotFound.Function") {
+			return platform.ErrFunctionNotExists
+		}
+		return &apiError{
+			Code:    respJSON.Response.Error.Code,
+			Message: respJSON.Response.Error.Message,
+		}
+	}
+	return nil
+}
+
 type response struct {
 	*http.Response
 }
@@ -4,8 +4,67 @@
 
 package tencentcloud
 
+import (
+	"time"
+
+	"github.com/wuhan005/Raika/internal/platform/pack"
+)
+
 const (
 	RegionIDField  = "region_id"
 	SecretIDField  = "secret_id"
 	SecretKeyField = "secret_key"
 )
+
+// defaultNamespace is the namespace the functions are deployed to.
+const defaultNamespace = "default"
+
+// cst is the time zone of the time returned by the API.
+var cst = time.FixedZone("CST", 8*60*60)
+
+// The types of the functions. The web functions serve HTTP on the API gateway
+// triggers, and the event functions receive the events, such as the timer
+// events and the invocations, from the runtime API.
+const (
+	httpFunction  = "HTTP"
+	eventFunction = "Event"
+)
+
+// bootstrapEntry is the `scf_bootstrap` entry required by the web functions,
+// which starts the binary.
+var bootstrapEntry = pack.Entry{
+	Name: "scf_bootstrap",
+	Mode: 0755,
+	Data: []byte("#!/bin/bash\n./bootstrap"),
+}
+
+// eventBootstrapEntry is the `scf_bootstrap` entry of the event functions on
+// the custom runtime. It starts the binary, then posts each event from the
+// runtime API to it and reports its response back.
+var eventBootstrapEntry = pack.Entry{
+	Name: "scf_bootstrap",
+	Mode: 0755,
+	Data: []byte(`#!/bin/bash
+export PORT=${PORT:-9000}
+./bootstrap &
+pid=$!
+until curl -s -o /dev/null "http://127.0.0.1:$PORT/"; do
+  kill -0 $pid || exit 1
+  sleep 0.1
+done
+
+api="http://$SCF_RUNTIME_API:$SCF_RUNTIME_API_PORT/runtime"
+dir=$(mktemp -d)
+curl -s -o /dev/null -X POST "$api/init/ready"
+while kill -0 $pid; do
+  curl -s -o "$dir/event" "$api/invocation/next"
+  : > "$dir/response"
+  code=$(curl -s -o "$dir/response" -w "%{http_code}" --data-binary "@$dir/event" "http://127.0.0.1:$PORT/")
+  if [ "$code" -ge 200 ] && [ "$code" -lt 500 ]; then
+    curl -s -o /dev/null --data-binary "@$dir/response" "$api/invocation/response"
+  else
+    curl -s -o /dev/null --data-binary "@$dir/response" "$api/invocation/error"
+  fi
+done
+`),
+}
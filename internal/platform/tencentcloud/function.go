@@ -5,17 +5,20 @@
 package tencentcloud
 
 import (
-	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"io"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	log "unknwon.dev/clog/v2"
 
 	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/platform/pack"
 )
 
 type kv struct {
@@ -40,21 +43,49 @@ type CreateFunctionRequest struct {
 	PublicNetConfig map[string]interface{} `json:"PublicNetConfig"`
 }
 
-func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, error) {
-	zipFile, err := packFile(opts.File)
+// localName returns the name of the function in the default namespace, which
+// is prefixed by the namespace if the function is imported. Raika only manages
+// the functions in the default namespace.
+func localName(name string) (string, error) {
+	namespace, name := platform.SplitName(name, defaultNamespace)
+	if namespace != defaultNamespace {
+		return "", errors.Errorf("function %q is in the namespace %q, only the %q namespace is supported", name, namespace, defaultNamespace)
+	}
+	return name, nil
+}
+
+func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if err := c.CheckTriggers(opts.Triggers); err != nil {
+		return nil, err
+	}
+	name, err := localName(opts.Name)
 	if err != nil {
-		return "", errors.Wrap(err, "pack file")
+		return nil, err
+	}
+	opts.Name = name
+
+	// Check current function name exists.
+	_, err = c.GetFunction(defaultNamespace, opts.Name)
+	if err != nil && err != platform.ErrFunctionNotExists {
+		return nil, errors.Wrap(err, "get function")
+	} else if err == nil {
+		// Function exists, update it in place.
+		log.Trace("Function %q exists on tencentcloud, update...", opts.Name)
+		return c.UpdateFunction(opts)
+	}
+
+	zipFile, err := packFunction(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
 	}
 
 	log.Trace("Deploy function %q...", opts.Name)
 
-	// Parse environment key-value pairs.
-	environmentKV := make([]kv, 0, len(opts.EnvironmentVariables))
-	for k, v := range opts.EnvironmentVariables {
-		environmentKV = append(environmentKV, kv{
-			Key:   k,
-			Value: v,
-		})
+	// The event functions are run by the custom runtime, as the Go runtime
+	// requires the handler of the SCF library.
+	typ, runtime := functionType(opts.Triggers), "Go1"
+	if typ == eventFunction {
+		runtime = "CustomRuntime"
 	}
 
 	request := CreateFunctionRequest{
@@ -63,14 +94,14 @@ func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, er
 		Code: struct {
 			ZipFile []byte `json:"ZipFile"`
 		}{ZipFile: zipFile},
-		Runtime:    "Go1",
+		Runtime:    runtime,
 		MemorySize: opts.MemorySize,
 		Environment: struct {
 			Variables []kv `json:"Variables"`
-		}{Variables: environmentKV},
+		}{Variables: environmentKV(opts.EnvironmentVariables)},
 		InitTimeout: int(opts.InitializationTimeout / time.Second),
 		Timeout:     int(opts.RuntimeTimeout / time.Second),
-		Type:        "HTTP",
+		Type:        typ,
 		PublicNetConfig: map[string]interface{}{
 			"PublicNetStatus": "ENABLE",
 			"EipConfig": map[string]interface{}{
@@ -79,89 +110,325 @@ func (c *Client) CreateFunction(opts platform.CreateFunctionOptions) (string, er
 		},
 	}
 
-	_, err = c.request(http.MethodPost, "CreateFunction", request)
+	if err := c.action("CreateFunction", request); err != nil {
+		return nil, errors.Wrap(err, "create function")
+	}
+
+	if err := c.waitFunctionActive(opts.Name); err != nil {
+		return nil, err
+	}
+	return c.release(opts)
+}
+
+type UpdateFunctionCodeRequest struct {
+	FunctionName string `json:"FunctionName"`
+	ZipFile      []byte `json:"ZipFile"`
+}
+
+type UpdateFunctionConfigurationRequest struct {
+	FunctionName string `json:"FunctionName"`
+	Description  string `json:"Description"`
+	MemorySize   int64  `json:"MemorySize"`
+	Environment  struct {
+		Variables []kv `json:"Variables"`
+	} `json:"Environment"`
+	InitTimeout int `json:"InitTimeout"`
+	Timeout     int `json:"Timeout"`
+}
+
+// UpdateFunction updates the code and the configuration of the existing function.
+// The triggers under the function are kept, so the trigger URL won't change.
+func (c *Client) UpdateFunction(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	if err := c.CheckTriggers(opts.Triggers); err != nil {
+		return nil, err
+	}
+	name, err := localName(opts.Name)
 	if err != nil {
-		return "", errors.Wrap(err, "create function")
+		return nil, err
 	}
+	opts.Name = name
 
-	var functionStatus string
-	for functionStatus != "Active" {
-		time.Sleep(2 * time.Second)
-		functionInfo, err := c.GetFunction(opts.Name)
+	// The type of the function can't be changed.
+	function, err := c.GetFunction(defaultNamespace, opts.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get function")
+	}
+	if want := functionType(opts.Triggers); function.Response.Type != want {
+		return nil, errors.Errorf("function %q is deployed as a %s function and can't be changed to a %s function, delete it first", opts.Name, function.Response.Type, want)
+	}
+
+	zipFile, err := packFunction(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "pack file")
+	}
+
+	info, err := c.Describe(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []string
+	if info.CodeChecksum == codeChecksum(zipFile) {
+		log.Trace("Code of function %q is unchanged, skip uploading", opts.Name)
+		skipped = append(skipped, platform.SkippedCodeUpload)
+	} else {
+		log.Trace("Update function code %q...", opts.Name)
+		err = c.action("UpdateFunctionCode", UpdateFunctionCodeRequest{
+			FunctionName: opts.Name,
+			ZipFile:      zipFile,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update function code")
+		}
+		if err := c.waitFunctionActive(opts.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if !platform.ConfigurationChanged(info, opts) {
+		skipped = append(skipped, platform.SkippedConfigurationUpdate)
+	} else {
+		log.Trace("Update function configuration %q...", opts.Name)
+		err = c.action("UpdateFunctionConfiguration", UpdateFunctionConfigurationRequest{
+			FunctionName: opts.Name,
+			Description:  opts.Description,
+			MemorySize:   opts.MemorySize,
+			Environment: struct {
+				Variables []kv `json:"Variables"`
+			}{Variables: environmentKV(opts.EnvironmentVariables)},
+			InitTimeout: int(opts.InitializationTimeout / time.Second),
+			Timeout:     int(opts.RuntimeTimeout / time.Second),
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "get function")
+			return nil, errors.Wrap(err, "update function configuration")
+		}
+		if err := c.waitFunctionActive(opts.Name); err != nil {
+			return nil, err
 		}
-		functionStatus = functionInfo.Response.Status
 	}
 
-	// Check the HTTP trigger exists or not before creating the function.
-	triggers, err := c.GetTriggers(opts.Name)
+	deployment, err := c.release(opts)
 	if err != nil {
-		return "", errors.Wrap(err, "get triggers")
+		return nil, err
 	}
+	deployment.Skipped = skipped
+	return deployment, nil
+}
 
-	for _, trigger := range triggers.Response.Triggers {
-		log.Trace("Delete trigger %q...", trigger.TriggerName)
-		if err := c.DeleteTrigger(opts.Name, trigger.TriggerName, trigger.Type); err != nil {
-			return "", errors.Wrap(err, "delete trigger")
+// codeChecksum returns the checksum of the code package in the format of the
+// `CodeSha256` of the function address, which is the hex encoded SHA-256.
+func codeChecksum(zipFile []byte) string {
+	sum := sha256.Sum256(zipFile)
+	return hex.EncodeToString(sum[:])
+}
+
+// CodeChecksum returns the checksum of the code package of the function.
+func (c *Client) CodeChecksum(opts platform.CreateFunctionOptions) (string, error) {
+	zipFile, err := packFunction(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "pack file")
+	}
+	return codeChecksum(zipFile), nil
+}
+
+// functionType returns the type of the function with the triggers, which is a
+// web function if it has an HTTP trigger.
+func functionType(specs []platform.TriggerSpec) string {
+	for _, trigger := range specs {
+		if trigger.Type == platform.HTTPTrigger {
+			return httpFunction
 		}
 	}
+	return eventFunction
+}
 
-	// Create HTTP trigger for function.
-	log.Trace("Create HTTP trigger...")
-	resp, err := c.CreateHTTPTrigger(CreateHTTPTriggerOptions{
-		TriggerName:  platform.HTTPTriggerName,
-		FunctionName: opts.Name,
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "create HTTP trigger")
+// packFunction returns the code package with the `scf_bootstrap` of the type
+// of the function.
+func packFunction(opts platform.CreateFunctionOptions) ([]byte, error) {
+	if functionType(opts.Triggers) == eventFunction {
+		return pack.Zip(opts, eventBootstrapEntry)
 	}
-	return resp.Service.SubDomain, nil
+	return pack.Zip(opts, bootstrapEntry)
 }
 
-func packFile(path string) ([]byte, error) {
-	output := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(output)
+// release points the alias to a new version if the alias is set, and ensures
+// the triggers of the function.
+func (c *Client) release(opts platform.CreateFunctionOptions) (*platform.Deployment, error) {
+	deployment := &platform.Deployment{}
+	qualifier := "$LATEST"
+	if opts.Alias != "" {
+		version, err := platform.PublishAlias(c, opts.Name, opts.Alias, opts.Description)
+		if err != nil {
+			return nil, err
+		}
+		deployment.Version = version
+		qualifier = opts.Alias
+	}
 
-	bootstrapHeader := &zip.FileHeader{
-		Name:     "scf_bootstrap",
-		Modified: time.Now(),
+	triggers, err := c.GetTriggers(defaultNamespace, opts.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get triggers")
 	}
-	bootstrapHeader.SetMode(0777)
-	zipEntry, err := zipWriter.CreateHeader(bootstrapHeader)
+
+	for _, trigger := range opts.Triggers {
+		switch trigger.Type {
+		case platform.HTTPTrigger:
+			triggerURL, err := c.ensureHTTPTrigger(triggers, opts.Name, trigger.Name, qualifier)
+			if err != nil {
+				return nil, errors.Wrapf(err, "trigger %q", trigger.Name)
+			}
+			deployment.URL = triggerURL
+		case platform.CronTrigger:
+			if err := c.ensureTimerTrigger(triggers, opts.Name, qualifier, trigger); err != nil {
+				return nil, errors.Wrapf(err, "trigger %q", trigger.Name)
+			}
+		default:
+			return nil, errors.Errorf("unexpected trigger type %q", trigger.Type)
+		}
+	}
+	return deployment, nil
+}
+
+// ensureTimerTrigger creates the timer trigger of the function bound to the
+// qualifier, the existing one is re-created if it is changed.
+func (c *Client) ensureTimerTrigger(triggers *GetTriggerResponse, functionName, qualifier string, spec platform.TriggerSpec) error {
+	cronExpression, err := toTimerCron(spec.Cron)
 	if err != nil {
-		return nil, errors.Wrap(err, "create bootstrap file header")
+		return err
+	}
+
+	for _, trigger := range triggers.Response.Triggers {
+		if trigger.TriggerName != spec.Name {
+			continue
+		}
+
+		// The description of the timer trigger is listed as `{"cron":"..."}`.
+		var desc struct {
+			Cron string `json:"cron"`
+		}
+		if err := json.Unmarshal([]byte(trigger.TriggerDesc), &desc); err != nil {
+			desc.Cron = trigger.TriggerDesc
+		}
+		if trigger.Type == "timer" && trigger.Qualifier == qualifier &&
+			desc.Cron == cronExpression && trigger.CustomArgument == spec.Payload {
+			return nil
+		}
+
+		// The timer trigger can't be updated, re-create it.
+		log.Trace("Re-create trigger %q...", trigger.TriggerName)
+		if err := c.DeleteTrigger(defaultNamespace, functionName, trigger.TriggerName, trigger.Type); err != nil {
+			return errors.Wrap(err, "delete trigger")
+		}
+		break
 	}
 
-	_, err = zipEntry.Write([]byte("#!/bin/bash\n./bootstrap"))
+	log.Trace("Create timer trigger %q...", spec.Name)
+	err = c.CreateTimerTrigger(CreateTimerTriggerOptions{
+		TriggerName:  spec.Name,
+		FunctionName: functionName,
+		Qualifier:    qualifier,
+		CronString:   spec.Cron,
+		Payload:      spec.Payload,
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "write bootstrap file")
+		return errors.Wrap(err, "create timer trigger")
 	}
+	return nil
+}
+
+// ensureHTTPTrigger creates the HTTP trigger of the function bound to the
+// qualifier if it does not exist, and returns the trigger URL.
+func (c *Client) ensureHTTPTrigger(triggers *GetTriggerResponse, functionName, triggerName, qualifier string) (string, error) {
+	for _, trigger := range triggers.Response.Triggers {
+		if trigger.TriggerName != triggerName {
+			continue
+		}
+
+		// The qualifier of the trigger can't be changed, re-create it.
+		if trigger.Type != "apigw" || trigger.Qualifier != qualifier {
+			log.Trace("Re-create trigger %q for %q...", trigger.TriggerName, qualifier)
+			if err := c.DeleteTrigger(defaultNamespace, functionName, trigger.TriggerName, trigger.Type); err != nil {
+				return "", errors.Wrap(err, "delete trigger")
+			}
+			break
+		}
 
-	binaryHeader := &zip.FileHeader{
-		Name:     "bootstrap",
-		Modified: time.Now(),
+		var desc HTTPTriggerDesc
+		if err := json.Unmarshal([]byte(trigger.TriggerDesc), &desc); err != nil {
+			return "", errors.Wrap(err, "parse trigger description")
+		}
+		if desc.Service.SubDomain != "" {
+			return desc.Service.SubDomain, nil
+		}
 	}
-	binaryHeader.SetMode(0777)
-	zipEntry, err = zipWriter.CreateHeader(binaryHeader)
+
+	// Create HTTP trigger for function.
+	log.Trace("Create HTTP trigger %q...", triggerName)
+	resp, err := c.CreateHTTPTrigger(CreateHTTPTriggerOptions{
+		TriggerName:  triggerName,
+		FunctionName: functionName,
+		Qualifier:    qualifier,
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "create binary file header")
+		return "", errors.Wrap(err, "create HTTP trigger")
 	}
+	return resp.Service.SubDomain, nil
+}
 
-	file, err := os.Open(path)
+// RemoveTrigger deletes the trigger under the function.
+func (c *Client) RemoveTrigger(functionName, triggerName string) error {
+	functionName, err := localName(functionName)
 	if err != nil {
-		return nil, errors.Wrap(err, "open file")
+		return err
+	}
+	triggers, err := c.GetTriggers(defaultNamespace, functionName)
+	if err != nil {
+		return errors.Wrap(err, "get triggers")
+	}
+	for _, trigger := range triggers.Response.Triggers {
+		if trigger.TriggerName == triggerName {
+			log.Trace("Delete trigger %q...", triggerName)
+			return c.DeleteTrigger(defaultNamespace, functionName, trigger.TriggerName, trigger.Type)
+		}
 	}
-	if _, err := io.Copy(zipEntry, file); err != nil {
-		return nil, errors.Wrap(err, "copy")
+	return errors.Errorf("trigger %q not found", triggerName)
+}
+
+// waitFunctionActive waits until the status of the function becomes active.
+func (c *Client) waitFunctionActive(functionName string) error {
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		time.Sleep(2 * time.Second)
+		functionInfo, err := c.GetFunction(defaultNamespace, functionName)
+		if err != nil {
+			return errors.Wrap(err, "get function")
+		}
+
+		status := functionInfo.Response.Status
+		if status == "Active" {
+			return nil
+		} else if strings.HasSuffix(status, "Failed") {
+			return errors.Errorf("function status %q: %s", status, functionInfo.Response.StatusDesc)
+		}
 	}
-	_ = zipWriter.Close()
+	return errors.Errorf("wait for function %q active timeout", functionName)
+}
 
-	return output.Bytes(), nil
+// environmentKV converts the environment variables to key-value pairs.
+func environmentKV(environmentVariables map[string]string) []kv {
+	environmentKV := make([]kv, 0, len(environmentVariables))
+	for k, v := range environmentVariables {
+		environmentKV = append(environmentKV, kv{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return environmentKV
 }
 
 type GetFunctionRequest struct {
 	FunctionName string `json:"FunctionName"`
+	Namespace    string `json:"Namespace,omitempty"`
 }
 
 type GetFunctionResponse struct {
@@ -177,7 +444,7 @@ type GetFunctionResponse struct {
 			SubnetId string `json:"SubnetId"`
 		} `json:"VpcConfig"`
 		Environment struct {
-			Variables []interface{} `json:"Variables"`
+			Variables []kv `json:"Variables"`
 		} `json:"Environment"`
 		Handler           string `json:"Handler"`
 		UseGpu            string `json:"UseGpu"`
@@ -234,17 +501,329 @@ type GetFunctionResponse struct {
 		TraceEnable    string        `json:"TraceEnable"`
 		LogType        string        `json:"LogType"`
 		RequestId      string        `json:"RequestId"`
+		Error          struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
 	} `json:"Response"`
 }
 
-func (c *Client) GetFunction(functionName string) (*GetFunctionResponse, error) {
+func (c *Client) GetFunction(namespace, functionName string) (*GetFunctionResponse, error) {
 	resp, err := c.request(http.MethodPost, "GetFunction", GetFunctionRequest{
 		FunctionName: functionName,
+		Namespace:    namespace,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "get function")
 	}
 
 	var respJSON GetFunctionResponse
-	return &respJSON, resp.ToJSON(&respJSON)
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	if respJSON.Response.Error.Code != "" {
+		if strings.HasPrefix(respJSON.Response.Error.Code, "ResourceNotFound") {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+	}
+	return &respJSON, nil
+}
+
+type GetFunctionAddressResponse struct {
+	Response struct {
+		// Url is the temporary download URL of the code package.
+		Url        string `json:"Url"`
+		CodeSha256 string `json:"CodeSha256"`
+		RequestId  string `json:"RequestId"`
+		Error      struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	} `json:"Response"`
+}
+
+// GetFunctionAddress returns the download address and the checksum of the
+// code package of the function.
+func (c *Client) GetFunctionAddress(namespace, functionName string) (*GetFunctionAddressResponse, error) {
+	resp, err := c.request(http.MethodPost, "GetFunctionAddress", GetFunctionRequest{
+		FunctionName: functionName,
+		Namespace:    namespace,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "get function address")
+	}
+
+	var respJSON GetFunctionAddressResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	if respJSON.Response.Error.Code != "" {
+		if strings.HasPrefix(respJSON.Response.Error.Code, "ResourceNotFound") {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+	}
+	return &respJSON, nil
+}
+
+// Describe returns the live information of the function. The code checksum is
+// only reported by the function address. The name is prefixed by the
+// namespace if the function is not in the default namespace.
+func (c *Client) Describe(name string) (*platform.FunctionInfo, error) {
+	namespace, name := platform.SplitName(name, defaultNamespace)
+	return c.describe(namespace, name)
+}
+
+func (c *Client) describe(namespace, name string) (*platform.FunctionInfo, error) {
+	function, err := c.GetFunction(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	address, err := c.GetFunctionAddress(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := make(map[string]string, len(function.Response.Environment.Variables))
+	for _, v := range function.Response.Environment.Variables {
+		environment[v.Key] = v.Value
+	}
+	updatedAt, _ := time.ParseInLocation("2006-01-02 15:04:05", function.Response.ModTime, cst)
+
+	return &platform.FunctionInfo{
+		Name:                  function.Response.FunctionName,
+		Namespace:             namespace,
+		Description:           function.Response.Description,
+		Status:                function.Response.Status,
+		Active:                function.Response.Status == "Active",
+		MemorySize:            int64(function.Response.MemorySize),
+		EnvironmentVariables:  environment,
+		InitializationTimeout: time.Duration(function.Response.InitTimeout) * time.Second,
+		RuntimeTimeout:        time.Duration(function.Response.Timeout) * time.Second,
+		CodeChecksum:          strings.ToLower(address.Response.CodeSha256),
+		UpdatedAt:             updatedAt,
+	}, nil
+}
+
+type ListFunctionsRequest struct {
+	Offset int `json:"Offset"`
+	Limit  int `json:"Limit"`
+}
+
+type ListFunctionsResponse struct {
+	Response struct {
+		Functions []struct {
+			FunctionName string `json:"FunctionName"`
+			FunctionId   string `json:"FunctionId"`
+			Namespace    string `json:"Namespace"`
+			Description  string `json:"Description"`
+			Status       string `json:"Status"`
+			StatusDesc   string `json:"StatusDesc"`
+			Runtime      string `json:"Runtime"`
+			Type         string `json:"Type"`
+			AddTime      string `json:"AddTime"`
+			ModTime      string `json:"ModTime"`
+		} `json:"Functions"`
+		TotalCount int `json:"TotalCount"`
+		Error      struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// ListFunctions returns the functions in the default namespace.
+func (c *Client) ListFunctions() ([]*platform.FunctionInfo, error) {
+	var functions []*platform.FunctionInfo
+	for offset := 0; ; {
+		resp, err := c.request(http.MethodPost, "ListFunctions", ListFunctionsRequest{
+			Offset: offset,
+			Limit:  100,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "list functions")
+		}
+
+		var respJSON ListFunctionsResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		if respJSON.Response.Error.Code != "" {
+			return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+		}
+
+		for _, function := range respJSON.Response.Functions {
+			// The list has no configuration of the functions.
+			info, err := c.describe(function.Namespace, function.FunctionName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "describe %q", function.FunctionName)
+			}
+
+			triggers, err := c.GetTriggers(function.Namespace, function.FunctionName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get triggers of %q", function.FunctionName)
+			}
+			info.URL = httpTriggerURL(triggers)
+			functions = append(functions, info)
+		}
+
+		offset += len(respJSON.Response.Functions)
+		if len(respJSON.Response.Functions) == 0 || offset >= respJSON.Response.TotalCount {
+			break
+		}
+	}
+	return functions, nil
+}
+
+type InvokeRequest struct {
+	FunctionName   string `json:"FunctionName"`
+	Namespace      string `json:"Namespace,omitempty"`
+	InvocationType string `json:"InvocationType"`
+	ClientContext  string `json:"ClientContext"`
+	LogType        string `json:"LogType"`
+}
+
+type InvokeResponse struct {
+	Response struct {
+		Result struct {
+			FunctionRequestId string  `json:"FunctionRequestId"`
+			RetMsg            string  `json:"RetMsg"`
+			ErrMsg            string  `json:"ErrMsg"`
+			InvokeResult      int     `json:"InvokeResult"`
+			Duration          float64 `json:"Duration"`
+			BillDuration      int     `json:"BillDuration"`
+			MemUsage          int     `json:"MemUsage"`
+		} `json:"Result"`
+		Error struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// httpTriggerURL returns the URL of the first API gateway trigger, or empty if
+// there is none.
+func httpTriggerURL(triggers *GetTriggerResponse) string {
+	for _, trigger := range triggers.Response.Triggers {
+		if trigger.Type != "apigw" {
+			continue
+		}
+		var desc HTTPTriggerDesc
+		if err := json.Unmarshal([]byte(trigger.TriggerDesc), &desc); err == nil && desc.Service.SubDomain != "" {
+			return desc.Service.SubDomain
+		}
+	}
+	return ""
+}
+
+// Invoke calls the function synchronously. The Invoke API does not serve the
+// web functions, so the payload is posted to their HTTP trigger instead.
+func (c *Client) Invoke(name string, payload []byte) (*platform.InvokeResponse, error) {
+	namespace, name := platform.SplitName(name, defaultNamespace)
+	function, err := c.GetFunction(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if function.Response.Type == httpFunction {
+		return c.invokeHTTP(namespace, name, payload)
+	}
+
+	resp, err := c.request(http.MethodPost, "Invoke", InvokeRequest{
+		FunctionName:   name,
+		Namespace:      namespace,
+		InvocationType: "RequestResponse",
+		ClientContext:  string(payload),
+		LogType:        "None",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invoke function")
+	}
+
+	var respJSON InvokeResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return nil, errors.Wrap(err, "json decode")
+	}
+	if respJSON.Response.Error.Code != "" {
+		if strings.HasPrefix(respJSON.Response.Error.Code, "ResourceNotFound") {
+			return nil, platform.ErrFunctionNotExists
+		}
+		return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+	}
+
+	result := respJSON.Response.Result
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: http.StatusOK,
+		Body:       []byte(result.RetMsg),
+	}
+	if result.InvokeResult != 0 {
+		invokeResponse.StatusCode = http.StatusInternalServerError
+		invokeResponse.Error = result.ErrMsg
+	}
+	return invokeResponse, nil
+}
+
+// invokeHTTP posts the payload to the HTTP trigger of the web function.
+func (c *Client) invokeHTTP(namespace, name string, payload []byte) (*platform.InvokeResponse, error) {
+	triggers, err := c.GetTriggers(namespace, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get triggers")
+	}
+	triggerURL := httpTriggerURL(triggers)
+	if triggerURL == "" {
+		return nil, errors.Errorf("function %q has no HTTP trigger to invoke on tencentcloud", name)
+	}
+
+	resp, err := c.httpClient.Post(triggerURL, "application/octet-stream", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrap(err, "post")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+
+	invokeResponse := &platform.InvokeResponse{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		invokeResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return invokeResponse, nil
+}
+
+type DeleteFunctionRequest struct {
+	FunctionName string `json:"FunctionName"`
+	Namespace    string `json:"Namespace,omitempty"`
+}
+
+// DeleteFunction deletes the triggers under the function, then the function itself.
+func (c *Client) DeleteFunction(name string) error {
+	namespace, name := platform.SplitName(name, defaultNamespace)
+	_, err := c.GetFunction(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	triggers, err := c.GetTriggers(namespace, name)
+	if err != nil {
+		return errors.Wrap(err, "get triggers")
+	}
+	for _, trigger := range triggers.Response.Triggers {
+		log.Trace("Delete trigger %q...", trigger.TriggerName)
+		if err := c.DeleteTrigger(namespace, name, trigger.TriggerName, trigger.Type); err != nil {
+			return errors.Wrapf(err, "delete trigger: %q", trigger.TriggerName)
+		}
+	}
+
+	log.Trace("Delete function %q...", name)
+	return c.action("DeleteFunction", DeleteFunctionRequest{
+		FunctionName: name,
+		Namespace:    namespace,
+	})
 }
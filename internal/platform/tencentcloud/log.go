@@ -0,0 +1,113 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tencentcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+type SearchLogRequest struct {
+	TopicId string `json:"TopicId"`
+	From    int64  `json:"From"`
+	To      int64  `json:"To"`
+	Query   string `json:"Query"`
+	Limit   int    `json:"Limit"`
+	Context string `json:"Context,omitempty"`
+	Sort    string `json:"Sort"`
+}
+
+type SearchLogResponse struct {
+	Response struct {
+		Context  string `json:"Context"`
+		ListOver bool   `json:"ListOver"`
+		Results  []struct {
+			Time     int64  `json:"Time"`
+			TopicId  string `json:"TopicId"`
+			PkgId    string `json:"PkgId"`
+			PkgLogId string `json:"PkgLogId"`
+			Source   string `json:"Source"`
+			LogJson  string `json:"LogJson"`
+		} `json:"Results"`
+		Error struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// Logs returns the function logs from the CLS topic of the function.
+func (c *Client) Logs(name string, opts platform.LogOptions) ([]*platform.LogEntry, error) {
+	namespace, name := platform.SplitName(name, defaultNamespace)
+	function, err := c.GetFunction(namespace, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get function")
+	}
+	topicID := function.Response.ClsTopicId
+	if topicID == "" {
+		return nil, errors.Errorf("CLS topic of function %q is not set", name)
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var logs []*platform.LogEntry
+	request := SearchLogRequest{
+		TopicId: topicID,
+		From:    opts.Since.UnixNano() / int64(time.Millisecond),
+		To:      until.UnixNano() / int64(time.Millisecond),
+		Query:   fmt.Sprintf("SCF_Namespace:%s AND SCF_FunctionName:%s", namespace, name),
+		Limit:   100,
+		Sort:    "asc",
+	}
+	for {
+		resp, err := c.requestService("cls", "2020-10-16", http.MethodPost, "SearchLog", request)
+		if err != nil {
+			return nil, errors.Wrap(err, "search log")
+		}
+
+		var respJSON SearchLogResponse
+		if err := resp.ToJSON(&respJSON); err != nil {
+			return nil, errors.Wrap(err, "json decode")
+		}
+		if respJSON.Response.Error.Code != "" {
+			return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+		}
+
+		for _, result := range respJSON.Response.Results {
+			message := result.LogJson
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(result.LogJson), &fields); err == nil {
+				if m, ok := fields["SCF_Message"].(string); ok {
+					message = m
+				}
+			}
+			var id string
+			if result.PkgId != "" {
+				id = result.PkgId + "-" + result.PkgLogId
+			}
+			logs = append(logs, &platform.LogEntry{
+				ID:      id,
+				Time:    time.Unix(0, result.Time*int64(time.Millisecond)),
+				Message: message,
+			})
+		}
+
+		if respJSON.Response.ListOver || respJSON.Response.Context == "" {
+			break
+		}
+		request.Context = respJSON.Response.Context
+	}
+	return logs, nil
+}
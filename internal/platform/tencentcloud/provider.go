@@ -0,0 +1,28 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tencentcloud
+
+import (
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func init() {
+	platform.Register(&platform.Provider{
+		Platform: types.TencentCloud,
+		Fields: append([]platform.Field{
+			{Name: RegionIDField, Usage: "Region ID, e.g. ap-shanghai", Required: true},
+			{Name: SecretIDField, Usage: "Secret ID", Required: true},
+			{Name: SecretKeyField, Usage: "Secret key", Required: true, Secret: true},
+			{Name: platform.EndpointField, Usage: "API endpoint, e.g. http://127.0.0.1:9102 for the SCF API of `Raika emulator`"},
+		}, platform.NetworkFields...),
+		AccountID: func(opts platform.AuthenticateOptions) string {
+			return opts[SecretIDField] + "@" + opts[RegionIDField]
+		},
+		New: func(opts platform.AuthenticateOptions) (platform.Cloud, error) {
+			return New(opts)
+		},
+	})
+}
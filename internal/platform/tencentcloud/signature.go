@@ -17,11 +17,11 @@ import (
 )
 
 // GetAuthorizationHeader returns the authorization header.
-func (c *Client) GetAuthorizationHeader(req *http.Request, body []byte) string {
+func (c *Client) GetAuthorizationHeader(req *http.Request, service string, body []byte) string {
 	t := time.Now().UTC()
 	date := t.Format("2006-01-02")
 	req.Header.Set("x-tc-timestamp", strconv.Itoa(int(t.Unix())))
-	credentialScope := date + "/scf/tc3_request"
+	credentialScope := date + "/" + service + "/tc3_request"
 
 	var headerKeys []string
 	for k := range req.Header {
@@ -53,7 +53,7 @@ func (c *Client) GetAuthorizationHeader(req *http.Request, body []byte) string {
 
 	// Signature
 	secretDate := hmacSha256(date, "TC3"+c.secretKey)
-	secretService := hmacSha256("scf", secretDate)
+	secretService := hmacSha256(service, secretDate)
 	secretSigning := hmacSha256("tc3_request", secretService)
 	signature := hex.EncodeToString([]byte(hmacSha256(
 		fmt.Sprintf("%s\n%d\n%s\n%s",
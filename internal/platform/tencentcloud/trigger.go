@@ -8,20 +8,36 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/wuhan005/Raika/internal/platform"
 )
 
 type CreateHTTPTriggerOptions struct {
 	TriggerName  string
 	FunctionName string
+	Qualifier    string
+}
+
+type CreateTimerTriggerOptions struct {
+	TriggerName  string
+	FunctionName string
+	Qualifier    string
+	// CronString is the cron expression with seconds, e.g. `0 30 * * * *`.
+	CronString string
+	Payload    string
 }
 
-type CreateHTTPTriggerRequest struct {
+type CreateTriggerRequest struct {
 	FunctionName string `json:"FunctionName"`
 	TriggerName  string `json:"TriggerName"`
 	Type         string `json:"Type"`
 	TriggerDesc  string `json:"TriggerDesc"`
+	Qualifier    string `json:"Qualifier,omitempty"`
+	// CustomArgument is passed to the function when the timer trigger fires.
+	CustomArgument string `json:"CustomArgument,omitempty"`
 }
 
 type CreateTriggerResponse struct {
@@ -71,10 +87,11 @@ type HTTPTriggerDesc struct {
 }
 
 func (c *Client) CreateHTTPTrigger(opts CreateHTTPTriggerOptions) (*HTTPTriggerDesc, error) {
-	requestBody := CreateHTTPTriggerRequest{
+	requestBody := CreateTriggerRequest{
 		FunctionName: opts.FunctionName,
 		TriggerName:  opts.TriggerName,
 		Type:         "apigw",
+		Qualifier:    opts.Qualifier,
 		TriggerDesc: `{
     "api": {
         "authRequired": "FALSE",
@@ -91,6 +108,34 @@ func (c *Client) CreateHTTPTrigger(opts CreateHTTPTriggerOptions) (*HTTPTriggerD
     }
 }`,
 	}
+	respJSON, err := c.createTrigger(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var desc HTTPTriggerDesc
+	return &desc, json.Unmarshal([]byte(respJSON.Response.TriggerInfo.TriggerDesc), &desc)
+}
+
+func (c *Client) CreateTimerTrigger(opts CreateTimerTriggerOptions) error {
+	cronExpression, err := toTimerCron(opts.CronString)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.createTrigger(CreateTriggerRequest{
+		FunctionName: opts.FunctionName,
+		TriggerName:  opts.TriggerName,
+		Type:         "timer",
+		Qualifier:    opts.Qualifier,
+		TriggerDesc:  cronExpression,
+
+		CustomArgument: opts.Payload,
+	})
+	return err
+}
+
+func (c *Client) createTrigger(requestBody CreateTriggerRequest) (*CreateTriggerResponse, error) {
 	resp, err := c.request(http.MethodPost, "CreateTrigger", requestBody)
 	if err != nil {
 		return nil, err
@@ -103,9 +148,44 @@ func (c *Client) CreateHTTPTrigger(opts CreateHTTPTriggerOptions) (*HTTPTriggerD
 	if respJSON.Response.Error.Code != "" {
 		return nil, errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
 	}
+	return &respJSON, nil
+}
 
-	var desc HTTPTriggerDesc
-	return &desc, json.Unmarshal([]byte(respJSON.Response.TriggerInfo.TriggerDesc), &desc)
+// CheckTriggers checks the triggers before deploying the function. The
+// function with an HTTP trigger is deployed as a web function, which only
+// accepts the API gateway triggers, so it can't have cron triggers.
+func (c *Client) CheckTriggers(specs []platform.TriggerSpec) error {
+	if err := platform.ValidateTriggers(specs); err != nil {
+		return err
+	}
+	for _, trigger := range specs {
+		if trigger.Type != platform.CronTrigger {
+			continue
+		}
+		if functionType(specs) == httpFunction {
+			return errors.Errorf("trigger %q: cron triggers can't be used with HTTP triggers on tencentcloud, as the function with an HTTP trigger is deployed as a web function", trigger.Name)
+		}
+		if _, err := toTimerCron(trigger.Cron); err != nil {
+			return errors.Wrapf(err, "trigger %q", trigger.Name)
+		}
+	}
+	return nil
+}
+
+// toTimerCron converts the cron expression with seconds to the 7-field cron
+// expression of SCF timer trigger, e.g. `0 30 * * * *` => `0 30 * * * * *`.
+func toTimerCron(expr string) (string, error) {
+	fields, err := platform.ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	for i, field := range fields {
+		// SCF doesn't support the `?` placeholder.
+		if field == "?" {
+			fields[i] = "*"
+		}
+	}
+	return strings.Join(append(fields, "*"), " "), nil
 }
 
 type GetTriggerResponse struct {
@@ -129,9 +209,10 @@ type GetTriggerResponse struct {
 	} `json:"Response"`
 }
 
-func (c *Client) GetTriggers(functionName string) (*GetTriggerResponse, error) {
+func (c *Client) GetTriggers(namespace, functionName string) (*GetTriggerResponse, error) {
 	resp, err := c.request(http.MethodGet, "ListTriggers", url.Values{
 		"FunctionName": []string{functionName},
+		"Namespace":    []string{namespace},
 	})
 	if err != nil {
 		return nil, err
@@ -150,9 +231,10 @@ type DeleteTriggerResponse struct {
 	} `json:"Response"`
 }
 
-func (c *Client) DeleteTrigger(functionName, triggerName, triggerType string) error {
+func (c *Client) DeleteTrigger(namespace, functionName, triggerName, triggerType string) error {
 	resp, err := c.request(http.MethodGet, "DeleteTrigger", url.Values{
 		"FunctionName": []string{functionName},
+		"Namespace":    []string{namespace},
 		"TriggerName":  []string{triggerName},
 		"Type":         []string{triggerType},
 	})
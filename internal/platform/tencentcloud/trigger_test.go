@@ -0,0 +1,74 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tencentcloud
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wuhan005/Raika/internal/platform"
+)
+
+func TestCheckTriggers(t *testing.T) {
+	tests := []struct {
+		name     string
+		triggers []platform.TriggerSpec
+		wantType string
+		wantErr  string
+	}{
+		{
+			name:     "no trigger",
+			wantType: eventFunction,
+		},
+		{
+			name: "http",
+			triggers: []platform.TriggerSpec{
+				{Name: "http", Type: platform.HTTPTrigger},
+			},
+			wantType: httpFunction,
+		},
+		{
+			name: "cron",
+			triggers: []platform.TriggerSpec{
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+				{Name: "nightly", Type: platform.CronTrigger, Cron: "0 0 2 * * ?"},
+			},
+			wantType: eventFunction,
+		},
+		{
+			name: "http and cron",
+			triggers: []platform.TriggerSpec{
+				{Name: "http", Type: platform.HTTPTrigger},
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * * * *"},
+			},
+			wantErr: `trigger "hourly": cron triggers can't be used with HTTP triggers`,
+		},
+		{
+			name: "bad cron",
+			triggers: []platform.TriggerSpec{
+				{Name: "hourly", Type: platform.CronTrigger, Cron: "0 0 * *"},
+			},
+			wantErr: `trigger "hourly"`,
+		},
+	}
+	client := &Client{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := platform.CheckTriggers(client, test.triggers)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := functionType(test.triggers); got != test.wantType {
+					t.Fatalf("want function type %q, got %q", test.wantType, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("want error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
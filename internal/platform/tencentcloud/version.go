@@ -0,0 +1,79 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tencentcloud
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+type PublishVersionRequest struct {
+	FunctionName string `json:"FunctionName"`
+	Description  string `json:"Description"`
+}
+
+type PublishVersionResponse struct {
+	Response struct {
+		FunctionVersion string `json:"FunctionVersion"`
+		Error           struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+func (c *Client) PublishVersion(name, description string) (string, error) {
+	name, err := localName(name)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.request(http.MethodPost, "PublishVersion", PublishVersionRequest{
+		FunctionName: name,
+		Description:  description,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "publish version")
+	}
+
+	var respJSON PublishVersionResponse
+	if err := resp.ToJSON(&respJSON); err != nil {
+		return "", errors.Wrap(err, "json decode")
+	}
+	if respJSON.Response.Error.Code != "" {
+		return "", errors.Errorf("%s: %s", respJSON.Response.Error.Code, respJSON.Response.Error.Message)
+	}
+	if err := c.waitFunctionActive(name); err != nil {
+		return "", err
+	}
+	return respJSON.Response.FunctionVersion, nil
+}
+
+type AliasRequest struct {
+	FunctionName    string `json:"FunctionName"`
+	Name            string `json:"Name"`
+	FunctionVersion string `json:"FunctionVersion"`
+}
+
+// UpdateAlias points the alias to the given version, the alias is created if it does not exist.
+func (c *Client) UpdateAlias(name, alias, version string) error {
+	name, err := localName(name)
+	if err != nil {
+		return err
+	}
+	request := AliasRequest{
+		FunctionName:    name,
+		Name:            alias,
+		FunctionVersion: version,
+	}
+
+	err = c.action("UpdateAlias", request)
+	var aerr *apiError
+	if errors.As(err, &aerr) && aerr.Code == "ResourceNotFound.Alias" {
+		return c.action("CreateAlias", request)
+	}
+	return err
+}
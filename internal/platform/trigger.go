@@ -4,5 +4,132 @@
 
 package platform
 
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
 const HTTPTriggerName = "Raika_HTTPTrigger"
 const CronTriggerName = "Raika_CronTrigger"
+
+const (
+	HTTPTrigger = "http"
+	CronTrigger = "cron"
+)
+
+// TriggerSpec describes a trigger of the function, the triggers of a function
+// are identified by their names.
+type TriggerSpec struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	// Cron is the cron expression with seconds of the cron trigger, e.g. `0 30 * * * *`.
+	Cron string `json:"cron,omitempty" yaml:"cron"`
+	// Payload is passed to the function when the cron trigger fires.
+	Payload string `json:"payload,omitempty" yaml:"payload"`
+}
+
+var triggerNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]{0,59}$`)
+
+// ValidateTriggers checks the trigger specs of a function. The names of the
+// triggers must be unique, and a function can have one HTTP trigger at most.
+func ValidateTriggers(specs []TriggerSpec) error {
+	names := make(map[string]struct{}, len(specs))
+	var hasHTTPTrigger bool
+	for _, spec := range specs {
+		if !triggerNameRegexp.MatchString(spec.Name) {
+			return errors.Errorf("invalid trigger name %q: it should only contain letters, digits, `_` and `-`", spec.Name)
+		}
+		if _, ok := names[spec.Name]; ok {
+			return errors.Errorf("duplicate trigger name %q", spec.Name)
+		}
+		names[spec.Name] = struct{}{}
+
+		switch spec.Type {
+		case HTTPTrigger:
+			if hasHTTPTrigger {
+				return errors.Errorf("trigger %q: only one HTTP trigger is allowed", spec.Name)
+			}
+			hasHTTPTrigger = true
+		case CronTrigger:
+			if _, err := ParseCron(spec.Cron); err != nil {
+				return errors.Wrapf(err, "trigger %q", spec.Name)
+			}
+		default:
+			return errors.Errorf("trigger %q: unexpected trigger type %q", spec.Name, spec.Type)
+		}
+	}
+	return nil
+}
+
+// TriggerCheckPlatform is implemented by the platforms which only support some
+// of the triggers, so that the triggers are rejected before the function is
+// deployed on any platform.
+type TriggerCheckPlatform interface {
+	CheckTriggers(specs []TriggerSpec) error
+}
+
+// CheckTriggers checks whether the platform supports the triggers.
+func CheckTriggers(c Cloud, specs []TriggerSpec) error {
+	if p, ok := c.(TriggerCheckPlatform); ok {
+		return p.CheckTriggers(specs)
+	}
+	return nil
+}
+
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// ParseCron validates the cron expression with seconds, e.g. `0 30 * * * *`,
+// and returns the fields of second, minute, hour, day of month, month and day of week.
+func ParseCron(expr string) ([]string, error) {
+	if _, err := cronParser.Parse(expr); err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 6 {
+		return nil, errors.Errorf("invalid cron expression %q: expected 6 fields", expr)
+	}
+	return fields, nil
+}
+
+var dayOfWeekNumber = regexp.MustCompile(`\d+`)
+
+// QuartzCron converts the cron expression with seconds to the Quartz style
+// fields, which are used by aws and huaweicloud. One of the day of month and
+// the day of week is `?`, and the day of week starts from 1 (Sunday).
+func QuartzCron(expr string) ([]string, error) {
+	fields, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	dayOfMonth, dayOfWeek := fields[3], fields[5]
+
+	if dayOfWeek == "*" || dayOfWeek == "?" {
+		dayOfWeek = "?"
+	} else if dayOfMonth == "*" || dayOfMonth == "?" {
+		dayOfMonth = "?"
+
+		// Convert the numbers but the steps.
+		parts := strings.Split(dayOfWeek, ",")
+		for i, part := range parts {
+			step := ""
+			if index := strings.Index(part, "/"); index != -1 {
+				part, step = part[:index], part[index:]
+			}
+			parts[i] = dayOfWeekNumber.ReplaceAllStringFunc(part, func(s string) string {
+				n, _ := strconv.Atoi(s)
+				return strconv.Itoa(n%7 + 1)
+			}) + step
+		}
+		dayOfWeek = strings.Join(parts, ",")
+	} else {
+		return nil, errors.Errorf("invalid cron expression %q: the day of month and the day of week can't be set both", expr)
+	}
+
+	fields[3], fields[5] = dayOfMonth, dayOfWeek
+	return fields, nil
+}
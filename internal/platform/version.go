@@ -0,0 +1,52 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package platform
+
+import (
+	"github.com/pkg/errors"
+)
+
+// DefaultAliasName is the alias name used when publishing a function without alias given.
+const DefaultAliasName = "Raika_Live"
+
+// PublishAlias publishes a new version of the function and points the alias to it,
+// the published version ID is returned.
+func PublishAlias(c Cloud, name, alias, description string) (string, error) {
+	version, err := c.PublishVersion(name, description)
+	if err != nil {
+		return "", errors.Wrap(err, "publish version")
+	}
+	if err := c.UpdateAlias(name, alias, version); err != nil {
+		return "", errors.Wrap(err, "update alias")
+	}
+	return version, nil
+}
+
+// QualifierPlatform is implemented by the platforms which describe and invoke
+// a version or an alias of the function, while Describe and Invoke use the
+// latest version.
+type QualifierPlatform interface {
+	DescribeQualifier(name, qualifier string) (*FunctionInfo, error)
+	InvokeQualifier(name, qualifier string, payload []byte) (*InvokeResponse, error)
+}
+
+// DescribeQualified returns the live information of the version or the alias
+// of the function, the latest version is described if the qualifier is empty
+// or the platform does not support it.
+func DescribeQualified(c Cloud, name, qualifier string) (*FunctionInfo, error) {
+	if p, ok := c.(QualifierPlatform); ok && qualifier != "" {
+		return p.DescribeQualifier(name, qualifier)
+	}
+	return c.Describe(name)
+}
+
+// InvokeQualified invokes the version or the alias of the function, the latest
+// version is invoked if the qualifier is empty or the platform does not support it.
+func InvokeQualified(c Cloud, name, qualifier string, payload []byte) (*InvokeResponse, error) {
+	if p, ok := c.(QualifierPlatform); ok && qualifier != "" {
+		return p.InvokeQualifier(name, qualifier, payload)
+	}
+	return c.Invoke(name, payload)
+}
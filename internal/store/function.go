@@ -43,6 +43,29 @@ func (s *FunctionStore) Set(functionName string, platformID string, triggerURL s
 		s.Functions[functionName] = make([]types.Function, 0)
 	}
 
+	triggers := make([]types.FunctionTrigger, 0, len(opts.Triggers))
+	for _, trigger := range opts.Triggers {
+		t := types.FunctionTrigger{
+			Name:    trigger.Name,
+			Type:    trigger.Type,
+			Cron:    trigger.Cron,
+			Payload: trigger.Payload,
+		}
+		if trigger.Type == platform.HTTPTrigger {
+			t.URL = triggerURL
+		}
+		triggers = append(triggers, t)
+	}
+
+	var pkg *types.FunctionPackage
+	if opts.Package != nil {
+		pkg = &types.FunctionPackage{
+			Dir:     opts.Package.Dir,
+			Include: opts.Package.Include,
+			Exclude: opts.Package.Exclude,
+		}
+	}
+
 	f := types.Function{
 		PlatformID:            platformID,
 		URL:                   triggerURL,
@@ -55,10 +78,40 @@ func (s *FunctionStore) Set(functionName string, platformID string, triggerURL s
 		RuntimeTimeout:        opts.RuntimeTimeout,
 		HTTPPort:              opts.HTTPPort,
 		File:                  opts.File,
+		Package:               pkg,
+		Image:                 opts.Image,
+		Triggers:              triggers,
+		Alias:                 opts.Alias,
 	}
 
 	for k, function := range s.Functions[functionName] {
 		if function.PlatformID == platformID {
+			// Keep the published versions, the project, and the function on
+			// the platform the record is imported from.
+			f.Version = function.Version
+			f.Versions = function.Versions
+			f.Project = function.Project
+			if function.Namespace != "" {
+				f.Name = function.Name
+				f.Namespace = function.Namespace
+			}
+			// The alias is kept once the function is published with it.
+			if f.Alias == "" {
+				f.Alias = function.Alias
+			}
+			s.Functions[functionName][k] = f
+			return s.Save()
+		}
+	}
+
+	s.Functions[functionName] = append(s.Functions[functionName], f)
+	return s.Save()
+}
+
+// Put creates or replaces the function record on the platform of the given function.
+func (s *FunctionStore) Put(functionName string, f types.Function) error {
+	for k, function := range s.Functions[functionName] {
+		if function.PlatformID == f.PlatformID {
 			s.Functions[functionName][k] = f
 			return s.Save()
 		}
@@ -68,6 +121,66 @@ func (s *FunctionStore) Set(functionName string, platformID string, triggerURL s
 	return s.Save()
 }
 
+// AddVersion records the published version of the function on the given
+// platform, and marks it as the version the alias points to.
+func (s *FunctionStore) AddVersion(functionName string, platformID string, alias string, version types.FunctionVersion) error {
+	for k, function := range s.Functions[functionName] {
+		if function.PlatformID == platformID {
+			s.Functions[functionName][k].Alias = alias
+			s.Functions[functionName][k].Version = version.ID
+			s.Functions[functionName][k].Versions = append(function.Versions, version)
+			return s.Save()
+		}
+	}
+	return ErrFunctionNotExists
+}
+
+// SetProject marks the function on the given platform as deployed from the
+// manifest of the project.
+func (s *FunctionStore) SetProject(functionName string, platformID string, project string) error {
+	for k, function := range s.Functions[functionName] {
+		if function.PlatformID == platformID {
+			s.Functions[functionName][k].Project = project
+			return s.Save()
+		}
+	}
+	return ErrFunctionNotExists
+}
+
+// SetVersion marks the version the alias of the function points to on the given platform.
+func (s *FunctionStore) SetVersion(functionName string, platformID string, versionID string) error {
+	for k, function := range s.Functions[functionName] {
+		if function.PlatformID == platformID {
+			s.Functions[functionName][k].Version = versionID
+			return s.Save()
+		}
+	}
+	return ErrFunctionNotExists
+}
+
+// DeleteTrigger removes the trigger of the function record on the given platform.
+func (s *FunctionStore) DeleteTrigger(functionName string, platformID string, triggerName string) error {
+	for k, function := range s.Functions[functionName] {
+		if function.PlatformID != platformID {
+			continue
+		}
+
+		triggers := make([]types.FunctionTrigger, 0, len(function.Triggers))
+		for _, trigger := range function.Triggers {
+			if trigger.Name != triggerName {
+				triggers = append(triggers, trigger)
+				continue
+			}
+			if trigger.Type == platform.HTTPTrigger {
+				s.Functions[functionName][k].URL = ""
+			}
+		}
+		s.Functions[functionName][k].Triggers = triggers
+		return s.Save()
+	}
+	return ErrFunctionNotExists
+}
+
 func (s *FunctionStore) Get(functionName string) ([]types.Function, error) {
 	function, ok := s.Functions[functionName]
 	if !ok {
@@ -76,6 +189,29 @@ func (s *FunctionStore) Get(functionName string) ([]types.Function, error) {
 	return function, nil
 }
 
+// Delete removes the function record on the given platform. The function is
+// removed entirely once it is not deployed on any platform.
+func (s *FunctionStore) Delete(functionName string, platformID string) error {
+	functions, ok := s.Functions[functionName]
+	if !ok {
+		return ErrFunctionNotExists
+	}
+
+	remain := make([]types.Function, 0, len(functions))
+	for _, function := range functions {
+		if function.PlatformID != platformID {
+			remain = append(remain, function)
+		}
+	}
+
+	if len(remain) == 0 {
+		delete(s.Functions, functionName)
+	} else {
+		s.Functions[functionName] = remain
+	}
+	return s.Save()
+}
+
 // Load reads the configuration data from the given file path.
 func (s *FunctionStore) Load() error {
 	path := filepath.Dir(s.FileName)
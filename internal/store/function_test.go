@@ -0,0 +1,85 @@
+// Copyright 2021 E99p1ant. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/wuhan005/Raika/internal/platform"
+	"github.com/wuhan005/Raika/internal/types"
+)
+
+func TestFunctionStoreSet(t *testing.T) {
+	versions := []types.FunctionVersion{{Label: "v1", ID: "1"}}
+	tests := []struct {
+		name     string
+		existing *types.Function
+		opts     platform.CreateFunctionOptions
+		want     types.Function
+	}{
+		{
+			name: "new record",
+			opts: platform.CreateFunctionOptions{Name: "hello", MemorySize: 128},
+			want: types.Function{Name: "hello", MemorySize: 128},
+		},
+		{
+			name:     "versions and project are kept",
+			existing: &types.Function{Name: "hello", MemorySize: 128, Version: "1", Versions: versions, Project: "demo"},
+			opts:     platform.CreateFunctionOptions{Name: "hello", MemorySize: 256},
+			want:     types.Function{Name: "hello", MemorySize: 256, Version: "1", Versions: versions, Project: "demo"},
+		},
+		{
+			name:     "imported function is kept",
+			existing: &types.Function{Name: "greeting", Namespace: "my-service"},
+			opts:     platform.CreateFunctionOptions{Name: "hello", MemorySize: 128},
+			want:     types.Function{Name: "greeting", Namespace: "my-service", MemorySize: 128},
+		},
+		{
+			name:     "alias is kept",
+			existing: &types.Function{Name: "hello", Alias: "live"},
+			opts:     platform.CreateFunctionOptions{Name: "hello"},
+			want:     types.Function{Name: "hello", Alias: "live"},
+		},
+		{
+			name:     "alias is changed",
+			existing: &types.Function{Name: "hello", Alias: "live"},
+			opts:     platform.CreateFunctionOptions{Name: "hello", Alias: "canary"},
+			want:     types.Function{Name: "hello", Alias: "canary"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &FunctionStore{
+				FileName:  filepath.Join(t.TempDir(), "functions.json"),
+				Functions: make(map[string][]types.Function),
+			}
+			if test.existing != nil {
+				test.existing.PlatformID = "aliyun-1"
+				if err := s.Put("hello", *test.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := s.Set("hello", "aliyun-1", "", test.opts); err != nil {
+				t.Fatal(err)
+			}
+			records, err := s.Get("hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("want 1 record, got %d", len(records))
+			}
+			got := records[0]
+			if got.Name != test.want.Name || got.Namespace != test.want.Namespace ||
+				got.MemorySize != test.want.MemorySize || got.Alias != test.want.Alias ||
+				got.Version != test.want.Version || len(got.Versions) != len(test.want.Versions) ||
+				got.Project != test.want.Project {
+				t.Fatalf("want %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
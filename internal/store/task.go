@@ -63,7 +63,7 @@ func (s *TaskStore) Upsert(opts CreateTaskOptions) error {
 
 func (s *TaskStore) Delete(functionName string) error {
 	delete(s.Tasks, functionName)
-	return nil
+	return s.Save()
 }
 
 // Load reads the configuration data from the given file path.
@@ -4,24 +4,64 @@
 
 package types
 
+import (
+	"encoding/json"
+)
+
 // AuthConfig contains authorization information for connecting to a cloud service.
 type AuthConfig struct {
-	Platform        Platform `json:"platform"`
-	RegionID        string   `json:"region_id,omitempty"`
-	SecretID        string   `json:"secret_id,omitempty"`
-	SecretKey       string   `json:"secret_key,omitempty"`
-	AccountID       string   `json:"account_id,omitempty"`
-	AccessKeyID     string   `json:"access_key_id,omitempty"`
-	AccessKeySecret string   `json:"access_key_secret,omitempty"`
+	Platform Platform `json:"platform"`
+	// AccountID is the account ID reported by the platform plugin, the
+	// built-in platforms derive it from the options.
+	AccountID string `json:"account_id,omitempty"`
+	// Options are the credential fields in the schema of the platform, or
+	// the options passed to the platform plugin as is.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// legacyFieldNames maps the legacy top-level fields of the built-in platforms
+// to their field names in the schema, if they are renamed.
+var legacyFieldNames = map[Platform]map[string]string{
+	AWS: {
+		"secret_key": "secret_access_key",
+	},
+	HuaweiCloud: {
+		"access_key_secret": "secret_access_key",
+	},
 }
 
-func (a *AuthConfig) GetID() string {
-	switch a.Platform {
-	case "aliyun":
-		return a.AccessKeyID
-	case "tencentcloud":
-		return a.SecretID
-	default:
-		return a.AccessKeyID
+// UnmarshalJSON moves the credentials stored as the top-level fields by the
+// previous versions to the options.
+func (a *AuthConfig) UnmarshalJSON(data []byte) error {
+	type authConfig AuthConfig
+	var config authConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	*a = AuthConfig(config)
+	if !a.Platform.Check() {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if a.Options == nil {
+		a.Options = make(map[string]string)
+	}
+	for name, value := range fields {
+		value, ok := value.(string)
+		if !ok || value == "" || name == "platform" {
+			continue
+		}
+		if newName, ok := legacyFieldNames[a.Platform][name]; ok {
+			name = newName
+		}
+		if _, ok := a.Options[name]; !ok {
+			a.Options[name] = value
+		}
 	}
+	a.AccountID = ""
+	return nil
 }
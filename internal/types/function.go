@@ -22,4 +22,76 @@ type Function struct {
 	RuntimeTimeout        time.Duration     `json:"runtime_timeout"`
 	HTTPPort              int               `json:"http_port"`
 	File                  string            `json:"file"`
+	Package               *FunctionPackage  `json:"package,omitempty"`
+	Image                 string            `json:"image,omitempty"`
+	Triggers              []FunctionTrigger `json:"triggers,omitempty"`
+
+	// Alias is the alias the triggers are bound to, the function is deployed
+	// to the latest version if it is empty.
+	Alias    string            `json:"alias,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Versions []FunctionVersion `json:"versions,omitempty"`
+
+	// Project is the project of the manifest the function is deployed from,
+	// it is empty if the function is deployed by the flags.
+	Project string `json:"project,omitempty"`
+
+	// Namespace is the service or the namespace the imported function belongs
+	// to on the platform, it is empty for the functions deployed by Raika.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RemoteName returns the function name on the platform, which is prefixed by
+// the namespace of the imported function, e.g. "my-service/hello".
+func (f *Function) RemoteName() string {
+	if f.Namespace == "" {
+		return f.Name
+	}
+	return f.Namespace + "/" + f.Name
+}
+
+// FunctionPackage represents as the files packed with the function binary.
+type FunctionPackage struct {
+	Dir     string   `json:"dir"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// FunctionTrigger represents as a trigger of the function.
+type FunctionTrigger struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Cron    string `json:"cron,omitempty"`
+	Payload string `json:"payload,omitempty"`
+	// URL is the trigger URL of the HTTP trigger.
+	URL string `json:"url,omitempty"`
+}
+
+// GetTrigger returns the trigger with the given name.
+func (f *Function) GetTrigger(name string) (*FunctionTrigger, bool) {
+	for i := range f.Triggers {
+		if f.Triggers[i].Name == name {
+			return &f.Triggers[i], true
+		}
+	}
+	return nil, false
+}
+
+// FunctionVersion represents as a published version of the function.
+type FunctionVersion struct {
+	// Label is the version name shared by all the platforms.
+	Label string `json:"label"`
+	// ID is the version ID on the platform.
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetVersion returns the version with the given label.
+func (f *Function) GetVersion(label string) (*FunctionVersion, bool) {
+	for i := range f.Versions {
+		if f.Versions[i].Label == label {
+			return &f.Versions[i], true
+		}
+	}
+	return nil, false
 }
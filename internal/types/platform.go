@@ -10,11 +10,17 @@ const (
 	Aliyun       Platform = "aliyun"
 	TencentCloud Platform = "tencentcloud"
 	AWS          Platform = "aws"
+	HuaweiCloud  Platform = "huaweicloud"
+	Local        Platform = "local"
+	GCP          Platform = "gcp"
+	Azure        Platform = "azure"
+	OpenFaaS     Platform = "openfaas"
+	Knative      Platform = "knative"
 )
 
 func (p Platform) Check() bool {
 	switch p {
-	case Aliyun, TencentCloud, AWS:
+	case Aliyun, TencentCloud, AWS, HuaweiCloud, Local, GCP, Azure, OpenFaaS, Knative:
 		return true
 	}
 	return false
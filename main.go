@@ -25,6 +25,10 @@ func main() {
 		cmd.Daemon,
 		cmd.Platform,
 		cmd.Function,
+		cmd.Deploy,
+		cmd.Plan,
+		cmd.Build,
+		cmd.Emulator,
 	}
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{Name: "config-file", Value: config.DefaultConfigPath, Usage: "Config file path"},
@@ -41,7 +45,17 @@ func main() {
 		return nil
 	}
 
+	// The exit code is set below, after the logs are flushed.
+	app.ExitErrHandler = func(*cli.Context, error) {}
+
 	if err := app.Run(os.Args); err != nil {
 		log.Error("%v", err)
+		log.Stop()
+
+		code := 1
+		if exitErr, ok := err.(cli.ExitCoder); ok {
+			code = exitErr.ExitCode()
+		}
+		os.Exit(code)
 	}
 }